@@ -92,6 +92,18 @@ func main() {
 		}
 	}()
 
+	// Collect ban/unban events from servers in pull mode (hosts that cannot reach the callback URL)
+	go web.RunEventPullLoop(context.Background())
+
+	// Probe servers in the background and alert when one stays down or stops sending callbacks
+	go web.RunHealthMonitorLoop(context.Background())
+
+	// Run scheduled actions (expiring manual bans, timed unblocks and jail toggles) when they fall due
+	go web.RunScheduledActionLoop(context.Background())
+
+	// Deliver queued alerts and retry failed deliveries with backoff
+	go web.RunAlertOutboxLoop(context.Background())
+
 	// Initialize OIDC authentication
 	oidcConfig, err := config.GetOIDCConfigFromEnv()
 	if err != nil {
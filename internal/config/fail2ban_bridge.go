@@ -16,7 +16,12 @@
 
 package config
 
-import "github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+import (
+	"context"
+
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
 
 // =========================================================================
 //  Bridge between config and fail2ban --> used for both dependency injection and manager reload orchestration.
@@ -42,8 +47,29 @@ func (fail2banRuntime) BuildFail2banActionConfig(callbackURL, serverID, secret s
 	return BuildFail2banActionConfig(callbackURL, serverID, secret)
 }
 
-func (fail2banRuntime) BuildJailLocalContent() string {
-	return BuildJailLocalContent()
+func (fail2banRuntime) BuildJailLocalContent(server Fail2banServer, caps fail2ban.ServerCapabilities) string {
+	return BuildJailLocalContent(server, caps)
+}
+
+func (fail2banRuntime) RecordSSHHostKey(serverID, hostKey string) {
+	if err := SetServerSSHHostKey(serverID, hostKey); err != nil {
+		DebugLog("Warning: failed to store SSH host key for server %s: %v", serverID, err)
+	}
+}
+
+func (fail2banRuntime) RecordConfigRevision(ctx context.Context, rev fail2ban.ConfigRevision) error {
+	_, err := storage.RecordConfigRevision(ctx, storage.ConfigRevisionRecord{
+		ServerID:    rev.ServerID,
+		Kind:        rev.Kind,
+		Name:        rev.Name,
+		Path:        rev.Path,
+		Action:      rev.Action,
+		Author:      rev.Author,
+		Content:     rev.Content,
+		ContentHash: rev.ContentHash,
+		Exists:      rev.Exists,
+	})
+	return err
 }
 
 func registerFail2banProvider() {
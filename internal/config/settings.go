@@ -46,42 +46,47 @@ import (
 
 type Fail2banServer = shared.Fail2banServer
 
+type JailDefaultOverrides = shared.JailDefaultOverrides
+
 type AppSettings struct {
-	Language             string                `json:"language"`
-	Port                 int                   `json:"port"`
-	Debug                bool                  `json:"debug"`
-	RestartNeeded        bool                  `json:"restartNeeded"`
-	AlertCountries       []string              `json:"alertCountries"`
-	SMTP                 SMTPSettings          `json:"smtp"`
-	CallbackURL          string                `json:"callbackUrl"`
-	CallbackSecret       string                `json:"callbackSecret"`
-	AdvancedActions      AdvancedActionsConfig `json:"advancedActions"`
-	Servers              []Fail2banServer      `json:"servers"`
-	BantimeIncrement     bool                  `json:"bantimeIncrement"`
-	DefaultJailEnable    bool                  `json:"defaultJailEnable"`
-	IgnoreIPs            []string              `json:"ignoreips"`
-	Bantime              string                `json:"bantime"`
-	Findtime             string                `json:"findtime"`
-	Maxretry             int                   `json:"maxretry"`
-	Destemail            string                `json:"destemail"`
-	Banaction            string                `json:"banaction"`
-	BanactionAllports    string                `json:"banactionAllports"`
-	Chain                string                `json:"chain"`
-	BantimeRndtime       string                `json:"bantimeRndtime"`
-	BantimeMaxtime       string                `json:"bantimeMaxtime"`
-	BantimeFactor        string                `json:"bantimeFactor"`
-	BantimeOveralljails  bool                  `json:"bantimeOveralljails"`
-	GeoIPProvider        string                `json:"geoipProvider"`
-	GeoIPDatabasePath    string                `json:"geoipDatabasePath"`
-	MaxLogLines          int                   `json:"maxLogLines"`
-	EventRetentionDays   int                   `json:"eventRetentionDays"`
-	EmailAlertsForBans   bool                  `json:"emailAlertsForBans"`
-	EmailAlertsForUnbans bool                  `json:"emailAlertsForUnbans"`
-	AlertProvider        string                `json:"alertProvider"`
-	Webhook              WebhookSettings       `json:"webhook"`
-	Elasticsearch        ElasticsearchSettings `json:"elasticsearch"`
-	ThreatIntel          ThreatIntelSettings   `json:"threatIntel"`
-	ConsoleOutput        bool                  `json:"consoleOutput"`
+	Language             string                 `json:"language"`
+	Port                 int                    `json:"port"`
+	Debug                bool                   `json:"debug"`
+	RestartNeeded        bool                   `json:"restartNeeded"`
+	AlertCountries       []string               `json:"alertCountries"`
+	SMTP                 SMTPSettings           `json:"smtp"`
+	CallbackURL          string                 `json:"callbackUrl"`
+	CallbackSecret       string                 `json:"callbackSecret"`
+	AdvancedActions      AdvancedActionsConfig  `json:"advancedActions"`
+	Servers              []Fail2banServer       `json:"servers"`
+	BantimeIncrement     bool                   `json:"bantimeIncrement"`
+	DefaultJailEnable    bool                   `json:"defaultJailEnable"`
+	IgnoreIPs            []string               `json:"ignoreips"`
+	Bantime              string                 `json:"bantime"`
+	Findtime             string                 `json:"findtime"`
+	Maxretry             int                    `json:"maxretry"`
+	Destemail            string                 `json:"destemail"`
+	Banaction            string                 `json:"banaction"`
+	BanactionAllports    string                 `json:"banactionAllports"`
+	Chain                string                 `json:"chain"`
+	BantimeRndtime       string                 `json:"bantimeRndtime"`
+	BantimeMaxtime       string                 `json:"bantimeMaxtime"`
+	BantimeFactor        string                 `json:"bantimeFactor"`
+	BantimeOveralljails  bool                   `json:"bantimeOveralljails"`
+	GeoIPProvider        string                 `json:"geoipProvider"`
+	GeoIPDatabasePath    string                 `json:"geoipDatabasePath"`
+	MaxLogLines          int                    `json:"maxLogLines"`
+	EventRetentionDays   int                    `json:"eventRetentionDays"`
+	EmailAlertsForBans   bool                   `json:"emailAlertsForBans"`
+	EmailAlertsForUnbans bool                   `json:"emailAlertsForUnbans"`
+	AlertProvider        string                 `json:"alertProvider"`
+	Webhook              WebhookSettings        `json:"webhook"`
+	Elasticsearch        ElasticsearchSettings  `json:"elasticsearch"`
+	ThreatIntel          ThreatIntelSettings    `json:"threatIntel"`
+	HealthMonitor        HealthMonitorSettings  `json:"healthMonitor"`
+	BanPropagation       BanPropagationSettings `json:"banPropagation"`
+	AlertChannels        []AlertChannel         `json:"alertChannels"`
+	ConsoleOutput        bool                   `json:"consoleOutput"`
 }
 
 type SMTPSettings struct {
@@ -135,6 +140,12 @@ type WebhookSettings struct {
 	Method        string            `json:"method"`
 	Headers       map[string]string `json:"headers"`
 	SkipTLSVerify bool              `json:"skipTLSVerify"`
+	// Go text/template for the request body; empty sends the default JSON payload.
+	Template string `json:"template,omitempty"`
+	// Content type of templated bodies; defaults to application/json.
+	ContentType string `json:"contentType,omitempty"`
+	// Shared secret for the HMAC-SHA256 signature header; empty sends unsigned requests.
+	Secret string `json:"secret,omitempty"`
 }
 
 type ElasticsearchSettings struct {
@@ -152,6 +163,62 @@ type ThreatIntelSettings struct {
 	AbuseIPDBAPIKey  string `json:"abuseIpDbApiKey"`
 }
 
+// Background server probing. Alerts are off when their period is 0.
+type HealthMonitorSettings struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"intervalSeconds"`
+	// Alert once a server has been unreachable this long.
+	DownAlertMinutes int `json:"downAlertMinutes"`
+	// Alert when a server in callback mode has not sent an event for this long.
+	CallbackSilenceMinutes int `json:"callbackSilenceMinutes"`
+}
+
+// Copies bans from opted-in jails to the other servers.
+type BanPropagationSettings struct {
+	Enabled bool `json:"enabled"`
+	// Jails whose bans are propagated; bans in other jails stay local.
+	Jails []string `json:"jails"`
+	// Jail the propagated bans are placed in on the receiving servers.
+	TargetJail string `json:"targetJail"`
+	// Only servers carrying one of these tags receive bans; empty means all servers.
+	TargetTags []string `json:"targetTags"`
+	// IPs and CIDR ranges that are never propagated.
+	Allowlist []string `json:"allowlist"`
+}
+
+// An alert destination with its own rules. Empty filters match every event;
+// the country, jail and server filters only apply to events that carry the field.
+type AlertChannel struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Provider string `json:"provider"` // email, webhook or elasticsearch
+	// ban, unban, permanent_block, server_down, server_recovered, callback_silence
+	Events    []string `json:"events"`
+	Countries []string `json:"countries"`
+	Jails     []string `json:"jails"`
+	Servers   []string `json:"servers"` // server IDs
+	// Lowest severity forwarded: info, warning or critical.
+	MinSeverity string `json:"minSeverity"`
+	// Recipient of email channels; empty uses destemail.
+	Destination string `json:"destination,omitempty"`
+	// Endpoint of webhook channels; nil uses the global webhook.
+	Webhook *WebhookSettings `json:"webhook,omitempty"`
+	// Limits on ban, unban and permanent_block alerts; zero values disable them.
+	Throttle AlertThrottle `json:"throttle,omitzero"`
+}
+
+// Per-channel alert limits. Alerts over the cap are summarised in a digest.
+type AlertThrottle struct {
+	// Skip repeated alerts for the same IP and jail within this many minutes.
+	DedupeMinutes int `json:"dedupeMinutes,omitempty"`
+	// Send at most MaxAlerts alerts per WindowMinutes.
+	MaxAlerts     int `json:"maxAlerts,omitempty"`
+	WindowMinutes int `json:"windowMinutes,omitempty"`
+	// Send a digest this many minutes after the cap was first hit; zero drops the overflow.
+	DigestMinutes int `json:"digestMinutes,omitempty"`
+}
+
 type OIDCConfig struct {
 	Enabled              bool     `json:"enabled"`
 	Provider             string   `json:"provider"`
@@ -197,6 +264,24 @@ func normalizeAdvancedActionsConfig(cfg AdvancedActionsConfig) AdvancedActionsCo
 	return cfg
 }
 
+func defaultHealthMonitorSettings() HealthMonitorSettings {
+	return HealthMonitorSettings{
+		Enabled:          true,
+		IntervalSeconds:  60,
+		DownAlertMinutes: 5,
+	}
+}
+
+func normalizeHealthMonitorSettings(cfg HealthMonitorSettings) HealthMonitorSettings {
+	if cfg.IntervalSeconds <= 0 {
+		cfg.IntervalSeconds = 60
+	}
+	cfg.IntervalSeconds = min(max(cfg.IntervalSeconds, 15), 3600)
+	cfg.DownAlertMinutes = max(cfg.DownAlertMinutes, 0)
+	cfg.CallbackSilenceMinutes = max(cfg.CallbackSilenceMinutes, 0)
+	return cfg
+}
+
 // =========================================================================
 //  Constants
 // =========================================================================
@@ -478,6 +563,33 @@ func applyAppSettingsRecordLocked(rec storage.AppSettingsRecord) {
 			currentSettings.ThreatIntel = ThreatIntelSettings{}
 		}
 	}
+	if rec.HealthMonitorJSON != "" {
+		var hm HealthMonitorSettings
+		if err := json.Unmarshal([]byte(rec.HealthMonitorJSON), &hm); err == nil {
+			currentSettings.HealthMonitor = hm
+		} else {
+			DebugLog("warning: invalid health_monitor JSON in app_settings, resetting to defaults: %v", err)
+			currentSettings.HealthMonitor = HealthMonitorSettings{}
+		}
+	}
+	if rec.BanPropagationJSON != "" {
+		var bp BanPropagationSettings
+		if err := json.Unmarshal([]byte(rec.BanPropagationJSON), &bp); err == nil {
+			currentSettings.BanPropagation = bp
+		} else {
+			DebugLog("warning: invalid ban_propagation JSON in app_settings, resetting to defaults: %v", err)
+			currentSettings.BanPropagation = BanPropagationSettings{}
+		}
+	}
+	if rec.AlertChannelsJSON != "" {
+		var channels []AlertChannel
+		if err := json.Unmarshal([]byte(rec.AlertChannelsJSON), &channels); err == nil {
+			currentSettings.AlertChannels = channels
+		} else {
+			DebugLog("warning: invalid alert_channels JSON in app_settings, ignoring: %v", err)
+			currentSettings.AlertChannels = nil
+		}
+	}
 	currentSettings.ConsoleOutput = rec.ConsoleOutput
 }
 
@@ -488,6 +600,10 @@ func applyServerRecordsLocked(records []storage.ServerRecord) {
 		if rec.TagsJSON != "" {
 			_ = json.Unmarshal([]byte(rec.TagsJSON), &tags)
 		}
+		var jailDefaults JailDefaultOverrides
+		if rec.JailDefaultsJSON != "" {
+			_ = json.Unmarshal([]byte(rec.JailDefaultsJSON), &jailDefaults)
+		}
 		server := Fail2banServer{
 			ID:                   rec.ID,
 			Name:                 rec.Name,
@@ -498,6 +614,7 @@ func applyServerRecordsLocked(records []storage.ServerRecord) {
 			ConfigPath:           rec.ConfigPath,
 			SSHUser:              rec.SSHUser,
 			SSHKeyPath:           rec.SSHKeyPath,
+			SSHHostKey:           rec.SSHHostKey,
 			AgentURL:             rec.AgentURL,
 			AgentSecret:          rec.AgentSecret,
 			Hostname:             rec.Hostname,
@@ -505,6 +622,8 @@ func applyServerRecordsLocked(records []storage.ServerRecord) {
 			IsDefault:            rec.IsDefault,
 			Enabled:              rec.Enabled,
 			ReverseTunnelEnabled: rec.ReverseTunnelEnabled,
+			EventMode:            rec.EventMode,
+			JailDefaults:         jailDefaults,
 			RestartNeeded:        rec.NeedsRestart,
 			CreatedAt:            rec.CreatedAt,
 			UpdatedAt:            rec.UpdatedAt,
@@ -543,6 +662,22 @@ func toAppSettingsRecordLocked() (storage.AppSettingsRecord, error) {
 	if err != nil {
 		return storage.AppSettingsRecord{}, err
 	}
+	healthMonitorBytes, err := json.Marshal(currentSettings.HealthMonitor)
+	if err != nil {
+		return storage.AppSettingsRecord{}, err
+	}
+	banPropagationBytes, err := json.Marshal(currentSettings.BanPropagation)
+	if err != nil {
+		return storage.AppSettingsRecord{}, err
+	}
+	alertChannels := currentSettings.AlertChannels
+	if alertChannels == nil {
+		alertChannels = []AlertChannel{}
+	}
+	alertChannelsBytes, err := json.Marshal(alertChannels)
+	if err != nil {
+		return storage.AppSettingsRecord{}, err
+	}
 
 	alertProvider := currentSettings.AlertProvider
 	if alertProvider == "" {
@@ -590,6 +725,9 @@ func toAppSettingsRecordLocked() (storage.AppSettingsRecord, error) {
 		WebhookJSON:            string(webhookBytes),
 		ElasticsearchJSON:      string(esBytes),
 		ThreatIntelJSON:        string(threatIntelBytes),
+		HealthMonitorJSON:      string(healthMonitorBytes),
+		BanPropagationJSON:     string(banPropagationBytes),
+		AlertChannelsJSON:      string(alertChannelsBytes),
 		ConsoleOutput:          currentSettings.ConsoleOutput,
 	}, nil
 }
@@ -605,6 +743,10 @@ func toServerRecordsLocked() ([]storage.ServerRecord, error) {
 		if err != nil {
 			return nil, err
 		}
+		jailDefaultBytes, err := json.Marshal(srv.JailDefaults)
+		if err != nil {
+			return nil, err
+		}
 		createdAt := srv.CreatedAt
 		if createdAt.IsZero() {
 			createdAt = time.Now().UTC()
@@ -623,6 +765,7 @@ func toServerRecordsLocked() ([]storage.ServerRecord, error) {
 			ConfigPath:           srv.ConfigPath,
 			SSHUser:              srv.SSHUser,
 			SSHKeyPath:           srv.SSHKeyPath,
+			SSHHostKey:           srv.SSHHostKey,
 			AgentURL:             srv.AgentURL,
 			AgentSecret:          srv.AgentSecret,
 			Hostname:             srv.Hostname,
@@ -630,6 +773,8 @@ func toServerRecordsLocked() ([]storage.ServerRecord, error) {
 			IsDefault:            srv.IsDefault,
 			Enabled:              srv.Enabled,
 			ReverseTunnelEnabled: srv.ReverseTunnelEnabled,
+			EventMode:            srv.EventMode,
+			JailDefaultsJSON:     string(jailDefaultBytes),
 			NeedsRestart:         srv.RestartNeeded,
 			CreatedAt:            createdAt,
 			UpdatedAt:            updatedAt,
@@ -750,6 +895,10 @@ func setDefaultsLocked() {
 		currentSettings.AdvancedActions = defaultAdvancedActionsConfig()
 	}
 	currentSettings.AdvancedActions = normalizeAdvancedActionsConfig(currentSettings.AdvancedActions)
+	if (currentSettings.HealthMonitor == HealthMonitorSettings{}) {
+		currentSettings.HealthMonitor = defaultHealthMonitorSettings()
+	}
+	currentSettings.HealthMonitor = normalizeHealthMonitorSettings(currentSettings.HealthMonitor)
 	normalizeServersLocked()
 }
 
@@ -860,6 +1009,7 @@ func normalizeServersLocked() {
 			server.SocketPath = normalizeLocalSocketPath(server.SocketPath)
 			server.ConfigPath = normalizeLocalConfigPath(server.ConfigPath)
 		}
+		server.EventMode = normalizeEventMode(server.EventMode)
 		if !server.EnabledSet {
 			if server.Type == "local" {
 				server.Enabled = false
@@ -909,6 +1059,14 @@ func normalizeServerNameKey(name string) string {
 	return strings.ToLower(normalizeServerName(name))
 }
 
+// Returns "pull" when the server's events are collected by polling its fail2ban log, "callback" otherwise.
+func normalizeEventMode(mode string) string {
+	if strings.EqualFold(strings.TrimSpace(mode), "pull") {
+		return "pull"
+	}
+	return "callback"
+}
+
 func normalizePathValue(path string) string {
 	trimmed := strings.TrimSpace(path)
 	if trimmed == "" {
@@ -972,57 +1130,126 @@ func validateServerUniquenessLocked(input Fail2banServer) error {
 // =========================================================================
 
 // Ensures the local action files exist. (local connector only) -> will be moved to the connector_local.go
-func ensureFail2banActionFiles(callbackURL, serverID, configPath string) error {
+func ensureFail2banActionFiles(callbackURL string, server Fail2banServer) error {
 	DebugLog("----------------------------")
 	DebugLog("ensureFail2banActionFiles called (settings.go)")
-	return fail2ban.EnsureLocalConnectorArtifacts(callbackURL, serverID, configPath)
+	return fail2ban.EnsureLocalConnectorArtifacts(callbackURL, server)
+}
+
+// [DEFAULT] values written to a server's jail.local, with the names of the
+// fields that come from the server's own overrides.
+type EffectiveJailDefaultValues struct {
+	IgnoreIPs         []string `json:"ignoreips"`
+	Bantime           string   `json:"bantime"`
+	Findtime          string   `json:"findtime"`
+	Maxretry          int      `json:"maxretry"`
+	Banaction         string   `json:"banaction"`
+	BanactionAllports string   `json:"banactionAllports"`
+	Chain             string   `json:"chain"`
+	Overridden        []string `json:"overridden"`
+}
+
+// Merges the server's [DEFAULT] overrides over the global defaults. An empty
+// override field keeps the global value; ignoreips replaces the global list.
+func EffectiveJailDefaults(server Fail2banServer) EffectiveJailDefaultValues {
+	settings := GetSettings()
+	eff := EffectiveJailDefaultValues{
+		IgnoreIPs:         append([]string{}, settings.IgnoreIPs...),
+		Bantime:           settings.Bantime,
+		Findtime:          settings.Findtime,
+		Maxretry:          settings.Maxretry,
+		Banaction:         settings.Banaction,
+		BanactionAllports: settings.BanactionAllports,
+		Chain:             settings.Chain,
+		Overridden:        []string{},
+	}
+	o := server.JailDefaults
+	if len(o.IgnoreIPs) > 0 {
+		eff.IgnoreIPs = append([]string{}, o.IgnoreIPs...)
+		eff.Overridden = append(eff.Overridden, "ignoreips")
+	}
+	if o.Bantime != "" {
+		eff.Bantime = o.Bantime
+		eff.Overridden = append(eff.Overridden, "bantime")
+	}
+	if o.Findtime != "" {
+		eff.Findtime = o.Findtime
+		eff.Overridden = append(eff.Overridden, "findtime")
+	}
+	if o.Maxretry > 0 {
+		eff.Maxretry = o.Maxretry
+		eff.Overridden = append(eff.Overridden, "maxretry")
+	}
+	if o.Banaction != "" {
+		eff.Banaction = o.Banaction
+		eff.Overridden = append(eff.Overridden, "banaction")
+	}
+	if o.BanactionAllports != "" {
+		eff.BanactionAllports = o.BanactionAllports
+		eff.Overridden = append(eff.Overridden, "banactionAllports")
+	}
+	if o.Chain != "" {
+		eff.Chain = o.Chain
+		eff.Overridden = append(eff.Overridden, "chain")
+	}
+	return eff
 }
 
 // Builds the content of our fail2ban-UI managed jail.local file. (used by all connectors)
-func BuildJailLocalContent() string {
+// Server overrides are merged over the global defaults; options the server's
+// fail2ban version does not know are left out.
+func BuildJailLocalContent(server Fail2banServer, caps fail2ban.ServerCapabilities) string {
 	settings := GetSettings()
-	ignoreIPStr := strings.Join(settings.IgnoreIPs, " ")
+	eff := EffectiveJailDefaults(server)
+	ignoreIPStr := strings.Join(eff.IgnoreIPs, " ")
 	if ignoreIPStr == "" {
 		ignoreIPStr = "127.0.0.1/8 ::1"
 	}
-	banaction := settings.Banaction
+	banaction := eff.Banaction
 	if banaction == "" {
 		banaction = "nftables-multiport"
 	}
-	banactionAllports := settings.BanactionAllports
+	banactionAllports := eff.BanactionAllports
 	if banactionAllports == "" {
 		banactionAllports = "nftables-allports"
 	}
-	chain := settings.Chain
+	chain := eff.Chain
 	if chain == "" {
 		chain = "INPUT"
 	}
 	defaultSection := fmt.Sprintf(`[DEFAULT]
 enabled = %t
-bantime.increment = %t
-ignoreip = %s
+`, settings.DefaultJailEnable)
+	increment := caps.Supports(fail2ban.FeatureBantimeIncrement)
+	if increment {
+		defaultSection += fmt.Sprintf("bantime.increment = %t\n", settings.BantimeIncrement)
+	}
+	defaultSection += fmt.Sprintf(`ignoreip = %s
 bantime = %s
 findtime = %s
 maxretry = %d
 banaction = %s
 banaction_allports = %s
 chain = %s
-`, settings.DefaultJailEnable, settings.BantimeIncrement, ignoreIPStr,
-		settings.Bantime, settings.Findtime, settings.Maxretry,
+`, ignoreIPStr, eff.Bantime, eff.Findtime, eff.Maxretry,
 		banaction, banactionAllports, chain)
-	if settings.BantimeRndtime != "" {
-		defaultSection += fmt.Sprintf("bantime.rndtime = %s\n", settings.BantimeRndtime)
-	}
-	// bantime.maxtime caps how large escalating bans may grow when
-	// bantime.increment is enabled. Only emitted when the operator sets it.
-	if settings.BantimeMaxtime != "" {
-		defaultSection += fmt.Sprintf("bantime.maxtime = %s\n", settings.BantimeMaxtime)
-	}
-	if settings.BantimeFactor != "" {
-		defaultSection += fmt.Sprintf("bantime.factor = %s\n", settings.BantimeFactor)
-	}
-	if settings.BantimeOveralljails {
-		defaultSection += "bantime.overalljails = true\n"
+	if increment {
+		if settings.BantimeRndtime != "" {
+			defaultSection += fmt.Sprintf("bantime.rndtime = %s\n", settings.BantimeRndtime)
+		}
+		// bantime.maxtime caps how large escalating bans may grow when
+		// bantime.increment is enabled. Only emitted when the operator sets it.
+		if settings.BantimeMaxtime != "" {
+			defaultSection += fmt.Sprintf("bantime.maxtime = %s\n", settings.BantimeMaxtime)
+		}
+		if settings.BantimeFactor != "" {
+			defaultSection += fmt.Sprintf("bantime.factor = %s\n", settings.BantimeFactor)
+		}
+		if settings.BantimeOveralljails {
+			defaultSection += "bantime.overalljails = true\n"
+		}
+	} else {
+		defaultSection += fmt.Sprintf("# bantime.increment and related options need fail2ban >= 0.11.1 (server runs %s)\n", caps.Fail2banVersion)
 	}
 	defaultSection += "\n"
 
@@ -1043,6 +1270,9 @@ func cloneServer(src Fail2banServer) Fail2banServer {
 	if src.Tags != nil {
 		dst.Tags = append([]string{}, src.Tags...)
 	}
+	if src.JailDefaults.IgnoreIPs != nil {
+		dst.JailDefaults.IgnoreIPs = append([]string{}, src.JailDefaults.IgnoreIPs...)
+	}
 	dst.EnabledSet = src.EnabledSet
 	return dst
 }
@@ -1118,7 +1348,7 @@ func EnsureLocalFail2banAction(server Fail2banServer) error {
 	settingsLock.RLock()
 	callbackURL := getCallbackURLLocked()
 	settingsLock.RUnlock()
-	return ensureFail2banActionFiles(callbackURL, server.ID, server.ConfigPath)
+	return ensureFail2banActionFiles(callbackURL, server)
 }
 
 // =========================================================================
@@ -1218,6 +1448,13 @@ func UpsertServer(input Fail2banServer) (Fail2banServer, error) {
 		input.SocketPath = normalizePathValue(input.SocketPath)
 		input.ConfigPath = ""
 	}
+	input.EventMode = normalizeEventMode(input.EventMode)
+	input.SSHHostKey = strings.TrimSpace(input.SSHHostKey)
+	resetHostKey := input.SSHHostKeyReset
+	input.SSHHostKeyReset = false
+	if input.Type != "ssh" {
+		input.SSHHostKey = ""
+	}
 	if input.Name == "" {
 		input.Name = "Fail2ban Server " + input.ID
 	}
@@ -1240,6 +1477,15 @@ func UpsertServer(input Fail2banServer) (Fail2banServer, error) {
 			if input.CreatedAt.IsZero() {
 				input.CreatedAt = srv.CreatedAt
 			}
+			// An empty key keeps the pinned one, so saving a form loaded before the
+			// first connect does not open a new trust-on-first-use window.
+			if input.Type == "ssh" && input.SSHHostKey == "" && !resetHostKey {
+				input.SSHHostKey = srv.SSHHostKey
+			}
+			// A pinned host key belongs to the old endpoint; re-pin on the next connect when the host moves.
+			if (srv.Host != input.Host || srv.Port != input.Port) && input.SSHHostKey == srv.SSHHostKey {
+				input.SSHHostKey = ""
+			}
 			currentSettings.Servers[idx] = input
 			replaced = true
 			break
@@ -1341,6 +1587,25 @@ func SetDefaultServer(id string) (Fail2banServer, error) {
 	return cloneServer(srv), nil
 }
 
+// Stores the SSH host key learned on first connect (trust on first use).
+func SetServerSSHHostKey(id, hostKey string) error {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	for idx := range currentSettings.Servers {
+		srv := &currentSettings.Servers[idx]
+		if srv.ID != id {
+			continue
+		}
+		if srv.SSHHostKey == hostKey {
+			return nil
+		}
+		srv.SSHHostKey = hostKey
+		srv.UpdatedAt = time.Now().UTC()
+		return persistServersLocked()
+	}
+	return fmt.Errorf("server %s not found", id)
+}
+
 // =========================================================================
 //  Get Settings from Environment Variables
 // =========================================================================
@@ -19,6 +19,8 @@ package config
 import (
 	"strings"
 	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
 )
 
 func TestValidateServerUniqueness(t *testing.T) {
@@ -124,3 +126,51 @@ func TestFail2banActionConfigEscapesPercent(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildJailLocalContentMergesServerOverrides(t *testing.T) {
+	settingsLock.Lock()
+	saved := currentSettings
+	currentSettings.IgnoreIPs = []string{"127.0.0.1/8", "10.0.0.0/8"}
+	currentSettings.Bantime = "48h"
+	currentSettings.Findtime = "30m"
+	currentSettings.Maxretry = 3
+	currentSettings.Banaction = "nftables-multiport"
+	currentSettings.BanactionAllports = "nftables-allports"
+	currentSettings.Chain = "INPUT"
+	settingsLock.Unlock()
+	t.Cleanup(func() {
+		settingsLock.Lock()
+		currentSettings = saved
+		settingsLock.Unlock()
+	})
+
+	server := Fail2banServer{ID: "edge", JailDefaults: JailDefaultOverrides{
+		IgnoreIPs: []string{"192.0.2.0/24"},
+		Bantime:   "-1",
+		Maxretry:  8,
+		Chain:     "DOCKER-USER",
+	}}
+	content := BuildJailLocalContent(server, fail2ban.ServerCapabilities{})
+	for _, want := range []string{
+		"ignoreip = 192.0.2.0/24\n",
+		"bantime = -1\n",
+		"findtime = 30m\n",
+		"maxretry = 8\n",
+		"banaction = nftables-multiport\n",
+		"chain = DOCKER-USER\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("jail.local missing %q:\n%s", want, content)
+		}
+	}
+
+	eff := EffectiveJailDefaults(server)
+	if got := strings.Join(eff.Overridden, ","); got != "ignoreips,bantime,maxretry,chain" {
+		t.Fatalf("unexpected overridden fields %q", got)
+	}
+
+	global := BuildJailLocalContent(Fail2banServer{ID: "plain"}, fail2ban.ServerCapabilities{})
+	if !strings.Contains(global, "ignoreip = 127.0.0.1/8 10.0.0.0/8\n") || !strings.Contains(global, "chain = INPUT\n") {
+		t.Fatalf("server without overrides should use the global defaults:\n%s", global)
+	}
+}
@@ -132,6 +132,21 @@ func ParseLogLines(logs, jail string) map[string]interface{} {
 	return enriched
 }
 
+// Matches a single log line against the known formats (ordered by jail name) and
+// returns the matching pattern with the extracted fields.
+func MatchLogLine(line, jail string) (PatternDef, map[string]interface{}, bool) {
+	ensureInit()
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return PatternDef{}, nil, false
+	}
+	result, def := parseLine(line, orderedPatterns(jail))
+	if result == nil {
+		return PatternDef{}, nil, false
+	}
+	return def, result, true
+}
+
 // =========================================================================
 // Helper functions
 // =========================================================================
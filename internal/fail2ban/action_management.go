@@ -0,0 +1,170 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// =========================================================================
+//  Action Files (action.d)
+// =========================================================================
+
+// Action written by Fail2ban-UI itself; it is regenerated from the settings and not editable here.
+const managedActionName = "ui-custom-action"
+
+// Validates an action name format.
+func ValidateActionName(name string) error {
+	if _, err := safeConfigName(name); err != nil {
+		return fmt.Errorf("invalid action name: %w", err)
+	}
+	if strings.TrimSpace(name)[0] == '-' {
+		return fmt.Errorf("action name '%s' must not start with a dash", name)
+	}
+	return nil
+}
+
+// Refuses writes to the action managed by Fail2ban-UI.
+func validateWritableAction(name string) error {
+	if err := ValidateActionName(name); err != nil {
+		return err
+	}
+	if strings.TrimSpace(name) == managedActionName {
+		return fmt.Errorf("action %s is managed by Fail2ban-UI and is updated from the settings", managedActionName)
+	}
+	return nil
+}
+
+// Returns all action names (from .conf and .local files) at the given config path.
+func DiscoverActionsFromFiles(configPath string) ([]string, error) {
+	actionDPath := ActionDir(configPath)
+	if _, err := os.Stat(actionDPath); os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	files, err := ListFilterFiles(actionDPath)
+	if err != nil {
+		return nil, err
+	}
+	return configNamesFromFiles(files), nil
+}
+
+// Reduces .conf/.local paths to sorted, unique base names.
+func configNamesFromFiles(files []string) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, filePath := range files {
+		filename := filepath.Base(filePath)
+		baseName := strings.TrimSuffix(strings.TrimSuffix(filename, ".local"), ".conf")
+		if baseName == "" || baseName == filename || seen[baseName] {
+			continue
+		}
+		seen[baseName] = true
+		names = append(names, baseName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reads an action from .local first, then falls back to .conf.
+func GetActionConfigLocal(actionName, configPath string) (string, string, error) {
+	if err := ValidateActionName(actionName); err != nil {
+		return "", "", err
+	}
+	actionDPath := ActionDir(configPath)
+	localPath, err := resolveWithinDir(actionDPath, actionName, ".local")
+	if err != nil {
+		return "", "", err
+	}
+	confPath, err := resolveWithinDir(actionDPath, actionName, ".conf")
+	if err != nil {
+		return "", "", err
+	}
+	if content, err := os.ReadFile(localPath); err == nil {
+		return string(content), localPath, nil
+	}
+	content, err := os.ReadFile(confPath)
+	if err == nil {
+		return string(content), confPath, nil
+	}
+	if os.IsNotExist(err) {
+		return "", localPath, fmt.Errorf("action %w: neither %s nor %s exists", ErrConfigNotFound, localPath, confPath)
+	}
+	return "", localPath, fmt.Errorf("failed to read action config %s: %w", confPath, err)
+}
+
+// Writes an action's .local file, creating action.d if needed.
+func SetActionConfigLocal(actionName, content, configPath string) error {
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	actionDPath := ActionDir(configPath)
+	localPath, err := resolveWithinDir(actionDPath, actionName, ".local")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(actionDPath, 0755); err != nil {
+		return fmt.Errorf("failed to create action.d directory: %w", err)
+	}
+	if err := os.WriteFile(localPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write action file %s: %w", localPath, err)
+	}
+	debugf("Wrote action file: %s", localPath)
+	return nil
+}
+
+// Creates a new action at the given config path.
+func CreateAction(actionName, content, configPath string) error {
+	return SetActionConfigLocal(actionName, content, configPath)
+}
+
+// Returned when an action has no .local override to delete, only the .conf
+// file shipped with fail2ban.
+var ErrActionNotOverridden = errors.New("only the distribution .conf exists; actions shipped with fail2ban cannot be deleted")
+
+// Deletes an action's .local override from action.d/. The distribution .conf
+// is left alone, so deleting an override restores the shipped action.
+func DeleteAction(actionName, configPath string) error {
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	actionDPath := ActionDir(configPath)
+	localPath, err := resolveWithinDir(actionDPath, actionName, ".local")
+	if err != nil {
+		return err
+	}
+	confPath, err := resolveWithinDir(actionDPath, actionName, ".conf")
+	if err != nil {
+		return err
+	}
+	err = os.Remove(localPath)
+	if err == nil {
+		debugf("Deleted action file: %s", localPath)
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete action file %s: %w", localPath, err)
+	}
+	if _, err := os.Stat(confPath); err == nil {
+		return fmt.Errorf("action %s: %w", actionName, ErrActionNotOverridden)
+	}
+	return fmt.Errorf("action %s does not exist in %s", actionName, actionDPath)
+}
@@ -0,0 +1,103 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalActionFiles(t *testing.T) {
+	configPath := t.TempDir()
+	actionDir := filepath.Join(configPath, "action.d")
+	if err := os.MkdirAll(actionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"iptables.conf":         "[Definition]\nactionban = iptables\n",
+		"mail.conf":             "[Definition]\nactionban = mail\n",
+		"mail.local":            "[Definition]\nactionban = sendmail\n",
+		"ui-custom-action.conf": "[Definition]\n",
+		"README":                "not an action\n",
+	} {
+		if err := os.WriteFile(filepath.Join(actionDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := DiscoverActionsFromFiles(configPath)
+	if err != nil {
+		t.Fatalf("DiscoverActionsFromFiles: %v", err)
+	}
+	if want := []string{"iptables", "mail", "ui-custom-action"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+
+	content, path, err := GetActionConfigLocal("mail", configPath)
+	if err != nil || !strings.Contains(content, "sendmail") || filepath.Base(path) != "mail.local" {
+		t.Fatalf("expected mail.local to win, got %q from %s (%v)", content, path, err)
+	}
+	content, path, err = GetActionConfigLocal("iptables", configPath)
+	if err != nil || !strings.Contains(content, "iptables") || filepath.Base(path) != "iptables.conf" {
+		t.Fatalf("expected fallback to iptables.conf, got %q from %s (%v)", content, path, err)
+	}
+
+	if err := SetActionConfigLocal("iptables", "[Definition]\nactionban = nft\n", configPath); err != nil {
+		t.Fatalf("SetActionConfigLocal: %v", err)
+	}
+	if content, path, _ = GetActionConfigLocal("iptables", configPath); filepath.Base(path) != "iptables.local" || !strings.Contains(content, "nft") {
+		t.Fatalf("expected write to iptables.local, got %q from %s", content, path)
+	}
+
+	if err := DeleteAction("mail", configPath); err != nil {
+		t.Fatalf("DeleteAction: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(actionDir, "mail.local")); !os.IsNotExist(err) {
+		t.Fatalf("expected mail.local to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(actionDir, "mail.conf")); err != nil {
+		t.Fatalf("expected the distribution mail.conf to stay: %v", err)
+	}
+	if err := DeleteAction("mail", configPath); !errors.Is(err, ErrActionNotOverridden) {
+		t.Fatalf("expected deleting a distribution action to be refused, got %v", err)
+	}
+	if err := DeleteAction("missing", configPath); err == nil || errors.Is(err, ErrActionNotOverridden) {
+		t.Fatalf("expected deleting a missing action to fail, got %v", err)
+	}
+}
+
+func TestActionWritesAreGuarded(t *testing.T) {
+	configPath := t.TempDir()
+	if err := SetActionConfigLocal(managedActionName, "[Definition]\n", configPath); err == nil {
+		t.Fatalf("expected write to %s to be refused", managedActionName)
+	}
+	if err := DeleteAction(managedActionName, configPath); err == nil {
+		t.Fatalf("expected delete of %s to be refused", managedActionName)
+	}
+	for _, name := range []string{"../jail", "a/b", "", "-x"} {
+		if err := SetActionConfigLocal(name, "[Definition]\n", configPath); err == nil {
+			t.Fatalf("expected action name %q to be rejected", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(configPath, "jail.local")); !os.IsNotExist(err) {
+		t.Fatalf("path guard let a write escape action.d")
+	}
+}
@@ -0,0 +1,150 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+// Upper bound for a copied fail2ban database; larger files are rejected instead of filling memory.
+const banDatabaseMaxBytes = 256 << 20
+
+// Implemented by connectors that can hand out a copy of fail2ban's own sqlite database (dbfile).
+type BanDatabaseReader interface {
+	ReadBanDatabase(ctx context.Context) ([]byte, error)
+}
+
+// A row of the "bans" table in fail2ban's database.
+type HistoricalBan struct {
+	Jail      string
+	IP        string
+	TimeOfBan time.Time
+	Failures  int
+	Matches   []string
+}
+
+// =========================================================================
+//  Ban History Import
+// =========================================================================
+
+// Copies the fail2ban database from the server and returns all recorded bans, oldest first.
+func ReadBanHistory(ctx context.Context, conn Connector) ([]HistoricalBan, error) {
+	reader, ok := conn.(BanDatabaseReader)
+	if !ok {
+		return nil, fmt.Errorf("connector %s does not support reading the fail2ban database", conn.Server().Name)
+	}
+	data, err := reader.ReadBanDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp("", "fail2ban-db-*.sqlite3")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	return readBanHistoryFile(ctx, tmp.Name())
+}
+
+func readBanHistoryFile(ctx context.Context, path string) ([]HistoricalBan, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, `SELECT jail, ip, timeofban, data FROM bans ORDER BY timeofban`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bans from fail2ban database: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []HistoricalBan
+	for rows.Next() {
+		var (
+			jail, ip  sql.NullString
+			timeOfBan sql.NullInt64
+			data      sql.NullString
+		)
+		if err := rows.Scan(&jail, &ip, &timeOfBan, &data); err != nil {
+			return nil, err
+		}
+		if !jail.Valid || !timeOfBan.Valid || ValidateIP(ip.String) != nil {
+			continue
+		}
+		ban := HistoricalBan{
+			Jail:      jail.String,
+			IP:        ip.String,
+			TimeOfBan: time.Unix(timeOfBan.Int64, 0).UTC(),
+		}
+		ban.Failures, ban.Matches = parseBanTicketData(data.String)
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
+
+// Decodes the JSON "data" column of a ban ticket. Depending on the fail2ban version
+// matches are plain lines or lists of line fragments.
+func parseBanTicketData(data string) (int, []string) {
+	var ticket struct {
+		Failures int               `json:"failures"`
+		Matches  []json.RawMessage `json:"matches"`
+	}
+	if data == "" || json.Unmarshal([]byte(data), &ticket) != nil {
+		return 0, nil
+	}
+	matches := make([]string, 0, len(ticket.Matches))
+	for _, raw := range ticket.Matches {
+		var line string
+		if json.Unmarshal(raw, &line) == nil {
+			matches = append(matches, line)
+			continue
+		}
+		var parts []string
+		if json.Unmarshal(raw, &parts) == nil {
+			matches = append(matches, strings.Join(parts, ""))
+		}
+	}
+	return ticket.Failures, matches
+}
+
+// Extracts the database path from "fail2ban-client get dbfile" output.
+func parseDBFile(output string) (string, error) {
+	last := lastTreeValue(output)
+	if strings.HasPrefix(last, "/") {
+		return last, nil
+	}
+	return "", fmt.Errorf("fail2ban has no database file configured (dbfile = %q)", last)
+}
@@ -0,0 +1,64 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadBanHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fail2ban.sqlite3")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Schema as created by fail2ban 0.11.
+	stmts := []string{
+		`CREATE TABLE bans(jail TEXT NOT NULL, ip TEXT, timeofban INTEGER NOT NULL, bantime INTEGER NOT NULL, bancount INTEGER NOT NULL default 1, data JSON)`,
+		`INSERT INTO bans VALUES ('sshd', '192.0.2.1', 1700000100, 600, 1, '{"matches": ["Nov 14 22:13:20 host sshd[1]: Failed password"], "failures": 5}')`,
+		`INSERT INTO bans VALUES ('nginx-http-auth', '2001:db8::1', 1700000000, 600, 1, '{"matches": [["Nov 14 ", "auth failed"]], "failures": 3}')`,
+		`INSERT INTO bans VALUES ('sshd', 'not-an-ip', 1700000200, 600, 1, NULL)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	bans, err := readBanHistoryFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("readBanHistoryFile: %v", err)
+	}
+	if len(bans) != 2 {
+		t.Fatalf("expected 2 bans, got %+v", bans)
+	}
+	if bans[0].IP != "2001:db8::1" || bans[0].Failures != 3 || bans[0].Matches[0] != "Nov 14 auth failed" {
+		t.Fatalf("unexpected first ban %+v", bans[0])
+	}
+	if want := time.Unix(1700000100, 0).UTC(); bans[1].Jail != "sshd" || !bans[1].TimeOfBan.Equal(want) || len(bans[1].Matches) != 1 {
+		t.Fatalf("unexpected second ban %+v", bans[1])
+	}
+
+	if _, err := parseDBFile("Current database file is:\n`- None\n"); err == nil {
+		t.Fatalf("expected dbfile None to be rejected")
+	}
+}
@@ -0,0 +1,232 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =========================================================================
+//  Types
+// =========================================================================
+
+// A currently banned IP with its ban window. The times stay empty when the server
+// reports neither "banip --with-time" nor a readable ban database.
+type BannedIP struct {
+	IP               string     `json:"ip"`
+	BannedAt         *time.Time `json:"bannedAt,omitempty"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	Bantime          int64      `json:"bantime,omitempty"`
+	RemainingSeconds int64      `json:"remainingSeconds,omitempty"`
+	Permanent        bool       `json:"permanent,omitempty"`
+	BanCount         int        `json:"banCount,omitempty"`
+}
+
+// Start, duration (seconds, -1 for permanent) and bantime.increment count of a ban.
+type banWindow struct {
+	Start   time.Time
+	Bantime int64
+	Count   int
+}
+
+// 192.0.2.1 	2024-01-15 10:23:45 + 600 = 2024-01-15 10:33:45
+var banipWithTimeLineRe = regexp.MustCompile(`^(\S+)\s+(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) \+ (-?\d+) = `)
+
+// Plain IP strings, e.g. for callers that only need to know whether an IP is banned.
+func BannedIPStrings(ips []BannedIP) []string {
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.IP)
+	}
+	return out
+}
+
+// Sets the ban window and the time left relative to now.
+func (b *BannedIP) setWindow(start time.Time, bantime int64, now time.Time) {
+	if start.IsZero() {
+		return
+	}
+	start = start.UTC()
+	b.BannedAt = &start
+	b.Bantime = bantime
+	if bantime < 0 {
+		b.Permanent = true
+		return
+	}
+	end := start.Add(time.Duration(bantime) * time.Second)
+	b.ExpiresAt = &end
+	b.RemainingSeconds = max(0, int64(end.Sub(now).Seconds()))
+}
+
+// Recomputes the time left of bans reported by another host against the local clock.
+func refreshRemaining(ips []BannedIP, now time.Time) {
+	for i := range ips {
+		if ips[i].ExpiresAt != nil {
+			ips[i].RemainingSeconds = max(0, int64(ips[i].ExpiresAt.Sub(now).Seconds()))
+		}
+	}
+}
+
+// =========================================================================
+//  Ban Window Sources
+// =========================================================================
+
+// Combines the banned IPs of "fail2ban-client status" with the windows from
+// "banip --with-time" (preferred) and the ban database (fallback, and the only
+// source of the ban count). The order of the status output is kept.
+func mergeBannedIPs(ips []string, timed, recorded map[string]banWindow, now time.Time) []BannedIP {
+	out := make([]BannedIP, 0, len(ips))
+	for _, ip := range ips {
+		entry := BannedIP{IP: ip}
+		w, ok := timed[ip]
+		if !ok {
+			w = recorded[ip]
+		}
+		entry.setWindow(w.Start, w.Bantime, now)
+		if rec, ok := recorded[ip]; ok {
+			entry.BanCount = rec.Count
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// Parses "fail2ban-client get <jail> banip --with-time" output; times are in the server's zone.
+func parseBanipWithTime(output string, loc *time.Location) map[string]banWindow {
+	windows := make(map[string]banWindow)
+	for _, line := range strings.Split(output, "\n") {
+		m := banipWithTimeLineRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		start, err := time.ParseInLocation("2006-01-02 15:04:05", m[2], loc)
+		if err != nil {
+			continue
+		}
+		bantime, err := strconv.ParseInt(m[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		windows[m[1]] = banWindow{Start: start, Bantime: bantime}
+	}
+	return windows
+}
+
+// Reads the current bans of a jail from the "bips" table of fail2ban's database (0.11+).
+func readCurrentBansFile(ctx context.Context, path, jail string) (map[string]banWindow, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, `SELECT ip, timeofban, bantime, bancount FROM bips WHERE jail = ?`, jail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current bans from fail2ban database: %w", err)
+	}
+	defer rows.Close()
+
+	windows := make(map[string]banWindow)
+	for rows.Next() {
+		var (
+			ip                 string
+			timeOfBan, bantime int64
+			count              int
+		)
+		if err := rows.Scan(&ip, &timeOfBan, &bantime, &count); err != nil {
+			return nil, err
+		}
+		windows[ip] = banWindow{Start: time.Unix(timeOfBan, 0), Bantime: bantime, Count: count}
+	}
+	return windows, rows.Err()
+}
+
+// Shell script printing the server's UTC offset, the "banip --with-time" output and
+// the jail's rows of the "bips" table, one marker per line. A database the SSH user
+// cannot read is copied with "sudo -n cat" first; "@@dberr" says why it was not read:
+//
+//	@@tz +0200
+//	@@time 192.0.2.1 	2024-01-15 10:23:45 + 600 = 2024-01-15 10:33:45
+//	@@bip 192.0.2.1 1705310625 600 2
+//	@@dberr sudo: a password is required
+func bannedIPsProbeScript(client, jail string, withTime bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "C=%s\n", shellQuote(client))
+	b.WriteString("echo \"@@tz $(date +%z)\"\n")
+	if withTime {
+		fmt.Fprintf(&b, "$C get %s banip --with-time 2>/dev/null | sed 's/^/@@time /'\n", shellQuote(jail))
+	}
+	query := fmt.Sprintf("SELECT ip, timeofban, bantime, bancount FROM bips WHERE jail = '%s'", strings.ReplaceAll(jail, "'", "''"))
+	fmt.Fprintf(&b, `DB=$($C get dbfile 2>/dev/null | tail -n 1 | sed 's/^`+"`"+`- //')
+case "$DB" in /*) ;; *) exit 0;; esac
+command -v sqlite3 >/dev/null 2>&1 || { echo "@@dberr sqlite3 is not installed"; exit 0; }
+F=$DB
+if [ ! -r "$DB" ]; then
+  T=$(mktemp) || exit 0
+  trap 'rm -f "$T"' EXIT
+  if ! ERR=$(sudo -n cat "$DB" 2>&1 >"$T"); then echo "@@dberr $(printf '%%s\n' "$ERR" | head -n 1)"; exit 0; fi
+  F=$T
+fi
+if OUT=$(sqlite3 -readonly -separator ' ' "$F" %s 2>&1); then
+  printf '%%s\n' "$OUT" | sed -n 's/^./@@bip &/p'
+else
+  echo "@@dberr $(printf '%%s\n' "$OUT" | head -n 1)"
+fi
+exit 0
+`, shellQuote(query))
+	return b.String()
+}
+
+// Splits the probe output into the --with-time and database windows, and the
+// reason the database could not be read, if any.
+func parseBannedIPsProbe(output string) (timed, recorded map[string]banWindow, dbErr string) {
+	loc := time.UTC
+	var timeLines []string
+	recorded = make(map[string]banWindow)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "@@tz "):
+			if hostLoc := parseUTCOffset(strings.TrimPrefix(line, "@@tz ")); hostLoc != nil {
+				loc = hostLoc
+			}
+		case strings.HasPrefix(line, "@@dberr "):
+			dbErr = strings.TrimSpace(strings.TrimPrefix(line, "@@dberr "))
+		case strings.HasPrefix(line, "@@time "):
+			timeLines = append(timeLines, strings.TrimPrefix(line, "@@time "))
+		case strings.HasPrefix(line, "@@bip "):
+			fields := strings.Fields(strings.TrimPrefix(line, "@@bip "))
+			if len(fields) != 4 {
+				continue
+			}
+			start, err1 := strconv.ParseInt(fields[1], 10, 64)
+			bantime, err2 := strconv.ParseInt(fields[2], 10, 64)
+			count, err3 := strconv.Atoi(fields[3])
+			if err1 != nil || err2 != nil || err3 != nil {
+				continue
+			}
+			recorded[fields[0]] = banWindow{Start: time.Unix(start, 0), Bantime: bantime, Count: count}
+		}
+	}
+	return parseBanipWithTime(strings.Join(timeLines, "\n"), loc), recorded, dbErr
+}
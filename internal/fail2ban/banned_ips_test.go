@@ -0,0 +1,124 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBannedIPsWithTimeAndDatabase(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	probe := "@@tz +0100\n" +
+		"@@time 192.0.2.1 \t2024-01-15 10:00:00 + 3600 = 2024-01-15 11:00:00\n" +
+		"@@time 192.0.2.2 \t2024-01-15 09:30:00 + -1 = 2038-01-19 04:14:07\n" +
+		"@@bip 192.0.2.1 1705305600 3600 3\n" +
+		"@@bip 192.0.2.3 1705306500 600 1\n"
+	timed, recorded, dbErr := parseBannedIPsProbe(probe)
+	if dbErr != "" {
+		t.Fatalf("unexpected database error %q", dbErr)
+	}
+	ips := mergeBannedIPs([]string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}, timed, recorded, now)
+
+	first := ips[0]
+	if first.BannedAt == nil || !first.BannedAt.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected --with-time start converted from +0100, got %+v", first)
+	}
+	if first.RemainingSeconds != 3600 || first.BanCount != 3 || first.Bantime != 3600 {
+		t.Fatalf("unexpected window for first IP %+v", first)
+	}
+	if !ips[1].Permanent || ips[1].ExpiresAt != nil {
+		t.Fatalf("expected permanent ban, got %+v", ips[1])
+	}
+	if third := ips[2]; third.ExpiresAt == nil || !third.ExpiresAt.Equal(time.Unix(1705307100, 0)) || third.RemainingSeconds != 0 {
+		t.Fatalf("expected database fallback window, got %+v", third)
+	}
+	if fourth := ips[3]; fourth.IP != "192.0.2.4" || fourth.BannedAt != nil {
+		t.Fatalf("expected bare entry without a window, got %+v", fourth)
+	}
+}
+
+func TestReadCurrentBansFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fail2ban.sqlite3")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Schema as created by fail2ban 0.11.
+	stmts := []string{
+		`CREATE TABLE bips(ip TEXT NOT NULL, jail TEXT NOT NULL, timeofban INTEGER NOT NULL, bantime INTEGER NOT NULL, bancount INTEGER NOT NULL default 1, data JSON, PRIMARY KEY(ip, jail))`,
+		`INSERT INTO bips VALUES ('192.0.2.1', 'sshd', 1700000000, 1200, 2, '{}')`,
+		`INSERT INTO bips VALUES ('192.0.2.1', 'nginx', 1700000500, 600, 1, '{}')`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	windows, err := readCurrentBansFile(context.Background(), path, "sshd")
+	if err != nil {
+		t.Fatalf("readCurrentBansFile: %v", err)
+	}
+	w, ok := windows["192.0.2.1"]
+	if len(windows) != 1 || !ok || w.Bantime != 1200 || w.Count != 2 || w.Start.Unix() != 1700000000 {
+		t.Fatalf("unexpected windows %+v", windows)
+	}
+}
+
+func TestBannedIPsProbeReportsUnreadableDatabase(t *testing.T) {
+	_, recorded, dbErr := parseBannedIPsProbe("@@tz +0000\n@@dberr sudo: a password is required\n")
+	if len(recorded) != 0 || dbErr != "sudo: a password is required" {
+		t.Fatalf("unexpected result %v %q", recorded, dbErr)
+	}
+}
+
+// Runs the probe in a local shell with a stub client that reports the database path.
+func TestBannedIPsProbeScriptReadsDatabase(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "fail2ban.sqlite3")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE bips(ip TEXT, jail TEXT, timeofban INTEGER, bantime INTEGER, bancount INTEGER); INSERT INTO bips VALUES ('192.0.2.1', 'sshd', 1700000000, 600, 2)`); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	db.Close()
+	client := filepath.Join(dir, "fail2ban-client")
+	if err := os.WriteFile(client, []byte("#!/bin/sh\necho 'Current database file is:'\necho '`- "+dbPath+"'\n"), 0o755); err != nil {
+		t.Fatalf("write client: %v", err)
+	}
+
+	out, err := exec.Command("sh", "-c", bannedIPsProbeScript(client, "sshd", false)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("probe: %v: %s", err, out)
+	}
+	_, recorded, dbErr := parseBannedIPsProbe(string(out))
+	if dbErr != "" || recorded["192.0.2.1"].Count != 2 || recorded["192.0.2.1"].Bantime != 600 {
+		t.Fatalf("unexpected probe result %v %q from %s", recorded, dbErr, out)
+	}
+}
@@ -0,0 +1,392 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+// What a server's fail2ban installation supports. An empty Fail2banVersion means
+// detection failed; callers then assume everything is supported, as before detection existed.
+type ServerCapabilities struct {
+	Fail2banVersion string    `json:"fail2banVersion,omitempty"`
+	PythonVersion   string    `json:"pythonVersion,omitempty"`
+	Backend         string    `json:"backend,omitempty"`
+	Backends        []string  `json:"backends,omitempty"`
+	Actions         []string  `json:"actions,omitempty"`
+	Features        []string  `json:"features,omitempty"`
+	DetectedAt      time.Time `json:"detectedAt"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// Implemented by connectors that can query version, python and backend of the host.
+// Actions and features are filled in by GetServerCapabilities.
+type CapabilityDetector interface {
+	DetectCapabilities(ctx context.Context) (ServerCapabilities, error)
+}
+
+// Optional fail2ban features that depend on the version.
+const (
+	FeatureBantimeIncrement = "bantime.increment"
+	FeatureBanipWithTime    = "banip.with-time"
+)
+
+type fail2banFeature struct {
+	Name       string
+	MinVersion string
+	// Jail options that only exist with this feature.
+	Keys []string
+}
+
+var fail2banFeatures = []fail2banFeature{
+	{
+		Name:       FeatureBantimeIncrement,
+		MinVersion: "0.11.1",
+		Keys: []string{
+			"bantime.increment", "bantime.rndtime", "bantime.maxtime", "bantime.factor",
+			"bantime.formula", "bantime.multipliers", "bantime.overalljails",
+		},
+	},
+	{Name: FeatureBanipWithTime, MinVersion: "0.11.2"},
+}
+
+const (
+	capabilityCacheTTL      = 6 * time.Hour
+	capabilityErrorCacheTTL = 2 * time.Minute
+)
+
+var versionRe = regexp.MustCompile(`\d+\.\d+(?:\.\d+)*`)
+
+// =========================================================================
+//  Feature Checks
+// =========================================================================
+
+// Reports whether the detected version has the feature. Unknown versions support everything.
+func (c ServerCapabilities) Supports(feature string) bool {
+	if c.Fail2banVersion == "" {
+		return true
+	}
+	for _, f := range fail2banFeatures {
+		if f.Name == feature {
+			return CompareVersions(c.Fail2banVersion, f.MinVersion) >= 0
+		}
+	}
+	return true
+}
+
+// Reports whether a jail option is understood by the detected version.
+func (c ServerCapabilities) SupportsKey(key string) bool {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, f := range fail2banFeatures {
+		if slices.Contains(f.Keys, key) {
+			return c.Supports(f.Name)
+		}
+	}
+	return true
+}
+
+// Returns the minimum fail2ban version for a jail option, or "" if any version has it.
+func keyMinVersion(key string) string {
+	for _, f := range fail2banFeatures {
+		if slices.Contains(f.Keys, key) {
+			return f.MinVersion
+		}
+	}
+	return ""
+}
+
+// Compares dotted version strings numerically; missing parts count as 0.
+func CompareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Comments out jail options the server's fail2ban does not understand, so an older
+// version does not refuse to load the jail. Returns the content and the disabled keys.
+func FilterUnsupportedJailKeys(content string, caps ServerCapabilities) (string, []string) {
+	if caps.Fail2banVersion == "" {
+		return content, nil
+	}
+	var skipped []string
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") || line != strings.TrimLeft(line, " \t") {
+			continue
+		}
+		key, _, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if caps.SupportsKey(key) {
+			continue
+		}
+		lines[i] = fmt.Sprintf("# %s  (needs fail2ban >= %s, server runs %s)", line, keyMinVersion(key), caps.Fail2banVersion)
+		if !slices.Contains(skipped, key) {
+			skipped = append(skipped, key)
+		}
+	}
+	return strings.Join(lines, "\n"), skipped
+}
+
+// =========================================================================
+//  Detection and Cache
+// =========================================================================
+
+type capabilityEntry struct {
+	connKey string
+	caps    ServerCapabilities
+}
+
+var capabilityCache = struct {
+	mu       sync.Mutex
+	entries  map[string]capabilityEntry
+	inFlight map[string]bool
+}{entries: make(map[string]capabilityEntry), inFlight: make(map[string]bool)}
+
+// Identifies how a server is reached, so editing the connection invalidates its cached capabilities.
+func capabilityConnKey(server shared.Fail2banServer) string {
+	return strings.Join([]string{server.Type, server.Host, strconv.Itoa(server.Port), server.AgentURL, server.SocketPath, server.ConfigPath}, "|")
+}
+
+// Returns the cached capabilities of a server without contacting it.
+func CachedServerCapabilities(server shared.Fail2banServer) (ServerCapabilities, bool) {
+	capabilityCache.mu.Lock()
+	defer capabilityCache.mu.Unlock()
+	entry, ok := capabilityCache.entries[server.ID]
+	if !ok || entry.connKey != capabilityConnKey(server) {
+		return ServerCapabilities{}, false
+	}
+	return entry.caps, true
+}
+
+// Looks up the cache by server ID alone, for callers that have no connector yet.
+func cachedCapabilitiesForID(serverID string) ServerCapabilities {
+	capabilityCache.mu.Lock()
+	defer capabilityCache.mu.Unlock()
+	return capabilityCache.entries[serverID].caps
+}
+
+// Returns the server's capabilities, detecting them when the cache is empty, stale or refresh is set.
+func GetServerCapabilities(ctx context.Context, conn Connector, refresh bool) ServerCapabilities {
+	server := conn.Server()
+	if caps, ok := CachedServerCapabilities(server); ok && !refresh {
+		ttl := capabilityCacheTTL
+		if caps.Error != "" {
+			ttl = capabilityErrorCacheTTL
+		}
+		if time.Since(caps.DetectedAt) < ttl {
+			return caps
+		}
+	}
+	caps := detectServerCapabilities(ctx, conn)
+	capabilityCache.mu.Lock()
+	capabilityCache.entries[server.ID] = capabilityEntry{connKey: capabilityConnKey(server), caps: caps}
+	capabilityCache.mu.Unlock()
+	return caps
+}
+
+// Detects capabilities in the background unless cached or already running.
+func RefreshServerCapabilitiesAsync(conn Connector, timeout time.Duration) {
+	server := conn.Server()
+	if _, ok := CachedServerCapabilities(server); ok {
+		return
+	}
+	capabilityCache.mu.Lock()
+	if capabilityCache.inFlight[server.ID] {
+		capabilityCache.mu.Unlock()
+		return
+	}
+	capabilityCache.inFlight[server.ID] = true
+	capabilityCache.mu.Unlock()
+	go func() {
+		defer func() {
+			capabilityCache.mu.Lock()
+			delete(capabilityCache.inFlight, server.ID)
+			capabilityCache.mu.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		GetServerCapabilities(ctx, conn, false)
+	}()
+}
+
+func detectServerCapabilities(ctx context.Context, conn Connector) ServerCapabilities {
+	var caps ServerCapabilities
+	if detector, ok := conn.(CapabilityDetector); ok {
+		detected, err := detector.DetectCapabilities(ctx)
+		caps = detected
+		if err != nil {
+			caps.Error = err.Error()
+		}
+	} else {
+		caps.Error = "capability detection is not supported by this connector"
+	}
+	if actions, err := conn.GetActions(ctx); err == nil {
+		caps.Actions = actions
+	}
+	if caps.Fail2banVersion != "" {
+		for _, f := range fail2banFeatures {
+			if caps.Supports(f.Name) {
+				caps.Features = append(caps.Features, f.Name)
+			}
+		}
+	}
+	caps.DetectedAt = time.Now().UTC()
+	debugf("Detected capabilities of %s: fail2ban %q, python %q, backend %q", conn.Server().Name, caps.Fail2banVersion, caps.PythonVersion, caps.Backend)
+	return caps
+}
+
+// =========================================================================
+//  Probe Script (local and SSH)
+// =========================================================================
+
+// Shell script that prints the fail2ban and python versions, the importable backend
+// modules and the jail config files, so [DEFAULT] backend can be resolved.
+// client is the fail2ban-client invocation (with sudo and -s where needed).
+func capabilityProbeScript(client, configDir string) string {
+	return fmt.Sprintf(`C=%[1]s
+D=%[2]s
+echo "@@server $($C version 2>/dev/null | tail -n 1)"
+echo "@@client $(fail2ban-client --version 2>/dev/null | head -n 1)"
+P=$(head -n 1 "$(command -v fail2ban-client)" 2>/dev/null | sed -n 's/^#! *//p')
+[ -n "$P" ] || P=python3
+echo "@@python $($P -c 'import sys; print(sys.version.split()[0])' 2>/dev/null)"
+$P -c 'import pyinotify' >/dev/null 2>&1 && echo "@@module pyinotify"
+$P -c 'import systemd.journal' >/dev/null 2>&1 && echo "@@module systemd"
+for f in "$D/jail.conf" "$D"/jail.d/*.conf "$D/jail.local" "$D"/jail.d/*.local; do
+  [ -f "$f" ] && { echo "@@file $f"; cat "$f"; echo; }
+done
+exit 0
+`, shellQuote(client), shellQuote(configDir))
+}
+
+// Parses the output of capabilityProbeScript.
+func parseCapabilityProbe(out string) (ServerCapabilities, error) {
+	var caps ServerCapabilities
+	var serverVersion, clientVersion, configured string
+	modules := map[string]bool{}
+	var file strings.Builder
+	flushFile := func() {
+		if v := defaultSectionValue(file.String(), "backend"); v != "" {
+			configured = v
+		}
+		file.Reset()
+	}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "@@server "):
+			serverVersion = versionRe.FindString(line)
+		case strings.HasPrefix(line, "@@client "):
+			clientVersion = versionRe.FindString(line)
+		case strings.HasPrefix(line, "@@python "):
+			caps.PythonVersion = strings.TrimSpace(strings.TrimPrefix(line, "@@python "))
+		case strings.HasPrefix(line, "@@module "):
+			modules[strings.TrimSpace(strings.TrimPrefix(line, "@@module "))] = true
+		case strings.HasPrefix(line, "@@file "):
+			flushFile()
+		default:
+			file.WriteString(line)
+			file.WriteByte('\n')
+		}
+	}
+	flushFile()
+
+	// The running server is authoritative; the client binary is the fallback when it is down.
+	caps.Fail2banVersion = serverVersion
+	if caps.Fail2banVersion == "" {
+		caps.Fail2banVersion = clientVersion
+	}
+	caps.Backends = []string{"polling"}
+	for _, m := range []string{"pyinotify", "systemd"} {
+		if modules[m] {
+			caps.Backends = append(caps.Backends, m)
+		}
+	}
+	caps.Backend = resolveBackend(configured, modules)
+	if caps.Fail2banVersion == "" {
+		return caps, fmt.Errorf("could not determine the fail2ban version (is fail2ban-client installed?)")
+	}
+	return caps, nil
+}
+
+// Resolves the [DEFAULT] backend the way fail2ban does for "auto" (pyinotify, else polling).
+func resolveBackend(configured string, modules map[string]bool) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(configured), "[")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name != "" && name != "auto" {
+		return name
+	}
+	if modules["pyinotify"] {
+		return "pyinotify"
+	}
+	return "polling"
+}
+
+// Returns the value of key in the [DEFAULT] section of an ini file, or "".
+func defaultSectionValue(content, key string) string {
+	inDefault := false
+	value := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			inDefault = strings.EqualFold(trimmed, "[DEFAULT]")
+			continue
+		}
+		if !inDefault || trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		k, v, ok := strings.Cut(trimmed, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			value = strings.TrimSpace(v)
+		}
+	}
+	return value
+}
@@ -0,0 +1,155 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"0.10.2", "0.11.1", -1},
+		{"0.11.1", "0.11.1", 0},
+		{"0.11", "0.11.0", 0},
+		{"1.0.2", "0.11.2", 1},
+		{"0.9.10", "0.10.0", -1},
+	}
+	for _, tc := range cases {
+		if got := CompareVersions(tc.a, tc.b); got != tc.want {
+			t.Fatalf("CompareVersions(%s, %s) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestParseCapabilityProbe(t *testing.T) {
+	out := strings.Join([]string{
+		"@@server 1.0.2",
+		"@@client Fail2Ban v1.0.2",
+		"@@python 3.11.2",
+		"@@module systemd",
+		"@@file /etc/fail2ban/jail.conf",
+		"[DEFAULT]",
+		"backend = auto",
+		"[sshd]",
+		"backend = %(sshd_backend)s",
+		"@@file /etc/fail2ban/jail.d/defaults-debian.conf",
+		"[DEFAULT]",
+		"backend = systemd[journalflags=1]",
+	}, "\n")
+	caps, err := parseCapabilityProbe(out)
+	if err != nil {
+		t.Fatalf("parseCapabilityProbe: %v", err)
+	}
+	if caps.Fail2banVersion != "1.0.2" || caps.PythonVersion != "3.11.2" || caps.Backend != "systemd" {
+		t.Fatalf("unexpected capabilities %+v", caps)
+	}
+	if !slices.Equal(caps.Backends, []string{"polling", "systemd"}) {
+		t.Fatalf("unexpected backends %v", caps.Backends)
+	}
+
+	// A stopped server falls back to the client version, "auto" resolves like fail2ban does.
+	caps, err = parseCapabilityProbe("@@server \n@@client Fail2Ban v0.10.2\n@@module pyinotify\n")
+	if err != nil || caps.Fail2banVersion != "0.10.2" || caps.Backend != "pyinotify" {
+		t.Fatalf("unexpected fallback result %+v (%v)", caps, err)
+	}
+
+	if _, err := parseCapabilityProbe("@@server \n@@client \n"); err == nil {
+		t.Fatalf("expected an error without any version")
+	}
+}
+
+func TestFilterUnsupportedJailKeys(t *testing.T) {
+	content := "[sshd]\nenabled = true\nbantime.increment = true\n  bantime.factor = 2\n# bantime.maxtime = 1w\nbantime.maxtime = 1w\n"
+
+	out, skipped := FilterUnsupportedJailKeys(content, ServerCapabilities{})
+	if out != content || skipped != nil {
+		t.Fatalf("unknown versions must not change the content")
+	}
+	out, skipped = FilterUnsupportedJailKeys(content, ServerCapabilities{Fail2banVersion: "1.0.2"})
+	if out != content || skipped != nil {
+		t.Fatalf("1.0.2 supports bantime.*, got %q %v", out, skipped)
+	}
+
+	out, skipped = FilterUnsupportedJailKeys(content, ServerCapabilities{Fail2banVersion: "0.10.2"})
+	if !slices.Equal(skipped, []string{"bantime.increment", "bantime.maxtime"}) {
+		t.Fatalf("unexpected skipped keys %v", skipped)
+	}
+	for _, want := range []string{
+		"enabled = true\n",
+		"# bantime.increment = true  (needs fail2ban >= 0.11.1, server runs 0.10.2)\n",
+		// Continuation lines belong to the previous value and are left alone.
+		"\n  bantime.factor = 2\n",
+		"\n# bantime.maxtime = 1w\n# bantime.maxtime = 1w  (needs",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestGetServerCapabilitiesAgentCache(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/capabilities":
+			calls.Add(1)
+			_, _ = w.Write([]byte(`{"fail2banVersion":"Fail2Ban v0.11.2","pythonVersion":"3.9.2","backend":"systemd","backends":["polling","systemd"]}`))
+		case "/v1/actions":
+			_, _ = w.Write([]byte(`{"actions":["iptables-multiport","nftables"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	server := shared.Fail2banServer{ID: "caps-agent", Name: "agent", Type: "agent", AgentURL: srv.URL, AgentSecret: "secret123"}
+	conn, err := NewAgentConnector(server)
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	caps := GetServerCapabilities(context.Background(), conn, false)
+	if caps.Fail2banVersion != "0.11.2" || caps.Backend != "systemd" || caps.Error != "" {
+		t.Fatalf("unexpected capabilities %+v", caps)
+	}
+	if !slices.Equal(caps.Features, []string{FeatureBantimeIncrement, FeatureBanipWithTime}) {
+		t.Fatalf("unexpected features %v", caps.Features)
+	}
+	GetServerCapabilities(context.Background(), conn, false)
+	if calls.Load() != 1 {
+		t.Fatalf("expected the second lookup to be cached, agent was asked %d times", calls.Load())
+	}
+	GetServerCapabilities(context.Background(), conn, true)
+	if calls.Load() != 2 {
+		t.Fatalf("refresh must detect again")
+	}
+
+	server.AgentURL = "http://127.0.0.1:1"
+	if _, ok := CachedServerCapabilities(server); ok {
+		t.Fatalf("a changed connection must not use the cached capabilities")
+	}
+}
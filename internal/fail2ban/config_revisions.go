@@ -0,0 +1,329 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	ConfigKindJail   = "jail"
+	ConfigKindFilter = "filter"
+	ConfigKindAction = "action"
+)
+
+// Content of a jail, filter or action file captured before a connector writes to it.
+// Exists is false when there was nothing to read, e.g. before a create;
+// rolling back to such a revision removes the file again.
+type ConfigRevision struct {
+	ServerID    string
+	Kind        string
+	Name        string
+	Path        string
+	Action      string
+	Author      string
+	Content     string
+	ContentHash string
+	Exists      bool
+}
+
+type revisionContextKey struct{}
+
+type revisionMeta struct {
+	author string
+	action string
+}
+
+// Line pairs compared by UnifiedDiff beyond which the whole file is shown as replaced.
+const diffMaxCells = 4_000_000
+
+// =========================================================================
+//  Request Context
+// =========================================================================
+
+// Attaches the user responsible for config writes made with ctx.
+func WithRevisionAuthor(ctx context.Context, author string) context.Context {
+	meta := revisionMetaFrom(ctx)
+	meta.author = author
+	return context.WithValue(ctx, revisionContextKey{}, meta)
+}
+
+func withRevisionAction(ctx context.Context, action string) context.Context {
+	meta := revisionMetaFrom(ctx)
+	meta.action = action
+	return context.WithValue(ctx, revisionContextKey{}, meta)
+}
+
+func revisionMetaFrom(ctx context.Context) revisionMeta {
+	meta, _ := ctx.Value(revisionContextKey{}).(revisionMeta)
+	return meta
+}
+
+// =========================================================================
+//  Snapshots
+// =========================================================================
+
+// Records the current content of a jail, filter or action before it is overwritten.
+// A failure aborts the write, so no change goes unrecorded.
+func snapshotConfig(ctx context.Context, conn Connector, kind, name, action string) error {
+	var content, path string
+	var err error
+	switch kind {
+	case ConfigKindJail:
+		content, path, err = conn.GetJailConfig(ctx, name)
+	case ConfigKindFilter:
+		content, path, err = conn.GetFilterConfig(ctx, name)
+	case ConfigKindAction:
+		content, path, err = conn.GetActionConfig(ctx, name)
+	default:
+		return fmt.Errorf("unknown config kind %q", kind)
+	}
+	// Only a missing file counts as absent: restoring such a revision deletes
+	// the file, so any other read failure aborts the write instead.
+	exists := true
+	switch {
+	case errors.Is(err, ErrConfigNotFound):
+		exists = false
+	case err != nil:
+		return fmt.Errorf("failed to read %s %s before writing it: %w", kind, name, err)
+	case kind == ConfigKindJail && content == fmt.Sprintf("[%s]\n", name):
+		// Connectors return a bare section header for jails without a file.
+		exists = false
+	}
+	if !exists {
+		content = ""
+	}
+	meta := revisionMetaFrom(ctx)
+	if meta.action != "" {
+		action = meta.action
+	}
+	rev := ConfigRevision{
+		ServerID:    conn.Server().ID,
+		Kind:        kind,
+		Name:        name,
+		Path:        path,
+		Action:      action,
+		Author:      meta.author,
+		Content:     content,
+		ContentHash: ConfigContentHash(content),
+		Exists:      exists,
+	}
+	if err := mustProvider().RecordConfigRevision(ctx, rev); err != nil {
+		return fmt.Errorf("failed to record revision of %s %s: %w", kind, name, err)
+	}
+	return nil
+}
+
+func snapshotJailStates(ctx context.Context, conn Connector, updates map[string]bool) error {
+	for jail := range updates {
+		jail = strings.TrimSpace(jail)
+		if jail == "" || ValidateJailName(jail) != nil {
+			continue
+		}
+		if err := snapshotConfig(ctx, conn, ConfigKindJail, jail, "enable"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the hex SHA-256 of a file's content.
+func ConfigContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Writes a recorded revision back through the connector. The write itself is
+// recorded as a "rollback" revision, so a rollback can be undone as well.
+func RestoreConfigRevision(ctx context.Context, conn Connector, rev ConfigRevision) error {
+	ctx = withRevisionAction(ctx, "rollback")
+	switch rev.Kind {
+	case ConfigKindJail:
+		if !rev.Exists {
+			return conn.DeleteJail(ctx, rev.Name)
+		}
+		return conn.SetJailConfig(ctx, rev.Name, rev.Content)
+	case ConfigKindFilter:
+		if !rev.Exists {
+			return conn.DeleteFilter(ctx, rev.Name)
+		}
+		return conn.SetFilterConfig(ctx, rev.Name, rev.Content)
+	case ConfigKindAction:
+		if !rev.Exists {
+			return conn.DeleteAction(ctx, rev.Name)
+		}
+		return conn.SetActionConfig(ctx, rev.Name, rev.Content)
+	}
+	return fmt.Errorf("unknown config kind %q", rev.Kind)
+}
+
+// Returns the current content of a jail, filter or action, or "" when it does not exist.
+func CurrentConfigContent(ctx context.Context, conn Connector, kind, name string) (string, string, bool) {
+	var content, path string
+	var err error
+	switch kind {
+	case ConfigKindJail:
+		content, path, err = conn.GetJailConfig(ctx, name)
+	case ConfigKindFilter:
+		content, path, err = conn.GetFilterConfig(ctx, name)
+	case ConfigKindAction:
+		content, path, err = conn.GetActionConfig(ctx, name)
+	default:
+		return "", "", false
+	}
+	if err != nil {
+		return "", "", false
+	}
+	return content, path, true
+}
+
+// =========================================================================
+//  Unified Diff
+// =========================================================================
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// Returns a unified diff (3 lines of context) between two file contents,
+// or "" when they are equal.
+func UnifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+	ops := diffLines(splitDiffLines(from), splitDiffLines(to))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	const diffContext = 3
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	// Line numbers in each file before ops[i].
+	fromLine := make([]int, len(ops)+1)
+	toLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+		if op.kind != '+' {
+			fromLine[i+1]++
+		}
+		if op.kind != '-' {
+			toLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext+1 {
+			j++
+		}
+		start := max(changes[i]-diffContext, 0)
+		end := min(changes[j]+diffContext+1, len(ops))
+		fromCount := fromLine[end] - fromLine[start]
+		toCount := toLine[end] - toLine[start]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(fromLine[start], fromCount), hunkRange(toLine[start], toCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			b.WriteByte('\n')
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Computes a line diff from the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	if len(a)*len(b) > diffMaxCells {
+		ops := make([]diffOp, 0, len(a)+len(b))
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,175 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+type revisionRecorder struct {
+	testProvider
+	mu   sync.Mutex
+	revs []ConfigRevision
+}
+
+func (r *revisionRecorder) RecordConfigRevision(ctx context.Context, rev ConfigRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revs = append(r.revs, rev)
+	return nil
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "[sshd]\nenabled = true\nport = ssh\nfilter = sshd\nlogpath = /var/log/auth.log\nmaxretry = 5\nbantime = 600\nfindtime = 600\nbackend = auto\nignoreip = 127.0.0.1\n"
+	to := "[sshd]\nenabled = true\nport = ssh\nfilter = sshd\nlogpath = /var/log/auth.log\nmaxretry = 3\nbantime = 600\nfindtime = 600\nbackend = auto\nignoreip = 127.0.0.1\nmode = aggressive\n"
+
+	want := `--- a
++++ b
+@@ -3,8 +3,9 @@
+ port = ssh
+ filter = sshd
+ logpath = /var/log/auth.log
+-maxretry = 5
++maxretry = 3
+ bantime = 600
+ findtime = 600
+ backend = auto
+ ignoreip = 127.0.0.1
++mode = aggressive
+`
+	if got := UnifiedDiff("a", "b", from, to); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	// Changes further apart than twice the context get separate hunks.
+	long := strings.Repeat("x\n", 20)
+	if got := UnifiedDiff("a", "b", "1\n"+long+"2\n", "one\n"+long+"two\n"); strings.Count(got, "@@ -") != 2 {
+		t.Fatalf("expected two hunks, got:\n%s", got)
+	}
+	if got := UnifiedDiff("a", "b", from, from); got != "" {
+		t.Fatalf("expected empty diff for equal content, got %q", got)
+	}
+	if got := UnifiedDiff("a", "b", "", "[x]\n"); got != "--- a\n+++ b\n@@ -0,0 +1 @@\n+[x]\n" {
+		t.Fatalf("unexpected diff for new file: %q", got)
+	}
+}
+
+func TestLocalConnectorRecordsRevisionsAndRollsBack(t *testing.T) {
+	recorder := &revisionRecorder{}
+	SetProvider(recorder)
+	defer SetProvider(noopProvider{})
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "jail.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "local-rev", Name: "local", Type: "local", ConfigPath: dir}}
+	ctx := WithRevisionAuthor(context.Background(), "alice")
+
+	if err := lc.CreateJail(ctx, "demo", "[demo]\nenabled = false\n"); err != nil {
+		t.Fatalf("CreateJail: %v", err)
+	}
+	if err := lc.SetJailConfig(ctx, "demo", "[demo]\nenabled = true\n"); err != nil {
+		t.Fatalf("SetJailConfig: %v", err)
+	}
+	if len(recorder.revs) != 2 {
+		t.Fatalf("expected 2 revisions, got %+v", recorder.revs)
+	}
+	created, updated := recorder.revs[0], recorder.revs[1]
+	if created.Action != "create" || created.Exists || created.Author != "alice" {
+		t.Fatalf("unexpected create revision %+v", created)
+	}
+	if updated.Action != "update" || !updated.Exists || !strings.Contains(updated.Content, "enabled = false") {
+		t.Fatalf("unexpected update revision %+v", updated)
+	}
+	if updated.ContentHash != ConfigContentHash(updated.Content) || updated.ServerID != "local-rev" {
+		t.Fatalf("unexpected hash or server in %+v", updated)
+	}
+
+	if err := RestoreConfigRevision(ctx, lc, updated); err != nil {
+		t.Fatalf("RestoreConfigRevision: %v", err)
+	}
+	content, _, err := lc.GetJailConfig(ctx, "demo")
+	if err != nil || !strings.Contains(content, "enabled = false") {
+		t.Fatalf("expected rolled back content, got %q (%v)", content, err)
+	}
+	if last := recorder.revs[len(recorder.revs)-1]; last.Action != "rollback" || !strings.Contains(last.Content, "enabled = true") {
+		t.Fatalf("expected the rollback to be recorded, got %+v", last)
+	}
+
+	// Rolling back past the create removes the jail again.
+	if err := RestoreConfigRevision(ctx, lc, created); err != nil {
+		t.Fatalf("RestoreConfigRevision (create): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "jail.d", "demo.local")); !os.IsNotExist(err) {
+		t.Fatalf("expected jail file to be removed, stat err=%v", err)
+	}
+}
+
+// Fails every jail read, like an SSH session that timed out.
+type unreachableJailConnector struct {
+	*LocalConnector
+}
+
+func (c unreachableJailConnector) GetJailConfig(ctx context.Context, jail string) (string, string, error) {
+	return "", "", errors.New("ssh: handshake failed: i/o timeout")
+}
+
+func TestSnapshotRefusesWhenJailCannotBeRead(t *testing.T) {
+	recorder := &revisionRecorder{}
+	SetProvider(recorder)
+	defer SetProvider(noopProvider{})
+
+	dir := t.TempDir()
+	jailPath := filepath.Join(dir, "jail.d", "demo.local")
+	if err := os.MkdirAll(filepath.Dir(jailPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jailPath, []byte("[demo]\nenabled = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "local-rev", Name: "local", Type: "local", ConfigPath: dir}}
+	ctx := context.Background()
+
+	if err := snapshotConfig(ctx, unreachableJailConnector{lc}, ConfigKindJail, "demo", "update"); err == nil {
+		t.Fatal("expected the snapshot to fail when the jail cannot be read")
+	}
+	if len(recorder.revs) != 0 {
+		t.Fatalf("a failed read must not be recorded as an absent file, got %+v", recorder.revs)
+	}
+
+	// A missing file is still recorded as absent, and restoring it removes only that file.
+	if err := snapshotConfig(ctx, lc, ConfigKindJail, "other", "create"); err != nil {
+		t.Fatalf("snapshot of a missing jail: %v", err)
+	}
+	if len(recorder.revs) != 1 || recorder.revs[0].Exists {
+		t.Fatalf("expected one absent revision, got %+v", recorder.revs)
+	}
+	if err := RestoreConfigRevision(ctx, lc, recorder.revs[0]); err == nil {
+		t.Fatal("expected deleting a jail without a file to fail")
+	}
+	if _, err := os.Stat(jailPath); err != nil {
+		t.Fatalf("the existing jail must survive, stat err=%v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// =========================================================================
+//  Types
+// =========================================================================
+
+// One problem reported by "fail2ban-client -t".
+type ConfigTestIssue struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Jail    string `json:"jail,omitempty"`
+	Message string `json:"message"`
+}
+
+// Returned by jail, filter and action writes that were refused because the
+// configuration test failed on the staged tree.
+type ConfigValidationError struct {
+	Kind   string            `json:"kind"`
+	Name   string            `json:"name"`
+	Output string            `json:"output"`
+	Issues []ConfigTestIssue `json:"issues"`
+}
+
+func (e *ConfigValidationError) Error() string {
+	msg := fmt.Sprintf("fail2ban configuration test failed for %s %s", e.Kind, e.Name)
+	if len(e.Issues) == 0 {
+		return msg
+	}
+	first := e.Issues[0]
+	if first.File != "" && first.Line > 0 {
+		msg += fmt.Sprintf(": %s line %d: %s", first.File, first.Line, first.Message)
+	} else {
+		msg += ": " + first.Message
+	}
+	if len(e.Issues) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Issues)-1)
+	}
+	return msg
+}
+
+// Returned when a change cannot be staged for the config test. The write is
+// refused, since an untested change could stop fail2ban from reloading.
+var ErrConfigNotTested = errors.New("configuration change could not be tested")
+
+var (
+	configTestErrorRe = regexp.MustCompile(`\b(?:ERROR|CRITICAL)\b:?\s+(.*)$`)
+	configTestFileRe  = regexp.MustCompile(`'(/[^']+)'`)
+	configTestLineRe  = regexp.MustCompile(`\[line\s+(\d+)\]`)
+	configTestJailRe  = regexp.MustCompile(`(?:jail|section) '([^']+)'|for (\S+) jail`)
+)
+
+// =========================================================================
+//  Output Parsing
+// =========================================================================
+
+func newConfigValidationError(kind, name, output string) *ConfigValidationError {
+	output = strings.TrimSpace(output)
+	issues := ParseConfigTestOutput(output)
+	if len(issues) == 0 && output != "" {
+		issues = []ConfigTestIssue{{Message: lastTreeValue(output)}}
+	}
+	return &ConfigValidationError{Kind: kind, Name: name, Output: output, Issues: issues}
+}
+
+// Extracts errors from "fail2ban-client -t" output. Indented lines following an
+// error (e.g. the "[line 3]: ..." details of a parse error) belong to it.
+func ParseConfigTestOutput(output string) []ConfigTestIssue {
+	var issues []ConfigTestIssue
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimRight(raw, "\r")
+		if m := configTestErrorRe.FindStringSubmatch(line); m != nil {
+			issues = append(issues, ConfigTestIssue{Message: strings.TrimSpace(m[1])})
+			annotateConfigTestIssue(&issues[len(issues)-1], line)
+			continue
+		}
+		if len(issues) > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			last := &issues[len(issues)-1]
+			last.Message += " " + strings.TrimSpace(line)
+			annotateConfigTestIssue(last, line)
+		}
+	}
+	return issues
+}
+
+func annotateConfigTestIssue(issue *ConfigTestIssue, line string) {
+	if issue.File == "" {
+		if m := configTestFileRe.FindStringSubmatch(line); m != nil {
+			issue.File = m[1]
+		}
+	}
+	if issue.Line == 0 {
+		if m := configTestLineRe.FindStringSubmatch(line); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+		}
+	}
+	if issue.Jail == "" {
+		if m := configTestJailRe.FindStringSubmatch(line); m != nil {
+			issue.Jail = m[1] + m[2]
+		}
+	}
+}
+
+// =========================================================================
+//  Staging
+// =========================================================================
+
+func validateConfigName(kind, name string) error {
+	switch kind {
+	case ConfigKindFilter:
+		return ValidateFilterName(name)
+	case ConfigKindAction:
+		return ValidateActionName(name)
+	}
+	return ValidateJailName(name)
+}
+
+// Path of the file a jail, filter or action write goes to, relative to the config root.
+func stagedConfigFile(kind, name string) string {
+	switch kind {
+	case ConfigKindFilter:
+		return filepath.Join("filter.d", name+".local")
+	case ConfigKindAction:
+		return filepath.Join("action.d", name+".local")
+	}
+	return filepath.Join("jail.d", name+".local")
+}
+
+// Jail files are written with their section header; stage them the same way.
+func stagedConfigContent(kind, name, content string) string {
+	if kind != ConfigKindJail {
+		return content
+	}
+	section := fmt.Sprintf("[%s]", name)
+	if !strings.HasPrefix(strings.TrimSpace(content), section) {
+		return section + "\n" + content
+	}
+	return content
+}
+
+// Copies the config tree into a temporary directory, applies the change and runs
+// "fail2ban-client -t" on it. Without fail2ban-client the change is not tested;
+// when the tree cannot be staged the write is refused with ErrConfigNotTested.
+func testConfigChangeLocal(ctx context.Context, configPath, kind, name, content string) error {
+	if err := validateConfigName(kind, name); err != nil {
+		return err
+	}
+	client, err := exec.LookPath("fail2ban-client")
+	if err != nil {
+		debugf("fail2ban-client not found, skipping config test of %s %s", kind, name)
+		return nil
+	}
+	staged, err := os.MkdirTemp("", "fail2ban-ui-stage-")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigNotTested, err)
+	}
+	defer os.RemoveAll(staged)
+	if err := copyConfigTree(configPath, staged); err != nil {
+		return fmt.Errorf("%w: failed to stage %s: %v", ErrConfigNotTested, configPath, err)
+	}
+	target := filepath.Join(staged, stagedConfigFile(kind, name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigNotTested, err)
+	}
+	if err := os.WriteFile(target, []byte(stagedConfigContent(kind, name, content)), 0o644); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigNotTested, err)
+	}
+
+	out, err := exec.CommandContext(ctx, client, "-c", staged, "-t").CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("%w: %v", ErrConfigNotTested, err)
+	}
+	return newConfigValidationError(kind, name, strings.ReplaceAll(string(out), staged, configPath))
+}
+
+// Copies regular files below src into dst, following symlinked files.
+func copyConfigTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			// Dangling links and sockets are not part of the configuration.
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
@@ -0,0 +1,112 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestParseConfigTestOutput(t *testing.T) {
+	output := strings.Join([]string{
+		"ERROR  Failed during configuration: Source contains parsing errors: '/etc/fail2ban/jail.d/sshd.local'",
+		"\t[line  3]: 'maxretry 5\\n'",
+		"ERROR  Errors in jail 'sshd'. Skipping...",
+		"ERROR  Failed during configuration: Have not found any log file for nginx jail",
+		"OK: configuration test is successful",
+	}, "\n")
+
+	issues := ParseConfigTestOutput(output)
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	if issues[0].File != "/etc/fail2ban/jail.d/sshd.local" || issues[0].Line != 3 || !strings.Contains(issues[0].Message, "maxretry 5") {
+		t.Fatalf("unexpected parse error issue %+v", issues[0])
+	}
+	if issues[1].Jail != "sshd" || issues[2].Jail != "nginx" {
+		t.Fatalf("unexpected jail attribution %+v", issues)
+	}
+}
+
+// Installs a fake fail2ban-client that fails when the staged jail contains "broken".
+func installFakeFail2banClient(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	script := `#!/bin/sh
+[ "$1" = "-c" ] && [ "$3" = "-t" ] || exit 2
+if grep -q broken "$2/jail.d/demo.local" 2>/dev/null; then
+	echo "ERROR  Failed during configuration: Source contains parsing errors: '$2/jail.d/demo.local'" >&2
+	echo "	[line  2]: 'broken'" >&2
+	exit 255
+fi
+echo "OK: configuration test is successful"
+`
+	if err := os.WriteFile(filepath.Join(bin, "fail2ban-client"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestLocalConnectorRefusesInvalidJail(t *testing.T) {
+	installFakeFail2banClient(t)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "jail.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "jail.conf"), []byte("[DEFAULT]\nbantime = 600\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "local-test", Name: "local", Type: "local", ConfigPath: dir}}
+	ctx := context.Background()
+
+	err := lc.CreateJail(ctx, "demo", "[demo]\nbroken\n")
+	var validationErr *ConfigValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ConfigValidationError, got %v", err)
+	}
+	issue := validationErr.Issues[0]
+	if issue.File != filepath.Join(dir, "jail.d", "demo.local") || issue.Line != 2 {
+		t.Fatalf("expected issue to point at the real jail file, got %+v", issue)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "jail.d", "demo.local")); !os.IsNotExist(err) {
+		t.Fatalf("refused write must not create the jail file, stat err=%v", err)
+	}
+
+	if err := lc.CreateJail(ctx, "demo", "[demo]\nenabled = false\n"); err != nil {
+		t.Fatalf("expected valid jail to be written, got %v", err)
+	}
+}
+
+func TestLocalConnectorRefusesUnstagedChange(t *testing.T) {
+	installFakeFail2banClient(t)
+	dir := filepath.Join(t.TempDir(), "missing")
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "local-test", Name: "local", Type: "local", ConfigPath: dir}}
+
+	err := lc.CreateJail(context.Background(), "demo", "[demo]\nenabled = false\n")
+	if !errors.Is(err, ErrConfigNotTested) {
+		t.Fatalf("expected ErrConfigNotTested when the tree cannot be staged, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "jail.d", "demo.local")); !os.IsNotExist(err) {
+		t.Fatalf("untested write must not create the jail file, stat err=%v", err)
+	}
+}
@@ -17,6 +17,7 @@
 package fail2ban
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
@@ -29,6 +30,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -242,7 +244,49 @@ func (ac *AgentConnector) GetJailInfos(ctx context.Context) ([]JailInfo, error)
 	return resp.Jails, nil
 }
 
-func (ac *AgentConnector) GetBannedIPs(ctx context.Context, jail string) ([]string, error) {
+// Asks the agent whether fail2ban answers. Agents without /v1/health are
+// checked by listing jails instead.
+func (ac *AgentConnector) CheckHealth(ctx context.Context) error {
+	var resp struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	err := ac.get(ctx, "/v1/health", &resp)
+	var httpErr *AgentHTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		_, err = ac.GetJailInfos(ctx)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Status != "" && resp.Status != "ok" {
+		if resp.Error != "" {
+			return fmt.Errorf("agent reports %s: %s", resp.Status, resp.Error)
+		}
+		return fmt.Errorf("agent reports %s", resp.Status)
+	}
+	return nil
+}
+
+// Older agents without /banned only report the bare IPs of a jail.
+func (ac *AgentConnector) GetBannedIPs(ctx context.Context, jail string) ([]BannedIP, error) {
+	var detailed struct {
+		BannedIPs []BannedIP `json:"bannedIPs"`
+	}
+	err := ac.get(ctx, fmt.Sprintf("/v1/jails/%s/banned", url.PathEscape(jail)), &detailed)
+	var httpErr *AgentHTTPError
+	if err == nil {
+		if detailed.BannedIPs == nil {
+			return []BannedIP{}, nil
+		}
+		refreshRemaining(detailed.BannedIPs, time.Now())
+		return detailed.BannedIPs, nil
+	}
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		return nil, err
+	}
+
 	var resp struct {
 		Jail        string   `json:"jail"`
 		BannedIPs   []string `json:"bannedIPs"`
@@ -251,10 +295,38 @@ func (ac *AgentConnector) GetBannedIPs(ctx context.Context, jail string) ([]stri
 	if err := ac.get(ctx, fmt.Sprintf("/v1/jails/%s", url.PathEscape(jail)), &resp); err != nil {
 		return nil, err
 	}
-	if len(resp.BannedIPs) > 0 {
-		return resp.BannedIPs, nil
+	return mergeBannedIPs(resp.BannedIPs, nil, nil, time.Now()), nil
+}
+
+// Older agents without /status only report the banned IPs of a jail.
+func (ac *AgentConnector) GetJailStatus(ctx context.Context, jail string) (JailStatus, error) {
+	if err := ValidateJailName(jail); err != nil {
+		return JailStatus{}, err
 	}
-	return []string{}, nil
+	var status JailStatus
+	err := ac.get(ctx, fmt.Sprintf("/v1/jails/%s/status", url.PathEscape(jail)), &status)
+	var httpErr *AgentHTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		banned, err := ac.GetBannedIPs(ctx, jail)
+		if err != nil {
+			return JailStatus{}, err
+		}
+		ips := BannedIPStrings(banned)
+		status = JailStatus{CurrentlyBanned: len(ips), BannedIPs: ips}
+	} else if err != nil {
+		return JailStatus{}, err
+	}
+	status.Jail = jail
+	if status.FileList == nil {
+		status.FileList = []string{}
+	}
+	if status.JournalMatches == nil {
+		status.JournalMatches = []string{}
+	}
+	if status.BannedIPs == nil {
+		status.BannedIPs = []string{}
+	}
+	return status, nil
 }
 
 func (ac *AgentConnector) UnbanIP(ctx context.Context, jail, ip string) error {
@@ -279,6 +351,30 @@ func (ac *AgentConnector) BanIP(ctx context.Context, jail, ip string) error {
 	return ac.post(ctx, fmt.Sprintf("/v1/jails/%s/ban", url.PathEscape(jail)), payload, nil)
 }
 
+func (ac *AgentConnector) GetJailRuntimeConfig(ctx context.Context, jail string) (JailRuntimeConfig, error) {
+	if err := ValidateJailName(jail); err != nil {
+		return JailRuntimeConfig{}, err
+	}
+	var cfg JailRuntimeConfig
+	if err := ac.get(ctx, fmt.Sprintf("/v1/jails/%s/runtime", url.PathEscape(jail)), &cfg); err != nil {
+		return JailRuntimeConfig{}, err
+	}
+	if cfg.Jail == "" {
+		cfg.Jail = jail
+	}
+	return cfg, nil
+}
+
+func (ac *AgentConnector) SetJailRuntimeConfig(ctx context.Context, jail string, update JailRuntimeUpdate) error {
+	if err := ValidateJailName(jail); err != nil {
+		return err
+	}
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	return ac.put(ctx, fmt.Sprintf("/v1/jails/%s/runtime", url.PathEscape(jail)), update, nil)
+}
+
 func (ac *AgentConnector) Reload(ctx context.Context) error {
 	return ac.post(ctx, "/v1/actions/reload", nil, nil)
 }
@@ -304,7 +400,7 @@ func (ac *AgentConnector) GetFilterConfig(ctx context.Context, jail string) (str
 		FilePath string `json:"filePath"`
 	}
 	if err := ac.get(ctx, fmt.Sprintf("/v1/filters/%s", url.PathEscape(jail)), &resp); err != nil {
-		return "", "", err
+		return "", "", agentConfigReadError("filter", err)
 	}
 	filePath := resp.FilePath
 	if filePath == "" {
@@ -313,7 +409,22 @@ func (ac *AgentConnector) GetFilterConfig(ctx context.Context, jail string) (str
 	return resp.Config, filePath, nil
 }
 
+// Marks a 404 from the agent as a missing config file.
+func agentConfigReadError(kind string, err error) error {
+	var httpErr *AgentHTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %w: %w", kind, ErrConfigNotFound, err)
+	}
+	return err
+}
+
 func (ac *AgentConnector) SetFilterConfig(ctx context.Context, jail, content string) error {
+	if err := ac.testConfigChange(ctx, ConfigKindFilter, jail, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindFilter, jail, "update"); err != nil {
+		return err
+	}
 	payload := map[string]string{"config": content}
 	return ac.put(ctx, fmt.Sprintf("/v1/filters/%s", url.PathEscape(jail)), payload, nil)
 }
@@ -356,7 +467,9 @@ func (ac *AgentConnector) delete(ctx context.Context, endpoint string, out any)
 
 func (ac *AgentConnector) newRequest(ctx context.Context, method, endpoint string, payload any) (*http.Request, error) {
 	u := *ac.base
+	endpoint, query, _ := strings.Cut(endpoint, "?")
 	u.Path = path.Join(ac.base.Path, strings.TrimPrefix(endpoint, "/"))
+	u.RawQuery = query
 
 	var body io.Reader
 	if payload != nil {
@@ -441,6 +554,9 @@ func (ac *AgentConnector) GetAllJails(ctx context.Context) ([]JailInfo, error) {
 }
 
 func (ac *AgentConnector) UpdateJailEnabledStates(ctx context.Context, updates map[string]bool) error {
+	if err := snapshotJailStates(ctx, ac, updates); err != nil {
+		return err
+	}
 	return ac.post(ctx, "/v1/jails/update-enabled", updates, nil)
 }
 
@@ -455,9 +571,11 @@ func (ac *AgentConnector) GetFilters(ctx context.Context) ([]string, error) {
 }
 
 func (ac *AgentConnector) TestFilter(ctx context.Context, filterName string, logLines []string, filterContent string) (string, string, error) {
+	// printAll asks the agent to run fail2ban-regex with the same verbose flags as the other connectors.
 	payload := map[string]any{
 		"filterName": filterName,
 		"logLines":   logLines,
+		"printAll":   true,
 	}
 	if filterContent != "" {
 		payload["filterContent"] = filterContent
@@ -503,7 +621,7 @@ func (ac *AgentConnector) GetJailConfig(ctx context.Context, jail string) (strin
 		FilePath string `json:"filePath"`
 	}
 	if err := ac.get(ctx, fmt.Sprintf("/v1/jails/%s/config", url.PathEscape(jail)), &resp); err != nil {
-		return "", "", err
+		return "", "", agentConfigReadError("jail", err)
 	}
 	filePath := resp.FilePath
 	if filePath == "" {
@@ -513,6 +631,12 @@ func (ac *AgentConnector) GetJailConfig(ctx context.Context, jail string) (strin
 }
 
 func (ac *AgentConnector) SetJailConfig(ctx context.Context, jail, content string) error {
+	if err := ac.testConfigChange(ctx, ConfigKindJail, jail, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindJail, jail, "update"); err != nil {
+		return err
+	}
 	payload := map[string]string{"config": content}
 	return ac.put(ctx, fmt.Sprintf("/v1/jails/%s/config", url.PathEscape(jail)), payload, nil)
 }
@@ -601,7 +725,7 @@ func (ac *AgentConnector) EnsureJailLocalStructure(ctx context.Context) error {
 		debugf("jail.local on agent server %s exists but is not managed by Fail2ban-UI -- skipping overwrite", ac.server.Name)
 		return nil
 	}
-	content := mustProvider().BuildJailLocalContent()
+	content := mustProvider().BuildJailLocalContent(ac.server, GetServerCapabilities(ctx, ac, false))
 	payload := map[string]any{}
 	if strings.TrimSpace(content) != "" {
 		payload["content"] = content
@@ -614,6 +738,12 @@ func (ac *AgentConnector) EnsureJailLocalStructure(ctx context.Context) error {
 // =========================================================================
 
 func (ac *AgentConnector) CreateJail(ctx context.Context, jailName, content string) error {
+	if err := ac.testConfigChange(ctx, ConfigKindJail, jailName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindJail, jailName, "create"); err != nil {
+		return err
+	}
 	payload := map[string]interface{}{
 		"name":    jailName,
 		"content": content,
@@ -622,10 +752,19 @@ func (ac *AgentConnector) CreateJail(ctx context.Context, jailName, content stri
 }
 
 func (ac *AgentConnector) DeleteJail(ctx context.Context, jailName string) error {
+	if err := snapshotConfig(ctx, ac, ConfigKindJail, jailName, "delete"); err != nil {
+		return err
+	}
 	return ac.delete(ctx, fmt.Sprintf("/v1/jails/%s", url.PathEscape(jailName)), nil)
 }
 
 func (ac *AgentConnector) CreateFilter(ctx context.Context, filterName, content string) error {
+	if err := ac.testConfigChange(ctx, ConfigKindFilter, filterName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindFilter, filterName, "create"); err != nil {
+		return err
+	}
 	payload := map[string]interface{}{
 		"name":    filterName,
 		"content": content,
@@ -634,5 +773,238 @@ func (ac *AgentConnector) CreateFilter(ctx context.Context, filterName, content
 }
 
 func (ac *AgentConnector) DeleteFilter(ctx context.Context, filterName string) error {
+	if err := snapshotConfig(ctx, ac, ConfigKindFilter, filterName, "delete"); err != nil {
+		return err
+	}
 	return ac.delete(ctx, fmt.Sprintf("/v1/filters/%s", url.PathEscape(filterName)), nil)
 }
+
+func (ac *AgentConnector) GetActions(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Actions []string `json:"actions"`
+	}
+	if err := ac.get(ctx, "/v1/actions", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Actions, nil
+}
+
+func (ac *AgentConnector) GetActionConfig(ctx context.Context, action string) (string, string, error) {
+	if err := ValidateActionName(action); err != nil {
+		return "", "", err
+	}
+	var resp struct {
+		Config   string `json:"config"`
+		FilePath string `json:"filePath"`
+	}
+	if err := ac.get(ctx, fmt.Sprintf("/v1/actions/%s", url.PathEscape(action)), &resp); err != nil {
+		return "", "", agentConfigReadError("action", err)
+	}
+	filePath := resp.FilePath
+	if filePath == "" {
+		filePath = fmt.Sprintf("/etc/fail2ban/action.d/%s.local", action)
+	}
+	return resp.Config, filePath, nil
+}
+
+func (ac *AgentConnector) SetActionConfig(ctx context.Context, action, content string) error {
+	if err := validateWritableAction(action); err != nil {
+		return err
+	}
+	if err := ac.testConfigChange(ctx, ConfigKindAction, action, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindAction, action, "update"); err != nil {
+		return err
+	}
+	payload := map[string]string{"config": content}
+	return ac.put(ctx, fmt.Sprintf("/v1/actions/%s", url.PathEscape(action)), payload, nil)
+}
+
+func (ac *AgentConnector) CreateAction(ctx context.Context, actionName, content string) error {
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	if err := ac.testConfigChange(ctx, ConfigKindAction, actionName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindAction, actionName, "create"); err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"name":    actionName,
+		"content": content,
+	}
+	return ac.post(ctx, "/v1/actions", payload, nil)
+}
+
+func (ac *AgentConnector) DeleteAction(ctx context.Context, actionName string) error {
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, ac, ConfigKindAction, actionName, "delete"); err != nil {
+		return err
+	}
+	return ac.delete(ctx, fmt.Sprintf("/v1/actions/%s", url.PathEscape(actionName)), nil)
+}
+
+// Asks the agent to run "fail2ban-client -t" with the change staged. Agents
+// without the endpoint accept the write untested, as before.
+func (ac *AgentConnector) testConfigChange(ctx context.Context, kind, name, content string) error {
+	payload := map[string]string{
+		"kind":    kind,
+		"name":    name,
+		"content": content,
+	}
+	var resp struct {
+		Valid  bool   `json:"valid"`
+		Output string `json:"output"`
+	}
+	if err := ac.post(ctx, "/v1/config/test", payload, &resp); err != nil {
+		var httpErr *AgentHTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			debugf("Agent on %s does not support config tests, skipping test of %s %s", ac.server.Name, kind, name)
+			return nil
+		}
+		return fmt.Errorf("failed to test config on %s: %w", ac.server.Name, err)
+	}
+	if resp.Valid {
+		return nil
+	}
+	return newConfigValidationError(kind, name, resp.Output)
+}
+
+// =========================================================================
+//  Capabilities
+// =========================================================================
+
+func (ac *AgentConnector) DetectCapabilities(ctx context.Context) (ServerCapabilities, error) {
+	var caps ServerCapabilities
+	if err := ac.get(ctx, "/v1/capabilities", &caps); err != nil {
+		var httpErr *AgentHTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return ServerCapabilities{}, fmt.Errorf("agent on %s does not support capability detection (update fail2ban-ui-agent)", ac.server.Name)
+		}
+		return ServerCapabilities{}, fmt.Errorf("failed to detect capabilities of %s: %w", ac.server.Name, err)
+	}
+	caps.Fail2banVersion = versionRe.FindString(caps.Fail2banVersion)
+	if caps.Fail2banVersion == "" {
+		return caps, fmt.Errorf("agent on %s did not report a fail2ban version", ac.server.Name)
+	}
+	return caps, nil
+}
+
+// =========================================================================
+//  Event Log Pull
+// =========================================================================
+
+func (ac *AgentConnector) ReadEventLog(ctx context.Context, offset, limit int64) (EventLogChunk, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.FormatInt(offset, 10))
+	query.Set("limit", strconv.FormatInt(limit, 10))
+	var chunk EventLogChunk
+	if err := ac.get(ctx, "/v1/events/log?"+query.Encode(), &chunk); err != nil {
+		var httpErr *AgentHTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return EventLogChunk{}, fmt.Errorf("agent on %s does not support pull mode (update fail2ban-ui-agent)", ac.server.Name)
+		}
+		return EventLogChunk{}, fmt.Errorf("failed to read fail2ban log on %s: %w", ac.server.Name, err)
+	}
+	return chunk, nil
+}
+
+// =========================================================================
+//  Log File Access
+// =========================================================================
+
+func (ac *AgentConnector) ReadLogFileTail(ctx context.Context, path string, maxBytes int64) (string, error) {
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("bytes", strconv.FormatInt(maxBytes, 10))
+	var resp struct {
+		Data string `json:"data"`
+	}
+	if err := ac.get(ctx, "/v1/logs/tail?"+query.Encode(), &resp); err != nil {
+		var httpErr *AgentHTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("agent on %s does not support reading log files (update fail2ban-ui-agent)", ac.server.Name)
+		}
+		return "", err
+	}
+	return resp.Data, nil
+}
+
+// Streams newline-delimited {"file","line"} objects from the agent. The request
+// runs without the client timeout, it lasts until ctx is cancelled.
+func (ac *AgentConnector) FollowLogFiles(ctx context.Context, paths []string, emit func(LogTailLine)) error {
+	query := url.Values{}
+	for _, path := range paths {
+		query.Add("path", path)
+	}
+	req, err := ac.newRequest(ctx, http.MethodGet, "/v1/logs/follow?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+	client := &http.Client{Transport: ac.client.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &AgentTransportError{Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("agent on %s does not support following log files (update fail2ban-ui-agent)", ac.server.Name)
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &AgentHTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(body))}
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 4*logTailMaxLineBytes)
+	for scanner.Scan() {
+		var line LogTailLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		emitTailLine(line.File, line.Line, emit)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// =========================================================================
+//  Ban Database
+// =========================================================================
+
+func (ac *AgentConnector) ReadBanDatabase(ctx context.Context) ([]byte, error) {
+	req, err := ac.newRequest(ctx, http.MethodGet, "/v1/database", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := ac.client.Do(req)
+	if err != nil {
+		return nil, &AgentTransportError{Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("agent on %s does not support exporting the fail2ban database (update fail2ban-ui-agent)", ac.server.Name)
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &AgentHTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(body))}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, banDatabaseMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > banDatabaseMaxBytes {
+		return nil, fmt.Errorf("fail2ban database on %s is too large to import", ac.server.Name)
+	}
+	return data, nil
+}
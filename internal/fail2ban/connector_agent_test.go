@@ -37,7 +37,11 @@ func (testProvider) CallbackSecret() string                   { return "test-sec
 func (testProvider) BuildFail2banActionConfig(callbackURL, serverID, secret string) string {
 	return ""
 }
-func (testProvider) BuildJailLocalContent() string {
+func (testProvider) RecordSSHHostKey(serverID, hostKey string) {}
+func (testProvider) RecordConfigRevision(ctx context.Context, rev ConfigRevision) error {
+	return nil
+}
+func (testProvider) BuildJailLocalContent(server shared.Fail2banServer, caps ServerCapabilities) string {
 	return "[DEFAULT]\nenabled = true\naction_mwlg = %(action_)s\n             ui-custom-action[logpath=\"%(logpath)s\", chain=\"%(chain)s\"]\naction = %(action_mwlg)s\n"
 }
 
@@ -430,3 +434,52 @@ func TestAgentConnectorUnauthorizedIncludesStructuredCode(t *testing.T) {
 		t.Fatalf("message key=%q", got)
 	}
 }
+
+func TestAgentConnectorJailStatusFallsBackForOlderAgents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/jails/sshd":
+			_ = json.NewEncoder(w).Encode(map[string]any{"jail": "sshd", "bannedIPs": []string{"192.0.2.1"}, "totalBanned": 1})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewAgentConnector(shared.Fail2banServer{ID: "s1", Name: "agent", Type: "agent", AgentURL: srv.URL, AgentSecret: "secret123"})
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	status, err := c.GetJailStatus(context.Background(), "sshd")
+	if err != nil {
+		t.Fatalf("GetJailStatus: %v", err)
+	}
+	if status.Jail != "sshd" || status.CurrentlyBanned != 1 || len(status.BannedIPs) != 1 || status.FileList == nil {
+		t.Fatalf("unexpected fallback status %+v", status)
+	}
+}
+
+func TestAgentConnectorMissingFilterIsConfigNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/callback/config":
+			_, _ = w.Write([]byte(`{}`))
+		case "/v1/filters/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewAgentConnector(shared.Fail2banServer{ID: "s1", Name: "agent", Type: "agent", AgentURL: srv.URL, AgentSecret: "secret123"})
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	if _, _, err := c.GetFilterConfig(context.Background(), "missing"); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("expected ErrConfigNotFound for a 404, got %v", err)
+	}
+	if _, _, err := c.GetFilterConfig(context.Background(), "broken"); err == nil || errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("expected a plain error for a 500, got %v", err)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -54,11 +55,25 @@ func checkReloadOutput(output string) error {
 
 // JailInfo holds summary data for a single Fail2ban jail.
 type JailInfo struct {
-	JailName      string   `json:"jailName"`
-	TotalBanned   int      `json:"totalBanned"`
-	NewInLastHour int      `json:"newInLastHour"`
-	BannedIPs     []string `json:"bannedIPs"`
-	Enabled       bool     `json:"enabled"`
+	JailName      string      `json:"jailName"`
+	TotalBanned   int         `json:"totalBanned"`
+	NewInLastHour int         `json:"newInLastHour"`
+	BannedIPs     []string    `json:"bannedIPs"`
+	Enabled       bool        `json:"enabled"`
+	Status        *JailStatus `json:"status,omitempty"`
+}
+
+// Counters and sources of a jail as reported by "fail2ban-client status <jail>".
+// TotalFailed and TotalBanned count since the jail was (re)started.
+type JailStatus struct {
+	Jail            string   `json:"jail"`
+	CurrentlyFailed int      `json:"currentlyFailed"`
+	TotalFailed     int      `json:"totalFailed"`
+	CurrentlyBanned int      `json:"currentlyBanned"`
+	TotalBanned     int      `json:"totalBanned"`
+	FileList        []string `json:"fileList"`
+	JournalMatches  []string `json:"journalMatches"`
+	BannedIPs       []string `json:"bannedIPs"`
 }
 
 // =========================================================================
@@ -95,16 +110,16 @@ func RestartFail2ban(serverID string) (string, error) {
 //  Jail Info Collection
 // =========================================================================
 
-// bannedIPsFn is the signature used by any connector's GetBannedIPs method.
-type bannedIPsFn func(ctx context.Context, jail string) ([]string, error)
+// jailStatusFn is the signature used by any connector's GetJailStatus method.
+type jailStatusFn func(ctx context.Context, jail string) (JailStatus, error)
 
-// Fans out to fetch banned IPs for each jail concurrently, then returns the results sorted alphabetically. (local connector)
-func collectJailInfos(ctx context.Context, jails []string, getBannedIPs bannedIPsFn) ([]JailInfo, error) {
-	return collectJailInfosLimited(ctx, jails, getBannedIPs, 0)
+// Fans out to fetch the status of each jail concurrently, then returns the results sorted alphabetically. (local connector)
+func collectJailInfos(ctx context.Context, jails []string, getStatus jailStatusFn) ([]JailInfo, error) {
+	return collectJailInfosLimited(ctx, jails, getStatus, 0)
 }
 
-// Caps the number of concurrent getBannedIPs calls when maxConcurrent > 0. (SSH connector)
-func collectJailInfosLimited(ctx context.Context, jails []string, getBannedIPs bannedIPsFn, maxConcurrent int) ([]JailInfo, error) {
+// Caps the number of concurrent getStatus calls when maxConcurrent > 0. (SSH connector)
+func collectJailInfosLimited(ctx context.Context, jails []string, getStatus jailStatusFn, maxConcurrent int) ([]JailInfo, error) {
 	type jailResult struct {
 		jail JailInfo
 		err  error
@@ -125,18 +140,20 @@ func collectJailInfosLimited(ctx context.Context, jails []string, getBannedIPs b
 				sem <- struct{}{}
 				defer func() { <-sem }()
 			}
-			ips, err := getBannedIPs(ctx, j)
+			status, err := getStatus(ctx, j)
 			if err != nil {
 				results <- jailResult{err: err}
 				return
 			}
-			totalBanned := len(ips)
+			totalBanned := len(status.BannedIPs)
+			status.BannedIPs = []string{}
 			results <- jailResult{
 				jail: JailInfo{
 					JailName:    j,
 					TotalBanned: totalBanned,
 					BannedIPs:   []string{},
 					Enabled:     true,
+					Status:      &status,
 				},
 			}
 		}(jail)
@@ -161,3 +178,56 @@ func collectJailInfosLimited(ctx context.Context, jails []string, getBannedIPs b
 
 	return infos, nil
 }
+
+// =========================================================================
+//  Jail Status Parsing
+// =========================================================================
+
+// Parses the tree printed by "fail2ban-client status <jail>":
+//
+//	Status for the jail: sshd
+//	|- Filter
+//	|  |- Currently failed:	2
+//	|  |- Total failed:	17
+//	|  `- File list:	/var/log/auth.log
+//	`- Actions
+//	   |- Currently banned:	1
+//	   |- Total banned:	4
+//	   `- Banned IP list:	192.0.2.1
+func ParseJailStatus(jail, output string) JailStatus {
+	status := JailStatus{
+		Jail:           jail,
+		FileList:       []string{},
+		JournalMatches: []string{},
+		BannedIPs:      []string{},
+	}
+	for _, line := range strings.Split(output, "\n") {
+		label, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		label = strings.TrimSpace(strings.TrimLeft(label, " |`-"))
+		value = strings.TrimSpace(value)
+		switch label {
+		case "Currently failed":
+			status.CurrentlyFailed, _ = strconv.Atoi(value)
+		case "Total failed":
+			status.TotalFailed, _ = strconv.Atoi(value)
+		case "Currently banned":
+			status.CurrentlyBanned, _ = strconv.Atoi(value)
+		case "Total banned":
+			status.TotalBanned, _ = strconv.Atoi(value)
+		case "File list":
+			status.FileList = append(status.FileList, strings.Fields(value)...)
+		case "Journal matches":
+			for _, match := range strings.Split(value, "+") {
+				if match = strings.TrimSpace(match); match != "" {
+					status.JournalMatches = append(status.JournalMatches, match)
+				}
+			}
+		case "Banned IP list":
+			status.BannedIPs = append(status.BannedIPs, strings.Fields(value)...)
+		}
+	}
+	return status
+}
@@ -0,0 +1,65 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJailStatus(t *testing.T) {
+	fileBased := "Status for the jail: sshd\n" +
+		"|- Filter\n" +
+		"|  |- Currently failed:\t2\n" +
+		"|  |- Total failed:\t17\n" +
+		"|  `- File list:\t/var/log/auth.log /var/log/secure\n" +
+		"`- Actions\n" +
+		"   |- Currently banned:\t2\n" +
+		"   |- Total banned:\t4\n" +
+		"   `- Banned IP list:\t192.0.2.1 2001:db8::1\n"
+	got := ParseJailStatus("sshd", fileBased)
+	want := JailStatus{
+		Jail:            "sshd",
+		CurrentlyFailed: 2,
+		TotalFailed:     17,
+		CurrentlyBanned: 2,
+		TotalBanned:     4,
+		FileList:        []string{"/var/log/auth.log", "/var/log/secure"},
+		JournalMatches:  []string{},
+		BannedIPs:       []string{"192.0.2.1", "2001:db8::1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	journal := "Status for the jail: sshd\n" +
+		"|- Filter\n" +
+		"|  |- Currently failed:\t0\n" +
+		"|  |- Total failed:\t0\n" +
+		"|  `- Journal matches:\t_SYSTEMD_UNIT=sshd.service + _COMM=sshd\n" +
+		"`- Actions\n" +
+		"   |- Currently banned:\t0\n" +
+		"   |- Total banned:\t0\n" +
+		"   `- Banned IP list:\t\n"
+	got = ParseJailStatus("sshd", journal)
+	if !reflect.DeepEqual(got.JournalMatches, []string{"_SYSTEMD_UNIT=sshd.service", "_COMM=sshd"}) {
+		t.Fatalf("unexpected journal matches %v", got.JournalMatches)
+	}
+	if len(got.FileList) != 0 || len(got.BannedIPs) != 0 {
+		t.Fatalf("expected no files and no banned IPs, got %+v", got)
+	}
+}
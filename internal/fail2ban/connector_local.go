@@ -20,9 +20,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/swissmakers/fail2ban-ui/internal/shared"
 )
@@ -59,29 +62,40 @@ func (lc *LocalConnector) GetJailInfos(ctx context.Context) ([]JailInfo, error)
 	if err != nil {
 		return nil, err
 	}
-	return collectJailInfos(ctx, jails, lc.GetBannedIPs)
+	return collectJailInfos(ctx, jails, lc.GetJailStatus)
 }
 
-// Get banned IPs for a given jail.
-func (lc *LocalConnector) GetBannedIPs(ctx context.Context, jail string) ([]string, error) {
-	args := []string{"status", jail}
-	out, err := lc.runFail2banClient(ctx, args...)
+// Get banned IPs for a given jail with their ban window and ban count.
+func (lc *LocalConnector) GetBannedIPs(ctx context.Context, jail string) ([]BannedIP, error) {
+	status, err := lc.GetJailStatus(ctx, jail)
 	if err != nil {
-		return nil, fmt.Errorf("fail2ban-client status %s failed: %w", jail, err)
+		return nil, err
 	}
-	var bannedIPs []string
-	lines := strings.Split(out, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "IP list:") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) > 1 {
-				ips := strings.Fields(strings.TrimSpace(parts[1]))
-				bannedIPs = append(bannedIPs, ips...)
+	var timed, recorded map[string]banWindow
+	if len(status.BannedIPs) > 0 {
+		if GetServerCapabilities(ctx, lc, false).Supports(FeatureBanipWithTime) {
+			if out, err := lc.runFail2banClient(ctx, "get", jail, "banip", "--with-time"); err == nil {
+				timed = parseBanipWithTime(out, time.Local)
+			}
+		}
+		if out, err := lc.runFail2banClient(ctx, "get", "dbfile"); err == nil {
+			if path, err := parseDBFile(out); err == nil {
+				if recorded, err = readCurrentBansFile(ctx, path, jail); err != nil {
+					debugf("Could not read current bans of jail %s from %s: %v", jail, path, err)
+				}
 			}
-			break
 		}
 	}
-	return bannedIPs, nil
+	return mergeBannedIPs(status.BannedIPs, timed, recorded, time.Now()), nil
+}
+
+// Get the counters, log sources and banned IPs of a jail.
+func (lc *LocalConnector) GetJailStatus(ctx context.Context, jail string) (JailStatus, error) {
+	out, err := lc.runFail2banClient(ctx, "status", jail)
+	if err != nil {
+		return JailStatus{}, fmt.Errorf("fail2ban-client status %s failed: %w", jail, err)
+	}
+	return ParseJailStatus(jail, out), nil
 }
 
 // Unban an IP from a given jail.
@@ -114,6 +128,16 @@ func (lc *LocalConnector) BanIP(ctx context.Context, jail, ip string) error {
 	return nil
 }
 
+// Returns the live bantime, findtime, maxretry and ignoreip of a jail.
+func (lc *LocalConnector) GetJailRuntimeConfig(ctx context.Context, jail string) (JailRuntimeConfig, error) {
+	return getJailRuntimeConfig(ctx, lc.runFail2banClient, jail)
+}
+
+// Changes jail parameters on the running daemon; failure counters are kept.
+func (lc *LocalConnector) SetJailRuntimeConfig(ctx context.Context, jail string, update JailRuntimeUpdate) error {
+	return setJailRuntimeConfig(ctx, lc.runFail2banClient, jail, update)
+}
+
 // Reload the Fail2ban service.
 func (lc *LocalConnector) Reload(ctx context.Context) error {
 	out, err := lc.runFail2banClient(ctx, "reload")
@@ -156,6 +180,12 @@ func (lc *LocalConnector) GetFilterConfig(ctx context.Context, jail string) (str
 }
 
 func (lc *LocalConnector) SetFilterConfig(ctx context.Context, jail, content string) error {
+	if err := testConfigChangeLocal(ctx, lc.configPath(), ConfigKindFilter, jail, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, lc, ConfigKindFilter, jail, "update"); err != nil {
+		return err
+	}
 	return SetFilterConfigLocal(jail, content, lc.configPath())
 }
 
@@ -221,6 +251,10 @@ func (lc *LocalConnector) checkFail2banHealthy(ctx context.Context) error {
 	return nil
 }
 
+func (lc *LocalConnector) CheckHealth(ctx context.Context) error {
+	return lc.checkFail2banHealthy(ctx)
+}
+
 // =========================================================================
 //  Delegated Operations
 // =========================================================================
@@ -230,6 +264,9 @@ func (lc *LocalConnector) GetAllJails(ctx context.Context) ([]JailInfo, error) {
 }
 
 func (lc *LocalConnector) UpdateJailEnabledStates(ctx context.Context, updates map[string]bool) error {
+	if err := snapshotJailStates(ctx, lc, updates); err != nil {
+		return err
+	}
 	return UpdateJailEnabledStates(updates, lc.configPath())
 }
 
@@ -246,6 +283,12 @@ func (lc *LocalConnector) GetJailConfig(ctx context.Context, jail string) (strin
 }
 
 func (lc *LocalConnector) SetJailConfig(ctx context.Context, jail, content string) error {
+	if err := testConfigChangeLocal(ctx, lc.configPath(), ConfigKindJail, jail, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, lc, ConfigKindJail, jail, "update"); err != nil {
+		return err
+	}
 	return SetJailConfig(jail, content, lc.configPath())
 }
 
@@ -262,27 +305,92 @@ func (lc *LocalConnector) UpdateDefaultSettings(ctx context.Context) error {
 }
 
 func (lc *LocalConnector) EnsureJailLocalStructure(ctx context.Context) error {
-	_ = ctx
-	content := []byte(mustProvider().BuildJailLocalContent())
+	content := []byte(mustProvider().BuildJailLocalContent(lc.server, GetServerCapabilities(ctx, lc, false)))
 	return EnsureManagedJailLocal(lc.configPath(), content)
 }
 
 func (lc *LocalConnector) CreateJail(ctx context.Context, jailName, content string) error {
+	if err := testConfigChangeLocal(ctx, lc.configPath(), ConfigKindJail, jailName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, lc, ConfigKindJail, jailName, "create"); err != nil {
+		return err
+	}
 	return CreateJail(jailName, content, lc.configPath())
 }
 
 func (lc *LocalConnector) DeleteJail(ctx context.Context, jailName string) error {
+	if err := snapshotConfig(ctx, lc, ConfigKindJail, jailName, "delete"); err != nil {
+		return err
+	}
 	return DeleteJail(jailName, lc.configPath())
 }
 
 func (lc *LocalConnector) CreateFilter(ctx context.Context, filterName, content string) error {
+	if err := testConfigChangeLocal(ctx, lc.configPath(), ConfigKindFilter, filterName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, lc, ConfigKindFilter, filterName, "create"); err != nil {
+		return err
+	}
 	return CreateFilter(filterName, content, lc.configPath())
 }
 
 func (lc *LocalConnector) DeleteFilter(ctx context.Context, filterName string) error {
+	if err := snapshotConfig(ctx, lc, ConfigKindFilter, filterName, "delete"); err != nil {
+		return err
+	}
 	return DeleteFilter(filterName, lc.configPath())
 }
 
+func (lc *LocalConnector) GetActions(ctx context.Context) ([]string, error) {
+	return DiscoverActionsFromFiles(lc.configPath())
+}
+
+func (lc *LocalConnector) GetActionConfig(ctx context.Context, action string) (string, string, error) {
+	return GetActionConfigLocal(action, lc.configPath())
+}
+
+func (lc *LocalConnector) SetActionConfig(ctx context.Context, action, content string) error {
+	if err := validateWritableAction(action); err != nil {
+		return err
+	}
+	if err := testConfigChangeLocal(ctx, lc.configPath(), ConfigKindAction, action, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, lc, ConfigKindAction, action, "update"); err != nil {
+		return err
+	}
+	return SetActionConfigLocal(action, content, lc.configPath())
+}
+
+func (lc *LocalConnector) CreateAction(ctx context.Context, actionName, content string) error {
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	if err := testConfigChangeLocal(ctx, lc.configPath(), ConfigKindAction, actionName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, lc, ConfigKindAction, actionName, "create"); err != nil {
+		return err
+	}
+	return CreateAction(actionName, content, lc.configPath())
+}
+
+func (lc *LocalConnector) DeleteAction(ctx context.Context, actionName string) error {
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	// Only an existing override is deleted; anything else is refused below
+	// without recording a revision.
+	if _, err := os.Stat(filepath.Join(ActionDir(lc.configPath()), actionName+".local")); err == nil {
+		if err := snapshotConfig(ctx, lc, ConfigKindAction, actionName, "delete"); err != nil {
+			return err
+		}
+	}
+	return DeleteAction(actionName, lc.configPath())
+}
+
 func (lc *LocalConnector) CheckJailLocalIntegrity(ctx context.Context) (bool, bool, error) {
 	jailLocalPath := JailLocal(lc.configPath())
 	content, err := os.ReadFile(jailLocalPath)
@@ -296,6 +404,129 @@ func (lc *LocalConnector) CheckJailLocalIntegrity(ctx context.Context) (bool, bo
 	return true, hasUIAction, nil
 }
 
+// =========================================================================
+//  Event Log Pull
+// =========================================================================
+
+func (lc *LocalConnector) ReadEventLog(ctx context.Context, offset, limit int64) (EventLogChunk, error) {
+	out, err := lc.runFail2banClient(ctx, "get", "logtarget")
+	if err != nil {
+		return EventLogChunk{}, fmt.Errorf("failed to query fail2ban log target: %w", err)
+	}
+	path, err := parseLogTarget(out)
+	if err != nil {
+		return EventLogChunk{}, err
+	}
+	return readLocalLogChunk(path, offset, limit)
+}
+
+func readLocalLogChunk(path string, offset, limit int64) (EventLogChunk, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return EventLogChunk{}, fmt.Errorf("failed to open fail2ban log: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return EventLogChunk{}, fmt.Errorf("failed to stat fail2ban log: %w", err)
+	}
+	chunk := EventLogChunk{Path: path, Size: info.Size(), UTCOffset: time.Now().Format("-0700")}
+	if limit <= 0 {
+		return chunk, nil
+	}
+	if offset < 0 || offset > chunk.Size {
+		offset = 0
+	}
+	chunk.Offset = offset
+	buf := make([]byte, min(limit, chunk.Size-offset))
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return EventLogChunk{}, fmt.Errorf("failed to read fail2ban log: %w", err)
+	}
+	chunk.Data = string(buf[:n])
+	return chunk, nil
+}
+
+// =========================================================================
+//  Log File Access
+// =========================================================================
+
+func (lc *LocalConnector) ReadLogFileTail(ctx context.Context, path string, maxBytes int64) (string, error) {
+	return readLocalFileTail(path, maxBytes)
+}
+
+func readLocalFileTail(path string, maxBytes int64) (string, error) {
+	if strings.ContainsRune(path, 0) || !filepath.IsAbs(path) {
+		return "", fmt.Errorf("invalid log path")
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(info.Size()-maxBytes, 0)
+	buf := make([]byte, info.Size()-offset)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+func (lc *LocalConnector) FollowLogFiles(ctx context.Context, paths []string, emit func(LogTailLine)) error {
+	for _, path := range paths {
+		if strings.ContainsRune(path, 0) || !filepath.IsAbs(path) {
+			return fmt.Errorf("invalid log path")
+		}
+	}
+	return followLocalFiles(ctx, paths, emit)
+}
+
+// =========================================================================
+//  Ban Database
+// =========================================================================
+
+func (lc *LocalConnector) ReadBanDatabase(ctx context.Context) ([]byte, error) {
+	out, err := lc.runFail2banClient(ctx, "get", "dbfile")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fail2ban database file: %w", err)
+	}
+	path, err := parseDBFile(out)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fail2ban database: %w", err)
+	}
+	if info.Size() > banDatabaseMaxBytes {
+		return nil, fmt.Errorf("fail2ban database %s is too large to import (%d bytes)", path, info.Size())
+	}
+	return os.ReadFile(path)
+}
+
+// =========================================================================
+//  Capabilities
+// =========================================================================
+
+func (lc *LocalConnector) DetectCapabilities(ctx context.Context) (ServerCapabilities, error) {
+	client := "fail2ban-client"
+	if lc.server.SocketPath != "" {
+		client += " -s " + lc.server.SocketPath
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-s")
+	cmd.Stdin = strings.NewReader(capabilityProbeScript(client, lc.configPath()))
+	out, err := cmd.Output()
+	if err != nil {
+		return ServerCapabilities{}, fmt.Errorf("failed to run capability probe: %w", err)
+	}
+	return parseCapabilityProbe(string(out))
+}
+
 // =========================================================================
 //  Shell Execution
 // =========================================================================
@@ -18,21 +18,18 @@ package fail2ban
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/swissmakers/fail2ban-ui/internal/shared"
@@ -100,7 +97,7 @@ func NewSSHConnector(server shared.Fail2banServer) (Connector, error) {
 			if host := parsedURL.Hostname(); host != "localhost" && host != "127.0.0.1" && host != "::1" {
 				log.Printf("warning: reverse tunnel for server %s forwards localhost:%d, but the callback URL points to host %q - the remote fail2ban will bypass the tunnel unless the callback URL host is localhost", server.Name, conn.tunnelPort, host)
 			}
-			debugf("Reverse tunnel enabled for server %s, will use -R %d:localhost:%d", server.Name, conn.tunnelPort, conn.tunnelPort)
+			debugf("Reverse tunnel enabled for server %s, remote localhost:%d forwards to localhost:%d", server.Name, conn.tunnelPort, conn.tunnelPort)
 		}
 	}
 
@@ -142,7 +139,7 @@ func (sc *SSHConnector) Server() shared.Fail2banServer {
 	return sc.server
 }
 
-// Caps how many concurrent SSH sessions the per-jail status fan-out may open over a single multiplexed master connection. (Keeping this below sshd's default MaxSessions (10) avoids "Session open refused by peer").
+// Caps how many concurrent SSH sessions the per-jail status fan-out may open over the pooled connection. (Keeping this below sshd's default MaxSessions (10) avoids "Session open refused by peer").
 const sshFanoutConcurrency = 4
 
 // Collects jail status for every active remote jail.
@@ -151,36 +148,54 @@ func (sc *SSHConnector) GetJailInfos(ctx context.Context) ([]JailInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	sc.warmControlMaster(ctx)
-	return collectJailInfosLimited(ctx, jails, sc.GetBannedIPs, sshFanoutConcurrency)
+	return collectJailInfosLimited(ctx, jails, sc.GetJailStatus, sshFanoutConcurrency)
 }
 
-// Opens a single SSH session to warm up the ControlMaster socket so it is fully established before any concurrent commands run.
-func (sc *SSHConnector) warmControlMaster(ctx context.Context) {
-	if _, err := sc.runRemoteCommand(ctx, []string{"true"}); err != nil {
-		debugf("SSH control master warm-up failed for %s: %v", sc.server.Name, err)
+// Get banned IPs for a given jail with their ban window and ban count.
+// The windows are best effort: without them the bare IPs are returned.
+func (sc *SSHConnector) GetBannedIPs(ctx context.Context, jail string) ([]BannedIP, error) {
+	status, err := sc.GetJailStatus(ctx, jail)
+	if err != nil {
+		return nil, err
 	}
+	var timed, recorded map[string]banWindow
+	if len(status.BannedIPs) > 0 {
+		client := strings.Join(append([]string{"sudo", "fail2ban-client"}, sc.buildFail2banArgs()...), " ")
+		withTime := GetServerCapabilities(ctx, sc, false).Supports(FeatureBanipWithTime)
+		out, err := sc.runRemoteSession(ctx, "sh -s", strings.NewReader(bannedIPsProbeScript(client, jail, withTime)))
+		if err != nil {
+			debugf("Could not read ban times of jail %s on %s: %v", jail, sc.server.Name, err)
+		} else {
+			var dbErr string
+			timed, recorded, dbErr = parseBannedIPsProbe(out)
+			sc.warnBanDatabaseUnreadable(dbErr)
+		}
+	}
+	return mergeBannedIPs(status.BannedIPs, timed, recorded, time.Now()), nil
 }
 
-// Get banned IPs for a given jail.
-func (sc *SSHConnector) GetBannedIPs(ctx context.Context, jail string) ([]string, error) {
+// Servers already warned about an unreadable ban database, so the warning is logged once.
+var banDatabaseWarned sync.Map
+
+// Logs why ban counts and windows from the ban database are missing; an empty reason clears the warning.
+func (sc *SSHConnector) warnBanDatabaseUnreadable(reason string) {
+	if reason == "" {
+		banDatabaseWarned.Delete(sc.server.ID)
+		return
+	}
+	if _, warned := banDatabaseWarned.LoadOrStore(sc.server.ID, true); warned {
+		debugf("Ban database of %s not read: %s", sc.server.Name, reason)
+		return
+	}
+	log.Printf("warning: fail2ban ban database on %s not read, ban counts are unavailable: %s (see docs/security.md)", sc.server.Name, reason)
+}
+
+func (sc *SSHConnector) GetJailStatus(ctx context.Context, jail string) (JailStatus, error) {
 	out, err := sc.runFail2banCommand(ctx, "status", jail)
 	if err != nil {
-		return nil, err
+		return JailStatus{}, err
 	}
-	var bannedIPs []string
-	lines := strings.Split(out, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "IP list:") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) > 1 {
-				ips := strings.Fields(strings.TrimSpace(parts[1]))
-				bannedIPs = append(bannedIPs, ips...)
-			}
-			break
-		}
-	}
-	return bannedIPs, nil
+	return ParseJailStatus(jail, out), nil
 }
 
 func (sc *SSHConnector) UnbanIP(ctx context.Context, jail, ip string) error {
@@ -205,6 +220,14 @@ func (sc *SSHConnector) BanIP(ctx context.Context, jail, ip string) error {
 	return err
 }
 
+func (sc *SSHConnector) GetJailRuntimeConfig(ctx context.Context, jail string) (JailRuntimeConfig, error) {
+	return getJailRuntimeConfig(ctx, sc.runFail2banCommand, jail)
+}
+
+func (sc *SSHConnector) SetJailRuntimeConfig(ctx context.Context, jail string, update JailRuntimeUpdate) error {
+	return setJailRuntimeConfig(ctx, sc.runFail2banCommand, jail, update)
+}
+
 func (sc *SSHConnector) Reload(ctx context.Context) error {
 	out, err := sc.runFail2banCommand(ctx, "reload")
 	if err != nil {
@@ -262,6 +285,9 @@ func (sc *SSHConnector) GetFilterConfig(ctx context.Context, filterName string)
 
 	content, err = sc.readRemoteFile(ctx, confPath)
 	if err != nil {
+		if exists, existsErr := sc.remoteFileExists(ctx, localPath, confPath); existsErr == nil && !exists {
+			return "", "", fmt.Errorf("filter %w: neither %s nor %s exists", ErrConfigNotFound, localPath, confPath)
+		}
 		return "", "", fmt.Errorf("failed to read remote filter config (tried .local and .conf): %w", err)
 	}
 	return content, confPath, nil
@@ -272,6 +298,12 @@ func (sc *SSHConnector) SetFilterConfig(ctx context.Context, filterName, content
 	if err := ValidateFilterName(filterName); err != nil {
 		return err
 	}
+	if err := sc.testConfigChange(ctx, ConfigKindFilter, filterName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, sc, ConfigKindFilter, filterName, "update"); err != nil {
+		return err
+	}
 
 	fail2banPath := sc.getFail2banPath(ctx)
 	filterDPath := filepath.Join(fail2banPath, "filter.d")
@@ -301,51 +333,16 @@ func (sc *SSHConnector) ensureAction(ctx context.Context) error {
 	payload := base64.StdEncoding.EncodeToString([]byte(actionConfig))
 	script := strings.ReplaceAll(sshEnsureActionScript, "__PAYLOAD__", payload)
 	scriptB64 := base64.StdEncoding.EncodeToString([]byte(script))
-	args := sc.buildSSHArgs([]string{"sh", "-s"})
-	cmd := exec.CommandContext(ctx, "ssh", args...)
 
-	// Set process group to ensure all child processes (including SSH control master) are killed when the context is cancelled.
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
-	}
-
-	// Create a script that reads the base64 string from stdin and pipes it through base64 -d | bash.
+	// Feed the script via stdin: it is decoded remotely and piped through bash.
 	scriptContent := fmt.Sprintf("cat <<'ENDBASE64' | base64 -d | bash\n%s\nENDBASE64\n", scriptB64)
-	cmd.Stdin = strings.NewReader(scriptContent)
-
-	debugf("SSH ensureAction command [%s]: ssh %s (with here-doc via stdin)", sc.server.Name, strings.Join(args, " "))
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start ssh command: %w", err)
-	}
-
-	// Monitor context cancellation and command completion
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
+	debugf("SSH ensureAction command [%s]: sh -s (with here-doc via stdin)", sc.server.Name)
 
-	var err error
-	select {
-	case err = <-done:
-	case <-ctx.Done():
-		if cmd.Process != nil && cmd.Process.Pid > 0 {
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
-			time.Sleep(100 * time.Millisecond)
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-			_, _ = cmd.Process.Wait()
-		}
-		return ctx.Err()
-	}
-
-	combinedOutput := append(stdout.Bytes(), stderr.Bytes()...)
-	output := strings.TrimSpace(string(combinedOutput))
+	output, err := sc.runRemoteSession(ctx, "sh -s", strings.NewReader(scriptContent))
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		debugf("Failed to ensure action file for server %s: %v (output: %s)", sc.server.Name, err, output)
 		return fmt.Errorf("failed to ensure action file on remote server %s: %w (remote output: %s)", sc.server.Name, err, output)
 	}
@@ -428,6 +425,10 @@ func (sc *SSHConnector) checkFail2banHealthyRemote(ctx context.Context) error {
 	return nil
 }
 
+func (sc *SSHConnector) CheckHealth(ctx context.Context) error {
+	return sc.checkFail2banHealthyRemote(ctx)
+}
+
 func (sc *SSHConnector) buildFail2banArgs(args ...string) []string {
 	if sc.server.SocketPath == "" {
 		return args
@@ -436,90 +437,210 @@ func (sc *SSHConnector) buildFail2banArgs(args ...string) []string {
 	return append(base, args...)
 }
 
+// Runs the command on the remote host. Arguments are joined and interpreted by the remote shell, like the ssh CLI does.
 func (sc *SSHConnector) runRemoteCommand(ctx context.Context, command []string) (string, error) {
-	args := sc.buildSSHArgs(command)
-	cmd := exec.Command("ssh", args...)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
+	return sc.runRemoteSession(ctx, strings.Join(command, " "), nil)
+}
+
+// =========================================================================
+//  Event Log Pull
+// =========================================================================
+
+func (sc *SSHConnector) ReadEventLog(ctx context.Context, offset, limit int64) (EventLogChunk, error) {
+	out, err := sc.runFail2banCommand(ctx, "get", "logtarget")
+	if err != nil {
+		return EventLogChunk{}, fmt.Errorf("failed to query fail2ban log target: %w", err)
 	}
-	debugf("SSH command [%s]: ssh %s", sc.server.Name, strings.Join(args, " "))
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	path, err := parseLogTarget(out)
+	if err != nil {
+		return EventLogChunk{}, err
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	// First line: "<size> <offset> <utc offset>", followed by the raw log bytes.
+	// The stat fails when the log is neither readable nor allowed through sudo.
+	script := remoteLogReadFunc + fmt.Sprintf(`; P=%s; S=$(lr size "$P") || exit 1; O=%d; if [ "$O" -lt 0 ] || [ "$O" -gt "$S" ]; then O=0; fi; echo "$S $O $(date +%%z)"; if [ %d -gt 0 ]; then lr from "$P" "$O" | head -c %d; fi`,
+		shellQuote(path), offset, limit, limit)
+	raw, err := sc.runRemoteSessionRaw(ctx, script)
+	if err != nil {
+		return EventLogChunk{}, remoteReadError("fail2ban log", path, err)
+	}
+	header, data, _ := strings.Cut(raw, "\n")
+	var chunk EventLogChunk
+	if _, err := fmt.Sscanf(header, "%d %d", &chunk.Size, &chunk.Offset); err != nil {
+		return EventLogChunk{}, fmt.Errorf("unexpected log read output %q", header)
+	}
+	if fields := strings.Fields(header); len(fields) == 3 {
+		chunk.UTCOffset = fields[2]
+	}
+	chunk.Path = path
+	if limit > 0 {
+		chunk.Data = data
+	}
+	return chunk, nil
+}
+
+// =========================================================================
+//  Log File Access
+// =========================================================================
 
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start ssh command: %w", err)
+func (sc *SSHConnector) ReadLogFileTail(ctx context.Context, path string, maxBytes int64) (string, error) {
+	if strings.ContainsRune(path, 0) || !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("invalid log path")
 	}
-	done := make(chan error, 1)
+	quoted := shellQuote(path)
+	out, err := sc.runRemoteSessionRaw(ctx, fmt.Sprintf("%s; lr tail %s %d", remoteLogReadFunc, quoted, maxBytes))
+	if err != nil && isRemotePermissionError(err) {
+		return "", remoteReadError("log file", path, err)
+	}
+	return out, err
+}
+
+// Follows the files with "tail -F", which keeps reading across rotations.
+func (sc *SSHConnector) FollowLogFiles(ctx context.Context, paths []string, emit func(LogTailLine)) error {
+	quoted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if strings.ContainsRune(path, 0) || !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid log path")
+		}
+		quoted = append(quoted, shellQuote(path))
+	}
+	pr, pw := io.Pipe()
+	scanned := make(chan error, 1)
 	go func() {
-		done <- cmd.Wait()
+		err := scanTailOutput(pr, paths, emit)
+		// Unblock the session if the reader stopped early.
+		pr.CloseWithError(io.ErrClosedPipe)
+		scanned <- err
 	}()
-	select {
-	case err := <-done:
-		combinedOutput := append(stdout.Bytes(), stderr.Bytes()...)
-		output := strings.TrimSpace(string(combinedOutput))
-		if err != nil {
-			debugf("SSH command error [%s]: %v | output: %s", sc.server.Name, err, output)
-			return output, fmt.Errorf("ssh command failed: %w (output: %s)", err, output)
+	// Use the read helper for all files when any existing one is unreadable;
+	// "tail -F" would otherwise keep retrying it without an error.
+	files := strings.Join(quoted, " ")
+	command := fmt.Sprintf(`for f in %s; do [ ! -e "$f" ] || [ -r "$f" ] || exec sudo -n %s follow %s; done; exec tail -n 0 -F -- %s`, files, remoteLogReadHelper, files, files)
+	err := sc.streamRemote(ctx, command, pw)
+	pw.Close()
+	if scanErr := <-scanned; err == nil {
+		err = scanErr
+	}
+	if err != nil && isRemotePermissionError(err) {
+		return remoteReadError("log files", strings.Join(paths, ", "), err)
+	}
+	return err
+}
+
+// =========================================================================
+//  Ban Database
+// =========================================================================
+
+func (sc *SSHConnector) ReadBanDatabase(ctx context.Context) ([]byte, error) {
+	out, err := sc.runFail2banCommand(ctx, "get", "dbfile")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fail2ban database file: %w", err)
+	}
+	path, err := parseDBFile(out)
+	if err != nil {
+		return nil, err
+	}
+	// Prefer an online backup so the copy is consistent while fail2ban writes to
+	// the database. The database is root-owned (0600); without read access it is
+	// copied with "sudo -n cat", since sqlite3 under sudo could run shell commands.
+	script := remoteReadFunc + fmt.Sprintf(`; P=%s; S=$(rd "$P" stat -c %%s "$P") || exit 1; if [ "$S" -gt %d ]; then echo "fail2ban database too large ($S bytes)" >&2; exit 1; fi; if [ -r "$P" ] && command -v sqlite3 >/dev/null 2>&1; then T=$(mktemp) && sqlite3 -readonly "$P" ".backup '$T'" && cat "$T"; R=$?; rm -f "$T"; exit $R; fi; rd "$P" cat "$P"`,
+		shellQuote(path), banDatabaseMaxBytes)
+	raw, err := sc.runRemoteSessionRaw(ctx, script)
+	if err != nil {
+		return nil, remoteReadError("fail2ban database", path, err)
+	}
+	return []byte(raw), nil
+}
+
+// =========================================================================
+//  Capabilities
+// =========================================================================
+
+func (sc *SSHConnector) DetectCapabilities(ctx context.Context) (ServerCapabilities, error) {
+	client := strings.Join(append([]string{"sudo", "fail2ban-client"}, sc.buildFail2banArgs()...), " ")
+	script := capabilityProbeScript(client, sc.getFail2banPath(ctx))
+	out, err := sc.runRemoteSession(ctx, "sh -s", strings.NewReader(script))
+	if err != nil {
+		return ServerCapabilities{}, fmt.Errorf("failed to run capability probe on %s: %w", sc.server.Name, err)
+	}
+	return parseCapabilityProbe(out)
+}
+
+// =========================================================================
+//  Config Test
+// =========================================================================
+
+// Stages the change in a temporary copy of the remote config tree and runs
+// "fail2ban-client -t" on it. The content is passed base64 encoded, so it needs no quoting.
+// Files the SSH user cannot read are left out of the copy; the test only
+// counts as failed when it also fails without them.
+func (sc *SSHConnector) testConfigChange(ctx context.Context, kind, name, content string) error {
+	if err := validateConfigName(kind, name); err != nil {
+		return err
+	}
+	fail2banPath := sc.getFail2banPath(ctx)
+	rel := filepath.ToSlash(stagedConfigFile(kind, name))
+	encoded := base64.StdEncoding.EncodeToString([]byte(stagedConfigContent(kind, name, content)))
+	script := fmt.Sprintf(`command -v fail2ban-client >/dev/null 2>&1 || { echo "@@skip fail2ban-client not found"; exit 0; }
+T=$(mktemp -d 2>/dev/null) || { echo "@@fail mktemp failed"; exit 0; }
+trap 'rm -rf "$T"' EXIT
+if ! cp -rL %[1]s/. "$T"/ 2>/dev/null; then
+	U=$(cd %[1]s && find -L . ! -type d | while IFS= read -r f; do [ -e "$T/$f" ] || echo "@@unreadable ${f#./}"; done)
+	[ -n "$U" ] || { echo "@@fail failed to copy %[1]s"; exit 0; }
+	echo "$U"
+fi
+mkdir -p "$(dirname "$T/%[2]s")"
+printf '%%s' '%[3]s' | base64 -d > "$T/%[2]s" || { echo "@@fail failed to stage %[2]s"; exit 0; }
+echo "@@stage $T"
+sudo fail2ban-client -c "$T" -t 2>&1
+echo "@@exit $?"
+`, shellQuote(fail2banPath), rel, encoded)
+	out, err := sc.runRemoteSession(ctx, "sh -s", strings.NewReader(script))
+	if err != nil {
+		return fmt.Errorf("failed to run config test: %w", err)
+	}
+
+	var staged string
+	var body, unreadable []string
+	exitCode := -1
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@skip "):
+			debugf("Skipping config test of %s %s on %s: %s", kind, name, sc.server.Name, strings.TrimPrefix(line, "@@skip "))
+			return nil
+		case strings.HasPrefix(line, "@@fail "):
+			return fmt.Errorf("%w on %s: %s", ErrConfigNotTested, sc.server.Name, strings.TrimPrefix(line, "@@fail "))
+		case strings.HasPrefix(line, "@@unreadable "):
+			unreadable = append(unreadable, strings.TrimPrefix(line, "@@unreadable "))
+		case strings.HasPrefix(line, "@@stage "):
+			staged = strings.TrimPrefix(line, "@@stage ")
+		case strings.HasPrefix(line, "@@exit "):
+			exitCode, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "@@exit ")))
+		default:
+			body = append(body, line)
 		}
-		debugf("SSH command output [%s]: %s", sc.server.Name, output)
-		return output, nil
-	case <-ctx.Done():
-		if cmd.Process != nil && cmd.Process.Pid > 0 {
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
-			time.Sleep(100 * time.Millisecond)
-			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-			_, _ = cmd.Process.Wait()
+	}
+	if exitCode == 0 {
+		if len(unreadable) > 0 {
+			debugf("Config test of %s %s on %s ran without unreadable files: %s", kind, name, sc.server.Name, strings.Join(unreadable, ", "))
 		}
-		return "", ctx.Err()
-	}
-}
-
-func (sc *SSHConnector) buildSSHArgs(command []string) []string {
-	args := []string{"-o", "BatchMode=yes"}
-	args = append(args,
-		"-o", "ConnectTimeout=10",
-		"-o", "ServerAliveInterval=5",
-		"-o", "ServerAliveCountMax=2",
-	)
-	if _, container := os.LookupEnv("CONTAINER"); container {
-		args = append(args,
-			"-o", "StrictHostKeyChecking=no",
-			"-o", "UserKnownHostsFile=/dev/null",
-			"-o", "LogLevel=ERROR",
-		)
-	}
-	controlPath := fmt.Sprintf("/tmp/ssh_control_%s_%s", sc.server.ID, strings.ReplaceAll(sc.server.Host, ".", "_"))
-	// ControlPersist=0 keeps the master SSH process (and with it the reverse
-	// tunnel) alive indefinitely; without a tunnel it expires after 300s.
-	controlPersist := "ControlPersist=300"
-	if sc.tunnelPort > 0 {
-		controlPersist = "ControlPersist=0"
-	}
-	args = append(args,
-		"-o", "ControlMaster=auto",
-		"-o", fmt.Sprintf("ControlPath=%s", controlPath),
-		"-o", controlPersist,
-	)
-	if sc.tunnelPort > 0 {
-		tunnelArg := fmt.Sprintf("%d:localhost:%d", sc.tunnelPort, sc.tunnelPort)
-		args = append(args, "-R", tunnelArg)
-		debugf("SSH reverse tunnel enabled: -R %s with %s (indefinite)", tunnelArg, controlPersist)
-	}
-	if sc.server.SSHKeyPath != "" {
-		args = append(args, "-i", sc.server.SSHKeyPath)
-	}
-	if sc.server.Port > 0 {
-		args = append(args, "-p", strconv.Itoa(sc.server.Port))
-	}
-	target := sc.server.Host
-	if sc.server.SSHUser != "" {
-		target = fmt.Sprintf("%s@%s", sc.server.SSHUser, target)
-	}
-	args = append(args, target)
-	args = append(args, command...)
-	return args
+		return nil
+	}
+	if exitCode < 0 {
+		return fmt.Errorf("unexpected config test output: %s", out)
+	}
+	output := strings.Join(body, "\n")
+	if staged != "" {
+		output = strings.ReplaceAll(output, staged, fail2banPath)
+	}
+	// The failure may come from a left-out file, so the change is untested
+	// rather than invalid.
+	if len(unreadable) > 0 {
+		return fmt.Errorf("%w on %s: the test failed without the unreadable files %s: %s", ErrConfigNotTested, sc.server.Name, strings.Join(unreadable, ", "), strings.TrimSpace(output))
+	}
+	return newConfigValidationError(kind, name, output)
 }
 
 // =========================================================================
@@ -564,6 +685,60 @@ func (sc *SSHConnector) readRemoteFile(ctx context.Context, filePath string) (st
 	return content, nil
 }
 
+// Reports whether any of the files exists on the host.
+func (sc *SSHConnector) remoteFileExists(ctx context.Context, paths ...string) (bool, error) {
+	tests := make([]string, len(paths))
+	for i, path := range paths {
+		tests[i] = "[ -e " + shellQuote(path) + " ]"
+	}
+	out, err := sc.runRemoteSession(ctx, "if "+strings.Join(tests, " || ")+"; then echo yes; else echo no; fi", nil)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "yes", nil
+}
+
+// Shell function for reads of root-owned files such as the ban database:
+// "rd FILE CMD..." runs CMD directly when the SSH user can read FILE and through
+// "sudo -n" otherwise, so a read ACL and the exact read-only sudo rules both work.
+const remoteReadFunc = `rd() { f=$1; shift; if [ -r "$f" ]; then "$@"; else sudo -n "$@"; fi; }`
+
+// Installed path of deployment/ssh/fail2ban-ui-read, which sudo may run to read
+// logs below /var/log. Wildcard sudo rules for tail would allow reading any file.
+const remoteLogReadHelper = "/usr/local/sbin/fail2ban-ui-read"
+
+// Shell function for log reads: "lr OP FILE [N]" runs the operation directly
+// when the SSH user can read FILE and through the read helper otherwise.
+//
+//	size FILE    size in bytes
+//	from FILE N  content from byte N on
+//	tail FILE N  last N bytes
+const remoteLogReadFunc = `lr() { op=$1; f=$2; n=${3-}; if [ -r "$f" ]; then case $op in size) stat -c %s -- "$f";; from) tail -c "+$((n+1))" -- "$f";; tail) tail -c "$n" -- "$f";; esac; elif [ -n "$n" ]; then sudo -n ` + remoteLogReadHelper + ` "$op" "$n" "$f"; else sudo -n ` + remoteLogReadHelper + ` "$op" "$f"; fi; }`
+
+// Explains read failures caused by missing permissions on the remote host.
+func remoteReadError(what, path string, err error) error {
+	if isRemotePermissionError(err) {
+		return fmt.Errorf("no permission to read %s %s: give the SSH user read access or the read-only sudo rules (see docs/security.md): %w", what, path, err)
+	}
+	return fmt.Errorf("failed to read %s %s: %w", what, path, err)
+}
+
+// Reports whether a remote command failed on file permissions or a refused sudo.
+func isRemotePermissionError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, hint := range []string{"permission denied", "a password is required", "a terminal is required", "is not allowed to execute"} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wraps s in single quotes for the remote shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
 func (sc *SSHConnector) writeRemoteFile(ctx context.Context, filePath, content string) error {
 	escaped := strings.ReplaceAll(content, "'", "'\"'\"'")
 
@@ -831,6 +1006,9 @@ func (sc *SSHConnector) getAllJailsFallback(ctx context.Context, jailDPath strin
 }
 
 func (sc *SSHConnector) UpdateJailEnabledStates(ctx context.Context, updates map[string]bool) error {
+	if err := snapshotJailStates(ctx, sc, updates); err != nil {
+		return err
+	}
 	fail2banPath := sc.getFail2banPath(ctx)
 	jailDPath := filepath.Join(fail2banPath, "jail.d")
 
@@ -1167,8 +1345,8 @@ trap 'rm -f "$TMPFILE" "$TMPFILTER"' EXIT
 cat <<'%[2]s' > "$TMPFILE"
 %[3]s
 %[2]s
-fail2ban-regex "$TMPFILE" "$FILTER_PATH" || true
-`, resolvedContentB64, heredocMarker, logContent)
+fail2ban-regex %[4]s "$TMPFILE" "$FILTER_PATH" || true
+`, resolvedContentB64, heredocMarker, logContent, strings.Join(filterRegexArgs, " "))
 	} else {
 		script = fmt.Sprintf(`
 set -e
@@ -1189,8 +1367,8 @@ trap 'rm -f "$TMPFILE"' EXIT
 cat <<'%[3]s' > "$TMPFILE"
 %[4]s
 %[3]s
-fail2ban-regex "$TMPFILE" "$FILTER_PATH" || true
-`, localPath, confPath, heredocMarker, logContent)
+fail2ban-regex %[5]s "$TMPFILE" "$FILTER_PATH" || true
+`, localPath, confPath, heredocMarker, logContent, strings.Join(filterRegexArgs, " "))
 	}
 
 	out, err := sc.runRemoteCommand(ctx, []string{script})
@@ -1247,6 +1425,15 @@ func (sc *SSHConnector) GetJailConfig(ctx context.Context, jail string) (string,
 
 	content, err = sc.readRemoteFile(ctx, confPath)
 	if err != nil {
+		// Only a jail without any file gets the empty section; a failed read must
+		// not look like one, or a revision would record the file as absent.
+		exists, existsErr := sc.remoteFileExists(ctx, localPath, confPath)
+		if existsErr != nil {
+			return "", "", fmt.Errorf("failed to read remote jail config: %w", existsErr)
+		}
+		if exists {
+			return "", "", fmt.Errorf("failed to read remote jail config (tried .local and .conf): %w", err)
+		}
 		return fmt.Sprintf("[%s]\n", jail), localPath, nil
 	}
 	return content, confPath, nil
@@ -1257,6 +1444,12 @@ func (sc *SSHConnector) SetJailConfig(ctx context.Context, jail, content string)
 	if err := ValidateJailName(jail); err != nil {
 		return err
 	}
+	if err := sc.testConfigChange(ctx, ConfigKindJail, jail, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, sc, ConfigKindJail, jail, "update"); err != nil {
+		return err
+	}
 
 	fail2banPath := sc.getFail2banPath(ctx)
 	jailDPath := filepath.Join(fail2banPath, "jail.d")
@@ -1492,7 +1685,7 @@ func (sc *SSHConnector) EnsureJailLocalStructure(ctx context.Context) error {
 	}
 
 	// Build content using the shared helper.
-	content := mustProvider().BuildJailLocalContent()
+	content := mustProvider().BuildJailLocalContent(sc.server, GetServerCapabilities(ctx, sc, false))
 
 	// Escape single quotes for safe use in a single-quoted heredoc
 	escaped := strings.ReplaceAll(content, "'", "'\"'\"'")
@@ -1593,6 +1786,12 @@ func (sc *SSHConnector) CreateJail(ctx context.Context, jailName, content string
 	if err := ValidateJailName(jailName); err != nil {
 		return err
 	}
+	if err := sc.testConfigChange(ctx, ConfigKindJail, jailName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, sc, ConfigKindJail, jailName, "create"); err != nil {
+		return err
+	}
 	fail2banPath := sc.getFail2banPath(ctx)
 	jailDPath := filepath.Join(fail2banPath, "jail.d")
 
@@ -1614,6 +1813,9 @@ func (sc *SSHConnector) DeleteJail(ctx context.Context, jailName string) error {
 	if err := ValidateJailName(jailName); err != nil {
 		return err
 	}
+	if err := snapshotConfig(ctx, sc, ConfigKindJail, jailName, "delete"); err != nil {
+		return err
+	}
 	fail2banPath := sc.getFail2banPath(ctx)
 	localPath := filepath.Join(fail2banPath, "jail.d", jailName+".local")
 	confPath := filepath.Join(fail2banPath, "jail.d", jailName+".conf")
@@ -1630,6 +1832,12 @@ func (sc *SSHConnector) CreateFilter(ctx context.Context, filterName, content st
 	if err := ValidateFilterName(filterName); err != nil {
 		return err
 	}
+	if err := sc.testConfigChange(ctx, ConfigKindFilter, filterName, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, sc, ConfigKindFilter, filterName, "create"); err != nil {
+		return err
+	}
 	fail2banPath := sc.getFail2banPath(ctx)
 	filterDPath := filepath.Join(fail2banPath, "filter.d")
 
@@ -1649,6 +1857,9 @@ func (sc *SSHConnector) DeleteFilter(ctx context.Context, filterName string) err
 	if err := ValidateFilterName(filterName); err != nil {
 		return err
 	}
+	if err := snapshotConfig(ctx, sc, ConfigKindFilter, filterName, "delete"); err != nil {
+		return err
+	}
 
 	fail2banPath := sc.getFail2banPath(ctx)
 	localPath := filepath.Join(fail2banPath, "filter.d", filterName+".local")
@@ -1661,6 +1872,103 @@ func (sc *SSHConnector) DeleteFilter(ctx context.Context, filterName string) err
 	return nil
 }
 
+// =========================================================================
+//  Action Management
+// =========================================================================
+
+func (sc *SSHConnector) GetActions(ctx context.Context) ([]string, error) {
+	actionDPath := filepath.Join(sc.getFail2banPath(ctx), "action.d")
+	localFiles, err := sc.listRemoteFiles(ctx, actionDPath, ".local")
+	if err != nil {
+		return nil, err
+	}
+	confFiles, err := sc.listRemoteFiles(ctx, actionDPath, ".conf")
+	if err != nil {
+		return nil, err
+	}
+	return configNamesFromFiles(append(localFiles, confFiles...)), nil
+}
+
+func (sc *SSHConnector) GetActionConfig(ctx context.Context, action string) (string, string, error) {
+	action = strings.TrimSpace(action)
+	if err := ValidateActionName(action); err != nil {
+		return "", "", err
+	}
+	actionDPath := filepath.Join(sc.getFail2banPath(ctx), "action.d")
+	localPath := filepath.Join(actionDPath, action+".local")
+	confPath := filepath.Join(actionDPath, action+".conf")
+	if content, err := sc.readRemoteFile(ctx, localPath); err == nil {
+		return content, localPath, nil
+	}
+	content, err := sc.readRemoteFile(ctx, confPath)
+	if err != nil {
+		if exists, existsErr := sc.remoteFileExists(ctx, localPath, confPath); existsErr == nil && !exists {
+			return "", "", fmt.Errorf("action %w: neither %s nor %s exists", ErrConfigNotFound, localPath, confPath)
+		}
+		return "", "", fmt.Errorf("failed to read remote action config (tried .local and .conf): %w", err)
+	}
+	return content, confPath, nil
+}
+
+func (sc *SSHConnector) SetActionConfig(ctx context.Context, action, content string) error {
+	return sc.writeAction(ctx, action, content, "update")
+}
+
+func (sc *SSHConnector) CreateAction(ctx context.Context, actionName, content string) error {
+	return sc.writeAction(ctx, actionName, content, "create")
+}
+
+func (sc *SSHConnector) writeAction(ctx context.Context, action, content, revisionAction string) error {
+	action = strings.TrimSpace(action)
+	if err := validateWritableAction(action); err != nil {
+		return err
+	}
+	if err := sc.testConfigChange(ctx, ConfigKindAction, action, content); err != nil {
+		return err
+	}
+	if err := snapshotConfig(ctx, sc, ConfigKindAction, action, revisionAction); err != nil {
+		return err
+	}
+	actionDPath := filepath.Join(sc.getFail2banPath(ctx), "action.d")
+	if _, err := sc.runRemoteCommand(ctx, []string{"mkdir", "-p", actionDPath}); err != nil {
+		return fmt.Errorf("failed to create action.d directory: %w", err)
+	}
+	if err := sc.writeRemoteFile(ctx, filepath.Join(actionDPath, action+".local"), content); err != nil {
+		return fmt.Errorf("failed to write action file: %w", err)
+	}
+	return nil
+}
+
+func (sc *SSHConnector) DeleteAction(ctx context.Context, actionName string) error {
+	actionName = strings.TrimSpace(actionName)
+	if err := validateWritableAction(actionName); err != nil {
+		return err
+	}
+	actionDPath := filepath.Join(sc.getFail2banPath(ctx), "action.d")
+	localPath := filepath.Join(actionDPath, actionName+".local")
+	confPath := filepath.Join(actionDPath, actionName+".conf")
+
+	// Only the .local override is deleted; the distribution .conf stays.
+	out, err := sc.runRemoteSession(ctx, fmt.Sprintf(`if [ -e %s ]; then echo local; elif [ -e %s ]; then echo conf; fi`, shellQuote(localPath), shellQuote(confPath)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to check action files: %w", err)
+	}
+	switch strings.TrimSpace(out) {
+	case "local":
+	case "conf":
+		return fmt.Errorf("action %s: %w", actionName, ErrActionNotOverridden)
+	default:
+		return fmt.Errorf("action %s does not exist in %s", actionName, actionDPath)
+	}
+	if err := snapshotConfig(ctx, sc, ConfigKindAction, actionName, "delete"); err != nil {
+		return err
+	}
+	if _, err := sc.runRemoteCommand(ctx, []string{"rm", "-f", shellQuote(localPath)}); err != nil {
+		return fmt.Errorf("failed to delete action file %s: %w", localPath, err)
+	}
+	return nil
+}
+
 // =========================================================================
 //  Config Parsing
 // =========================================================================
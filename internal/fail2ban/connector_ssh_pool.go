@@ -0,0 +1,664 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	sshDialTimeout       = 10 * time.Second
+	sshKeepaliveInterval = 5 * time.Second
+	sshKeepaliveMaxMiss  = 2
+	// Sessions multiplexed over one pooled connection. Stays below sshd's default MaxSessions (10).
+	sshMaxSessionsPerConn = 8
+)
+
+// One pooled SSH connection per server. The client is dialed lazily and
+// replaced transparently when keepalives fail or the peer drops it.
+type sshPoolEntry struct {
+	serverID   string
+	serverName string
+	signature  string
+	tunnelPort int
+
+	mu       sync.Mutex
+	hostKey  string
+	client   *ssh.Client
+	dialing  *sshDial
+	sessions chan struct{}
+	closed   bool
+}
+
+// A dial in progress; concurrent callers wait for it instead of dialing themselves.
+type sshDial struct {
+	done chan struct{}
+	err  error
+	// The dialing caller's context ended; waiters dial again with their own.
+	abandoned bool
+}
+
+type sshClientPool struct {
+	mu      sync.Mutex
+	entries map[string]*sshPoolEntry
+}
+
+var sshPool = &sshClientPool{entries: make(map[string]*sshPoolEntry)}
+
+// Returned when the remote host presents a key that does not match the pinned one
+// or its known_hosts entry.
+type SSHHostKeyError struct {
+	Host      string
+	Presented string
+	// Set when the key was checked against a known_hosts file instead of the pin.
+	KnownHosts string
+}
+
+func (e *SSHHostKeyError) Error() string {
+	if e.KnownHosts != "" {
+		return fmt.Sprintf("ssh host key mismatch for %s (presented %s) - the key differs from the entry in %s", e.Host, e.Presented, e.KnownHosts)
+	}
+	return fmt.Sprintf("ssh host key mismatch for %s (presented %s) - clear the pinned host key of this server if the host was reinstalled", e.Host, e.Presented)
+}
+
+// =========================================================================
+//  Pool Management
+// =========================================================================
+
+// Identifies everything that requires a fresh connection when it changes.
+func sshConnSignature(server shared.Fail2banServer, tunnelPort int) string {
+	return strings.Join([]string{
+		server.Host,
+		strconv.Itoa(server.Port),
+		server.SSHUser,
+		server.SSHKeyPath,
+		strconv.Itoa(tunnelPort),
+	}, "|")
+}
+
+// Returns the pool entry for the connector, replacing it when the connection settings changed.
+func (p *sshClientPool) entryFor(sc *SSHConnector) *sshPoolEntry {
+	sig := sshConnSignature(sc.server, sc.tunnelPort)
+	p.mu.Lock()
+	old, ok := p.entries[sc.server.ID]
+	if ok && old.signature == sig {
+		p.mu.Unlock()
+		return old
+	}
+	e := &sshPoolEntry{
+		serverID:   sc.server.ID,
+		serverName: sc.server.Name,
+		signature:  sig,
+		tunnelPort: sc.tunnelPort,
+		hostKey:    strings.TrimSpace(sc.server.SSHHostKey),
+		sessions:   make(chan struct{}, sshMaxSessionsPerConn),
+	}
+	p.entries[sc.server.ID] = e
+	p.mu.Unlock()
+	if ok {
+		old.close()
+	}
+	return e
+}
+
+// Drops pooled connections of servers that were removed, disabled or had their pinned host key changed.
+func (p *sshClientPool) sync(servers []shared.Fail2banServer) {
+	active := make(map[string]shared.Fail2banServer, len(servers))
+	for _, srv := range servers {
+		if srv.Enabled && srv.Type == "ssh" {
+			active[srv.ID] = srv
+		}
+	}
+	var stale []*sshPoolEntry
+	p.mu.Lock()
+	for id, e := range p.entries {
+		srv, ok := active[id]
+		if ok {
+			e.mu.Lock()
+			pinned := e.hostKey
+			e.mu.Unlock()
+			if pinned == strings.TrimSpace(srv.SSHHostKey) {
+				continue
+			}
+		}
+		stale = append(stale, e)
+		delete(p.entries, id)
+	}
+	p.mu.Unlock()
+	// Closed outside p.mu so that other servers' lookups are never held up.
+	for _, e := range stale {
+		e.close()
+	}
+}
+
+// Closes the pooled connection for a server, if any.
+func (p *sshClientPool) remove(serverID string) {
+	p.mu.Lock()
+	e, ok := p.entries[serverID]
+	delete(p.entries, serverID)
+	p.mu.Unlock()
+	if ok {
+		e.close()
+	}
+}
+
+func (e *sshPoolEntry) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	if e.client != nil {
+		_ = e.client.Close()
+		e.client = nil
+	}
+}
+
+// Forgets the client if it is still the current one, so the next call redials.
+func (e *sshPoolEntry) drop(client *ssh.Client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client == client {
+		_ = client.Close()
+		e.client = nil
+	}
+}
+
+// Returns the live client, dialing a new connection when needed. Only one dial
+// runs at a time and e.mu is not held during it, so an unreachable host never
+// blocks the pool or close().
+func (e *sshPoolEntry) get(ctx context.Context, server shared.Fail2banServer) (*ssh.Client, error) {
+	for {
+		e.mu.Lock()
+		if e.closed {
+			e.mu.Unlock()
+			return nil, fmt.Errorf("ssh connection to %s was closed", server.Name)
+		}
+		if e.client != nil {
+			client := e.client
+			e.mu.Unlock()
+			return client, nil
+		}
+		if d := e.dialing; d != nil {
+			e.mu.Unlock()
+			select {
+			case <-d.done:
+				if d.err != nil && !d.abandoned {
+					return nil, d.err
+				}
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		d := &sshDial{done: make(chan struct{})}
+		e.dialing = d
+		e.mu.Unlock()
+
+		client, err := e.dial(ctx, server)
+		if err == nil && e.tunnelPort > 0 {
+			if err := e.startReverseTunnel(client); err != nil {
+				log.Printf("warning: failed to open reverse tunnel for server %s: %v", server.Name, err)
+			}
+		}
+
+		e.mu.Lock()
+		e.dialing = nil
+		if err == nil && e.closed {
+			_ = client.Close()
+			err = fmt.Errorf("ssh connection to %s was closed", server.Name)
+		}
+		if err == nil {
+			e.client = client
+			go e.keepalive(client)
+		}
+		d.err = err
+		d.abandoned = err != nil && ctx.Err() != nil
+		close(d.done)
+		e.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+}
+
+// Reserves one session slot on the pooled connection.
+func (e *sshPoolEntry) acquire(ctx context.Context) error {
+	select {
+	case e.sessions <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *sshPoolEntry) release() {
+	<-e.sessions
+}
+
+// =========================================================================
+//  Dialing and Authentication
+// =========================================================================
+
+func (e *sshPoolEntry) dial(ctx context.Context, server shared.Fail2banServer) (*ssh.Client, error) {
+	auth, closeAgent, err := sshAuthMethods(server.SSHKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	// The agent is only needed for the handshake.
+	defer closeAgent()
+	port := server.Port
+	if port <= 0 {
+		port = 22
+	}
+	address := net.JoinHostPort(server.Host, strconv.Itoa(port))
+	clientCfg := &ssh.ClientConfig{
+		User:            server.SSHUser,
+		Auth:            auth,
+		HostKeyCallback: e.hostKeyCallback(),
+		Timeout:         sshDialTimeout,
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, sshDialTimeout)
+	defer cancel()
+	var d net.Dialer
+	netConn, err := d.DialContext(dialCtx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	// The handshake itself has no context, so bound it with a deadline.
+	if deadline, ok := dialCtx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(netConn, address, clientCfg)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", address, err)
+	}
+	_ = netConn.SetDeadline(time.Time{})
+	debugf("SSH connection established to %s (%s)", server.Name, address)
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// Verifies the presented host key against the pinned one. Without a pin the
+// user's known_hosts file decides when it lists the host; otherwise the first
+// presented key is accepted and stored with the server record.
+func (e *sshPoolEntry) hostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		presented := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+		e.mu.Lock()
+		pinned := e.hostKey
+		e.mu.Unlock()
+		if pinned == "" {
+			if known, err := checkKnownHosts(hostname, remote, key); known || err != nil {
+				return err
+			}
+			e.mu.Lock()
+			first := e.hostKey == ""
+			if first {
+				e.hostKey = presented
+			}
+			pinned = e.hostKey
+			e.mu.Unlock()
+			if first {
+				log.Printf("Pinned SSH host key %s for server %s", ssh.FingerprintSHA256(key), e.serverName)
+				mustProvider().RecordSSHHostKey(e.serverID, presented)
+				return nil
+			}
+		}
+		if sshHostKeyMatches(pinned, key) {
+			return nil
+		}
+		return &SSHHostKeyError{Host: hostname, Presented: ssh.FingerprintSHA256(key)}
+	}
+}
+
+// Checks the key against the known_hosts file of the .ssh directory. Reports
+// whether the host is listed with this key, and an error when it is listed with
+// another one. A missing file or entry leaves the decision to the pin.
+func checkKnownHosts(hostname string, remote net.Addr, key ssh.PublicKey) (bool, error) {
+	dir := defaultSSHDir()
+	if dir == "" {
+		return false, nil
+	}
+	path := filepath.Join(dir, "known_hosts")
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("warning: ignoring %s: %v", path, err)
+		}
+		return false, nil
+	}
+	err = callback(hostname, remote, key)
+	if err == nil {
+		return true, nil
+	}
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+		return false, nil
+	}
+	return false, &SSHHostKeyError{Host: hostname, Presented: ssh.FingerprintSHA256(key), KnownHosts: path}
+}
+
+// Accepts either a public-key line ("ssh-ed25519 AAAA...") or a SHA256 fingerprint as the pin.
+func sshHostKeyMatches(pinned string, key ssh.PublicKey) bool {
+	if pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pinned)); err == nil {
+		return subtle.ConstantTimeCompare(pubKey.Marshal(), key.Marshal()) == 1
+	}
+	want := strings.TrimPrefix(pinned, "SHA256:")
+	got := strings.TrimPrefix(ssh.FingerprintSHA256(key), "SHA256:")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// Uses the configured key, otherwise the running ssh-agent and the default identities
+// in the .ssh directory. The returned func closes the agent connection, if one was opened.
+func sshAuthMethods(keyPath string) ([]ssh.AuthMethod, func(), error) {
+	noop := func() {}
+	if keyPath != "" {
+		signer, err := loadSSHSigner(keyPath)
+		if err != nil {
+			return nil, noop, err
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, noop, nil
+	}
+
+	var methods []ssh.AuthMethod
+	closeAgent := noop
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = func() { _ = conn.Close() }
+		}
+	}
+	var signers []ssh.Signer
+	if dir := defaultSSHDir(); dir != "" {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			signer, err := loadSSHSigner(filepath.Join(dir, name))
+			if err == nil {
+				signers = append(signers, signer)
+			}
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if len(methods) == 0 {
+		return nil, noop, fmt.Errorf("no ssh private key configured and no default identity found")
+	}
+	return methods, closeAgent, nil
+}
+
+func loadSSHSigner(path string) (ssh.Signer, error) {
+	if strings.ContainsRune(path, 0) {
+		return nil, fmt.Errorf("invalid ssh key path")
+	}
+	key, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh key %s: %w", path, err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key %s: %w", path, err)
+	}
+	return signer, nil
+}
+
+// Returns /config/.ssh in the container, ~/.ssh otherwise.
+func defaultSSHDir() string {
+	if _, container := os.LookupEnv("CONTAINER"); container {
+		return "/config/.ssh"
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh")
+}
+
+// =========================================================================
+//  Keepalive and Reverse Tunnel
+// =========================================================================
+
+// Sends OpenSSH keepalives and evicts the client once too many go unanswered.
+func (e *sshPoolEntry) keepalive(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		_ = client.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(sshKeepaliveInterval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-done:
+			e.drop(client)
+			return
+		case <-ticker.C:
+			reply := make(chan error, 1)
+			go func() {
+				_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+				reply <- err
+			}()
+			select {
+			case err := <-reply:
+				if err == nil {
+					missed = 0
+					continue
+				}
+				missed++
+			case <-time.After(sshKeepaliveInterval):
+				missed++
+			case <-done:
+				e.drop(client)
+				return
+			}
+			if missed >= sshKeepaliveMaxMiss {
+				debugf("SSH keepalive to %s failed %d times, dropping connection", e.serverName, missed)
+				e.drop(client)
+				return
+			}
+		}
+	}
+}
+
+// Forwards localhost:port on the remote host to localhost:port here, like "ssh -R port:localhost:port".
+func (e *sshPoolEntry) startReverseTunnel(client *ssh.Client) error {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(e.tunnelPort))
+	listener, err := client.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	debugf("SSH reverse tunnel for %s listening on remote %s", e.serverName, addr)
+	go func() {
+		defer listener.Close()
+		for {
+			remote, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go proxyTunnelConn(remote, addr)
+		}
+	}()
+	return nil
+}
+
+func proxyTunnelConn(remote net.Conn, addr string) {
+	defer remote.Close()
+	local, err := net.DialTimeout("tcp", addr, sshDialTimeout)
+	if err != nil {
+		debugf("SSH reverse tunnel: failed to reach local %s: %v", addr, err)
+		return
+	}
+	defer local.Close()
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// =========================================================================
+//  Remote Command Execution
+// =========================================================================
+
+// Runs a command on a pooled connection and returns the trimmed combined output.
+func (sc *SSHConnector) runRemoteSession(ctx context.Context, command string, stdin io.Reader) (string, error) {
+	debugf("SSH command [%s]: %s", sc.server.Name, command)
+	stdout, stderr, err := sc.execRemote(ctx, command, stdin)
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	output := strings.TrimSpace(string(append(stdout, stderr...)))
+	if err != nil {
+		debugf("SSH command error [%s]: %v | output: %s", sc.server.Name, err, output)
+		return output, fmt.Errorf("ssh command failed: %w (output: %s)", err, output)
+	}
+	debugf("SSH command output [%s]: %s", sc.server.Name, output)
+	return output, nil
+}
+
+// Like runRemoteSession but returns stdout untouched, for byte-exact reads.
+func (sc *SSHConnector) runRemoteSessionRaw(ctx context.Context, command string) (string, error) {
+	stdout, stderr, err := sc.execRemote(ctx, command, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("ssh command failed: %w (output: %s)", err, strings.TrimSpace(string(stderr)))
+	}
+	return string(stdout), nil
+}
+
+// Executes the command in a new session on the pooled connection.
+// A stale connection is redialed once before the error is returned.
+func (sc *SSHConnector) execRemote(ctx context.Context, command string, stdin io.Reader) ([]byte, []byte, error) {
+	entry := sshPool.entryFor(sc)
+	if err := entry.acquire(ctx); err != nil {
+		return nil, nil, err
+	}
+	defer entry.release()
+
+	for attempt := 0; ; attempt++ {
+		client, err := entry.get(ctx, sc.server)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ssh connection failed: %w", err)
+		}
+		session, err := client.NewSession()
+		if err != nil {
+			entry.drop(client)
+			if attempt == 0 && ctx.Err() == nil {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to open ssh session: %w", err)
+		}
+		return runSession(ctx, session, command, stdin)
+	}
+}
+
+func runSession(ctx context.Context, session *ssh.Session, command string, stdin io.Reader) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if stdin != nil {
+		session.Stdin = stdin
+	}
+	err := waitSession(ctx, session, command)
+	if err != nil && ctx.Err() != nil {
+		return nil, nil, err
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// Starts the command on the prepared session and waits for it; on cancellation
+// the remote process is killed and ctx.Err() returned.
+func waitSession(ctx context.Context, session *ssh.Session, command string) error {
+	defer session.Close()
+	if err := session.Start(command); err != nil {
+		return fmt.Errorf("failed to start ssh command: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		_ = session.Close()
+		return ctx.Err()
+	}
+}
+
+// Runs a long-lived command on the pooled connection and copies its stdout to w
+// until it exits or ctx is cancelled. Streams do not take one of the command slots,
+// so callers must cap how many they keep open per server (sshd MaxSessions is 10).
+func (sc *SSHConnector) streamRemote(ctx context.Context, command string, w io.Writer) error {
+	debugf("SSH stream [%s]: %s", sc.server.Name, command)
+	entry := sshPool.entryFor(sc)
+	for attempt := 0; ; attempt++ {
+		client, err := entry.get(ctx, sc.server)
+		if err != nil {
+			return fmt.Errorf("ssh connection failed: %w", err)
+		}
+		session, err := client.NewSession()
+		if err != nil {
+			entry.drop(client)
+			if attempt == 0 && ctx.Err() == nil {
+				continue
+			}
+			return fmt.Errorf("failed to open ssh session: %w", err)
+		}
+		var stderr bytes.Buffer
+		session.Stdout = w
+		session.Stderr = &stderr
+		err = waitSession(ctx, session, command)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("ssh stream failed: %w (output: %s)", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+}
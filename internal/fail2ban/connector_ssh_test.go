@@ -0,0 +1,730 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+// Handles one exec request of the in-process test server.
+type sshTestHandler func(command string, stdin []byte) (output string, status uint32)
+
+type sshTestServer struct {
+	addr    *net.TCPAddr
+	hostKey ssh.PublicKey
+	keyPath string
+	conns   atomic.Int32
+}
+
+type hostKeyRecorder struct {
+	testProvider
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (r *hostKeyRecorder) RecordSSHHostKey(serverID, hostKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[serverID] = hostKey
+}
+
+func newTestSigner(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	return signer, priv
+}
+
+// Starts an SSH server on 127.0.0.1 that only accepts the generated client key.
+func startTestSSHServer(t *testing.T, handler sshTestHandler) *sshTestServer {
+	t.Helper()
+	hostSigner, _ := newTestSigner(t)
+	clientSigner, clientPriv := newTestSigner(t)
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("marshal client key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write client key: %v", err)
+	}
+
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key for %s", meta.User())
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := &sshTestServer{
+		addr:    ln.Addr().(*net.TCPAddr),
+		hostKey: hostSigner.PublicKey(),
+		keyPath: keyPath,
+	}
+	go func() {
+		for {
+			nConn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			srv.conns.Add(1)
+			go serveTestSSHConn(nConn, cfg, handler)
+		}
+	}()
+	return srv
+}
+
+func serveTestSSHConn(nConn net.Conn, cfg *ssh.ServerConfig, handler sshTestHandler) {
+	_, chans, reqs, err := ssh.NewServerConn(nConn, cfg)
+	if err != nil {
+		nConn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				var payload struct{ Command string }
+				_ = ssh.Unmarshal(req.Payload, &payload)
+				_ = req.Reply(true, nil)
+				var stdin []byte
+				if payload.Command == "sh -s" {
+					stdin, _ = io.ReadAll(ch)
+				}
+				out, status := handler(payload.Command, stdin)
+				_, _ = io.WriteString(ch, out)
+				_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				_ = ch.Close()
+			}
+		}()
+	}
+}
+
+func newTestSSHConnector(t *testing.T, srv *sshTestServer, id, hostKey string) *SSHConnector {
+	t.Helper()
+	t.Cleanup(func() { sshPool.remove(id) })
+	return &SSHConnector{server: shared.Fail2banServer{
+		ID:         id,
+		Name:       id,
+		Type:       "ssh",
+		Host:       srv.addr.IP.String(),
+		Port:       srv.addr.Port,
+		SSHUser:    "fail2ban",
+		SSHKeyPath: srv.keyPath,
+		SSHHostKey: hostKey,
+		Enabled:    true,
+	}}
+}
+
+func TestSSHConnectorSharesPooledConnection(t *testing.T) {
+	recorder := &hostKeyRecorder{keys: map[string]string{}}
+	SetProvider(recorder)
+	defer SetProvider(noopProvider{})
+
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		if strings.Contains(command, "status sshd") {
+			return "Status for the jail: sshd\n   `- Banned IP list:\t192.0.2.1 192.0.2.2\n", 0
+		}
+		return "ran: " + command + "\n", 0
+	})
+	sc := newTestSSHConnector(t, srv, "ssh-pool-test", "")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 12)
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ips, err := sc.GetBannedIPs(context.Background(), "sshd")
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(ips) != 2 || ips[0].IP != "192.0.2.1" {
+				errs <- fmt.Errorf("unexpected banned IPs %v", ips)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("GetBannedIPs: %v", err)
+	}
+
+	out, err := sc.runRemoteCommand(context.Background(), []string{"cat", "/etc/fail2ban/jail.local"})
+	if err != nil {
+		t.Fatalf("runRemoteCommand: %v", err)
+	}
+	if out != "ran: cat /etc/fail2ban/jail.local" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if got := srv.conns.Load(); got != 1 {
+		t.Fatalf("expected a single pooled TCP connection, got %d", got)
+	}
+
+	recorder.mu.Lock()
+	pinned := recorder.keys["ssh-pool-test"]
+	recorder.mu.Unlock()
+	if !sshHostKeyMatches(pinned, srv.hostKey) {
+		t.Fatalf("expected presented host key to be pinned on first connect, got %q", pinned)
+	}
+}
+
+func TestSSHConnectorRejectsHostKeyMismatch(t *testing.T) {
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		return "ok", 0
+	})
+	other, _ := newTestSigner(t)
+
+	pins := map[string]string{
+		"authorized key": strings.TrimSpace(string(ssh.MarshalAuthorizedKey(other.PublicKey()))),
+		"fingerprint":    ssh.FingerprintSHA256(other.PublicKey()),
+	}
+	for name, pin := range pins {
+		t.Run(name, func(t *testing.T) {
+			sc := newTestSSHConnector(t, srv, "ssh-mismatch-"+strings.ReplaceAll(name, " ", "-"), pin)
+			_, err := sc.runRemoteCommand(context.Background(), []string{"true"})
+			var keyErr *SSHHostKeyError
+			if !errors.As(err, &keyErr) {
+				t.Fatalf("expected SSHHostKeyError, got %v", err)
+			}
+		})
+	}
+
+	sc := newTestSSHConnector(t, srv, "ssh-pinned-ok", ssh.FingerprintSHA256(srv.hostKey))
+	if _, err := sc.runRemoteCommand(context.Background(), []string{"true"}); err != nil {
+		t.Fatalf("expected matching pin to connect, got %v", err)
+	}
+}
+
+func TestSSHConnectorCommandFailureAndStdin(t *testing.T) {
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		switch command {
+		case "sh -s":
+			return "stdin: " + string(stdin), 0
+		case "false":
+			return "boom", 1
+		}
+		return "", 0
+	})
+	sc := newTestSSHConnector(t, srv, "ssh-exit-test", "")
+
+	out, err := sc.runRemoteCommand(context.Background(), []string{"false"})
+	if err == nil || !strings.Contains(err.Error(), "ssh command failed") {
+		t.Fatalf("expected command failure, got %v", err)
+	}
+	if out != "boom" {
+		t.Fatalf("expected output to be returned with the error, got %q", out)
+	}
+
+	out, err = sc.runRemoteSession(context.Background(), "sh -s", strings.NewReader("echo hi"))
+	if err != nil {
+		t.Fatalf("runRemoteSession: %v", err)
+	}
+	if out != "stdin: echo hi" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestSSHConnectorHonoursContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		<-release
+		return "", 0
+	})
+	sc := newTestSSHConnector(t, srv, "ssh-cancel-test", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := sc.runRemoteCommand(ctx, []string{"sleep", "60"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("cancellation took too long")
+	}
+}
+
+// Runs every command in a local shell, except fail2ban-client calls, which get the
+// given answer. Used to exercise the connector's remote read scripts.
+func startLocalShellSSHServer(t *testing.T, fail2banClientOutput string) *sshTestServer {
+	t.Helper()
+	return startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		if strings.Contains(command, "fail2ban-client") {
+			return fail2banClientOutput, 0
+		}
+		out, err := exec.Command("sh", "-c", command).CombinedOutput()
+		if err != nil {
+			return string(out), 1
+		}
+		return string(out), 0
+	})
+}
+
+func TestSSHConnectorReadEventLogScript(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "fail2ban.log")
+	if err := os.WriteFile(logPath, []byte("first line\nsecond line\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	srv := startLocalShellSSHServer(t, "Current logging target is:\n`- "+logPath+"\n")
+	sc := newTestSSHConnector(t, srv, "ssh-event-log", "")
+
+	chunk, err := sc.ReadEventLog(context.Background(), 11, 100)
+	if err != nil {
+		t.Fatalf("ReadEventLog: %v", err)
+	}
+	if chunk.Size != 23 || chunk.Offset != 11 || chunk.Data != "second line\n" || chunk.Path != logPath {
+		t.Fatalf("unexpected chunk %+v", chunk)
+	}
+	if parseUTCOffset(chunk.UTCOffset) == nil {
+		t.Fatalf("expected the host's UTC offset, got %q", chunk.UTCOffset)
+	}
+}
+
+func TestSSHConnectorReadBanDatabaseScript(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "fail2ban.sqlite3")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE bans(jail TEXT, ip TEXT); INSERT INTO bans VALUES ('sshd', '192.0.2.1')`); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	db.Close()
+	srv := startLocalShellSSHServer(t, "Current database file is:\n`- "+dbPath+"\n")
+	sc := newTestSSHConnector(t, srv, "ssh-ban-db", "")
+
+	data, err := sc.ReadBanDatabase(context.Background())
+	if err != nil {
+		t.Fatalf("ReadBanDatabase: %v", err)
+	}
+	copyPath := filepath.Join(dir, "copy.sqlite3")
+	if err := os.WriteFile(copyPath, data, 0o600); err != nil {
+		t.Fatalf("write copy: %v", err)
+	}
+	copied, err := sql.Open("sqlite", copyPath)
+	if err != nil {
+		t.Fatalf("open copy: %v", err)
+	}
+	defer copied.Close()
+	var ip string
+	if err := copied.QueryRow(`SELECT ip FROM bans WHERE jail = 'sshd'`).Scan(&ip); err != nil || ip != "192.0.2.1" {
+		t.Fatalf("unexpected copy: %q %v", ip, err)
+	}
+}
+
+func TestRemoteReadErrorExplainsPermissions(t *testing.T) {
+	denied := remoteReadError("fail2ban log", "/var/log/fail2ban.log", errors.New("ssh command failed: exit 1 (output: sudo: a password is required)"))
+	if !strings.Contains(denied.Error(), "no permission to read fail2ban log") {
+		t.Fatalf("expected a permission hint, got %v", denied)
+	}
+	other := remoteReadError("fail2ban log", "/var/log/fail2ban.log", errors.New("ssh command failed: exit 1"))
+	if strings.Contains(other.Error(), "permission") {
+		t.Fatalf("unexpected permission hint for %v", other)
+	}
+}
+
+func TestSSHConnectorReadLogFileTailScript(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "auth.log")
+	if err := os.WriteFile(logPath, []byte("old line\nnew line\n"), 0o640); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	srv := startLocalShellSSHServer(t, "")
+	sc := newTestSSHConnector(t, srv, "ssh-log-tail", "")
+
+	out, err := sc.ReadLogFileTail(context.Background(), logPath, 9)
+	if err != nil {
+		t.Fatalf("ReadLogFileTail: %v", err)
+	}
+	if out != "new line\n" {
+		t.Fatalf("unexpected tail %q", out)
+	}
+}
+
+func TestSSHConnectorDeleteActionKeepsDistributionConf(t *testing.T) {
+	dir := t.TempDir()
+	actionDir := filepath.Join(dir, "action.d")
+	if err := os.MkdirAll(actionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"mail.conf", "mail.local", "iptables.conf"} {
+		if err := os.WriteFile(filepath.Join(actionDir, name), []byte("[Definition]\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := startLocalShellSSHServer(t, "")
+	sc := newTestSSHConnector(t, srv, "ssh-delete-action", "")
+	sc.fail2banPath, sc.pathCached = dir, true
+	ctx := context.Background()
+
+	if err := sc.DeleteAction(ctx, "mail"); err != nil {
+		t.Fatalf("DeleteAction: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(actionDir, "mail.local")); !os.IsNotExist(err) {
+		t.Fatalf("expected mail.local to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(actionDir, "mail.conf")); err != nil {
+		t.Fatalf("expected the distribution mail.conf to stay: %v", err)
+	}
+	if err := sc.DeleteAction(ctx, "iptables"); !errors.Is(err, ErrActionNotOverridden) {
+		t.Fatalf("expected deleting a distribution action to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(actionDir, "iptables.conf")); err != nil {
+		t.Fatalf("refused delete must keep iptables.conf: %v", err)
+	}
+}
+
+func TestSSHConnectorMissingFilterIsConfigNotFound(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "filter.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "filter.d", "sshd.conf"), []byte("[Definition]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := startLocalShellSSHServer(t, "")
+	sc := newTestSSHConnector(t, srv, "ssh-filter-missing", "")
+	sc.fail2banPath, sc.pathCached = dir, true
+
+	if _, path, err := sc.GetFilterConfig(context.Background(), "sshd"); err != nil || filepath.Base(path) != "sshd.conf" {
+		t.Fatalf("expected sshd.conf, got %s (%v)", path, err)
+	}
+	if _, _, err := sc.GetFilterConfig(context.Background(), "missing"); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("expected ErrConfigNotFound, got %v", err)
+	}
+}
+
+// A file the SSH user cannot read must not stop the config test.
+func TestSSHConnectorConfigTestSkipsUnreadableFiles(t *testing.T) {
+	installFakeFail2banClient(t)
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "sudo"), []byte("#!/bin/sh\nexec \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "action.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "jail.conf"), []byte("[DEFAULT]\nbantime = 600\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Root reads any file, so a dangling link stands in for an unreadable one.
+	if err := os.WriteFile(filepath.Join(dir, "action.d", "secret.local"), []byte("[Definition]\n"), 0o000); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "gone.conf"), filepath.Join(dir, "action.d", "gone.conf")); err != nil {
+		t.Fatal(err)
+	}
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin = bytes.NewReader(stdin)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return string(out), 1
+		}
+		return string(out), 0
+	})
+	sc := newTestSSHConnector(t, srv, "ssh-config-test", "")
+	sc.fail2banPath, sc.pathCached = dir, true
+	ctx := context.Background()
+
+	if err := sc.testConfigChange(ctx, "jail", "demo", "[demo]\nenabled = false\n"); err != nil {
+		t.Fatalf("expected the change to pass without the unreadable files, got %v", err)
+	}
+	err := sc.testConfigChange(ctx, "jail", "demo", "[demo]\nbroken\n")
+	if !errors.Is(err, ErrConfigNotTested) || !strings.Contains(err.Error(), "action.d/gone.conf") {
+		t.Fatalf("expected a failure without the unreadable files to be untested, got %v", err)
+	}
+}
+
+func TestLogReadHelperRefusesFilesOutsideVarLog(t *testing.T) {
+	if _, err := exec.LookPath("realpath"); err != nil {
+		t.Skip("realpath not installed")
+	}
+	helper := filepath.Join("..", "..", "deployment", "ssh", "fail2ban-ui-read")
+	run := func(args ...string) (string, error) {
+		out, err := exec.Command("sh", append([]string{helper}, args...)...).CombinedOutput()
+		return string(out), err
+	}
+	for _, args := range [][]string{
+		{"size", "/etc/passwd"},
+		{"size", "/var/log/../etc/passwd"},
+		{"size", "var/log/syslog"},
+		{"size", "/var/log"},
+		{"from", "1G", "/var/log/fail2ban.log"},
+		{"from", "0", "/etc/passwd", "/var/log/fail2ban.log"},
+		{"tail", "100", "/etc/shadow"},
+		{"tail", "100", "/var/log/syslog", "/etc/shadow"},
+		{"tail", "-n", "/var/log/syslog"},
+		{"follow", "/etc/passwd"},
+		{"follow", "/var/log/../etc/passwd"},
+		{"follow", "/etc/missing.log"},
+		{"follow", "/var/log/missing/../../../etc/shadow"},
+		{"follow", "/var/log/"},
+		{"follow", "var/log/syslog"},
+		{"cat", "/var/log/fail2ban.log"},
+	} {
+		if out, err := run(args...); err == nil {
+			t.Fatalf("expected %v to be refused, got %q", args, out)
+		}
+	}
+
+	// Files below /var/log are served as the connector expects.
+	entries, _ := os.ReadDir("/var/log")
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join("/var/log", entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		out, err := run("size", path)
+		if err != nil || strings.TrimSpace(out) != strconv.FormatInt(info.Size(), 10) {
+			t.Fatalf("expected the size of %s, got %q (%v)", path, out, err)
+		}
+		want, _ := os.ReadFile(path)
+		if out, err := run("from", "0", path); err != nil || out != string(want) {
+			t.Fatalf("expected the content of %s (%v)", path, err)
+		}
+		if len(want) > 4 {
+			want = want[len(want)-4:]
+		}
+		if out, err := run("tail", "4", path); err != nil || out != string(want) {
+			t.Fatalf("expected the end of %s, got %q (%v)", path, out, err)
+		}
+		if out, err := run("follow", path, "/etc/passwd"); err == nil {
+			t.Fatalf("expected follow with /etc/passwd to be refused, got %q", out)
+		}
+		// An accepted follow keeps running until it is stopped.
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		followed, err := exec.CommandContext(ctx, "sh", helper, "follow", path, "/var/log/fail2ban-ui-missing.log").CombinedOutput()
+		cancel()
+		if ctx.Err() == nil {
+			t.Fatalf("expected follow of %s to keep running, got %q (%v)", path, followed, err)
+		}
+		break
+	}
+}
+
+// A host that accepts TCP but never completes the handshake must not hold up the pool.
+func TestSSHPoolUnreachableHostDoesNotBlockOthers(t *testing.T) {
+	blackhole, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { blackhole.Close() })
+	go func() {
+		for {
+			conn, err := blackhole.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		return "ok", 0
+	})
+	stuck := newTestSSHConnector(t, srv, "ssh-stuck", "")
+	stuck.server.Port = blackhole.Addr().(*net.TCPAddr).Port
+	healthy := newTestSSHConnector(t, srv, "ssh-healthy", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	dialing := make(chan error, 1)
+	go func() {
+		_, err := stuck.runRemoteCommand(ctx, []string{"true"})
+		dialing <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	sshPool.sync([]shared.Fail2banServer{stuck.server, healthy.server})
+	if out, err := healthy.runRemoteCommand(context.Background(), []string{"true"}); err != nil || out != "ok" {
+		t.Fatalf("healthy server: %q %v", out, err)
+	}
+	sshPool.remove(stuck.server.ID)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("pool was blocked by the unreachable host for %s", elapsed)
+	}
+	if err := <-dialing; err == nil {
+		t.Fatalf("expected the stuck dial to fail")
+	}
+}
+
+func TestSSHDialClosesAgentConnection(t *testing.T) {
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		return "ok", 0
+	})
+	keyData, err := os.ReadFile(srv.keyPath)
+	if err != nil {
+		t.Fatalf("read key: %v", err)
+	}
+	rawKey, err := ssh.ParseRawPrivateKey(keyData)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: rawKey}); err != nil {
+		t.Fatalf("add key: %v", err)
+	}
+
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	served := make(chan struct{}, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				// Returns once the client closes its end.
+				_ = agent.ServeAgent(keyring, conn)
+				served <- struct{}{}
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CONTAINER", "")
+	os.Unsetenv("CONTAINER")
+
+	sc := newTestSSHConnector(t, srv, "ssh-agent-test", "")
+	sc.server.SSHKeyPath = ""
+	if _, err := sc.runRemoteCommand(context.Background(), []string{"true"}); err != nil {
+		t.Fatalf("runRemoteCommand: %v", err)
+	}
+	select {
+	case <-served:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("agent connection was not closed after the handshake")
+	}
+}
+
+func TestSSHConnectorChecksKnownHostsBeforePinning(t *testing.T) {
+	recorder := &hostKeyRecorder{keys: map[string]string{}}
+	SetProvider(recorder)
+	defer SetProvider(noopProvider{})
+
+	srv := startTestSSHServer(t, func(command string, stdin []byte) (string, uint32) {
+		return "ok", 0
+	})
+	other, _ := newTestSigner(t)
+	address := knownhosts.Normalize(srv.addr.String())
+
+	writeKnownHosts := func(t *testing.T, key ssh.PublicKey) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0o700); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		line := knownhosts.Line([]string{address}, key) + "\n"
+		if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(line), 0o600); err != nil {
+			t.Fatalf("write known_hosts: %v", err)
+		}
+	}
+
+	t.Run("listed key is accepted without pinning", func(t *testing.T) {
+		writeKnownHosts(t, srv.hostKey)
+		sc := newTestSSHConnector(t, srv, "ssh-known-host", "")
+		if _, err := sc.runRemoteCommand(context.Background(), []string{"true"}); err != nil {
+			t.Fatalf("runRemoteCommand: %v", err)
+		}
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		if pinned, ok := recorder.keys["ssh-known-host"]; ok {
+			t.Fatalf("host listed in known_hosts should not be pinned, got %q", pinned)
+		}
+	})
+
+	t.Run("different listed key is refused", func(t *testing.T) {
+		writeKnownHosts(t, other.PublicKey())
+		sc := newTestSSHConnector(t, srv, "ssh-known-host-mismatch", "")
+		_, err := sc.runRemoteCommand(context.Background(), []string{"true"})
+		var keyErr *SSHHostKeyError
+		if !errors.As(err, &keyErr) || keyErr.KnownHosts == "" {
+			t.Fatalf("expected a known_hosts mismatch, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+// Caps how much of the log a single pull reads; a busy host is drained over several polls.
+const eventPullMaxBytes = 1 << 20
+
+// Raw slice of the fail2ban log returned by a connector.
+type EventLogChunk struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Offset int64  `json:"offset"`
+	Data   string `json:"data"`
+	// Host's UTC offset as printed by "date +%z"; the log timestamps are in that zone.
+	UTCOffset string `json:"utcOffset,omitempty"`
+}
+
+// Implemented by connectors that can read the fail2ban log for pull mode.
+// The connector resolves the log file itself (fail2ban-client get logtarget).
+// When offset is beyond the file size (rotation), reading restarts at 0 and
+// Offset reports where the data starts. A limit of 0 only reports the size.
+type EventLogReader interface {
+	ReadEventLog(ctx context.Context, offset, limit int64) (EventLogChunk, error)
+}
+
+// Position up to which a server's log has been consumed.
+type EventCursor struct {
+	Path   string
+	Offset int64
+}
+
+// A ban or unban parsed from the fail2ban log.
+type PulledEvent struct {
+	Type       string
+	Jail       string
+	IP         string
+	OccurredAt time.Time
+	Failures   int
+	Logs       []string
+}
+
+// 2024-01-15 10:23:45,123 fail2ban.actions [1234]: NOTICE  [sshd] Ban 192.0.2.1
+var eventLogLineRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})(?:,\d+)?\s+fail2ban\.\S+\s+\[\d+\]:\s+[A-Z]+\s+\[([^\]]+)\]\s+(Found|Ban|Unban)\s+(\S+)`)
+
+// =========================================================================
+//  Pull Logic
+// =========================================================================
+
+// Reads the log past the cursor and returns the parsed events with the advanced cursor.
+// Without a cursor (or after the log target changed) it starts at the end of the log,
+// so history is not replayed as fresh bans.
+func PullEvents(ctx context.Context, conn Connector, cursor EventCursor) ([]PulledEvent, EventCursor, error) {
+	reader, ok := conn.(EventLogReader)
+	if !ok {
+		return nil, cursor, fmt.Errorf("connector %s does not support pulling events", conn.Server().Name)
+	}
+	if cursor.Path == "" {
+		chunk, err := reader.ReadEventLog(ctx, 0, 0)
+		if err != nil {
+			return nil, cursor, err
+		}
+		return nil, EventCursor{Path: chunk.Path, Offset: chunk.Size}, nil
+	}
+
+	// The read starts one byte before the cursor. Unless that byte is a newline,
+	// the previous pull stopped inside a line too long for one window, and the
+	// rest of that line is skipped rather than parsed as a line of its own.
+	from, limit := cursor.Offset, int64(eventPullMaxBytes)
+	if from > 0 {
+		from, limit = from-1, limit+1
+	}
+	chunk, err := reader.ReadEventLog(ctx, from, limit)
+	if err != nil {
+		return nil, cursor, err
+	}
+	if chunk.Path != cursor.Path {
+		debugf("fail2ban log target of %s changed from %s to %s, restarting at end of log", conn.Server().Name, cursor.Path, chunk.Path)
+		return nil, EventCursor{Path: chunk.Path, Offset: chunk.Size}, nil
+	}
+	if chunk.Size < cursor.Offset && chunk.Offset != 0 {
+		// Rotated to a file that ends right before the cursor; read it from the start next time.
+		return nil, EventCursor{Path: chunk.Path}, nil
+	}
+
+	data, offset := chunk.Data, chunk.Offset
+	if offset == from && from < cursor.Offset && data != "" {
+		skip := 1
+		if data[0] != '\n' {
+			skip = len(data)
+			if i := strings.IndexByte(data, '\n'); i >= 0 {
+				skip = i + 1
+			}
+		}
+		data, offset = data[skip:], offset+int64(skip)
+	}
+	// Only complete lines are consumed; a line still being written is read again next time.
+	consumed := strings.LastIndexByte(data, '\n') + 1
+	if consumed == 0 && int64(len(data)) >= eventPullMaxBytes {
+		// A single line larger than the read window would block the cursor forever.
+		consumed = len(data)
+	}
+	loc := time.Local
+	if hostLoc := parseUTCOffset(chunk.UTCOffset); hostLoc != nil {
+		loc = hostLoc
+	}
+	events := ParseEventLog(data[:consumed], loc)
+	return events, EventCursor{Path: chunk.Path, Offset: offset + int64(consumed)}, nil
+}
+
+// Extracts ban and unban events from fail2ban log lines. "Found" lines preceding
+// a ban are attached to it as failure count and log excerpt. Restored bans after a
+// restart are skipped, they were already reported when they happened.
+func ParseEventLog(data string, loc *time.Location) []PulledEvent {
+	var events []PulledEvent
+	found := make(map[string][]string)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		m := eventLogLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ts, err := time.ParseInLocation("2006-01-02 15:04:05", m[1], loc)
+		if err != nil {
+			continue
+		}
+		jail, action, ip := m[2], m[3], m[4]
+		if ValidateIP(ip) != nil {
+			continue
+		}
+		key := jail + "|" + ip
+		switch action {
+		case "Found":
+			found[key] = append(found[key], line)
+		case "Ban":
+			events = append(events, PulledEvent{
+				Type:       "ban",
+				Jail:       jail,
+				IP:         ip,
+				OccurredAt: ts.UTC(),
+				Failures:   len(found[key]),
+				Logs:       found[key],
+			})
+			delete(found, key)
+		case "Unban":
+			events = append(events, PulledEvent{
+				Type:       "unban",
+				Jail:       jail,
+				IP:         ip,
+				OccurredAt: ts.UTC(),
+			})
+		}
+	}
+	return events
+}
+
+// Extracts the log file from "fail2ban-client get logtarget" output.
+func parseLogTarget(output string) (string, error) {
+	last := lastTreeValue(output)
+	if strings.HasPrefix(last, "/") {
+		return last, nil
+	}
+	return "", fmt.Errorf("fail2ban logs to %q; pull mode needs a file log target (logtarget = /var/log/fail2ban.log)", last)
+}
+
+// Returns the value of the last "`- value" line printed by fail2ban-client.
+func lastTreeValue(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	return strings.TrimSpace(strings.TrimPrefix(last, "`-"))
+}
+
+// Parses a "date +%z" offset such as "+0200"; nil when it is missing or malformed.
+func parseUTCOffset(value string) *time.Location {
+	t, err := time.Parse("-0700", strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	_, offset := t.Zone()
+	return time.FixedZone("", offset)
+}
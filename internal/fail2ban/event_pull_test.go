@@ -0,0 +1,190 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+// Serves ReadEventLog from a local file; other Connector methods are not used by PullEvents.
+type fileLogConnector struct {
+	Connector
+	path      string
+	utcOffset string
+}
+
+func (c *fileLogConnector) Server() shared.Fail2banServer {
+	return shared.Fail2banServer{Name: "file"}
+}
+
+func (c *fileLogConnector) ReadEventLog(ctx context.Context, offset, limit int64) (EventLogChunk, error) {
+	chunk, err := readLocalLogChunk(c.path, offset, limit)
+	if c.utcOffset != "" {
+		chunk.UTCOffset = c.utcOffset
+	}
+	return chunk, err
+}
+
+func TestParseEventLog(t *testing.T) {
+	data := `2024-01-15 10:23:40,001 fail2ban.filter  [812]: INFO    [sshd] Found 192.0.2.1 - 2024-01-15 10:23:40
+2024-01-15 10:23:42,002 fail2ban.filter  [812]: INFO    [sshd] Found 192.0.2.1 - 2024-01-15 10:23:42
+2024-01-15 10:23:45,123 fail2ban.actions [812]: NOTICE  [sshd] Ban 192.0.2.1
+2024-01-15 10:24:00,000 fail2ban.actions [812]: NOTICE  [sshd] Restore Ban 198.51.100.7
+2024-01-15 10:33:45,456 fail2ban.actions [812]: NOTICE  [sshd] Unban 192.0.2.1
+garbage line
+`
+	events := ParseEventLog(data, time.UTC)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	ban := events[0]
+	if ban.Type != "ban" || ban.Jail != "sshd" || ban.IP != "192.0.2.1" || ban.Failures != 2 || len(ban.Logs) != 2 {
+		t.Fatalf("unexpected ban event %+v", ban)
+	}
+	if want := time.Date(2024, 1, 15, 10, 23, 45, 0, time.UTC); !ban.OccurredAt.Equal(want) {
+		t.Fatalf("expected ban at %v, got %v", want, ban.OccurredAt)
+	}
+	if events[1].Type != "unban" || events[1].IP != "192.0.2.1" {
+		t.Fatalf("unexpected unban event %+v", events[1])
+	}
+}
+
+func TestParseLogTarget(t *testing.T) {
+	path, err := parseLogTarget("Current logging target is:\n`- /var/log/fail2ban.log\n")
+	if err != nil || path != "/var/log/fail2ban.log" {
+		t.Fatalf("expected /var/log/fail2ban.log, got %q (%v)", path, err)
+	}
+	if _, err := parseLogTarget("Current logging target is:\n`- SYSTEMD-JOURNAL\n"); err == nil {
+		t.Fatalf("expected journal log target to be rejected")
+	}
+}
+
+func TestPullEventsFollowsLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fail2ban.log")
+	history := "2024-01-15 09:00:00,000 fail2ban.actions [812]: NOTICE  [sshd] Ban 203.0.113.9\n"
+	if err := os.WriteFile(path, []byte(history), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := &fileLogConnector{path: path}
+	ctx := context.Background()
+
+	events, cursor, err := PullEvents(ctx, conn, EventCursor{})
+	if err != nil {
+		t.Fatalf("initial pull: %v", err)
+	}
+	if len(events) != 0 || cursor.Offset != int64(len(history)) {
+		t.Fatalf("expected first pull to start at end of log, got %d events at %+v", len(events), cursor)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second line is still being written and must not be consumed yet.
+	_, _ = f.WriteString("2024-01-15 10:00:00,000 fail2ban.actions [812]: NOTICE  [sshd] Ban 192.0.2.5\n2024-01-15 10:00:01,000 fail2ban.act")
+	f.Close()
+
+	events, next, err := PullEvents(ctx, conn, cursor)
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if len(events) != 1 || events[0].IP != "192.0.2.5" {
+		t.Fatalf("expected one ban for 192.0.2.5, got %+v", events)
+	}
+	if next.Offset <= cursor.Offset || next.Path != path {
+		t.Fatalf("expected cursor to advance, got %+v", next)
+	}
+
+	// Rotation: the file shrinks below the cursor and is read from the start.
+	rotated := "2024-01-15 11:00:00,000 fail2ban.actions [812]: NOTICE  [sshd] Unban 192.0.2.5\n"
+	if err := os.WriteFile(path, []byte(rotated), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	next.Offset += 1000
+	events, next, err = PullEvents(ctx, conn, next)
+	if err != nil {
+		t.Fatalf("pull after rotation: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "unban" || next.Offset != int64(len(rotated)) {
+		t.Fatalf("expected unban after rotation, got %+v at %+v", events, next)
+	}
+}
+
+func TestPullEventsUsesHostTimeZone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fail2ban.log")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := &fileLogConnector{path: path, utcOffset: "+0200"}
+	ctx := context.Background()
+	_, cursor, err := PullEvents(ctx, conn, EventCursor{})
+	if err != nil {
+		t.Fatalf("initial pull: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("2024-01-15 10:00:00,000 fail2ban.actions [812]: NOTICE  [sshd] Ban 192.0.2.5\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	events, _, err := PullEvents(ctx, conn, cursor)
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if want := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC); len(events) != 1 || !events[0].OccurredAt.Equal(want) {
+		t.Fatalf("expected a ban at %v, got %+v", want, events)
+	}
+}
+
+func TestPullEventsSkipsRestOfOversizedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fail2ban.log")
+	history := "2024-01-15 09:00:00,000 fail2ban.actions [812]: NOTICE  [sshd] Ban 203.0.113.9\n"
+	if err := os.WriteFile(path, []byte(history), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := &fileLogConnector{path: path}
+	ctx := context.Background()
+	_, cursor, err := PullEvents(ctx, conn, EventCursor{})
+	if err != nil {
+		t.Fatalf("initial pull: %v", err)
+	}
+
+	// The second window starts inside the long line, right at text that looks like a ban.
+	long := strings.Repeat("A", eventPullMaxBytes) + "2024-01-15 10:00:00,000 fail2ban.actions [812]: NOTICE  [sshd] Ban 198.51.100.66\n"
+	banLine := "2024-01-15 10:00:01,000 fail2ban.actions [812]: NOTICE  [sshd] Ban 192.0.2.7\n"
+	if err := os.WriteFile(path, []byte(history+long+banLine), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var events []PulledEvent
+	for i := 0; i < 3; i++ {
+		var pulled []PulledEvent
+		if pulled, cursor, err = PullEvents(ctx, conn, cursor); err != nil {
+			t.Fatalf("pull: %v", err)
+		}
+		events = append(events, pulled...)
+	}
+	if len(events) != 1 || events[0].IP != "192.0.2.7" {
+		t.Fatalf("expected only the ban of 192.0.2.7, got %+v", events)
+	}
+	if want := int64(len(history + long + banLine)); cursor.Offset != want {
+		t.Fatalf("expected the cursor at the end of the log (%d), got %+v", want, cursor)
+	}
+}
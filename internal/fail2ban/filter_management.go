@@ -110,11 +110,15 @@ func readFilterConfigWithFallback(filterName, configPath string) (string, string
 		return string(content), localPath, nil
 	}
 
-	if content, err := os.ReadFile(confPath); err == nil {
+	content, err := os.ReadFile(confPath)
+	if err == nil {
 		debugf("Reading filter config from .conf: %s", confPath)
 		return string(content), confPath, nil
 	}
-	return "", localPath, fmt.Errorf("filter config not found: neither %s nor %s exists", localPath, confPath)
+	if os.IsNotExist(err) {
+		return "", localPath, fmt.Errorf("filter %w: neither %s nor %s exists", ErrConfigNotFound, localPath, confPath)
+	}
+	return "", localPath, fmt.Errorf("failed to read filter config %s: %w", confPath, err)
 }
 
 func SetFilterConfigLocal(jail, newContent, configPath string) error {
@@ -626,7 +630,8 @@ func TestFilterLocal(filterName string, logLines []string, filterContent string,
 	}
 	tmpFile.Close()
 
-	cmd := exec.Command("fail2ban-regex", tmpFile.Name(), filterPath)
+	args := append(append([]string{}, filterRegexArgs...), tmpFile.Name(), filterPath)
+	cmd := exec.Command("fail2ban-regex", args...)
 	out, _ := cmd.CombinedOutput()
 	output := string(out)
 
@@ -0,0 +1,226 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// =========================================================================
+//  Types
+// =========================================================================
+
+// fail2ban-regex flags used for filter tests: -v lists the IP and time of every
+// failregex hit, the --print-all-* flags list every line instead of only the first 20.
+var filterRegexArgs = []string{"-v", "--print-all-matched", "--print-all-missed", "--print-all-ignored"}
+
+// Structured form of a fail2ban-regex run.
+type FilterTestResult struct {
+	DatePattern   string               `json:"datePattern,omitempty"`
+	Failregex     []FilterRegexStat    `json:"failregex"`
+	Ignoreregex   []FilterRegexStat    `json:"ignoreregex"`
+	DateTemplates []FilterDateTemplate `json:"dateTemplates"`
+	Matched       []FilterMatchedLine  `json:"matched"`
+	Ignored       []string             `json:"ignored"`
+	Missed        []string             `json:"missed"`
+	Totals        FilterTestTotals     `json:"totals"`
+}
+
+// Hit count of one failregex or ignoreregex; Index is 1-based like in fail2ban-regex.
+type FilterRegexStat struct {
+	Index int    `json:"index"`
+	Regex string `json:"regex"`
+	Hits  int    `json:"hits"`
+}
+
+// Date template that recognised the timestamp of at least one line.
+type FilterDateTemplate struct {
+	Format string `json:"format"`
+	Hits   int    `json:"hits"`
+}
+
+// Log line matched by the filter, with the failregex index(es) and the extracted host.
+type FilterMatchedLine struct {
+	Line    string `json:"line"`
+	Regexes []int  `json:"regexes"`
+	IP      string `json:"ip,omitempty"`
+	Time    string `json:"time,omitempty"`
+}
+
+type FilterTestTotals struct {
+	Lines   int `json:"lines"`
+	Matched int `json:"matched"`
+	Ignored int `json:"ignored"`
+	Missed  int `json:"missed"`
+}
+
+// One verbose hit line below a failregex: host, time and whether other regexes matched the same line.
+type filterRegexHit struct {
+	ip       string
+	time     string
+	multiple bool
+}
+
+var (
+	filterRegexSectionRe  = regexp.MustCompile(`^(Failregex|Ignoreregex): \d+ total`)
+	filterRegexStatRe     = regexp.MustCompile(`^\|\s*(\d+)\) \[(\d+)\] (.*)$`)
+	filterRegexHitRe      = regexp.MustCompile(`^\|\s{4,}(\S+)\s{2}(.+?)( \(multiple regex matched\))?$`)
+	filterDateTemplateRe  = regexp.MustCompile(`^\|\s+\[(\d+)\] (.*)$`)
+	filterLineSectionRe   = regexp.MustCompile(`^\|- (Matched|Ignored|Missed) line\(s\):$`)
+	filterTotalsRe        = regexp.MustCompile(`^Lines: (\d+) lines, (\d+) ignored, (\d+) matched, (\d+) missed`)
+	filterClockRe         = regexp.MustCompile(`\d{2}:\d{2}:\d{2}`)
+	filterDatePatternLine = regexp.MustCompile(`^Use\s+datepattern\s*:\s*(.*)$`)
+)
+
+// =========================================================================
+//  Parsing
+// =========================================================================
+
+// Parses fail2ban-regex output. Sections missing from the output (older agents,
+// runs without -v) are left empty; Totals always come from the "Lines:" summary.
+func ParseFilterTestOutput(output string) *FilterTestResult {
+	res := &FilterTestResult{
+		Failregex:     []FilterRegexStat{},
+		Ignoreregex:   []FilterRegexStat{},
+		DateTemplates: []FilterDateTemplate{},
+		Matched:       []FilterMatchedLine{},
+		Ignored:       []string{},
+		Missed:        []string{},
+	}
+	var (
+		section      string
+		hits         = map[int][]filterRegexHit{}
+		matchedLines []string
+	)
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimRight(raw, "\r")
+		if line == "`-" {
+			section = ""
+			continue
+		}
+		if m := filterDatePatternLine.FindStringSubmatch(line); m != nil {
+			res.DatePattern = strings.TrimSpace(m[1])
+			continue
+		}
+		if m := filterRegexSectionRe.FindStringSubmatch(line); m != nil {
+			section = strings.ToLower(m[1])
+			continue
+		}
+		if line == "Date template hits:" {
+			section = "date"
+			continue
+		}
+		if m := filterLineSectionRe.FindStringSubmatch(line); m != nil {
+			section = strings.ToLower(m[1])
+			continue
+		}
+		if m := filterTotalsRe.FindStringSubmatch(line); m != nil {
+			res.Totals.Lines, _ = strconv.Atoi(m[1])
+			res.Totals.Ignored, _ = strconv.Atoi(m[2])
+			res.Totals.Matched, _ = strconv.Atoi(m[3])
+			res.Totals.Missed, _ = strconv.Atoi(m[4])
+			section = ""
+			continue
+		}
+
+		switch section {
+		case "failregex", "ignoreregex":
+			if m := filterRegexStatRe.FindStringSubmatch(line); m != nil {
+				idx, _ := strconv.Atoi(m[1])
+				count, _ := strconv.Atoi(m[2])
+				stat := FilterRegexStat{Index: idx, Regex: m[3], Hits: count}
+				if section == "failregex" {
+					res.Failregex = append(res.Failregex, stat)
+				} else {
+					res.Ignoreregex = append(res.Ignoreregex, stat)
+				}
+			} else if m := filterRegexHitRe.FindStringSubmatch(line); m != nil && section == "failregex" && len(res.Failregex) > 0 {
+				idx := res.Failregex[len(res.Failregex)-1].Index
+				hits[idx] = append(hits[idx], filterRegexHit{ip: m[1], time: m[2], multiple: m[3] != ""})
+			}
+		case "date":
+			if m := filterDateTemplateRe.FindStringSubmatch(line); m != nil {
+				count, _ := strconv.Atoi(m[1])
+				res.DateTemplates = append(res.DateTemplates, FilterDateTemplate{Format: m[2], Hits: count})
+			}
+		case "matched", "ignored", "missed":
+			if !strings.HasPrefix(line, "|  ") {
+				continue
+			}
+			text := strings.TrimPrefix(line, "|  ")
+			switch section {
+			case "matched":
+				matchedLines = append(matchedLines, text)
+			case "ignored":
+				res.Ignored = append(res.Ignored, text)
+			case "missed":
+				res.Missed = append(res.Missed, text)
+			}
+		}
+	}
+	res.Matched = attributeFilterHits(matchedLines, res.Failregex, hits)
+	return res
+}
+
+// Assigns the verbose per-regex hits to the matched lines. fail2ban-regex lists
+// both in input order, so each line takes the first queued hit whose host and
+// clock time appear in it (host only when the line's clock is in another time
+// zone); a hit flagged "multiple regex matched" also takes the matching hits of
+// the other regexes.
+func attributeFilterHits(lines []string, stats []FilterRegexStat, hits map[int][]filterRegexHit) []FilterMatchedLine {
+	out := make([]FilterMatchedLine, 0, len(lines))
+	for _, text := range lines {
+		ml := FilterMatchedLine{Line: text, Regexes: []int{}}
+		for _, strict := range []bool{true, false} {
+			multiple := false
+			for _, stat := range stats {
+				queue := hits[stat.Index]
+				if len(queue) == 0 || !filterHitMatchesLine(queue[0], text, strict) {
+					continue
+				}
+				if len(ml.Regexes) > 0 && !(multiple && queue[0].multiple) {
+					continue
+				}
+				if len(ml.Regexes) == 0 {
+					ml.IP = queue[0].ip
+					ml.Time = queue[0].time
+					multiple = queue[0].multiple
+				}
+				ml.Regexes = append(ml.Regexes, stat.Index)
+				hits[stat.Index] = queue[1:]
+			}
+			if len(ml.Regexes) > 0 {
+				break
+			}
+		}
+		out = append(out, ml)
+	}
+	return out
+}
+
+func filterHitMatchesLine(hit filterRegexHit, line string, strict bool) bool {
+	if !strings.Contains(line, hit.ip) {
+		return false
+	}
+	if !strict {
+		return true
+	}
+	clock := filterClockRe.FindString(hit.time)
+	return clock == "" || strings.Contains(line, clock)
+}
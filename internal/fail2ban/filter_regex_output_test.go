@@ -0,0 +1,137 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleFilterRegexOutput = `
+Running tests
+=============
+
+Use   failregex filter file : sshd, basedir: /etc/fail2ban
+Use      datepattern : {^LN-BEG} : Default Detectors
+Use         log file : /tmp/fail2ban-test-123.log
+Use         encoding : UTF-8
+
+
+Results
+=======
+
+Failregex: 3 total
+|-  #) [# of hits] regular expression
+|   1) [2] ^Invalid user <F-USER>\S+</F-USER> from <HOST>
+|      192.0.2.1  Mon Jan 15 10:23:45 2024
+|      192.0.2.1  Mon Jan 15 10:23:50 2024
+|   2) [1] ^Failed password for .* from <HOST>
+|      198.51.100.7  Mon Jan 15 10:23:47 2024
+|   3) [0] ^Connection closed by <HOST>
+` + "`-" + `
+
+Ignoreregex: 1 total
+|-  #) [# of hits] regular expression
+|   1) [1] ^.* from 10\.
+` + "`-" + `
+
+Date template hits:
+|- [# of hits] date format
+|  [5] {^LN-BEG}(?:DAY )?MON Day %k:Minute:Second(?:\.Microseconds)?(?: ExYear)?
+|  [0] {^LN-BEG}ExYear(?P<_sep>[-/.])Month(?P=_sep)Day
+` + "`-" + `
+
+Lines: 5 lines, 1 ignored, 3 matched, 1 missed
+[processed in 0.01 sec]
+
+|- Matched line(s):
+|  Jan 15 10:23:45 host sshd[1]: Invalid user admin from 192.0.2.1
+|  Jan 15 10:23:47 host sshd[1]: Failed password for root from 198.51.100.7 port 22
+|  Jan 15 10:23:50 host sshd[1]: Invalid user test from 192.0.2.1
+` + "`-" + `
+|- Ignored line(s):
+|  Jan 15 10:23:51 host sshd[1]: Invalid user x from 10.0.0.1
+` + "`-" + `
+|- Missed line(s):
+|  Jan 15 10:23:52 host sshd[1]: Accepted publickey for ops
+` + "`-" + `
+`
+
+func TestParseFilterTestOutput(t *testing.T) {
+	res := ParseFilterTestOutput(sampleFilterRegexOutput)
+
+	if want := (FilterTestTotals{Lines: 5, Matched: 3, Ignored: 1, Missed: 1}); res.Totals != want {
+		t.Fatalf("totals: got %+v, want %+v", res.Totals, want)
+	}
+	if res.DatePattern != "{^LN-BEG} : Default Detectors" {
+		t.Fatalf("unexpected date pattern %q", res.DatePattern)
+	}
+	if len(res.Failregex) != 3 || res.Failregex[0].Hits != 2 || res.Failregex[2].Regex != `^Connection closed by <HOST>` {
+		t.Fatalf("unexpected failregex stats %+v", res.Failregex)
+	}
+	if len(res.Ignoreregex) != 1 || res.Ignoreregex[0].Hits != 1 {
+		t.Fatalf("unexpected ignoreregex stats %+v", res.Ignoreregex)
+	}
+	if len(res.DateTemplates) != 2 || res.DateTemplates[0].Hits != 5 {
+		t.Fatalf("unexpected date templates %+v", res.DateTemplates)
+	}
+
+	var regexes [][]int
+	var ips []string
+	for _, m := range res.Matched {
+		regexes = append(regexes, m.Regexes)
+		ips = append(ips, m.IP)
+	}
+	if want := [][]int{{1}, {2}, {1}}; !reflect.DeepEqual(regexes, want) {
+		t.Fatalf("regex attribution: got %v, want %v", regexes, want)
+	}
+	if want := []string{"192.0.2.1", "198.51.100.7", "192.0.2.1"}; !reflect.DeepEqual(ips, want) {
+		t.Fatalf("extracted hosts: got %v, want %v", ips, want)
+	}
+	if res.Matched[2].Time != "Mon Jan 15 10:23:50 2024" {
+		t.Fatalf("unexpected hit time %q", res.Matched[2].Time)
+	}
+	if len(res.Ignored) != 1 || len(res.Missed) != 1 || res.Missed[0] != "Jan 15 10:23:52 host sshd[1]: Accepted publickey for ops" {
+		t.Fatalf("unexpected ignored %v / missed %v", res.Ignored, res.Missed)
+	}
+}
+
+func TestParseFilterTestOutputMultipleRegexesAndOtherTimeZone(t *testing.T) {
+	out := `Failregex: 2 total
+|-  #) [# of hits] regular expression
+|   1) [1] from <HOST>
+|      203.0.113.5  Mon Jan 15 11:00:00 2024 (multiple regex matched)
+|   2) [1] user \S+ from <HOST>
+|      203.0.113.5  Mon Jan 15 11:00:00 2024 (multiple regex matched)
+` + "`-" + `
+
+Lines: 1 lines, 0 ignored, 1 matched, 0 missed
+|- Matched line(s):
+|  2024-01-15T10:00:00Z host app: user bob from 203.0.113.5
+` + "`-" + `
+`
+	res := ParseFilterTestOutput(out)
+	if len(res.Matched) != 1 || !reflect.DeepEqual(res.Matched[0].Regexes, []int{1, 2}) || res.Matched[0].IP != "203.0.113.5" {
+		t.Fatalf("unexpected matched lines %+v", res.Matched)
+	}
+
+	// Plain output without the verbose sections still yields totals and empty lists.
+	res = ParseFilterTestOutput("Lines: 2 lines, 0 ignored, 0 matched, 2 missed\n")
+	if res.Totals.Missed != 2 || res.Matched == nil || len(res.Failregex) != 0 {
+		t.Fatalf("unexpected result for plain output %+v", res)
+	}
+}
@@ -93,15 +93,24 @@ func readJailConfigWithFallback(jailName, configPath string) (string, string, er
 		return "", "", err
 	}
 
-	if content, err := os.ReadFile(localPath); err == nil {
+	content, localErr := os.ReadFile(localPath)
+	if localErr == nil {
 		debugf("Reading jail config from .local: %s", localPath)
 		return string(content), localPath, nil
 	}
 
-	if content, err := os.ReadFile(confPath); err == nil {
+	content, err = os.ReadFile(confPath)
+	if err == nil {
 		debugf("Reading jail config from .conf: %s", confPath)
 		return string(content), confPath, nil
 	}
+	// The empty section stands for a jail without a file, not for a failed read.
+	if !os.IsNotExist(localErr) {
+		return "", "", localErr
+	}
+	if !os.IsNotExist(err) {
+		return "", "", err
+	}
 
 	debugf("Neither .local nor .conf exists for jail %s, returning empty section", jailName)
 	return fmt.Sprintf("[%s]\n", jailName), localPath, nil
@@ -0,0 +1,282 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// =========================================================================
+//  Types
+// =========================================================================
+
+// Live parameters of a running jail as reported by fail2ban-client get.
+type JailRuntimeConfig struct {
+	Jail     string   `json:"jail"`
+	Bantime  int64    `json:"bantime"`
+	Findtime int64    `json:"findtime"`
+	MaxRetry int      `json:"maxretry"`
+	IgnoreIP []string `json:"ignoreip"`
+}
+
+// Parameters to change on a running jail. Empty or nil fields are left untouched;
+// Bantime and Findtime accept fail2ban time abbreviations ("600", "1h", "1d12h", "-1").
+// IgnoreIP replaces the whole list.
+type JailRuntimeUpdate struct {
+	Bantime  string    `json:"bantime,omitempty"`
+	Findtime string    `json:"findtime,omitempty"`
+	MaxRetry int       `json:"maxretry,omitempty"`
+	IgnoreIP *[]string `json:"ignoreip,omitempty"`
+}
+
+var (
+	runtimeTimeValue = regexp.MustCompile(`^(-1|\d+(\.\d+)?[a-z]*(\d+(\.\d+)?[a-z]+)*)$`)
+	ignoreHostname   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?$`)
+)
+
+// Checks an update before any of it is applied. Values end up on a fail2ban-client
+// command line, so only the characters fail2ban understands are accepted.
+func (u JailRuntimeUpdate) Validate() error {
+	if u.Bantime == "" && u.Findtime == "" && u.MaxRetry == 0 && u.IgnoreIP == nil {
+		return fmt.Errorf("no jail parameters to change")
+	}
+	if u.Bantime != "" && !runtimeTimeValue.MatchString(u.Bantime) {
+		return fmt.Errorf("invalid bantime %q", u.Bantime)
+	}
+	if u.Findtime != "" && (u.Findtime == "-1" || !runtimeTimeValue.MatchString(u.Findtime)) {
+		return fmt.Errorf("invalid findtime %q", u.Findtime)
+	}
+	if u.MaxRetry < 0 {
+		return fmt.Errorf("maxretry must be positive")
+	}
+	if u.IgnoreIP != nil {
+		for _, entry := range *u.IgnoreIP {
+			if ValidateIP(entry) != nil && !ignoreHostname.MatchString(entry) {
+				return fmt.Errorf("invalid ignoreip entry %q", entry)
+			}
+		}
+	}
+	return nil
+}
+
+// =========================================================================
+//  fail2ban-client get/set
+// =========================================================================
+
+// Runs fail2ban-client with the given arguments; implemented by the local and SSH connectors.
+type fail2banClientFunc func(ctx context.Context, args ...string) (string, error)
+
+func getJailRuntimeConfig(ctx context.Context, run fail2banClientFunc, jail string) (JailRuntimeConfig, error) {
+	if err := ValidateJailName(jail); err != nil {
+		return JailRuntimeConfig{}, err
+	}
+	cfg := JailRuntimeConfig{Jail: jail}
+	for _, field := range []struct {
+		key string
+		dst *int64
+	}{{"bantime", &cfg.Bantime}, {"findtime", &cfg.Findtime}} {
+		out, err := run(ctx, "get", jail, field.key)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to get %s of jail %s: %w", field.key, jail, err)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err != nil {
+			return cfg, fmt.Errorf("unexpected %s output for jail %s: %q", field.key, jail, strings.TrimSpace(out))
+		}
+		*field.dst = int64(v)
+	}
+	out, err := run(ctx, "get", jail, "maxretry")
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get maxretry of jail %s: %w", jail, err)
+	}
+	if cfg.MaxRetry, err = strconv.Atoi(strings.TrimSpace(out)); err != nil {
+		return cfg, fmt.Errorf("unexpected maxretry output for jail %s: %q", jail, strings.TrimSpace(out))
+	}
+	out, err = run(ctx, "get", jail, "ignoreip")
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get ignoreip of jail %s: %w", jail, err)
+	}
+	cfg.IgnoreIP = parseIgnoreIPList(out)
+	return cfg, nil
+}
+
+func setJailRuntimeConfig(ctx context.Context, run fail2banClientFunc, jail string, update JailRuntimeUpdate) error {
+	if err := ValidateJailName(jail); err != nil {
+		return err
+	}
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	set := func(args ...string) error {
+		if out, err := run(ctx, append([]string{"set", jail}, args...)...); err != nil {
+			return fmt.Errorf("failed to set %s of jail %s: %w (output: %s)", args[0], jail, err, strings.TrimSpace(out))
+		}
+		return nil
+	}
+	if update.Bantime != "" {
+		if err := set("bantime", update.Bantime); err != nil {
+			return err
+		}
+	}
+	if update.Findtime != "" {
+		if err := set("findtime", update.Findtime); err != nil {
+			return err
+		}
+	}
+	if update.MaxRetry > 0 {
+		if err := set("maxretry", strconv.Itoa(update.MaxRetry)); err != nil {
+			return err
+		}
+	}
+	if update.IgnoreIP != nil {
+		out, err := run(ctx, "get", jail, "ignoreip")
+		if err != nil {
+			return fmt.Errorf("failed to get ignoreip of jail %s: %w", jail, err)
+		}
+		current := parseIgnoreIPList(out)
+		wanted := make(map[string]bool, len(*update.IgnoreIP))
+		for _, entry := range *update.IgnoreIP {
+			wanted[entry] = true
+		}
+		present := make(map[string]bool, len(current))
+		for _, entry := range current {
+			present[entry] = true
+			if !wanted[entry] {
+				if err := set("delignoreip", entry); err != nil {
+					return err
+				}
+			}
+		}
+		for _, entry := range *update.IgnoreIP {
+			if !present[entry] {
+				if err := set("addignoreip", entry); err != nil {
+					return err
+				}
+				present[entry] = true
+			}
+		}
+	}
+	return nil
+}
+
+// Parses "fail2ban-client get <jail> ignoreip":
+//
+//	These IP addresses/networks are ignored:
+//	|- 127.0.0.1/8
+//	`- ::1
+func parseIgnoreIPList(output string) []string {
+	entries := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|-") && !strings.HasPrefix(line, "`-") {
+			continue
+		}
+		if entry := strings.TrimSpace(line[2:]); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// =========================================================================
+//  Persisting to jail.d/<jail>.local
+// =========================================================================
+
+// Writes the changed parameters into the jail's .local file, so they survive the next reload.
+func PersistJailRuntimeUpdate(ctx context.Context, conn Connector, jail string, update JailRuntimeUpdate) error {
+	content, _, err := conn.GetJailConfig(ctx, jail)
+	if err != nil {
+		return fmt.Errorf("failed to read jail config for %s: %w", jail, err)
+	}
+	var values [][2]string
+	if update.Bantime != "" {
+		values = append(values, [2]string{"bantime", update.Bantime})
+	}
+	if update.Findtime != "" {
+		values = append(values, [2]string{"findtime", update.Findtime})
+	}
+	if update.MaxRetry > 0 {
+		values = append(values, [2]string{"maxretry", strconv.Itoa(update.MaxRetry)})
+	}
+	if update.IgnoreIP != nil {
+		values = append(values, [2]string{"ignoreip", strings.Join(*update.IgnoreIP, " ")})
+	}
+	return conn.SetJailConfig(ctx, jail, setJailSectionValues(content, jail, values))
+}
+
+// Sets key = value lines in the [jail] section, replacing existing (uncommented)
+// assignments and inserting missing keys right after the section header.
+func setJailSectionValues(content, jail string, values [][2]string) string {
+	header := "[" + jail + "]"
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if strings.TrimSpace(content) == "" {
+		lines = []string{header}
+	}
+
+	headerIdx := -1
+	done := make(map[string]bool, len(values))
+	inSection := false
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			inSection = trimmed == header
+			if inSection && headerIdx < 0 {
+				headerIdx = i
+			}
+			continue
+		}
+		if !inSection || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		key, _, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		for _, kv := range values {
+			if kv[0] != key {
+				continue
+			}
+			lines[i] = kv[0] + " = " + kv[1]
+			done[key] = true
+			// Drop continuation lines of a multi-line value (e.g. ignoreip).
+			for i+1 < len(lines) && lines[i+1] != strings.TrimLeft(lines[i+1], " \t") && strings.TrimSpace(lines[i+1]) != "" {
+				lines = append(lines[:i+1], lines[i+2:]...)
+			}
+		}
+	}
+
+	var missing []string
+	for _, kv := range values {
+		if !done[kv[0]] {
+			missing = append(missing, kv[0]+" = "+kv[1])
+		}
+	}
+	if len(missing) > 0 {
+		if headerIdx < 0 {
+			lines = append(lines, header)
+			headerIdx = len(lines) - 1
+		}
+		rest := append(missing, lines[headerIdx+1:]...)
+		lines = append(lines[:headerIdx+1], rest...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
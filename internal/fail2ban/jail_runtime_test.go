@@ -0,0 +1,121 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Minimal in-memory stand-in for fail2ban-client get/set on one jail.
+type fakeJailRuntime struct {
+	values   map[string]string
+	ignoreip []string
+	calls    []string
+}
+
+func (f *fakeJailRuntime) run(ctx context.Context, args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	switch {
+	case args[0] == "get" && args[2] == "ignoreip":
+		if len(f.ignoreip) == 0 {
+			return "No IP address/network is ignored\n", nil
+		}
+		out := "These IP addresses/networks are ignored:\n"
+		for i, ip := range f.ignoreip {
+			prefix := "|- "
+			if i == len(f.ignoreip)-1 {
+				prefix = "`- "
+			}
+			out += prefix + ip + "\n"
+		}
+		return out, nil
+	case args[0] == "get":
+		return f.values[args[2]] + "\n", nil
+	case args[2] == "addignoreip":
+		f.ignoreip = append(f.ignoreip, args[3])
+	case args[2] == "delignoreip":
+		for i, ip := range f.ignoreip {
+			if ip == args[3] {
+				f.ignoreip = append(f.ignoreip[:i], f.ignoreip[i+1:]...)
+				break
+			}
+		}
+	default:
+		f.values[args[2]] = args[3]
+	}
+	return args[3] + "\n", nil
+}
+
+func TestJailRuntimeGetAndSet(t *testing.T) {
+	fake := &fakeJailRuntime{
+		values:   map[string]string{"bantime": "600", "findtime": "600", "maxretry": "5"},
+		ignoreip: []string{"127.0.0.1/8", "192.0.2.0/24"},
+	}
+	ctx := context.Background()
+
+	cfg, err := getJailRuntimeConfig(ctx, fake.run, "sshd")
+	if err != nil {
+		t.Fatalf("getJailRuntimeConfig: %v", err)
+	}
+	want := JailRuntimeConfig{Jail: "sshd", Bantime: 600, Findtime: 600, MaxRetry: 5, IgnoreIP: []string{"127.0.0.1/8", "192.0.2.0/24"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("got %+v, want %+v", cfg, want)
+	}
+
+	ignore := []string{"127.0.0.1/8", "198.51.100.7"}
+	update := JailRuntimeUpdate{Bantime: "3600", MaxRetry: 3, IgnoreIP: &ignore}
+	if err := setJailRuntimeConfig(ctx, fake.run, "sshd", update); err != nil {
+		t.Fatalf("setJailRuntimeConfig: %v", err)
+	}
+	if fake.values["bantime"] != "3600" || fake.values["maxretry"] != "3" || fake.values["findtime"] != "600" {
+		t.Fatalf("unexpected values after set: %v", fake.values)
+	}
+	if !reflect.DeepEqual(fake.ignoreip, ignore) {
+		t.Fatalf("ignoreip=%v want %v", fake.ignoreip, ignore)
+	}
+
+	for _, bad := range []JailRuntimeUpdate{
+		{},
+		{Bantime: "1h; reboot"},
+		{Findtime: "-1"},
+		{IgnoreIP: &[]string{"10.0.0.1 10.0.0.2"}},
+	} {
+		if err := setJailRuntimeConfig(ctx, fake.run, "sshd", bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestSetJailSectionValues(t *testing.T) {
+	content := "[sshd]\nenabled = true\n# bantime = 1d\nbantime = 10m\nignoreip = 127.0.0.1/8\n           10.0.0.0/8\nlogpath = /var/log/auth.log\n\n[other]\nbantime = 5m\n"
+	got := setJailSectionValues(content, "sshd", [][2]string{
+		{"bantime", "1h"},
+		{"maxretry", "3"},
+		{"ignoreip", "127.0.0.1/8 192.0.2.1"},
+	})
+	want := "[sshd]\nmaxretry = 3\nenabled = true\n# bantime = 1d\nbantime = 1h\nignoreip = 127.0.0.1/8 192.0.2.1\nlogpath = /var/log/auth.log\n\n[other]\nbantime = 5m\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := setJailSectionValues("", "nginx", [][2]string{{"findtime", "10m"}}); got != "[nginx]\nfindtime = 10m\n" {
+		t.Fatalf("unexpected content for empty file: %q", got)
+	}
+}
@@ -0,0 +1,385 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	// Bytes read from the end of each log file when replaying a jail's logpath.
+	simulationMaxLogBytes = 5 << 20
+	// Lines replayed at most; older lines are dropped first.
+	simulationMaxLines = 50000
+	// fail2ban defaults used when the jail is not running and nothing was given.
+	simulationDefaultMaxRetry = 5
+	simulationDefaultFindtime = 600
+	simulationDefaultBantime  = 600
+)
+
+// fail2ban-regex prints hit times in this format, in the host's local time.
+const filterHitTimeLayout = "Mon Jan 02 15:04:05 2006"
+
+// Timestamps in simulation results; host-local wall clock like the log itself.
+const simulationTimeLayout = "2006-01-02 15:04:05"
+
+// Implemented by connectors that can read an arbitrary log file on the host.
+// Returns at most maxBytes from the end of the file.
+type LogFileReader interface {
+	ReadLogFileTail(ctx context.Context, path string, maxBytes int64) (string, error)
+}
+
+// Jail parameters to replay with. Empty fields fall back to the running jail's
+// values, then to fail2ban's defaults. Times accept fail2ban abbreviations.
+type JailSimulationParams struct {
+	MaxRetry int      `json:"maxretry,omitempty"`
+	Findtime string   `json:"findtime,omitempty"`
+	Bantime  string   `json:"bantime,omitempty"`
+	IgnoreIP []string `json:"ignoreip,omitempty"`
+}
+
+// Result of replaying log lines through a jail's filter.
+type JailSimulation struct {
+	Jail      string        `json:"jail"`
+	Filter    string        `json:"filter"`
+	MaxRetry  int           `json:"maxretry"`
+	Findtime  int64         `json:"findtime"`
+	Bantime   int64         `json:"bantime"`
+	IgnoreIP  []string      `json:"ignoreip"`
+	LogFiles  []string      `json:"logFiles,omitempty"`
+	Lines     int           `json:"lines"`
+	Matched   int           `json:"matched"`
+	Undated   int           `json:"undated"`
+	TotalBans int           `json:"totalBans"`
+	IPs       []SimulatedIP `json:"ips"`
+}
+
+// Failures and resulting bans of one IP, in time order.
+type SimulatedIP struct {
+	IP       string         `json:"ip"`
+	Failures int            `json:"failures"`
+	Ignored  bool           `json:"ignored,omitempty"`
+	Bans     []SimulatedBan `json:"bans"`
+}
+
+// One simulated ban; UnbanAt is empty for a permanent ban (bantime -1).
+type SimulatedBan struct {
+	BannedAt string `json:"bannedAt"`
+	UnbanAt  string `json:"unbanAt,omitempty"`
+	Failures int    `json:"failures"`
+}
+
+type simulatedFailure struct {
+	ip string
+	at time.Time
+}
+
+var fail2banDurationPart = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]*)`)
+
+// =========================================================================
+//  Simulation
+// =========================================================================
+
+// Replays log lines through the jail's filter (via the connector's TestFilter, so
+// matching is done by fail2ban-regex on the host) and applies maxretry/findtime/
+// bantime windowing to the matches.
+func SimulateJail(ctx context.Context, conn Connector, jail string, logLines []string, params JailSimulationParams) (*JailSimulation, error) {
+	if err := ValidateJailName(jail); err != nil {
+		return nil, err
+	}
+	sim := &JailSimulation{Jail: jail, Filter: jail, IPs: []SimulatedIP{}}
+	if content, _, err := conn.GetJailConfig(ctx, jail); err == nil {
+		if filter := ExtractFilterFromJailConfig(content); filter != "" {
+			sim.Filter = filter
+		}
+	}
+	if err := ValidateFilterName(sim.Filter); err != nil {
+		return nil, fmt.Errorf("jail %s uses filter %q which cannot be tested: %w", jail, sim.Filter, err)
+	}
+	if err := resolveSimulationParams(ctx, conn, sim, params); err != nil {
+		return nil, err
+	}
+
+	lines := normalizeLogLines(logLines)
+	if len(lines) > simulationMaxLines {
+		lines = lines[len(lines)-simulationMaxLines:]
+	}
+	sim.Lines = len(lines)
+	if len(lines) == 0 {
+		return sim, nil
+	}
+	output, _, err := conn.TestFilter(ctx, sim.Filter, lines, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run filter %s: %w", sim.Filter, err)
+	}
+	result := ParseFilterTestOutput(output)
+
+	var failures []simulatedFailure
+	for _, m := range result.Matched {
+		at, err := time.Parse(filterHitTimeLayout, m.Time)
+		if m.IP == "" || err != nil {
+			sim.Undated++
+			continue
+		}
+		failures = append(failures, simulatedFailure{ip: m.IP, at: at})
+	}
+	sim.Matched = len(result.Matched)
+	sim.IPs = simulateBans(failures, sim.MaxRetry, sim.Findtime, sim.Bantime, sim.IgnoreIP)
+	for _, ip := range sim.IPs {
+		sim.TotalBans += len(ip.Bans)
+	}
+	return sim, nil
+}
+
+// Fills the effective parameters: explicit values first, then the running jail, then defaults.
+func resolveSimulationParams(ctx context.Context, conn Connector, sim *JailSimulation, params JailSimulationParams) error {
+	sim.MaxRetry, sim.Findtime, sim.Bantime = simulationDefaultMaxRetry, simulationDefaultFindtime, simulationDefaultBantime
+	sim.IgnoreIP = []string{}
+	if running, err := conn.GetJailRuntimeConfig(ctx, sim.Jail); err == nil {
+		if running.MaxRetry > 0 {
+			sim.MaxRetry = running.MaxRetry
+		}
+		if running.Findtime > 0 {
+			sim.Findtime = running.Findtime
+		}
+		if running.Bantime != 0 {
+			sim.Bantime = running.Bantime
+		}
+		if running.IgnoreIP != nil {
+			sim.IgnoreIP = running.IgnoreIP
+		}
+	}
+	if params.MaxRetry < 0 {
+		return fmt.Errorf("maxretry must be positive")
+	}
+	if params.MaxRetry > 0 {
+		sim.MaxRetry = params.MaxRetry
+	}
+	if params.Findtime != "" {
+		v, err := ParseFail2banDuration(params.Findtime)
+		if err != nil || v <= 0 {
+			return fmt.Errorf("invalid findtime %q", params.Findtime)
+		}
+		sim.Findtime = v
+	}
+	if params.Bantime != "" {
+		v, err := ParseFail2banDuration(params.Bantime)
+		if err != nil || v == 0 {
+			return fmt.Errorf("invalid bantime %q", params.Bantime)
+		}
+		sim.Bantime = v
+	}
+	if params.IgnoreIP != nil {
+		sim.IgnoreIP = params.IgnoreIP
+	}
+	return nil
+}
+
+// Applies fail2ban's windowing per IP: a ban fires once maxretry failures fall
+// within findtime, the counter is reset, and failures during the ban are ignored.
+// A negative bantime bans permanently.
+func simulateBans(failures []simulatedFailure, maxRetry int, findtime, bantime int64, ignoreIP []string) []SimulatedIP {
+	byIP := make(map[string][]time.Time)
+	for _, f := range failures {
+		byIP[f.ip] = append(byIP[f.ip], f.at)
+	}
+	ignore := parseIgnoreList(ignoreIP)
+	out := make([]SimulatedIP, 0, len(byIP))
+	for ip, times := range byIP {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		res := SimulatedIP{IP: ip, Failures: len(times), Bans: []SimulatedBan{}}
+		if ipIgnored(ip, ignore) {
+			res.Ignored = true
+			out = append(out, res)
+			continue
+		}
+		var (
+			window      []time.Time
+			bannedUntil time.Time
+			permanent   bool
+		)
+		for _, t := range times {
+			if permanent || t.Before(bannedUntil) {
+				continue
+			}
+			window = append(window, t)
+			cutoff := t.Add(-time.Duration(findtime) * time.Second)
+			for len(window) > 0 && !window[0].After(cutoff) {
+				window = window[1:]
+			}
+			if len(window) < maxRetry {
+				continue
+			}
+			ban := SimulatedBan{BannedAt: t.Format(simulationTimeLayout), Failures: len(window)}
+			if bantime < 0 {
+				permanent = true
+			} else {
+				bannedUntil = t.Add(time.Duration(bantime) * time.Second)
+				ban.UnbanAt = bannedUntil.Format(simulationTimeLayout)
+			}
+			res.Bans = append(res.Bans, ban)
+			window = nil
+		}
+		out = append(out, res)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Bans) != len(out[j].Bans) {
+			return len(out[i].Bans) > len(out[j].Bans)
+		}
+		if out[i].Failures != out[j].Failures {
+			return out[i].Failures > out[j].Failures
+		}
+		return out[i].IP < out[j].IP
+	})
+	return out
+}
+
+// Parses ignoreip entries into networks; hostnames cannot be matched offline and are skipped.
+func parseIgnoreList(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, n, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, n)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return nets
+}
+
+func ipIgnored(ip string, nets []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Converts a fail2ban time value ("600", "10m", "1h30m", "2d", "1w", "-1") to seconds.
+func ParseFail2banDuration(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, fmt.Errorf("empty time value")
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	parts := fail2banDurationPart.FindAllStringSubmatchIndex(value, -1)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid time value %q", value)
+	}
+	var total float64
+	consumed := 0
+	for _, p := range parts {
+		if strings.TrimSpace(value[consumed:p[0]]) != "" {
+			return 0, fmt.Errorf("invalid time value %q", value)
+		}
+		n, _ := strconv.ParseFloat(value[p[2]:p[3]], 64)
+		unit, ok := fail2banTimeUnit(value[p[4]:p[5]])
+		if !ok {
+			return 0, fmt.Errorf("unknown time unit in %q", value)
+		}
+		total += n * unit
+		consumed = p[1]
+	}
+	if strings.TrimSpace(value[consumed:]) != "" {
+		return 0, fmt.Errorf("invalid time value %q", value)
+	}
+	return int64(total), nil
+}
+
+func fail2banTimeUnit(unit string) (float64, bool) {
+	switch unit {
+	case "", "s", "sec", "secs", "second", "seconds":
+		return 1, true
+	case "m", "min", "mins", "minute", "minutes":
+		return 60, true
+	case "h", "hour", "hours":
+		return 3600, true
+	case "d", "day", "days":
+		return 86400, true
+	case "w", "week", "weeks":
+		return 7 * 86400, true
+	case "mo", "month", "months":
+		return 30 * 86400, true
+	case "y", "year", "years":
+		return 365 * 86400, true
+	}
+	return 0, false
+}
+
+// =========================================================================
+//  Reading the Jail's Logs
+// =========================================================================
+
+// Reads the tail of every file the jail's logpath resolves to on the host.
+// Returns the lines (oldest file content first) and the files that were read.
+func ReadJailLogLines(ctx context.Context, conn Connector, jail string) ([]string, []string, error) {
+	reader, ok := conn.(LogFileReader)
+	if !ok {
+		return nil, nil, fmt.Errorf("connector %s cannot read log files", conn.Server().Name)
+	}
+	files, err := ResolveJailLogFiles(ctx, conn, jail)
+	if err != nil {
+		return nil, nil, err
+	}
+	var lines []string
+	for _, file := range files {
+		data, err := reader.ReadLogFileTail(ctx, file, simulationMaxLogBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		lines = append(lines, tailLines(data, simulationMaxLogBytes)...)
+	}
+	return lines, files, nil
+}
+
+// Splits a tail read into lines, dropping the first one when the read was cut mid-line.
+func tailLines(data string, maxBytes int64) []string {
+	if int64(len(data)) >= maxBytes {
+		if idx := strings.IndexByte(data, '\n'); idx >= 0 {
+			data = data[idx+1:]
+		}
+	}
+	return strings.Split(data, "\n")
+}
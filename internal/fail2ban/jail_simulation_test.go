@@ -0,0 +1,182 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestParseFail2banDuration(t *testing.T) {
+	cases := map[string]int64{
+		"600":    600,
+		"-1":     -1,
+		"10m":    600,
+		"1h30m":  5400,
+		"2d":     172800,
+		"1w":     604800,
+		"1.5h":   5400,
+		"1 hour": 3600,
+	}
+	for in, want := range cases {
+		got, err := ParseFail2banDuration(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseFail2banDuration(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "10x", "1h;rm"} {
+		if _, err := ParseFail2banDuration(in); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestSimulateBans(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return base.Add(time.Duration(min) * time.Minute) }
+	var failures []simulatedFailure
+	// 203.0.113.1: three failures within 10m, two more during the ban, three after it expired.
+	for _, m := range []int{0, 1, 2, 5, 6, 20, 21, 22} {
+		failures = append(failures, simulatedFailure{ip: "203.0.113.1", at: at(m)})
+	}
+	// 203.0.113.2: failures spread wider than findtime never ban.
+	for _, m := range []int{0, 11, 22, 33} {
+		failures = append(failures, simulatedFailure{ip: "203.0.113.2", at: at(m)})
+	}
+	// 10.0.0.5 is ignored.
+	for _, m := range []int{0, 1, 2} {
+		failures = append(failures, simulatedFailure{ip: "10.0.0.5", at: at(m)})
+	}
+
+	res := simulateBans(failures, 3, 600, 600, []string{"10.0.0.0/8", "example.org"})
+	if len(res) != 3 || res[0].IP != "203.0.113.1" {
+		t.Fatalf("unexpected result order %+v", res)
+	}
+	var bannedAt []string
+	for _, b := range res[0].Bans {
+		bannedAt = append(bannedAt, b.BannedAt)
+	}
+	if want := []string{"2024-01-15 10:02:00", "2024-01-15 10:22:00"}; !reflect.DeepEqual(bannedAt, want) {
+		t.Fatalf("bans of 203.0.113.1: got %v, want %v", bannedAt, want)
+	}
+	if res[0].Bans[0].UnbanAt != "2024-01-15 10:12:00" || res[0].Bans[0].Failures != 3 {
+		t.Fatalf("unexpected first ban %+v", res[0].Bans[0])
+	}
+	for _, ip := range res[1:] {
+		if len(ip.Bans) != 0 {
+			t.Fatalf("expected no bans for %s, got %+v", ip.IP, ip.Bans)
+		}
+	}
+	if res[1].IP != "203.0.113.2" || res[2].IP != "10.0.0.5" || !res[2].Ignored {
+		t.Fatalf("unexpected unbanned entries %+v", res[1:])
+	}
+
+	// A permanent bantime stops further bans of the same IP.
+	res = simulateBans(failures[:8], 3, 600, -1, nil)
+	if len(res[0].Bans) != 1 || res[0].Bans[0].UnbanAt != "" {
+		t.Fatalf("expected one permanent ban, got %+v", res[0].Bans)
+	}
+}
+
+func TestSimulateJailUsesFilterTest(t *testing.T) {
+	bin := t.TempDir()
+	output := `Failregex: 4 total
+|-  #) [# of hits] regular expression
+|   1) [4] ^auth failure from <HOST>$
+|      192.0.2.9  Mon Jan 15 10:00:00 2024
+|      192.0.2.9  Mon Jan 15 10:00:30 2024
+|      192.0.2.9  Mon Jan 15 10:01:00 2024
+|      198.51.100.3  Mon Jan 15 10:02:00 2024
+` + "`-" + `
+
+Lines: 5 lines, 0 ignored, 4 matched, 1 missed
+|- Matched line(s):
+|  2024-01-15 10:00:00 auth failure from 192.0.2.9
+|  2024-01-15 10:00:30 auth failure from 192.0.2.9
+|  2024-01-15 10:01:00 auth failure from 192.0.2.9
+|  2024-01-15 10:02:00 auth failure from 198.51.100.3
+` + "`-" + `
+`
+	if err := os.WriteFile(filepath.Join(bin, "regex-output"), []byte(output), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nexec /bin/cat " + filepath.Join(bin, "regex-output") + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "fail2ban-regex"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	dir := t.TempDir()
+	for _, sub := range []string{"jail.d", "filter.d"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(dir, "jail.d", "demo.local"), []byte("[demo]\nenabled = true\nfilter = demo-auth\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "filter.d", "demo-auth.conf"), []byte("[Definition]\nfailregex = ^auth failure from <HOST>$\n"), 0o644)
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "sim-local", Name: "local", Type: "local", ConfigPath: dir}}
+
+	lines := []string{
+		"2024-01-15 10:00:00 auth failure from 192.0.2.9",
+		"2024-01-15 10:00:30 auth failure from 192.0.2.9",
+		"2024-01-15 10:01:00 auth failure from 192.0.2.9",
+		"2024-01-15 10:02:00 auth failure from 198.51.100.3",
+		"2024-01-15 10:03:00 unrelated",
+	}
+	sim, err := SimulateJail(context.Background(), lc, "demo", lines, JailSimulationParams{MaxRetry: 3, Findtime: "5m", Bantime: "1h"})
+	if err != nil {
+		t.Fatalf("SimulateJail: %v", err)
+	}
+	if sim.Filter != "demo-auth" || sim.Findtime != 300 || sim.Bantime != 3600 || sim.Lines != 5 || sim.Matched != 4 {
+		t.Fatalf("unexpected simulation summary %+v", sim)
+	}
+	if sim.TotalBans != 1 || sim.IPs[0].IP != "192.0.2.9" || sim.IPs[0].Bans[0].UnbanAt != "2024-01-15 11:01:00" {
+		t.Fatalf("unexpected bans %+v", sim.IPs)
+	}
+
+	if _, err := SimulateJail(context.Background(), lc, "demo", lines, JailSimulationParams{Findtime: "soon"}); err == nil {
+		t.Fatalf("expected invalid findtime to be rejected")
+	}
+}
+
+func TestReadJailLogLines(t *testing.T) {
+	dir := t.TempDir()
+	logDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "jail.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "jail.d", "demo.local"), []byte("[demo]\nlogpath = "+filepath.Join(logDir, "*.log")+"\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(logDir, "a.log"), []byte("one\ntwo\n"), 0o644)
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "sim-logs", Name: "local", Type: "local", ConfigPath: dir}}
+
+	lines, files, err := ReadJailLogLines(context.Background(), lc, "demo")
+	if err != nil {
+		t.Fatalf("ReadJailLogLines: %v", err)
+	}
+	if len(files) != 1 || !reflect.DeepEqual(normalizeLogLines(lines), []string{"one", "two"}) {
+		t.Fatalf("unexpected lines %v from %v", lines, files)
+	}
+	if got := tailLines("partial\nfull\n", 8); !reflect.DeepEqual(got, []string{"full", ""}) {
+		t.Fatalf("expected cut first line to be dropped, got %q", got)
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
 )
 
 func ensureWritableDirectory(path, purpose string) error {
@@ -91,8 +93,9 @@ func WriteLocalActionFile(configPath, callbackURL, serverID string) error {
 }
 
 // Ensures jail.local and the UI action file for a local tree.
-func EnsureLocalConnectorArtifacts(callbackURL, serverID, configPath string) error {
+func EnsureLocalConnectorArtifacts(callbackURL string, server shared.Fail2banServer) error {
 	debugf("Running EnsureLocalConnectorArtifacts()")
+	serverID, configPath := server.ID, server.ConfigPath
 	jailPath := JailLocal(configPath)
 	if _, err := os.Stat(filepath.Dir(jailPath)); os.IsNotExist(err) {
 		rootDir := NormalizeConfigPath(configPath)
@@ -106,7 +109,7 @@ func EnsureLocalConnectorArtifacts(callbackURL, serverID, configPath string) err
 		return err
 	}
 	p := mustProvider()
-	content := []byte(p.BuildJailLocalContent())
+	content := []byte(p.BuildJailLocalContent(server, cachedCapabilitiesForID(serverID)))
 	if err := EnsureManagedJailLocal(configPath, content); err != nil {
 		return err
 	}
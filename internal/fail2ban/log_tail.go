@@ -0,0 +1,396 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	// How often local log files are checked for new data.
+	logTailPollInterval = 500 * time.Millisecond
+	// Longer lines are cut; a runaway line must not grow buffers without bound.
+	logTailMaxLineBytes = 16 << 10
+	// Bytes read from one local file per poll; the rest is picked up on the next poll.
+	logTailMaxReadBytes = 1 << 20
+)
+
+// A line appended to a followed log file.
+type LogTailLine struct {
+	File string `json:"file"`
+	Line string `json:"line"`
+}
+
+// Implemented by connectors that can follow log files on the host. Blocks and calls
+// emit for every line appended after the call started, until ctx is cancelled or the
+// stream fails. Rotated or truncated files are followed into the new file.
+type LogFollower interface {
+	FollowLogFiles(ctx context.Context, paths []string, emit func(LogTailLine)) error
+}
+
+// Jail failregex compiled to Go regular expressions for highlighting.
+type FailregexMatcher struct {
+	regexes []*regexp.Regexp
+	// failregex lines that use Python-only syntax (lookarounds, back references, multi-line tags).
+	Skipped int
+}
+
+var (
+	failregexInterpolation = regexp.MustCompile(`%\(([\w-]+)\)s`)
+	failregexKeyTag        = regexp.MustCompile(`<([A-Za-z_][\w-]*)>`)
+	failregexFieldTag      = regexp.MustCompile(`</?F-[A-Za-z0-9_-]+/?>`)
+)
+
+// Go equivalents of fail2ban's host tags.
+var failregexHostTags = map[string]string{
+	"HOST":   `\[?(?:::f{4,6}:)?[\w\-.^_:]*\w\]?`,
+	"ADDR":   `(?:::f{4,6}:)?[\w\-.^_:]*\w`,
+	"DNS":    `[\w\-.^_]*\w`,
+	"IP4":    `\d{1,3}(?:\.\d{1,3}){3}`,
+	"IP6":    `[0-9a-fA-F:]+`,
+	"CIDR":   `\d+`,
+	"SUBNET": `[0-9a-fA-F:.]+(?:/\d+)?`,
+}
+
+// =========================================================================
+//  Log File Resolution
+// =========================================================================
+
+// Resolves the files a jail's logpath points to on the host.
+func ResolveJailLogFiles(ctx context.Context, conn Connector, jail string) ([]string, error) {
+	content, _, err := conn.GetJailConfig(ctx, jail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jail config: %w", err)
+	}
+	logpath := ExtractLogpathFromJailConfig(content)
+	if strings.TrimSpace(logpath) == "" {
+		return nil, fmt.Errorf("jail %s has no logpath configured", jail)
+	}
+	var files []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Fields(logpath) {
+		_, _, matched, err := conn.TestLogpathWithResolution(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range matched {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("logpath of jail %s matches no files", jail)
+	}
+	return files, nil
+}
+
+// =========================================================================
+//  Failregex Highlighting
+// =========================================================================
+
+// Loads the failregex of the jail's filter (with common.conf when it is included)
+// for highlighting followed lines.
+func LoadFailregexMatcher(ctx context.Context, conn Connector, jail string) (*FailregexMatcher, error) {
+	filter := jail
+	if content, _, err := conn.GetJailConfig(ctx, jail); err == nil {
+		if name := ExtractFilterFromJailConfig(content); name != "" {
+			filter = name
+		}
+	}
+	if err := ValidateFilterName(filter); err != nil {
+		return nil, err
+	}
+	content, _, err := conn.GetFilterConfig(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter %s: %w", filter, err)
+	}
+	var includes []string
+	if strings.Contains(content, "common.conf") {
+		if common, _, err := conn.GetFilterConfig(ctx, "common"); err == nil {
+			includes = append(includes, common)
+		}
+	}
+	return NewFailregexMatcher(content, includes...), nil
+}
+
+// Builds a matcher from filter content. Included files are read first so the filter's
+// own values win. %(var)s and <key> references are expanded, fail2ban host tags
+// become Go patterns; failregex lines Go cannot compile are counted as skipped.
+func NewFailregexMatcher(filterContent string, includes ...string) *FailregexMatcher {
+	values := make(map[string]string)
+	for _, content := range append(includes, filterContent) {
+		for k, v := range filterDefinitionValues(content) {
+			values[k] = v
+		}
+	}
+	m := &FailregexMatcher{}
+	for _, line := range strings.Split(expandFilterValue(values["failregex"], values), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "<SKIPLINES>") {
+			m.Skipped++
+			continue
+		}
+		line = failregexFieldTag.ReplaceAllStringFunc(line, func(tag string) string {
+			if strings.HasPrefix(tag, "</") {
+				return ")"
+			}
+			if strings.HasSuffix(tag, "/>") {
+				return ""
+			}
+			return "(?:"
+		})
+		line = failregexKeyTag.ReplaceAllStringFunc(line, func(tag string) string {
+			if re, ok := failregexHostTags[tag[1:len(tag)-1]]; ok {
+				return re
+			}
+			return tag
+		})
+		re, err := regexp.Compile(strings.ReplaceAll(line, `\Z`, `\z`))
+		if err != nil {
+			m.Skipped++
+			continue
+		}
+		m.regexes = append(m.regexes, re)
+	}
+	return m
+}
+
+// Number of failregex lines usable for highlighting.
+func (m *FailregexMatcher) Count() int {
+	return len(m.regexes)
+}
+
+// Returns the 1-based index of the first failregex matching the line, 0 for none.
+// The timestamp is cut first, as fail2ban does before applying failregex.
+func (m *FailregexMatcher) Match(line string) int {
+	if m == nil || len(m.regexes) == 0 {
+		return 0
+	}
+	text, _, _ := cutTimestamp(line)
+	text = strings.TrimSpace(text)
+	for i, re := range m.regexes {
+		if re.MatchString(text) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Collects the values of the [INCLUDES]-less sections fail2ban interpolates from.
+func filterDefinitionValues(content string) map[string]string {
+	values := make(map[string]string)
+	section, key := "", ""
+	for _, raw := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(raw)
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section, key = strings.ToLower(strings.Trim(trimmed, "[]")), ""
+			continue
+		}
+		if section != "default" && section != "definition" && section != "init" {
+			continue
+		}
+		if raw != "" && (raw[0] == ' ' || raw[0] == '\t') && key != "" {
+			if trimmed != "" {
+				values[key] += "\n" + trimmed
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			key = ""
+			continue
+		}
+		key = strings.TrimSpace(k)
+		values[key] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+// Expands %(var)s and <key> references; unknown variables match anything.
+func expandFilterValue(value string, values map[string]string) string {
+	for i := 0; i < 10; i++ {
+		expanded := failregexInterpolation.ReplaceAllStringFunc(value, func(ref string) string {
+			name := ref[2 : len(ref)-2]
+			if v, ok := values[name]; ok {
+				return v
+			}
+			return ".*?"
+		})
+		expanded = failregexKeyTag.ReplaceAllStringFunc(expanded, func(tag string) string {
+			name := tag[1 : len(tag)-1]
+			if _, host := failregexHostTags[name]; host {
+				return tag
+			}
+			if v, ok := values[name]; ok {
+				return v
+			}
+			return tag
+		})
+		if expanded == value {
+			break
+		}
+		value = expanded
+	}
+	return value
+}
+
+// =========================================================================
+//  Local Following
+// =========================================================================
+
+type localTailState struct {
+	info    os.FileInfo
+	offset  int64
+	partial string
+}
+
+// Polls the files for appended data. Starts at the current end; a file that is
+// replaced (rotation) or shrinks (truncation) is read again from the start.
+func followLocalFiles(ctx context.Context, paths []string, emit func(LogTailLine)) error {
+	states := make(map[string]*localTailState, len(paths))
+	for _, path := range paths {
+		st := &localTailState{}
+		if info, err := os.Stat(path); err == nil {
+			st.info, st.offset = info, info.Size()
+		}
+		states[path] = st
+	}
+	ticker := time.NewTicker(logTailPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		for _, path := range paths {
+			if err := pollLocalFile(path, states[path], emit); err != nil {
+				debugf("log tail: failed to read %s: %v", path, err)
+			}
+		}
+	}
+}
+
+func pollLocalFile(path string, st *localTailState, emit func(LogTailLine)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Missing for a moment during rotation; picked up again once it exists.
+		return nil
+	}
+	if st.info == nil || !os.SameFile(st.info, info) || info.Size() < st.offset {
+		st.offset, st.partial = 0, ""
+	}
+	st.info = info
+	if info.Size() == st.offset {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := make([]byte, min(info.Size()-st.offset, logTailMaxReadBytes))
+	n, err := f.ReadAt(buf, st.offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	st.offset += int64(n)
+	st.partial = emitTailData(path, st.partial+string(buf[:n]), emit)
+	return nil
+}
+
+// Emits the complete lines in data and returns the unterminated rest.
+func emitTailData(file, data string, emit func(LogTailLine)) string {
+	for {
+		idx := strings.IndexByte(data, '\n')
+		if idx < 0 {
+			break
+		}
+		emitTailLine(file, data[:idx], emit)
+		data = data[idx+1:]
+	}
+	if len(data) > logTailMaxLineBytes {
+		emitTailLine(file, data, emit)
+		return ""
+	}
+	return data
+}
+
+func emitTailLine(file, line string, emit func(LogTailLine)) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(line) > logTailMaxLineBytes {
+		line = line[:logTailMaxLineBytes]
+	}
+	emit(LogTailLine{File: file, Line: line})
+}
+
+// =========================================================================
+//  Stream Parsing
+// =========================================================================
+
+// Reads "tail -F" output. With several files tail prints "==> path <==" before
+// output from a different file; with a single file every line belongs to it.
+func scanTailOutput(r io.Reader, paths []string, emit func(LogTailLine)) error {
+	reader := bufio.NewReaderSize(r, 64<<10)
+	current := paths[0]
+	known := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		known[p] = true
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			trimmed := strings.TrimRight(line, "\r\n")
+			if len(paths) > 1 && strings.HasPrefix(trimmed, "==> ") && strings.HasSuffix(trimmed, " <==") {
+				if name := trimmed[4 : len(trimmed)-4]; known[name] {
+					current = name
+					continue
+				}
+			}
+			emitTailLine(current, trimmed, emit)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestFailregexMatcherExpandsFilter(t *testing.T) {
+	common := `[DEFAULT]
+_daemon = \S*
+__hostname = \S+
+__prefix_line = \s*(?:%(__hostname)s\s+)?%(_daemon)s(?:\[\d+\])?:\s*
+`
+	filter := `[INCLUDES]
+before = common.conf
+
+[Definition]
+_daemon = sshd
+mode = normal
+mdre-normal = ^Invalid user <F-USER>\S+</F-USER> from <HOST>
+failregex = ^%(__prefix_line)sFailed password for .* from <HOST> port \d+
+            <mdre-<mode>>
+            ^(?=lookahead)<HOST>
+            ^<SKIPLINES>Disconnected from <HOST>
+ignoreregex =
+`
+	m := NewFailregexMatcher(filter, common)
+	if m.Count() != 2 || m.Skipped != 2 {
+		t.Fatalf("expected 2 usable and 2 skipped regexes, got %d / %d", m.Count(), m.Skipped)
+	}
+	cases := map[string]int{
+		"Jan 15 10:23:45 host sshd[123]: Failed password for root from 192.0.2.1 port 22 ssh2": 1,
+		"2024-01-15T10:23:45 Invalid user admin from 2001:db8::1":                              2,
+		"Jan 15 10:23:45 host sshd[123]: Accepted publickey for root from 192.0.2.1":           0,
+	}
+	for line, want := range cases {
+		if got := m.Match(line); got != want {
+			t.Errorf("Match(%q) = %d, want %d", line, got, want)
+		}
+	}
+	var nilMatcher *FailregexMatcher
+	if nilMatcher.Match("anything") != 0 {
+		t.Fatalf("nil matcher must not match")
+	}
+}
+
+func TestFollowLocalFilesAppendAndRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "auth.log")
+	if err := os.WriteFile(path, []byte("old line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- followLocalFiles(ctx, []string{path}, func(l LogTailLine) {
+			mu.Lock()
+			got = append(got, l.Line)
+			mu.Unlock()
+		})
+	}()
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			l := len(got)
+			mu.Unlock()
+			if l >= n {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %d lines, got %v", n, got)
+	}
+	time.Sleep(2 * logTailPollInterval)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("first\nsec")
+	f.Close()
+	waitFor(1)
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.WriteString("ond\n")
+	f.Close()
+	waitFor(2)
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("after rotation\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(3)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"first", "second", "after rotation"}; !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestScanTailOutputTracksFileHeaders(t *testing.T) {
+	out := "==> /var/log/a.log <==\none\n\n==> /var/log/b.log <==\ntwo\n==> not a header <==\n"
+	var got []string
+	err := scanTailOutput(strings.NewReader(out), []string{"/var/log/a.log", "/var/log/b.log"}, func(l LogTailLine) {
+		got = append(got, l.File+": "+l.Line)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/var/log/a.log: one", "/var/log/b.log: two", "/var/log/b.log: ==> not a header <=="}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestAgentConnectorFollowLogFilesSendsQuery(t *testing.T) {
+	var gotPaths []string
+	var tailQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/logs/follow":
+			gotPaths = r.URL.Query()["path"]
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for _, p := range gotPaths {
+				line, _ := json.Marshal(LogTailLine{File: p, Line: "hello from " + filepath.Base(p)})
+				fmt.Fprintf(w, "%s\n", line)
+			}
+		case "/v1/logs/tail":
+			tailQuery = r.URL.RawQuery
+			_ = json.NewEncoder(w).Encode(map[string]string{"data": "x\n"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	base, _ := url.Parse(srv.URL)
+	ac := &AgentConnector{
+		server: shared.Fail2banServer{ID: "a1", Name: "agent", Type: "agent"},
+		base:   base,
+		client: srv.Client(),
+	}
+
+	var got []LogTailLine
+	err := ac.FollowLogFiles(context.Background(), []string{"/var/log/auth.log", "/var/log/mail.log"}, func(l LogTailLine) {
+		got = append(got, l)
+	})
+	if err != nil {
+		t.Fatalf("FollowLogFiles: %v", err)
+	}
+	if !slices.Equal(gotPaths, []string{"/var/log/auth.log", "/var/log/mail.log"}) {
+		t.Fatalf("unexpected paths sent: %v", gotPaths)
+	}
+	if len(got) != 2 || got[1].Line != "hello from mail.log" {
+		t.Fatalf("unexpected lines %v", got)
+	}
+
+	if _, err := ac.ReadLogFileTail(context.Background(), "/var/log/auth.log", 10); err != nil {
+		t.Fatalf("ReadLogFileTail: %v", err)
+	}
+	if tailQuery != "bytes=10&path=%2Fvar%2Flog%2Fauth.log" {
+		t.Fatalf("query not passed to agent: %q", tailQuery)
+	}
+}
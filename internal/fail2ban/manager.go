@@ -18,6 +18,7 @@ package fail2ban
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -30,13 +31,19 @@ import (
 //  Connector Interface
 // =========================================================================
 
+// Returned (wrapped) by GetFilterConfig and GetActionConfig when neither the
+// .local nor the .conf file exists. GetJailConfig returns the bare "[name]"
+// section instead, and agents answer a missing jail with ErrConfigNotFound.
+var ErrConfigNotFound = errors.New("config not found")
+
 // Connector is the communication backend for a Fail2ban server.
 type Connector interface {
 	ID() string
 	Server() shared.Fail2banServer
 
 	GetJailInfos(ctx context.Context) ([]JailInfo, error)
-	GetBannedIPs(ctx context.Context, jail string) ([]string, error)
+	GetBannedIPs(ctx context.Context, jail string) ([]BannedIP, error)
+	GetJailStatus(ctx context.Context, jail string) (JailStatus, error)
 	UnbanIP(ctx context.Context, jail, ip string) error
 	BanIP(ctx context.Context, jail, ip string) error
 	Reload(ctx context.Context) error
@@ -52,6 +59,10 @@ type Connector interface {
 	GetFilters(ctx context.Context) ([]string, error)
 	TestFilter(ctx context.Context, filterName string, logLines []string, filterContent string) (output string, filterPath string, err error)
 
+	// Runtime jail parameters (fail2ban-client get/set, applied without reload)
+	GetJailRuntimeConfig(ctx context.Context, jail string) (JailRuntimeConfig, error)
+	SetJailRuntimeConfig(ctx context.Context, jail string, update JailRuntimeUpdate) error
+
 	// Jail configuration operations
 	GetJailConfig(ctx context.Context, jail string) (string, string, error)
 	SetJailConfig(ctx context.Context, jail, content string) error
@@ -73,6 +84,13 @@ type Connector interface {
 	DeleteJail(ctx context.Context, jailName string) error
 	CreateFilter(ctx context.Context, filterName, content string) error
 	DeleteFilter(ctx context.Context, filterName string) error
+
+	// Action operations (action.d)
+	GetActions(ctx context.Context) ([]string, error)
+	GetActionConfig(ctx context.Context, action string) (string, string, error)
+	SetActionConfig(ctx context.Context, action, content string) error
+	CreateAction(ctx context.Context, actionName, content string) error
+	DeleteAction(ctx context.Context, actionName string) error
 }
 
 // =========================================================================
@@ -107,6 +125,7 @@ func (m *Manager) ReloadFromServers(servers []shared.Fail2banServer) error {
 
 	connectors := make(map[string]Connector)
 	defaultID := pickDefaultServerID(servers)
+	sshPool.sync(servers)
 
 	for _, srv := range servers {
 		if !srv.Enabled {
@@ -0,0 +1,67 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// =========================================================================
+//  Manual Bans
+// =========================================================================
+
+// Outcome of a manual ban in one jail of one server.
+type ManualBanResult struct {
+	ServerID   string     `json:"serverId"`
+	ServerName string     `json:"serverName"`
+	Jail       string     `json:"jail"`
+	Success    bool       `json:"success"`
+	Error      string     `json:"error,omitempty"`
+	Warning    string     `json:"warning,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Bans ip in the given jails on all connectors in parallel. With a duration (seconds)
+// a warning is added where the jail's own bantime lifts the ban earlier than requested.
+func BanIPOnAll(ctx context.Context, conns []Connector, jails []string, ip string, duration int64) []ManualBanResult {
+	perServer := forEachConnector(ctx, conns, func(ctx context.Context, conn Connector) []ManualBanResult {
+		server := conn.Server()
+		results := make([]ManualBanResult, 0, len(jails))
+		for _, jail := range jails {
+			res := ManualBanResult{ServerID: server.ID, ServerName: server.Name, Jail: jail}
+			if err := conn.BanIP(ctx, jail, ip); err != nil {
+				res.Error = err.Error()
+			} else {
+				res.Success = true
+				if duration > 0 {
+					if running, err := conn.GetJailRuntimeConfig(ctx, jail); err == nil && running.Bantime >= 0 && running.Bantime < duration {
+						res.Warning = fmt.Sprintf("jail %s lifts bans after %ds, before the requested duration", jail, running.Bantime)
+					}
+				}
+			}
+			results = append(results, res)
+		}
+		return results
+	})
+	var results []ManualBanResult
+	for _, r := range perServer {
+		results = append(results, r...)
+	}
+	return results
+}
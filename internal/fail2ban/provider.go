@@ -16,7 +16,12 @@
 
 package fail2ban
 
-import "sync"
+import (
+	"context"
+	"sync"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
 
 // Supplies application settings needed by connectors without importing config.
 type Provider interface {
@@ -24,7 +29,9 @@ type Provider interface {
 	CallbackURL() string
 	CallbackSecret() string
 	BuildFail2banActionConfig(callbackURL, serverID, secret string) string
-	BuildJailLocalContent() string
+	BuildJailLocalContent(server shared.Fail2banServer, caps ServerCapabilities) string
+	RecordSSHHostKey(serverID, hostKey string)
+	RecordConfigRevision(ctx context.Context, rev ConfigRevision) error
 }
 
 var (
@@ -65,4 +72,10 @@ func (noopProvider) BuildFail2banActionConfig(callbackURL, serverID, secret stri
 	return ""
 }
 
-func (noopProvider) BuildJailLocalContent() string { return "" }
+func (noopProvider) BuildJailLocalContent(server shared.Fail2banServer, caps ServerCapabilities) string {
+	return ""
+}
+
+func (noopProvider) RecordSSHHostKey(serverID, hostKey string) {}
+
+func (noopProvider) RecordConfigRevision(ctx context.Context, rev ConfigRevision) error { return nil }
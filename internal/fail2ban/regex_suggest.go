@@ -0,0 +1,426 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/swissmakers/fail2ban-ui/internal/enrichment"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+// Filter name used when test-running suggested failregex content.
+const suggestionFilterName = "ui-regex-suggestion"
+
+// Sample lines and options for SuggestFailregex.
+type RegexSuggestionRequest struct {
+	Lines     []string `json:"lines"`
+	IP        string   `json:"ip"`
+	Jail      string   `json:"jail"`
+	GoodLines []string `json:"goodLines"`
+}
+
+// One candidate failregex set with its test results.
+type RegexSuggestion struct {
+	Name           string   `json:"name"`
+	Failregex      []string `json:"failregex"`
+	FilterContent  string   `json:"filterContent"`
+	Matched        int      `json:"matched"`
+	Missed         []string `json:"missed"`
+	WrongHost      []string `json:"wrongHost"`
+	FalsePositives []string `json:"falsePositives"`
+	Valid          bool     `json:"valid"`
+	TestError      string   `json:"testError,omitempty"`
+}
+
+type RegexSuggestionResult struct {
+	Category    string            `json:"category,omitempty"`
+	Format      string            `json:"format,omitempty"`
+	GoodLines   []string          `json:"goodLines"`
+	Suggestions []RegexSuggestion `json:"suggestions"`
+}
+
+// Generalised form of one sample line.
+type suggestedLine struct {
+	strict   string
+	relaxed  string
+	usesPref bool
+	prefix   string
+	category string
+	format   string
+}
+
+// Part of a line replaced by a regex instead of being escaped.
+type regexSpan struct {
+	start, end int
+	pattern    string
+	generic    bool
+}
+
+// Timestamp formats recognised by fail2ban's default date detectors, roughly.
+// The first (leftmost) match is cut from the line like fail2ban does before failregex runs.
+var suggestTimestampPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+	regexp.MustCompile(`\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`),
+	regexp.MustCompile(`\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2}(?: [+-]\d{4})?`),
+	regexp.MustCompile(`(?:[A-Z][a-z]{2} )?[A-Z][a-z]{2} [ 0-9]\d \d{2}:\d{2}:\d{2}(?:\.\d+)?(?: \d{4})?`),
+	regexp.MustCompile(`^\d{10}(?:\.\d+)?`),
+}
+
+// "host prog[pid]: " as covered by %(__prefix_line)s from common.conf.
+var suggestSyslogPrefix = regexp.MustCompile(`^\s*\S+ \S+?(?:\[\d+\])?:\s*`)
+
+// Grok fields whose values vary between events and are generalised in the regex.
+var suggestFieldPatterns = []struct {
+	field   string
+	pattern string
+}{
+	{"source.user.name", `<F-USER>\S+</F-USER>`},
+	{"source.port", `\d+`},
+	{"process.pid", `\d+`},
+	{"url.original", `\S+`},
+	{"http.request.referrer", `[^"]*`},
+	{"user_agent.original", `[^"]*`},
+	{"http.response.body.bytes", `\d+`},
+	{"server.address", `\S+`},
+}
+
+// Known-good messages per grok category; prefixed with the sample's syslog prefix.
+var suggestGoodMessages = map[string][]string{
+	"ssh": {
+		"Accepted publickey for deploy from 192.0.2.200 port 50022 ssh2: ED25519 SHA256:Wm9vYmFyYmF6",
+		"Accepted password for alice from 192.0.2.201 port 50023 ssh2",
+		"pam_unix(sshd:session): session opened for user alice(uid=1000) by (uid=0)",
+		"Received disconnect from 192.0.2.201 port 50023:11: disconnected by user",
+		"Disconnected from user alice 192.0.2.201 port 50023",
+	},
+	"mail": {
+		"connect from mail.example.org[192.0.2.202]",
+		"disconnect from mail.example.org[192.0.2.202] ehlo=1 mail=1 rcpt=1 data=1 quit=1 commands=5",
+		"imap-login: Login: user=<alice>, method=PLAIN, rip=192.0.2.203, lip=192.0.2.1, mpid=1234, TLS",
+	},
+}
+
+// =========================================================================
+//  Suggestion
+// =========================================================================
+
+// Proposes failregex candidates for the sample lines and test-runs each one through
+// the connector's TestFilter against the samples and the known-good lines.
+func SuggestFailregex(ctx context.Context, conn Connector, req RegexSuggestionRequest) (*RegexSuggestionResult, error) {
+	samples := normalizeLogLines(req.Lines)
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no sample lines provided")
+	}
+	if req.IP != "" {
+		if err := ValidateIP(req.IP); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &RegexSuggestionResult{Suggestions: []RegexSuggestion{}}
+	var lines []suggestedLine
+	hosts := make([]string, len(samples))
+	for i, sample := range samples {
+		sl, host, err := generaliseLogLine(sample, req.IP, req.Jail)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		hosts[i] = host
+		lines = append(lines, sl)
+		if res.Category == "" {
+			res.Category, res.Format = sl.category, sl.format
+		}
+	}
+
+	res.GoodLines = normalizeLogLines(req.GoodLines)
+	if lines[0].prefix != "" {
+		for _, msg := range suggestGoodMessages[lines[0].category] {
+			res.GoodLines = append(res.GoodLines, lines[0].prefix+msg)
+		}
+	}
+	if res.GoodLines == nil {
+		res.GoodLines = []string{}
+	}
+
+	for _, variant := range []string{"strict", "relaxed"} {
+		var regexes []string
+		usesPrefix := false
+		seen := make(map[string]bool)
+		for _, sl := range lines {
+			re := sl.strict
+			if variant == "relaxed" {
+				re = sl.relaxed
+			}
+			usesPrefix = usesPrefix || sl.usesPref
+			if !seen[re] {
+				seen[re] = true
+				regexes = append(regexes, re)
+			}
+		}
+		if len(res.Suggestions) > 0 && slices.Equal(res.Suggestions[0].Failregex, regexes) {
+			continue
+		}
+		s := RegexSuggestion{Name: variant, Failregex: regexes, FilterContent: buildSuggestedFilter(regexes, usesPrefix)}
+		validateSuggestion(ctx, conn, &s, samples, hosts, res.GoodLines)
+		res.Suggestions = append(res.Suggestions, s)
+	}
+	sort.SliceStable(res.Suggestions, func(i, j int) bool {
+		return res.Suggestions[i].Valid && !res.Suggestions[j].Valid
+	})
+	return res, nil
+}
+
+// Runs the candidate against the samples (all must match, with the expected host) and the good lines (none may match).
+func validateSuggestion(ctx context.Context, conn Connector, s *RegexSuggestion, samples, hosts, good []string) {
+	s.Missed, s.WrongHost, s.FalsePositives = []string{}, []string{}, []string{}
+	output, _, err := conn.TestFilter(ctx, suggestionFilterName, samples, s.FilterContent)
+	if err != nil {
+		s.TestError = err.Error()
+		return
+	}
+	result := ParseFilterTestOutput(output)
+	s.Matched = result.Totals.Matched
+	s.Missed = append(s.Missed, result.Missed...)
+	s.Missed = append(s.Missed, result.Ignored...)
+	expected := make(map[string]string, len(samples))
+	for i, line := range samples {
+		expected[line] = hosts[i]
+	}
+	for _, m := range result.Matched {
+		if want, ok := expected[m.Line]; ok && m.IP != "" && m.IP != want {
+			s.WrongHost = append(s.WrongHost, m.Line)
+		}
+	}
+	if len(good) > 0 {
+		output, _, err = conn.TestFilter(ctx, suggestionFilterName, good, s.FilterContent)
+		if err != nil {
+			s.TestError = err.Error()
+			return
+		}
+		for _, m := range ParseFilterTestOutput(output).Matched {
+			s.FalsePositives = append(s.FalsePositives, m.Line)
+		}
+	}
+	s.Valid = result.Totals.Lines == len(samples) && s.Matched == len(samples) &&
+		len(s.Missed) == 0 && len(s.WrongHost) == 0 && len(s.FalsePositives) == 0
+}
+
+func buildSuggestedFilter(regexes []string, usesPrefix bool) string {
+	var b strings.Builder
+	if usesPrefix {
+		b.WriteString("[INCLUDES]\nbefore = common.conf\n\n")
+	}
+	b.WriteString("[Definition]\nfailregex = ")
+	b.WriteString(strings.Join(regexes, "\n            "))
+	b.WriteString("\nignoreregex =\n")
+	return b.String()
+}
+
+// =========================================================================
+//  Line Generalisation
+// =========================================================================
+
+// Turns one sample line into strict and relaxed failregex candidates: the timestamp
+// is cut (fail2ban's date detector handles it), a syslog prefix becomes
+// %(__prefix_line)s, the IP becomes <HOST>, variable grok fields become generic
+// classes and everything else is escaped.
+func generaliseLogLine(line, ip, jail string) (suggestedLine, string, error) {
+	def, fields, matched := enrichment.MatchLogLine(line, jail)
+	if ip == "" {
+		if addr, ok := fields["source.address"].(string); ok && ValidateIP(addr) == nil {
+			ip = addr
+		} else {
+			return suggestedLine{}, "", fmt.Errorf("no IP given and none recognised in %q", line)
+		}
+	}
+	if !strings.Contains(line, ip) {
+		return suggestedLine{}, "", fmt.Errorf("IP %s does not occur in %q", ip, line)
+	}
+	sl := suggestedLine{}
+	if matched {
+		sl.category, sl.format = def.Category, def.Name
+	}
+
+	text, cutAt, atStart := cutTimestamp(line)
+	if atStart {
+		text = strings.TrimLeft(text, " ")
+		cutAt = -1
+	}
+
+	head := ""
+	if _, syslog := fields["log.syslog.hostname"]; syslog && atStart {
+		if loc := suggestSyslogPrefix.FindStringIndex(text); loc != nil {
+			sl.prefix = line[:len(line)-len(text)+loc[1]]
+			text = text[loc[1]:]
+			head = "%(__prefix_line)s"
+			sl.usesPref = true
+		}
+	}
+
+	spans := suggestSpans(text, ip, fields, cutAt)
+	body, relaxed := renderSpans(text, spans)
+	sl.strict = "^" + head + body + "$"
+	sl.relaxed = "^" + head + relaxed
+	return sl, ip, nil
+}
+
+// Removes the leftmost timestamp. Returns the remaining text, the cut position
+// and whether the timestamp started the line.
+func cutTimestamp(line string) (string, int, bool) {
+	best := []int(nil)
+	for _, re := range suggestTimestampPatterns {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		if best == nil || loc[0] < best[0] || (loc[0] == best[0] && loc[1] > best[1]) {
+			best = loc
+		}
+	}
+	if best == nil {
+		return line, -1, false
+	}
+	return line[:best[0]] + line[best[1]:], best[0], strings.TrimSpace(line[:best[0]]) == ""
+}
+
+// Collects the parts of text to replace: every occurrence of the IP, the variable
+// grok fields and the place where a mid-line timestamp was cut.
+func suggestSpans(text, ip string, fields map[string]interface{}, cutAt int) []regexSpan {
+	var spans []regexSpan
+	overlaps := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && end > s.start {
+				return true
+			}
+		}
+		return false
+	}
+	host := false
+	for off := 0; ; {
+		idx := strings.Index(text[off:], ip)
+		if idx < 0 {
+			break
+		}
+		start := off + idx
+		pattern := `\S+`
+		if !host {
+			pattern, host = "<HOST>", true
+		}
+		spans = append(spans, regexSpan{start: start, end: start + len(ip), pattern: pattern, generic: pattern != "<HOST>"})
+		off = start + len(ip)
+	}
+	for _, fp := range suggestFieldPatterns {
+		value := fieldString(fields[fp.field])
+		if value == "" || value == ip {
+			continue
+		}
+		for off := 0; off < len(text); {
+			idx := strings.Index(text[off:], value)
+			if idx < 0 {
+				break
+			}
+			start, end := off+idx, off+idx+len(value)
+			if !overlaps(start, end) && tokenBoundary(text, start, end) {
+				spans = append(spans, regexSpan{start: start, end: end, pattern: fp.pattern, generic: true})
+				break
+			}
+			off = end
+		}
+	}
+	if cutAt >= 0 && cutAt <= len(text) && !overlaps(cutAt, cutAt) {
+		spans = append(spans, regexSpan{start: cutAt, end: cutAt, pattern: ".*?", generic: true})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// Builds the regex body; the relaxed form drops trailing generic parts and punctuation.
+func renderSpans(text string, spans []regexSpan) (string, string) {
+	type part struct {
+		re      string
+		generic bool
+	}
+	var parts []part
+	pos := 0
+	for _, s := range spans {
+		if s.start > pos {
+			parts = append(parts, part{re: escapeFailregexLiteral(text[pos:s.start])})
+		}
+		parts = append(parts, part{re: s.pattern, generic: s.generic})
+		pos = s.end
+	}
+	if pos < len(text) {
+		parts = append(parts, part{re: escapeFailregexLiteral(text[pos:])})
+	}
+	var strict strings.Builder
+	for _, p := range parts {
+		strict.WriteString(p.re)
+	}
+	keep := len(parts)
+	for keep > 0 {
+		p := parts[keep-1]
+		if p.re == "<HOST>" || (!p.generic && strings.IndexFunc(p.re, isAlnum) >= 0) {
+			break
+		}
+		keep--
+	}
+	var relaxed strings.Builder
+	for _, p := range parts[:keep] {
+		relaxed.WriteString(p.re)
+	}
+	return strict.String(), strings.TrimRight(relaxed.String(), " ")
+}
+
+// Escapes regex metacharacters, and % for fail2ban's config interpolation.
+func escapeFailregexLiteral(s string) string {
+	return strings.ReplaceAll(regexp.QuoteMeta(s), "%", "%%")
+}
+
+func fieldString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return ""
+}
+
+func tokenBoundary(text string, start, end int) bool {
+	if start > 0 && isAlnum(rune(text[start-1])) {
+		return false
+	}
+	return end >= len(text) || !isAlnum(rune(text[end]))
+}
+
+func isAlnum(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,130 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestGeneraliseLogLine(t *testing.T) {
+	cases := []struct {
+		line, ip, strict, relaxed string
+	}{
+		{
+			line:    "Feb 23 14:37:29 myhost sshd[12345]: Failed password for invalid user admin from 192.0.2.1 port 54321 ssh2",
+			strict:  `^%(__prefix_line)sFailed password for invalid user <F-USER>\S+</F-USER> from <HOST> port \d+ ssh2$`,
+			relaxed: `^%(__prefix_line)sFailed password for invalid user <F-USER>\S+</F-USER> from <HOST> port \d+ ssh2`,
+		},
+		{
+			line:    `www.example.ch 192.0.2.1 - - [23/Feb/2026:14:37:29 +0100] "GET /.git/config HTTP/1.1" 404 248 "-" "Mozilla/5.0"`,
+			strict:  `^\S+ <HOST> - - \[.*?\] "GET \S+ HTTP/1\.1" 404 \d+ "-" "[^"]*"$`,
+			relaxed: `^\S+ <HOST> - - \[.*?\] "GET \S+ HTTP/1\.1" 404`,
+		},
+		{
+			line:    "2026-02-23T14:37:29+01:00 app: login failed for bob from 192.0.2.1 (100%) via 192.0.2.1",
+			ip:      "192.0.2.1",
+			strict:  `^app: login failed for bob from <HOST> \(100%%\) via \S+$`,
+			relaxed: `^app: login failed for bob from <HOST> \(100%%\) via`,
+		},
+	}
+	for _, tc := range cases {
+		sl, host, err := generaliseLogLine(tc.line, tc.ip, "")
+		if err != nil {
+			t.Fatalf("generaliseLogLine(%q): %v", tc.line, err)
+		}
+		if host != "192.0.2.1" || sl.strict != tc.strict || sl.relaxed != tc.relaxed {
+			t.Fatalf("line %q:\n got  %s | %s (%s)\n want %s | %s", tc.line, sl.strict, sl.relaxed, host, tc.strict, tc.relaxed)
+		}
+	}
+	if _, _, err := generaliseLogLine("Feb 23 14:37:29 myhost sshd[1]: Failed password for root from 192.0.2.1 port 1 ssh2", "198.51.100.1", ""); err == nil {
+		t.Fatalf("expected an IP that does not occur in the line to be rejected")
+	}
+}
+
+// Installs a fake fail2ban-regex that matches every line unless the log contains "Accepted".
+func installFakeFail2banRegex(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	record := filepath.Join(bin, "filters")
+	script := `#!/bin/sh
+for last; do :; done
+log=""
+for a; do [ "$a" = "$last" ] && break; log="$a"; done
+/bin/cat "$last" >> ` + record + `
+n=$(/usr/bin/wc -l < "$log")
+if /bin/grep -q Accepted "$log"; then
+	echo "Lines: $n lines, 0 ignored, 0 matched, $n missed"
+	exit 0
+fi
+echo "Lines: $n lines, 0 ignored, $n matched, 0 missed"
+echo "|- Matched line(s):"
+/bin/sed 's/^/|  /' "$log"
+echo '` + "`-" + `'
+`
+	if err := os.WriteFile(filepath.Join(bin, "fail2ban-regex"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	return record
+}
+
+func TestSuggestFailregexValidatesCandidates(t *testing.T) {
+	record := installFakeFail2banRegex(t)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "filter.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "suggest-local", Name: "local", Type: "local", ConfigPath: dir}}
+
+	res, err := SuggestFailregex(context.Background(), lc, RegexSuggestionRequest{
+		Lines: []string{
+			"Feb 23 14:37:29 myhost sshd[12345]: Invalid user admin from 192.0.2.1 port 54321",
+			"Feb 23 14:37:31 myhost sshd[12345]: Invalid user oracle from 192.0.2.1 port 54400",
+		},
+		IP:   "192.0.2.1",
+		Jail: "sshd",
+	})
+	if err != nil {
+		t.Fatalf("SuggestFailregex: %v", err)
+	}
+	if res.Category != "ssh" || len(res.GoodLines) == 0 || !strings.HasPrefix(res.GoodLines[0], "Feb 23 14:37:29 myhost sshd[12345]: Accepted") {
+		t.Fatalf("unexpected category %q or known-good corpus %v", res.Category, res.GoodLines)
+	}
+	if len(res.Suggestions) != 2 {
+		t.Fatalf("expected strict and relaxed suggestions, got %+v", res.Suggestions)
+	}
+	strict := res.Suggestions[0]
+	if len(strict.Failregex) != 1 || strict.Failregex[0] != `^%(__prefix_line)sInvalid user <F-USER>\S+</F-USER> from <HOST> port \d+$` {
+		t.Fatalf("expected both samples to collapse into one regex, got %v", strict.Failregex)
+	}
+	if !strict.Valid || strict.Matched != 2 || len(strict.FalsePositives) != 0 {
+		t.Fatalf("expected suggestion to validate, got %+v", strict)
+	}
+	if !strings.HasPrefix(strict.FilterContent, "[INCLUDES]\nbefore = common.conf\n") {
+		t.Fatalf("expected common.conf include for %%(__prefix_line)s, got %q", strict.FilterContent)
+	}
+	filters, _ := os.ReadFile(record)
+	if !strings.Contains(string(filters), `failregex = ^%(__prefix_line)sInvalid user`) {
+		t.Fatalf("expected the candidate to be passed to fail2ban-regex, got %q", filters)
+	}
+}
@@ -0,0 +1,96 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"time"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	ServerStatusUp       = "up"
+	ServerStatusDegraded = "degraded"
+	ServerStatusDown     = "down"
+)
+
+// Implemented by connectors that can check whether fail2ban on the host answers.
+// Local and SSH connectors run "fail2ban-client ping" (over SSH this also proves
+// reachability); agents answer on /v1/health.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Result of a single probe step.
+type HealthCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Outcome of probing one server.
+type ServerProbe struct {
+	Status    string        `json:"status"`
+	Checks    []HealthCheck `json:"checks"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// =========================================================================
+//  Probing
+// =========================================================================
+
+// Probes a server: fail2ban reachability first, then whether jail.local still
+// carries the Fail2ban-UI callback action. A reachable server without the managed
+// jail.local is degraded, its bans no longer reach Fail2ban-UI.
+func ProbeServer(ctx context.Context, conn Connector) ServerProbe {
+	start := time.Now()
+	probe := ServerProbe{Status: ServerStatusUp, CheckedAt: start.UTC()}
+
+	var err error
+	if checker, ok := conn.(HealthChecker); ok {
+		err = checker.CheckHealth(ctx)
+	} else {
+		_, err = conn.GetJailInfos(ctx)
+	}
+	probe.Latency = time.Since(start)
+	if err != nil {
+		probe.Status = ServerStatusDown
+		probe.Checks = append(probe.Checks, HealthCheck{Name: "fail2ban", Detail: err.Error()})
+		return probe
+	}
+	probe.Checks = append(probe.Checks, HealthCheck{Name: "fail2ban", OK: true})
+
+	exists, managed, err := conn.CheckJailLocalIntegrity(ctx)
+	check := HealthCheck{Name: "callback_action", OK: err == nil && exists && managed}
+	switch {
+	case err != nil:
+		check.Detail = err.Error()
+	case !exists:
+		check.Detail = "jail.local is missing"
+	case !managed:
+		check.Detail = "jail.local is not managed by Fail2ban-UI, bans are not reported"
+	}
+	if !check.OK {
+		probe.Status = ServerStatusDegraded
+	}
+	probe.Checks = append(probe.Checks, check)
+	return probe
+}
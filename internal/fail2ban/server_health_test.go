@@ -0,0 +1,84 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestProbeServerAgentStatuses(t *testing.T) {
+	cases := []struct {
+		name      string
+		health    string
+		integrity string
+		want      string
+		failed    string
+	}{
+		{"up", `{"status":"ok"}`, `{"exists":true,"managed":true}`, ServerStatusUp, ""},
+		{"unmanaged jail.local", `{"status":"ok"}`, `{"exists":true,"managed":false}`, ServerStatusDegraded, "callback_action"},
+		{"fail2ban not running", `{"status":"error","error":"fail2ban socket not found"}`, `{"exists":true,"managed":true}`, ServerStatusDown, "fail2ban"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/health":
+					_, _ = w.Write([]byte(tc.health))
+				case "/v1/jails/check-integrity":
+					_, _ = w.Write([]byte(tc.integrity))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			conn, err := NewAgentConnector(shared.Fail2banServer{ID: "s1", Name: "agent", Type: "agent", AgentURL: srv.URL, AgentSecret: "secret123"})
+			if err != nil {
+				t.Fatalf("new connector: %v", err)
+			}
+			probe := ProbeServer(context.Background(), conn)
+			if probe.Status != tc.want {
+				t.Fatalf("expected status %s, got %s (%+v)", tc.want, probe.Status, probe.Checks)
+			}
+			for _, check := range probe.Checks {
+				if !check.OK && check.Name != tc.failed {
+					t.Fatalf("unexpected failed check %+v", check)
+				}
+			}
+		})
+	}
+}
+
+func TestProbeServerUnreachableAgent(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	conn, err := NewAgentConnector(shared.Fail2banServer{ID: "s1", Name: "agent", Type: "agent", AgentURL: url, AgentSecret: "secret123"})
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	probe := ProbeServer(context.Background(), conn)
+	if probe.Status != ServerStatusDown || len(probe.Checks) != 1 || probe.Checks[0].Detail == "" {
+		t.Fatalf("expected a single failed fail2ban check, got %+v", probe)
+	}
+}
@@ -0,0 +1,283 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+// Jail and/or filter content shared by many servers. Parameters are set as
+// "key = value" lines in the jail section on top of JailContent.
+type ConfigTemplate struct {
+	JailName      string            `json:"jailName"`
+	JailContent   string            `json:"jailContent"`
+	FilterName    string            `json:"filterName"`
+	FilterContent string            `json:"filterContent"`
+	Parameters    map[string]string `json:"parameters"`
+}
+
+// Outcome of applying a template to one server.
+type TemplateApplyResult struct {
+	ServerID   string `json:"serverId"`
+	ServerName string `json:"serverName"`
+	Success    bool   `json:"success"`
+	Reloaded   bool   `json:"reloaded"`
+	// Jail options commented out because the server's fail2ban version lacks them.
+	SkippedKeys []string               `json:"skippedKeys,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Validation  *ConfigValidationError `json:"validation,omitempty"`
+}
+
+// How a server's files compare to a template.
+type TemplateDriftResult struct {
+	ServerID   string `json:"serverId"`
+	ServerName string `json:"serverName"`
+	Status     string `json:"status"` // in_sync, drifted, missing, error
+	JailDiff   string `json:"jailDiff,omitempty"`
+	FilterDiff string `json:"filterDiff,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+const (
+	templateHostTimeout = 60 * time.Second
+	templateParallelism = 8
+)
+
+var templateParamKeyRe = regexp.MustCompile(`^[a-z][a-z0-9_.]*$`)
+
+// =========================================================================
+//  Template Content
+// =========================================================================
+
+// Checks names, parameters and that the template contains something to write.
+func (t ConfigTemplate) Validate() error {
+	if t.JailName == "" && t.FilterName == "" {
+		return errors.New("template needs a jail or a filter")
+	}
+	if t.JailName != "" {
+		if err := ValidateJailName(t.JailName); err != nil {
+			return err
+		}
+	} else if strings.TrimSpace(t.JailContent) != "" || len(t.Parameters) > 0 {
+		return errors.New("jail content and parameters need a jail name")
+	}
+	if t.FilterName != "" {
+		if err := ValidateFilterName(t.FilterName); err != nil {
+			return err
+		}
+		if strings.TrimSpace(t.FilterContent) == "" {
+			return fmt.Errorf("filter %s has no content", t.FilterName)
+		}
+	}
+	for key, value := range t.Parameters {
+		if !templateParamKeyRe.MatchString(key) {
+			return fmt.Errorf("invalid parameter name %q", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("parameter %s must be a single line", key)
+		}
+	}
+	return nil
+}
+
+// Returns the jail file content the template writes, with the parameters applied.
+func (t ConfigTemplate) RenderJail() string {
+	if t.JailName == "" {
+		return ""
+	}
+	keys := make([]string, 0, len(t.Parameters))
+	for key := range t.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, [2]string{key, t.Parameters[key]})
+	}
+	return setJailSectionValues(stagedConfigContent(ConfigKindJail, t.JailName, t.JailContent), t.JailName, values)
+}
+
+// =========================================================================
+//  Apply and Drift
+// =========================================================================
+
+// Writes the template to one server: the filter first, so the jail's config test can resolve it.
+func ApplyConfigTemplate(ctx context.Context, conn Connector, tpl ConfigTemplate, reload bool) TemplateApplyResult {
+	server := conn.Server()
+	res := TemplateApplyResult{ServerID: server.ID, ServerName: server.Name}
+	fail := func(err error) TemplateApplyResult {
+		res.Error = err.Error()
+		var validationErr *ConfigValidationError
+		if errors.As(err, &validationErr) {
+			res.Validation = validationErr
+		}
+		return res
+	}
+	if tpl.FilterName != "" {
+		if err := conn.SetFilterConfig(ctx, tpl.FilterName, tpl.FilterContent); err != nil {
+			return fail(fmt.Errorf("filter %s: %w", tpl.FilterName, err))
+		}
+	}
+	if tpl.JailName != "" {
+		content, skipped := FilterUnsupportedJailKeys(tpl.RenderJail(), GetServerCapabilities(ctx, conn, false))
+		res.SkippedKeys = skipped
+		if err := conn.SetJailConfig(ctx, tpl.JailName, content); err != nil {
+			return fail(fmt.Errorf("jail %s: %w", tpl.JailName, err))
+		}
+	}
+	if reload {
+		if err := conn.Reload(ctx); err != nil {
+			return fail(fmt.Errorf("written, but reload failed: %w", err))
+		}
+		res.Reloaded = true
+	}
+	res.Success = true
+	return res
+}
+
+// Compares the server's jail and filter files with the template.
+func CheckConfigTemplateDrift(ctx context.Context, conn Connector, tpl ConfigTemplate) TemplateDriftResult {
+	server := conn.Server()
+	res := TemplateDriftResult{ServerID: server.ID, ServerName: server.Name, Status: "in_sync"}
+	compare := func(kind, name, want string) (string, bool, error) {
+		var content, path string
+		var err error
+		if kind == ConfigKindJail {
+			content, path, err = conn.GetJailConfig(ctx, name)
+			if err == nil && content == fmt.Sprintf("[%s]\n", name) {
+				return "", true, nil
+			}
+		} else {
+			content, path, err = conn.GetFilterConfig(ctx, name)
+			if errors.Is(err, ErrConfigNotFound) {
+				return "", true, nil
+			}
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return UnifiedDiff(path, kind+" template", normalizeTemplateContent(content), normalizeTemplateContent(want)), false, nil
+	}
+
+	var missing bool
+	if tpl.FilterName != "" {
+		diff, absent, err := compare(ConfigKindFilter, tpl.FilterName, tpl.FilterContent)
+		if err != nil {
+			res.Status, res.Error = "error", err.Error()
+			return res
+		}
+		res.FilterDiff, missing = diff, absent
+	}
+	if tpl.JailName != "" {
+		want, _ := FilterUnsupportedJailKeys(tpl.RenderJail(), GetServerCapabilities(ctx, conn, false))
+		diff, absent, err := compare(ConfigKindJail, tpl.JailName, want)
+		if err != nil {
+			res.Status, res.Error = "error", err.Error()
+			return res
+		}
+		res.JailDiff, missing = diff, missing || absent
+	}
+	switch {
+	case missing:
+		res.Status = "missing"
+	case res.JailDiff != "" || res.FilterDiff != "":
+		res.Status = "drifted"
+	}
+	return res
+}
+
+// Ignores trailing whitespace, which editors and the connectors' writes do not preserve.
+func normalizeTemplateContent(content string) string {
+	lines := strings.Split(strings.TrimRight(content, " \t\r\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Returns the active connectors of the given servers plus those carrying any of the tags.
+func (m *Manager) SelectConnectors(serverIDs, tags []string) []Connector {
+	ids := make(map[string]bool, len(serverIDs))
+	for _, id := range serverIDs {
+		ids[id] = true
+	}
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+	var selected []Connector
+	for _, conn := range m.Connectors() {
+		server := conn.Server()
+		match := ids[server.ID]
+		for _, tag := range server.Tags {
+			if wanted[strings.ToLower(strings.TrimSpace(tag))] {
+				match = true
+			}
+		}
+		if match {
+			selected = append(selected, conn)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Server().Name < selected[j].Server().Name })
+	return selected
+}
+
+// Runs fn for every connector with bounded parallelism and a per-host timeout.
+// Results keep the order of conns.
+func forEachConnector[T any](ctx context.Context, conns []Connector, fn func(context.Context, Connector) T) []T {
+	results := make([]T, len(conns))
+	sem := make(chan struct{}, templateParallelism)
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		wg.Add(1)
+		go func(i int, conn Connector) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			hostCtx, cancel := context.WithTimeout(ctx, templateHostTimeout)
+			defer cancel()
+			results[i] = fn(hostCtx, conn)
+		}(i, conn)
+	}
+	wg.Wait()
+	return results
+}
+
+// Applies the template to all connectors in parallel.
+func ApplyConfigTemplateToAll(ctx context.Context, conns []Connector, tpl ConfigTemplate, reload bool) []TemplateApplyResult {
+	return forEachConnector(ctx, conns, func(ctx context.Context, conn Connector) TemplateApplyResult {
+		return ApplyConfigTemplate(ctx, conn, tpl, reload)
+	})
+}
+
+// Checks the template against all connectors in parallel.
+func CheckConfigTemplateDriftAll(ctx context.Context, conns []Connector, tpl ConfigTemplate) []TemplateDriftResult {
+	return forEachConnector(ctx, conns, func(ctx context.Context, conn Connector) TemplateDriftResult {
+		return CheckConfigTemplateDrift(ctx, conn, tpl)
+	})
+}
@@ -0,0 +1,93 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fail2ban
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/shared"
+)
+
+func TestConfigTemplateRenderJail(t *testing.T) {
+	tpl := ConfigTemplate{
+		JailName:    "nginx-http-auth",
+		JailContent: "enabled = true\nport = http,https\nmaxretry = 5\n",
+		Parameters:  map[string]string{"maxretry": "3", "bantime": "1h"},
+	}
+	if err := tpl.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	want := "[nginx-http-auth]\nbantime = 1h\nenabled = true\nport = http,https\nmaxretry = 3\n"
+	if got := tpl.RenderJail(); got != want {
+		t.Fatalf("unexpected jail content:\n%s\nwant:\n%s", got, want)
+	}
+
+	invalid := []ConfigTemplate{
+		{},
+		{JailName: "bad name"},
+		{JailContent: "[x]\n"},
+		{FilterName: "nginx"},
+		{JailName: "sshd", Parameters: map[string]string{"Bantime": "1h"}},
+		{JailName: "sshd", Parameters: map[string]string{"action": "a\nb"}},
+	}
+	for _, tpl := range invalid {
+		if err := tpl.Validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", tpl)
+		}
+	}
+}
+
+func TestApplyConfigTemplateAndDrift(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"jail.d", "filter.d"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lc := &LocalConnector{server: shared.Fail2banServer{ID: "tpl-local", Name: "local", Type: "local", ConfigPath: dir}}
+	tpl := ConfigTemplate{
+		JailName:      "demo",
+		JailContent:   "[demo]\nenabled = true\nfilter = demo\n",
+		FilterName:    "demo",
+		FilterContent: "[Definition]\nfailregex = ^fail from <HOST>$\n",
+		Parameters:    map[string]string{"maxretry": "3"},
+	}
+	ctx := context.Background()
+
+	if drift := CheckConfigTemplateDrift(ctx, lc, tpl); drift.Status != "missing" {
+		t.Fatalf("expected missing before apply, got %+v", drift)
+	}
+	if res := ApplyConfigTemplate(ctx, lc, tpl, false); !res.Success {
+		t.Fatalf("apply failed: %+v", res)
+	}
+	if drift := CheckConfigTemplateDrift(ctx, lc, tpl); drift.Status != "in_sync" {
+		t.Fatalf("expected in_sync after apply, got %+v", drift)
+	}
+
+	jailPath := filepath.Join(dir, "jail.d", "demo.local")
+	if err := os.WriteFile(jailPath, []byte("[demo]\nmaxretry = 10\nenabled = true\nfilter = demo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	drift := CheckConfigTemplateDrift(ctx, lc, tpl)
+	if drift.Status != "drifted" || !strings.Contains(drift.JailDiff, "-maxretry = 10") || drift.FilterDiff != "" {
+		t.Fatalf("expected jail drift, got %+v", drift)
+	}
+}
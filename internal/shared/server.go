@@ -24,26 +24,43 @@ import (
 // Describes a registered Fail2ban instance and how to reach it.
 // It lives in package shared so connector code does not import application config.
 type Fail2banServer struct {
-	ID                   string    `json:"id"`
-	Name                 string    `json:"name"`
-	Type                 string    `json:"type"`
-	Host                 string    `json:"host,omitempty"`
-	Port                 int       `json:"port,omitempty"`
-	SocketPath           string    `json:"socketPath,omitempty"`
-	ConfigPath           string    `json:"configPath,omitempty"`
-	SSHUser              string    `json:"sshUser,omitempty"`
-	SSHKeyPath           string    `json:"sshKeyPath,omitempty"`
-	AgentURL             string    `json:"agentUrl,omitempty"`
-	AgentSecret          string    `json:"agentSecret,omitempty"`
-	Hostname             string    `json:"hostname,omitempty"`
-	Tags                 []string  `json:"tags,omitempty"`
-	IsDefault            bool      `json:"isDefault"`
-	Enabled              bool      `json:"enabled"`
-	ReverseTunnelEnabled bool      `json:"reverseTunnelEnabled,omitempty"`
-	RestartNeeded        bool      `json:"restartNeeded"`
-	CreatedAt            time.Time `json:"createdAt"`
-	UpdatedAt            time.Time `json:"updatedAt"`
-	EnabledSet           bool      `json:"-"`
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Type                 string   `json:"type"`
+	Host                 string   `json:"host,omitempty"`
+	Port                 int      `json:"port,omitempty"`
+	SocketPath           string   `json:"socketPath,omitempty"`
+	ConfigPath           string   `json:"configPath,omitempty"`
+	SSHUser              string   `json:"sshUser,omitempty"`
+	SSHKeyPath           string   `json:"sshKeyPath,omitempty"`
+	SSHHostKey           string   `json:"sshHostKey,omitempty"`
+	AgentURL             string   `json:"agentUrl,omitempty"`
+	AgentSecret          string   `json:"agentSecret,omitempty"`
+	Hostname             string   `json:"hostname,omitempty"`
+	Tags                 []string `json:"tags,omitempty"`
+	IsDefault            bool     `json:"isDefault"`
+	Enabled              bool     `json:"enabled"`
+	ReverseTunnelEnabled bool     `json:"reverseTunnelEnabled,omitempty"`
+	EventMode            string   `json:"eventMode,omitempty"`
+	// Overrides for the [DEFAULT] section of this server's jail.local.
+	JailDefaults  JailDefaultOverrides `json:"jailDefaults"`
+	RestartNeeded bool                 `json:"restartNeeded"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+	EnabledSet    bool                 `json:"-"`
+	// Set on an update to forget the pinned host key; an empty SSHHostKey keeps it.
+	SSHHostKeyReset bool `json:"sshHostKeyReset,omitempty"`
+}
+
+// Per-server values for the [DEFAULT] section of jail.local. Empty fields use the global settings.
+type JailDefaultOverrides struct {
+	IgnoreIPs         []string `json:"ignoreips,omitempty"`
+	Bantime           string   `json:"bantime,omitempty"`
+	Findtime          string   `json:"findtime,omitempty"`
+	Maxretry          int      `json:"maxretry,omitempty"`
+	Banaction         string   `json:"banaction,omitempty"`
+	BanactionAllports string   `json:"banactionAllports,omitempty"`
+	Chain             string   `json:"chain,omitempty"`
 }
 
 // Distinguishes explicit false for "enabled" from a missing key.
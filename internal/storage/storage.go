@@ -217,6 +217,9 @@ type AppSettingsRecord struct {
 	WebhookJSON            string
 	ElasticsearchJSON      string
 	ThreatIntelJSON        string
+	HealthMonitorJSON      string
+	BanPropagationJSON     string
+	AlertChannelsJSON      string
 }
 
 type ServerRecord struct {
@@ -229,6 +232,7 @@ type ServerRecord struct {
 	ConfigPath           string
 	SSHUser              string
 	SSHKeyPath           string
+	SSHHostKey           string
 	AgentURL             string
 	AgentSecret          string
 	Hostname             string
@@ -236,6 +240,8 @@ type ServerRecord struct {
 	IsDefault            bool
 	Enabled              bool
 	ReverseTunnelEnabled bool
+	EventMode            string
+	JailDefaultsJSON     string
 	NeedsRestart         bool
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
@@ -255,6 +261,8 @@ type BanEventRecord struct {
 	HasWhois   bool      `json:"hasWhois"`
 	HasLogs    bool      `json:"hasLogs"`
 	EventType  string    `json:"eventType"`
+	Reason     string    `json:"reason,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
 	OccurredAt time.Time `json:"occurredAt"`
 	CreatedAt  time.Time `json:"createdAt"`
 }
@@ -421,18 +429,18 @@ func GetAppSettings(ctx context.Context) (AppSettingsRecord, bool, error) {
 	}
 
 	row := db.QueryRowContext(ctx, `
-SELECT language, port, debug, restart_needed, callback_url, callback_secret, alert_countries, email_alerts_for_bans, email_alerts_for_unbans, smtp_host, smtp_port, smtp_username, smtp_password, smtp_from, smtp_use_tls, bantime_increment, default_jail_enable, ignore_ip, bantime, findtime, maxretry, destemail, banaction, banaction_allports, advanced_actions, geoip_provider, geoip_database_path, max_log_lines, event_retention_days, console_output, smtp_insecure_skip_verify, smtp_auth_method, chain, bantime_rndtime, bantime_maxtime, bantime_factor, bantime_overalljails, alert_provider, webhook, elasticsearch, threat_intel
+SELECT language, port, debug, restart_needed, callback_url, callback_secret, alert_countries, email_alerts_for_bans, email_alerts_for_unbans, smtp_host, smtp_port, smtp_username, smtp_password, smtp_from, smtp_use_tls, bantime_increment, default_jail_enable, ignore_ip, bantime, findtime, maxretry, destemail, banaction, banaction_allports, advanced_actions, geoip_provider, geoip_database_path, max_log_lines, event_retention_days, console_output, smtp_insecure_skip_verify, smtp_auth_method, chain, bantime_rndtime, bantime_maxtime, bantime_factor, bantime_overalljails, alert_provider, webhook, elasticsearch, threat_intel, health_monitor, ban_propagation, alert_channels
 FROM app_settings
 WHERE id = 1`)
 
 	var (
 		lang, callback, callbackSecret, alerts, smtpHost, smtpUser, smtpPass, smtpFrom, ignoreIP, bantime, findtime, destemail, banaction, banactionAllports, chain, bantimeRndtime, bantimeMaxtime, bantimeFactor, advancedActions, geoipProvider, geoipDatabasePath, smtpAuthMethod sql.NullString
-		alertProvider, webhookJSON, elasticsearchJSON, threatIntelJSON                                                                                                                                                                                                                sql.NullString
+		alertProvider, webhookJSON, elasticsearchJSON, threatIntelJSON, healthMonitorJSON, banPropagationJSON, alertChannelsJSON                                                                                                                                                      sql.NullString
 		port, smtpPort, maxretry, maxLogLines, eventRetentionDays                                                                                                                                                                                                                     sql.NullInt64
 		debug, restartNeeded, smtpTLS, bantimeInc, bantimeOveralljails, defaultJailEn, emailAlertsForBans, emailAlertsForUnbans, consoleOutput, smtpInsecureSkipVerify                                                                                                                sql.NullInt64
 	)
 
-	err := row.Scan(&lang, &port, &debug, &restartNeeded, &callback, &callbackSecret, &alerts, &emailAlertsForBans, &emailAlertsForUnbans, &smtpHost, &smtpPort, &smtpUser, &smtpPass, &smtpFrom, &smtpTLS, &bantimeInc, &defaultJailEn, &ignoreIP, &bantime, &findtime, &maxretry, &destemail, &banaction, &banactionAllports, &advancedActions, &geoipProvider, &geoipDatabasePath, &maxLogLines, &eventRetentionDays, &consoleOutput, &smtpInsecureSkipVerify, &smtpAuthMethod, &chain, &bantimeRndtime, &bantimeMaxtime, &bantimeFactor, &bantimeOveralljails, &alertProvider, &webhookJSON, &elasticsearchJSON, &threatIntelJSON)
+	err := row.Scan(&lang, &port, &debug, &restartNeeded, &callback, &callbackSecret, &alerts, &emailAlertsForBans, &emailAlertsForUnbans, &smtpHost, &smtpPort, &smtpUser, &smtpPass, &smtpFrom, &smtpTLS, &bantimeInc, &defaultJailEn, &ignoreIP, &bantime, &findtime, &maxretry, &destemail, &banaction, &banactionAllports, &advancedActions, &geoipProvider, &geoipDatabasePath, &maxLogLines, &eventRetentionDays, &consoleOutput, &smtpInsecureSkipVerify, &smtpAuthMethod, &chain, &bantimeRndtime, &bantimeMaxtime, &bantimeFactor, &bantimeOveralljails, &alertProvider, &webhookJSON, &elasticsearchJSON, &threatIntelJSON, &healthMonitorJSON, &banPropagationJSON, &alertChannelsJSON)
 	if errors.Is(err, sql.ErrNoRows) {
 		return AppSettingsRecord{}, false, nil
 	}
@@ -481,6 +489,9 @@ WHERE id = 1`)
 		WebhookJSON:            stringFromNull(webhookJSON),
 		ElasticsearchJSON:      stringFromNull(elasticsearchJSON),
 		ThreatIntelJSON:        stringFromNull(threatIntelJSON),
+		HealthMonitorJSON:      stringFromNull(healthMonitorJSON),
+		BanPropagationJSON:     stringFromNull(banPropagationJSON),
+		AlertChannelsJSON:      stringFromNull(alertChannelsJSON),
 		ConsoleOutput:          intToBool(intFromNull(consoleOutput)),
 	}
 
@@ -493,9 +504,9 @@ func SaveAppSettings(ctx context.Context, rec AppSettingsRecord) error {
 	}
 	_, err := db.ExecContext(ctx, `
 INSERT INTO app_settings (
-	id, language, port, debug, restart_needed, callback_url, callback_secret, alert_countries, email_alerts_for_bans, email_alerts_for_unbans, smtp_host, smtp_port, smtp_username, smtp_password, smtp_from, smtp_use_tls, bantime_increment, default_jail_enable, ignore_ip, bantime, findtime, maxretry, destemail, banaction, banaction_allports, advanced_actions, geoip_provider, geoip_database_path, max_log_lines, event_retention_days, console_output, smtp_insecure_skip_verify, smtp_auth_method, chain, bantime_rndtime, bantime_maxtime, bantime_factor, bantime_overalljails, alert_provider, webhook, elasticsearch, threat_intel
+	id, language, port, debug, restart_needed, callback_url, callback_secret, alert_countries, email_alerts_for_bans, email_alerts_for_unbans, smtp_host, smtp_port, smtp_username, smtp_password, smtp_from, smtp_use_tls, bantime_increment, default_jail_enable, ignore_ip, bantime, findtime, maxretry, destemail, banaction, banaction_allports, advanced_actions, geoip_provider, geoip_database_path, max_log_lines, event_retention_days, console_output, smtp_insecure_skip_verify, smtp_auth_method, chain, bantime_rndtime, bantime_maxtime, bantime_factor, bantime_overalljails, alert_provider, webhook, elasticsearch, threat_intel, health_monitor, ban_propagation, alert_channels
 ) VALUES (
-	1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 ) ON CONFLICT(id) DO UPDATE SET
 	language = excluded.language,
 	port = excluded.port,
@@ -537,7 +548,10 @@ INSERT INTO app_settings (
 	alert_provider = excluded.alert_provider,
 	webhook = excluded.webhook,
 	elasticsearch = excluded.elasticsearch,
-	threat_intel = excluded.threat_intel
+	threat_intel = excluded.threat_intel,
+	health_monitor = excluded.health_monitor,
+	ban_propagation = excluded.ban_propagation,
+	alert_channels = excluded.alert_channels
 `, rec.Language,
 		rec.Port,
 		boolToInt(rec.Debug),
@@ -578,7 +592,10 @@ INSERT INTO app_settings (
 		rec.AlertProvider,
 		rec.WebhookJSON,
 		rec.ElasticsearchJSON,
-		rec.ThreatIntelJSON)
+		rec.ThreatIntelJSON,
+		rec.HealthMonitorJSON,
+		rec.BanPropagationJSON,
+		rec.AlertChannelsJSON)
 	return err
 }
 
@@ -592,7 +609,7 @@ func ListServers(ctx context.Context) ([]ServerRecord, error) {
 	}
 
 	rows, err := db.QueryContext(ctx, `
-SELECT id, name, type, host, port, socket_path, config_path, ssh_user, ssh_key_path, agent_url, agent_secret, hostname, tags, is_default, enabled, reverse_tunnel, needs_restart, created_at, updated_at
+SELECT id, name, type, host, port, socket_path, config_path, ssh_user, ssh_key_path, ssh_host_key, agent_url, agent_secret, hostname, tags, is_default, enabled, reverse_tunnel, event_mode, jail_defaults, needs_restart, created_at, updated_at
 FROM servers
 ORDER BY created_at`)
 	if err != nil {
@@ -603,7 +620,7 @@ ORDER BY created_at`)
 	var records []ServerRecord
 	for rows.Next() {
 		var rec ServerRecord
-		var host, socket, configPath, sshUser, sshKey, agentURL, agentSecret, hostname, tags sql.NullString
+		var host, socket, configPath, sshUser, sshKey, sshHostKey, agentURL, agentSecret, hostname, tags, eventMode, jailDefaults sql.NullString
 		var name, serverType sql.NullString
 		var created, updated sql.NullString
 		var port sql.NullInt64
@@ -619,6 +636,7 @@ ORDER BY created_at`)
 			&configPath,
 			&sshUser,
 			&sshKey,
+			&sshHostKey,
 			&agentURL,
 			&agentSecret,
 			&hostname,
@@ -626,6 +644,8 @@ ORDER BY created_at`)
 			&isDefault,
 			&enabled,
 			&reverseTunnel,
+			&eventMode,
+			&jailDefaults,
 			&needsRestart,
 			&created,
 			&updated,
@@ -641,6 +661,7 @@ ORDER BY created_at`)
 		rec.ConfigPath = stringFromNull(configPath)
 		rec.SSHUser = stringFromNull(sshUser)
 		rec.SSHKeyPath = stringFromNull(sshKey)
+		rec.SSHHostKey = stringFromNull(sshHostKey)
 		rec.AgentURL = stringFromNull(agentURL)
 		rec.AgentSecret = stringFromNull(agentSecret)
 		rec.Hostname = stringFromNull(hostname)
@@ -648,6 +669,8 @@ ORDER BY created_at`)
 		rec.IsDefault = intToBool(intFromNull(isDefault))
 		rec.Enabled = intToBool(intFromNull(enabled))
 		rec.ReverseTunnelEnabled = intToBool(intFromNull(reverseTunnel))
+		rec.EventMode = stringFromNull(eventMode)
+		rec.JailDefaultsJSON = stringFromNull(jailDefaults)
 		rec.NeedsRestart = intToBool(intFromNull(needsRestart))
 
 		if created.Valid {
@@ -688,9 +711,9 @@ func ReplaceServers(ctx context.Context, servers []ServerRecord) error {
 
 	stmt, err := tx.PrepareContext(ctx, `
 INSERT INTO servers (
-	id, name, type, host, port, socket_path, config_path, ssh_user, ssh_key_path, agent_url, agent_secret, hostname, tags, is_default, enabled, reverse_tunnel, needs_restart, created_at, updated_at
+	id, name, type, host, port, socket_path, config_path, ssh_user, ssh_key_path, ssh_host_key, agent_url, agent_secret, hostname, tags, is_default, enabled, reverse_tunnel, event_mode, jail_defaults, needs_restart, created_at, updated_at
 ) VALUES (
-	?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )`)
 	if err != nil {
 		return err
@@ -716,6 +739,7 @@ INSERT INTO servers (
 			srv.ConfigPath,
 			srv.SSHUser,
 			srv.SSHKeyPath,
+			srv.SSHHostKey,
 			srv.AgentURL,
 			srv.AgentSecret,
 			srv.Hostname,
@@ -723,6 +747,8 @@ INSERT INTO servers (
 			boolToInt(srv.IsDefault),
 			boolToInt(srv.Enabled),
 			boolToInt(srv.ReverseTunnelEnabled),
+			srv.EventMode,
+			srv.JailDefaultsJSON,
 			boolToInt(srv.NeedsRestart),
 			createdAt.Format(time.RFC3339Nano),
 			updatedAt.Format(time.RFC3339Nano),
@@ -763,8 +789,8 @@ func RecordBanEvent(ctx context.Context, record BanEventRecord) (int64, error) {
 
 	const query = `
 INSERT INTO ban_events (
-	server_id, server_name, jail, ip, country, hostname, failures, whois, logs, event_type, occurred_at, created_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	server_id, server_name, jail, ip, country, hostname, failures, whois, logs, event_type, reason, actor, occurred_at, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	res, err := db.ExecContext(
 		ctx,
@@ -779,6 +805,8 @@ INSERT INTO ban_events (
 		record.Whois,
 		record.Logs,
 		eventType,
+		record.Reason,
+		record.Actor,
 		formatStorageTime(record.OccurredAt),
 		formatStorageTime(record.CreatedAt),
 	)
@@ -789,6 +817,67 @@ INSERT INTO ban_events (
 	return res.LastInsertId()
 }
 
+// Inserts historical ban events (e.g. imported from fail2ban's database) in one transaction.
+// A record is skipped when an event of the same type for the same server, jail and IP already
+// exists within the window around its occurred_at. Returns the number of inserted rows.
+func ImportBanEvents(ctx context.Context, records []BanEventRecord, window time.Duration) (int, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	const query = `
+INSERT INTO ban_events (
+	server_id, server_name, jail, ip, country, hostname, failures, whois, logs, event_type, occurred_at, created_at
+)
+SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+WHERE NOT EXISTS (
+	SELECT 1 FROM ban_events
+	WHERE server_id = ? AND jail = ? AND ip = ? AND event_type = ? AND occurred_at BETWEEN ? AND ?
+)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	inserted := 0
+	for _, record := range records {
+		if record.ServerID == "" {
+			return 0, errors.New("server id is required")
+		}
+		eventType := record.EventType
+		if eventType == "" {
+			eventType = "ban"
+		}
+		res, err := stmt.ExecContext(ctx,
+			record.ServerID, record.ServerName, record.Jail, record.IP, record.Country, record.Hostname,
+			record.Failures, record.Whois, record.Logs, eventType,
+			formatStorageTime(record.OccurredAt), formatStorageTime(now),
+			record.ServerID, record.Jail, record.IP, eventType,
+			formatStorageTime(record.OccurredAt.Add(-window)), formatStorageTime(record.OccurredAt.Add(window)),
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			inserted += int(n)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
 // Fills in whois on an already stored event; used by the asynchronous enrichment after the callback has been answered.
 func UpdateBanEventEnrichment(ctx context.Context, id int64, whois, country string) error {
 	if db == nil {
@@ -859,7 +948,7 @@ func ListBanEventsFiltered(ctx context.Context, f BanEventFilter, limit, offset
 SELECT id, server_id, server_name, jail, ip, country, hostname, failures,
        (whois IS NOT NULL AND whois <> '') AS has_whois,
        (logs IS NOT NULL AND logs <> '') AS has_logs,
-       event_type, occurred_at, created_at
+       event_type, COALESCE(reason, ''), COALESCE(actor, ''), occurred_at, created_at
 ` + from + `
 WHERE 1=1`
 	conditions, args := f.buildWhere()
@@ -891,6 +980,8 @@ WHERE 1=1`
 			&hasWhois,
 			&hasLogs,
 			&eventType,
+			&rec.Reason,
+			&rec.Actor,
 			&rec.OccurredAt,
 			&rec.CreatedAt,
 		); err != nil {
@@ -915,7 +1006,8 @@ func GetBanEventByID(ctx context.Context, id int64) (BanEventRecord, bool, error
 	}
 
 	const query = `
-SELECT id, server_id, server_name, jail, ip, country, hostname, failures, whois, logs, event_type, occurred_at, created_at
+SELECT id, server_id, server_name, jail, ip, country, hostname, failures, whois, logs, event_type,
+       COALESCE(reason, ''), COALESCE(actor, ''), occurred_at, created_at
 FROM ban_events
 WHERE id = ?`
 
@@ -933,6 +1025,8 @@ WHERE id = ?`
 		&rec.Whois,
 		&rec.Logs,
 		&eventType,
+		&rec.Reason,
+		&rec.Actor,
 		&rec.OccurredAt,
 		&rec.CreatedAt,
 	)
@@ -1570,6 +1664,7 @@ CREATE TABLE IF NOT EXISTS servers (
 	config_path TEXT,
 	ssh_user TEXT,
 	ssh_key_path TEXT,
+	ssh_host_key TEXT,
 	agent_url TEXT,
 	agent_secret TEXT,
 	hostname TEXT,
@@ -1577,6 +1672,8 @@ CREATE TABLE IF NOT EXISTS servers (
 	is_default INTEGER,
 	enabled INTEGER,
 	reverse_tunnel INTEGER DEFAULT 0,
+	event_mode TEXT DEFAULT 'callback',
+	jail_defaults TEXT DEFAULT '{}',
 	needs_restart INTEGER DEFAULT 0,
 	created_at TEXT,
 	updated_at TEXT
@@ -1594,10 +1691,19 @@ CREATE TABLE IF NOT EXISTS ban_events (
 	whois TEXT,
 	logs TEXT,
 	event_type TEXT NOT NULL DEFAULT 'ban',
+	reason TEXT,
+	actor TEXT,
 	occurred_at DATETIME NOT NULL,
 	created_at DATETIME NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS event_pull_cursors (
+	server_id TEXT PRIMARY KEY,
+	path TEXT NOT NULL,
+	byte_offset INTEGER NOT NULL DEFAULT 0,
+	updated_at TEXT NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS permanent_blocks (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	ip TEXT NOT NULL,
@@ -1610,6 +1716,78 @@ CREATE TABLE IF NOT EXISTS permanent_blocks (
 	updated_at TEXT NOT NULL,
 	UNIQUE(ip, integration)
 );
+
+CREATE TABLE IF NOT EXISTS config_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	name TEXT NOT NULL,
+	path TEXT,
+	action TEXT NOT NULL,
+	author TEXT,
+	content TEXT,
+	content_hash TEXT,
+	file_exists INTEGER NOT NULL DEFAULT 1,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS config_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	description TEXT,
+	jail_name TEXT,
+	jail_content TEXT,
+	filter_name TEXT,
+	filter_content TEXT,
+	parameters TEXT DEFAULT '{}',
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS server_health_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id TEXT NOT NULL,
+	server_name TEXT NOT NULL,
+	status TEXT NOT NULL,
+	previous_status TEXT,
+	detail TEXT,
+	checks TEXT DEFAULT '[]',
+	occurred_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_actions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	action TEXT NOT NULL,
+	server_id TEXT,
+	jail TEXT,
+	ip TEXT,
+	run_at TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL DEFAULT 3,
+	last_error TEXT,
+	note TEXT,
+	created_by TEXT,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	completed_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS alert_outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel_id TEXT NOT NULL,
+	channel TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL DEFAULT 8,
+	next_attempt_at TEXT NOT NULL,
+	last_error TEXT,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	delivered_at TEXT
+);
 `
 
 	const createIndexes = `
@@ -1623,6 +1801,12 @@ CREATE INDEX IF NOT EXISTS idx_ban_events_country ON ban_events(country);
 
 CREATE INDEX IF NOT EXISTS idx_perm_blocks_status ON permanent_blocks(status);
 CREATE INDEX IF NOT EXISTS idx_perm_blocks_updated_at ON permanent_blocks(updated_at);
+
+CREATE INDEX IF NOT EXISTS idx_config_revisions_file ON config_revisions(server_id, kind, name, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_server_health_events_server ON server_health_events(server_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_actions_due ON scheduled_actions(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_alert_outbox_due ON alert_outbox(status, next_attempt_at);
 `
 
 	// Columns added after a table first shipped. CREATE TABLE IF NOT EXISTS is a no-op on existing databases, so every later column needs an entry here
@@ -1643,6 +1827,14 @@ CREATE INDEX IF NOT EXISTS idx_perm_blocks_updated_at ON permanent_blocks(update
 		`ALTER TABLE servers ADD COLUMN reverse_tunnel INTEGER DEFAULT 0`,
 		`ALTER TABLE app_settings ADD COLUMN event_retention_days INTEGER DEFAULT 180`,
 		`ALTER TABLE ban_events ADD COLUMN event_type TEXT NOT NULL DEFAULT 'ban'`,
+		`ALTER TABLE servers ADD COLUMN ssh_host_key TEXT`,
+		`ALTER TABLE servers ADD COLUMN event_mode TEXT DEFAULT 'callback'`,
+		`ALTER TABLE app_settings ADD COLUMN health_monitor TEXT DEFAULT '{}'`,
+		`ALTER TABLE servers ADD COLUMN jail_defaults TEXT DEFAULT '{}'`,
+		`ALTER TABLE ban_events ADD COLUMN reason TEXT`,
+		`ALTER TABLE ban_events ADD COLUMN actor TEXT`,
+		`ALTER TABLE app_settings ADD COLUMN ban_propagation TEXT DEFAULT '{}'`,
+		`ALTER TABLE app_settings ADD COLUMN alert_channels TEXT DEFAULT '[]'`,
 	}
 
 	if _, err := db.ExecContext(ctx, createTables); err != nil {
@@ -1839,3 +2031,951 @@ func ClearPermanentBlocks(ctx context.Context) (int64, error) {
 	}
 	return res.RowsAffected()
 }
+
+// =========================================================================
+//  Event Pull Cursors
+// =========================================================================
+
+// Position up to which a server's fail2ban log has been consumed in pull mode.
+type EventPullCursor struct {
+	ServerID  string
+	Path      string
+	Offset    int64
+	UpdatedAt time.Time
+}
+
+// Returns the stored cursor for a server.
+func GetEventPullCursor(ctx context.Context, serverID string) (EventPullCursor, bool, error) {
+	if db == nil {
+		return EventPullCursor{}, false, errors.New("storage not initialised")
+	}
+	var rec EventPullCursor
+	var updatedAt sql.NullString
+	err := db.QueryRowContext(ctx, `
+SELECT server_id, path, byte_offset, updated_at
+FROM event_pull_cursors
+WHERE server_id = ?`, serverID).Scan(&rec.ServerID, &rec.Path, &rec.Offset, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EventPullCursor{}, false, nil
+		}
+		return EventPullCursor{}, false, err
+	}
+	if updatedAt.Valid {
+		rec.UpdatedAt = parseStorageTime(updatedAt.String)
+	}
+	return rec, true, nil
+}
+
+// Stores or updates the cursor for a server.
+func SaveEventPullCursor(ctx context.Context, rec EventPullCursor) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	if rec.ServerID == "" {
+		return errors.New("server id is required")
+	}
+	_, err := db.ExecContext(ctx, `
+INSERT INTO event_pull_cursors (server_id, path, byte_offset, updated_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(server_id) DO UPDATE SET
+	path = excluded.path,
+	byte_offset = excluded.byte_offset,
+	updated_at = excluded.updated_at`,
+		rec.ServerID, rec.Path, rec.Offset, formatStorageTime(time.Now().UTC()))
+	return err
+}
+
+// Removes the cursor so the next pull starts again from the end of the log.
+func DeleteEventPullCursor(ctx context.Context, serverID string) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	_, err := db.ExecContext(ctx, `DELETE FROM event_pull_cursors WHERE server_id = ?`, serverID)
+	return err
+}
+
+// =========================================================================
+//  Config Revisions
+// =========================================================================
+
+// Content of a jail or filter file as it was before a write through a connector.
+type ConfigRevisionRecord struct {
+	ID          int64     `json:"id"`
+	ServerID    string    `json:"serverId"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Action      string    `json:"action"`
+	Author      string    `json:"author"`
+	Content     string    `json:"content,omitempty"`
+	ContentHash string    `json:"contentHash"`
+	Exists      bool      `json:"exists"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Number of revisions kept per file; older ones are removed when a new one is recorded.
+const configRevisionsPerFile = 100
+
+// Stores a revision, drops the revisions of the same file beyond
+// configRevisionsPerFile and returns the new revision's ID.
+func RecordConfigRevision(ctx context.Context, rec ConfigRevisionRecord) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	if rec.ServerID == "" || rec.Kind == "" || rec.Name == "" {
+		return 0, errors.New("server id, kind and name are required")
+	}
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	res, err := db.ExecContext(ctx, `
+INSERT INTO config_revisions (server_id, kind, name, path, action, author, content, content_hash, file_exists, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.ServerID, rec.Kind, rec.Name, rec.Path, rec.Action, rec.Author, rec.Content, rec.ContentHash, boolToInt(rec.Exists), formatStorageTime(createdAt))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.ExecContext(ctx, `
+DELETE FROM config_revisions
+WHERE server_id = ? AND kind = ? AND name = ? AND id NOT IN (
+	SELECT id FROM config_revisions
+	WHERE server_id = ? AND kind = ? AND name = ?
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+)`, rec.ServerID, rec.Kind, rec.Name, rec.ServerID, rec.Kind, rec.Name, configRevisionsPerFile); err != nil {
+		// The revision is stored; failing to drop old ones must not block the write.
+		log.Printf("warning: failed to prune old revisions of %s %s: %v", rec.Kind, rec.Name, err)
+	}
+	return id, nil
+}
+
+// Returns revisions newest first without their content. Empty kind or name match everything.
+func ListConfigRevisions(ctx context.Context, serverID, kind, name string, limit int) ([]ConfigRevisionRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	query := `
+SELECT id, server_id, kind, name, path, action, author, content_hash, file_exists, created_at
+FROM config_revisions
+WHERE server_id = ?`
+	args := []any{serverID}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	if name != "" {
+		query += ` AND name = ?`
+		args = append(args, name)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []ConfigRevisionRecord
+	for rows.Next() {
+		var rec ConfigRevisionRecord
+		var path, author, hash sql.NullString
+		var exists int64
+		var createdAt string
+		if err := rows.Scan(&rec.ID, &rec.ServerID, &rec.Kind, &rec.Name, &path, &rec.Action, &author, &hash, &exists, &createdAt); err != nil {
+			return nil, err
+		}
+		rec.Path = stringFromNull(path)
+		rec.Author = stringFromNull(author)
+		rec.ContentHash = stringFromNull(hash)
+		rec.Exists = exists == 1
+		rec.CreatedAt = parseStorageTime(createdAt)
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Returns a single revision including its content.
+func GetConfigRevision(ctx context.Context, id int64) (ConfigRevisionRecord, bool, error) {
+	if db == nil {
+		return ConfigRevisionRecord{}, false, errors.New("storage not initialised")
+	}
+	var rec ConfigRevisionRecord
+	var path, author, content, hash sql.NullString
+	var exists int64
+	var createdAt string
+	err := db.QueryRowContext(ctx, `
+SELECT id, server_id, kind, name, path, action, author, content, content_hash, file_exists, created_at
+FROM config_revisions
+WHERE id = ?`, id).Scan(&rec.ID, &rec.ServerID, &rec.Kind, &rec.Name, &path, &rec.Action, &author, &content, &hash, &exists, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ConfigRevisionRecord{}, false, nil
+		}
+		return ConfigRevisionRecord{}, false, err
+	}
+	rec.Path = stringFromNull(path)
+	rec.Author = stringFromNull(author)
+	rec.Content = stringFromNull(content)
+	rec.ContentHash = stringFromNull(hash)
+	rec.Exists = exists == 1
+	rec.CreatedAt = parseStorageTime(createdAt)
+	return rec, true, nil
+}
+
+// =========================================================================
+//  Config Templates
+// =========================================================================
+
+// Jail and filter content that can be pushed to many servers.
+type ConfigTemplateRecord struct {
+	ID             int64
+	Name           string
+	Description    string
+	JailName       string
+	JailContent    string
+	FilterName     string
+	FilterContent  string
+	ParametersJSON string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+const configTemplateColumns = `id, name, description, jail_name, jail_content, filter_name, filter_content, parameters, created_at, updated_at`
+
+func scanConfigTemplate(scanner interface{ Scan(...any) error }) (ConfigTemplateRecord, error) {
+	var rec ConfigTemplateRecord
+	var description, jailName, jailContent, filterName, filterContent, params sql.NullString
+	var createdAt, updatedAt string
+	if err := scanner.Scan(&rec.ID, &rec.Name, &description, &jailName, &jailContent, &filterName, &filterContent, &params, &createdAt, &updatedAt); err != nil {
+		return rec, err
+	}
+	rec.Description = stringFromNull(description)
+	rec.JailName = stringFromNull(jailName)
+	rec.JailContent = stringFromNull(jailContent)
+	rec.FilterName = stringFromNull(filterName)
+	rec.FilterContent = stringFromNull(filterContent)
+	rec.ParametersJSON = stringFromNull(params)
+	rec.CreatedAt = parseStorageTime(createdAt)
+	rec.UpdatedAt = parseStorageTime(updatedAt)
+	return rec, nil
+}
+
+// Returns all templates ordered by name.
+func ListConfigTemplates(ctx context.Context) ([]ConfigTemplateRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	rows, err := db.QueryContext(ctx, `SELECT `+configTemplateColumns+` FROM config_templates ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []ConfigTemplateRecord
+	for rows.Next() {
+		rec, err := scanConfigTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Returns a single template.
+func GetConfigTemplate(ctx context.Context, id int64) (ConfigTemplateRecord, bool, error) {
+	if db == nil {
+		return ConfigTemplateRecord{}, false, errors.New("storage not initialised")
+	}
+	rec, err := scanConfigTemplate(db.QueryRowContext(ctx, `SELECT `+configTemplateColumns+` FROM config_templates WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ConfigTemplateRecord{}, false, nil
+		}
+		return ConfigTemplateRecord{}, false, err
+	}
+	return rec, true, nil
+}
+
+// Creates a template (ID 0) or updates an existing one and returns its ID.
+func SaveConfigTemplate(ctx context.Context, rec ConfigTemplateRecord) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	if rec.Name == "" {
+		return 0, errors.New("template name is required")
+	}
+	if rec.ParametersJSON == "" {
+		rec.ParametersJSON = "{}"
+	}
+	now := formatStorageTime(time.Now().UTC())
+	if rec.ID == 0 {
+		res, err := db.ExecContext(ctx, `
+INSERT INTO config_templates (name, description, jail_name, jail_content, filter_name, filter_content, parameters, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			rec.Name, rec.Description, rec.JailName, rec.JailContent, rec.FilterName, rec.FilterContent, rec.ParametersJSON, now, now)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	res, err := db.ExecContext(ctx, `
+UPDATE config_templates
+SET name = ?, description = ?, jail_name = ?, jail_content = ?, filter_name = ?, filter_content = ?, parameters = ?, updated_at = ?
+WHERE id = ?`,
+		rec.Name, rec.Description, rec.JailName, rec.JailContent, rec.FilterName, rec.FilterContent, rec.ParametersJSON, now, rec.ID)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return rec.ID, nil
+}
+
+// Removes a template.
+func DeleteConfigTemplate(ctx context.Context, id int64) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	_, err := db.ExecContext(ctx, `DELETE FROM config_templates WHERE id = ?`, id)
+	return err
+}
+
+// =========================================================================
+//  Server Health
+// =========================================================================
+
+// A change of a server's health status as seen by the health monitor.
+type ServerHealthEventRecord struct {
+	ID             int64     `json:"id"`
+	ServerID       string    `json:"serverId"`
+	ServerName     string    `json:"serverName"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previousStatus"`
+	Detail         string    `json:"detail"`
+	ChecksJSON     string    `json:"-"`
+	OccurredAt     time.Time `json:"occurredAt"`
+}
+
+// Stores a status transition and returns its ID.
+func RecordServerHealthEvent(ctx context.Context, rec ServerHealthEventRecord) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	if rec.ServerID == "" || rec.Status == "" {
+		return 0, errors.New("server id and status are required")
+	}
+	occurredAt := rec.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+	checks := rec.ChecksJSON
+	if checks == "" {
+		checks = "[]"
+	}
+	res, err := db.ExecContext(ctx, `
+INSERT INTO server_health_events (server_id, server_name, status, previous_status, detail, checks, occurred_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rec.ServerID, rec.ServerName, rec.Status, rec.PreviousStatus, rec.Detail, checks, formatStorageTime(occurredAt))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Returns a server's status transitions, newest first.
+func ListServerHealthEvents(ctx context.Context, serverID string, limit int) ([]ServerHealthEventRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT id, server_id, server_name, status, previous_status, detail, checks, occurred_at
+FROM server_health_events
+WHERE server_id = ?
+ORDER BY occurred_at DESC, id DESC
+LIMIT ?`, serverID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []ServerHealthEventRecord
+	for rows.Next() {
+		rec, err := scanServerHealthEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Returns the most recent transition of every server, keyed by server ID.
+func LatestServerHealthEvents(ctx context.Context) (map[string]ServerHealthEventRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT id, server_id, server_name, status, previous_status, detail, checks, occurred_at
+FROM server_health_events
+WHERE id IN (SELECT MAX(id) FROM server_health_events GROUP BY server_id)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	latest := make(map[string]ServerHealthEventRecord)
+	for rows.Next() {
+		rec, err := scanServerHealthEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		latest[rec.ServerID] = rec
+	}
+	return latest, rows.Err()
+}
+
+func scanServerHealthEvent(scanner interface{ Scan(...any) error }) (ServerHealthEventRecord, error) {
+	var rec ServerHealthEventRecord
+	var previous, detail, checks sql.NullString
+	var occurredAt string
+	if err := scanner.Scan(&rec.ID, &rec.ServerID, &rec.ServerName, &rec.Status, &previous, &detail, &checks, &occurredAt); err != nil {
+		return ServerHealthEventRecord{}, err
+	}
+	rec.PreviousStatus = stringFromNull(previous)
+	rec.Detail = stringFromNull(detail)
+	rec.ChecksJSON = stringFromNull(checks)
+	rec.OccurredAt = parseStorageTime(occurredAt)
+	return rec, nil
+}
+
+// Returns when the last ban or unban event of a server was received.
+func LastBanEventReceivedAt(ctx context.Context, serverID string) (time.Time, bool, error) {
+	if db == nil {
+		return time.Time{}, false, errors.New("storage not initialised")
+	}
+	var last sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM ban_events WHERE server_id = ?`, serverID).Scan(&last)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if !last.Valid || last.String == "" {
+		return time.Time{}, false, nil
+	}
+	return parseStorageTime(last.String), true, nil
+}
+
+// =========================================================================
+//  Scheduled Actions
+// =========================================================================
+
+// Status values of a scheduled action.
+const (
+	ScheduledActionPending   = "pending"
+	ScheduledActionRunning   = "running"
+	ScheduledActionDone      = "done"
+	ScheduledActionFailed    = "failed"
+	ScheduledActionCancelled = "cancelled"
+)
+
+// An action the scheduler runs at RunAt, e.g. lifting a ban or re-enabling a jail.
+type ScheduledActionRecord struct {
+	ID          int64      `json:"id"`
+	Action      string     `json:"action"`
+	ServerID    string     `json:"serverId,omitempty"`
+	Jail        string     `json:"jail,omitempty"`
+	IP          string     `json:"ip,omitempty"`
+	RunAt       time.Time  `json:"runAt"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	LastError   string     `json:"lastError,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+const scheduledActionColumns = `id, action, server_id, jail, ip, run_at, status, attempts, max_attempts, last_error, note, created_by, created_at, updated_at, completed_at`
+
+func scanScheduledAction(scanner interface{ Scan(...any) error }) (ScheduledActionRecord, error) {
+	var rec ScheduledActionRecord
+	var serverID, jail, ip, lastError, note, createdBy, completedAt sql.NullString
+	var runAt, createdAt, updatedAt string
+	if err := scanner.Scan(&rec.ID, &rec.Action, &serverID, &jail, &ip, &runAt, &rec.Status, &rec.Attempts, &rec.MaxAttempts, &lastError, &note, &createdBy, &createdAt, &updatedAt, &completedAt); err != nil {
+		return rec, err
+	}
+	rec.ServerID = stringFromNull(serverID)
+	rec.Jail = stringFromNull(jail)
+	rec.IP = stringFromNull(ip)
+	rec.LastError = stringFromNull(lastError)
+	rec.Note = stringFromNull(note)
+	rec.CreatedBy = stringFromNull(createdBy)
+	rec.RunAt = parseStorageTime(runAt)
+	rec.CreatedAt = parseStorageTime(createdAt)
+	rec.UpdatedAt = parseStorageTime(updatedAt)
+	if completedAt.Valid && completedAt.String != "" {
+		t := parseStorageTime(completedAt.String)
+		rec.CompletedAt = &t
+	}
+	return rec, nil
+}
+
+// Stores a new pending action and returns its ID.
+func CreateScheduledAction(ctx context.Context, rec ScheduledActionRecord) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	if rec.Action == "" || rec.RunAt.IsZero() {
+		return 0, errors.New("action and run time are required")
+	}
+	if rec.MaxAttempts <= 0 {
+		rec.MaxAttempts = 3
+	}
+	now := formatStorageTime(time.Now().UTC())
+	res, err := db.ExecContext(ctx, `
+INSERT INTO scheduled_actions (action, server_id, jail, ip, run_at, status, attempts, max_attempts, note, created_by, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?)`,
+		rec.Action, rec.ServerID, rec.Jail, rec.IP, formatStorageTime(rec.RunAt.UTC()), ScheduledActionPending, rec.MaxAttempts, rec.Note, rec.CreatedBy, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Returns a single action.
+func GetScheduledAction(ctx context.Context, id int64) (ScheduledActionRecord, bool, error) {
+	if db == nil {
+		return ScheduledActionRecord{}, false, errors.New("storage not initialised")
+	}
+	rec, err := scanScheduledAction(db.QueryRowContext(ctx, `SELECT `+scheduledActionColumns+` FROM scheduled_actions WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ScheduledActionRecord{}, false, nil
+		}
+		return ScheduledActionRecord{}, false, err
+	}
+	return rec, true, nil
+}
+
+// Returns actions ordered by run time, optionally only those with the given status.
+func ListScheduledActions(ctx context.Context, status string, limit int) ([]ScheduledActionRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	query := `SELECT ` + scheduledActionColumns + ` FROM scheduled_actions`
+	args := []any{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY run_at, id LIMIT ?`
+	args = append(args, limit)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []ScheduledActionRecord
+	for rows.Next() {
+		rec, err := scanScheduledAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Changes the target, run time, attempts and note of a pending action.
+// Returns sql.ErrNoRows when the action does not exist or already ran.
+func UpdateScheduledAction(ctx context.Context, rec ScheduledActionRecord) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	if rec.MaxAttempts <= 0 {
+		rec.MaxAttempts = 3
+	}
+	res, err := db.ExecContext(ctx, `
+UPDATE scheduled_actions
+SET action = ?, server_id = ?, jail = ?, ip = ?, run_at = ?, max_attempts = ?, note = ?, updated_at = ?
+WHERE id = ? AND status = ?`,
+		rec.Action, rec.ServerID, rec.Jail, rec.IP, formatStorageTime(rec.RunAt.UTC()), rec.MaxAttempts, rec.Note, formatStorageTime(time.Now().UTC()), rec.ID, ScheduledActionPending)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Removes an action.
+func DeleteScheduledAction(ctx context.Context, id int64) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	_, err := db.ExecContext(ctx, `DELETE FROM scheduled_actions WHERE id = ?`, id)
+	return err
+}
+
+// Cancels the pending actions of a kind for one target and returns how many were cancelled.
+func CancelScheduledActions(ctx context.Context, action, serverID, jail, ip string) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	now := formatStorageTime(time.Now().UTC())
+	res, err := db.ExecContext(ctx, `
+UPDATE scheduled_actions
+SET status = ?, updated_at = ?, completed_at = ?
+WHERE status = ? AND action = ? AND server_id = ? AND jail = ? AND ip = ?`,
+		ScheduledActionCancelled, now, now, ScheduledActionPending, action, serverID, jail, ip)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Marks up to limit due actions as running and returns them. Actions are only
+// handed out once, even if several callers claim at the same time.
+func ClaimDueScheduledActions(ctx context.Context, now time.Time, limit int) ([]ScheduledActionRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT `+scheduledActionColumns+`
+FROM scheduled_actions
+WHERE status = ? AND run_at <= ?
+ORDER BY run_at, id
+LIMIT ?`, ScheduledActionPending, formatStorageTime(now.UTC()), limit)
+	if err != nil {
+		return nil, err
+	}
+	var due []ScheduledActionRecord
+	for rows.Next() {
+		rec, err := scanScheduledAction(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, rec)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	claimed := due[:0]
+	updatedAt := formatStorageTime(time.Now().UTC())
+	for _, rec := range due {
+		res, err := db.ExecContext(ctx, `UPDATE scheduled_actions SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+			ScheduledActionRunning, updatedAt, rec.ID, ScheduledActionPending)
+		if err != nil {
+			return claimed, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 1 {
+			rec.Status = ScheduledActionRunning
+			claimed = append(claimed, rec)
+		}
+	}
+	return claimed, nil
+}
+
+// Stores the outcome of a run: status, attempt count, error and, for a retry, the next run time.
+func FinishScheduledAction(ctx context.Context, rec ScheduledActionRecord) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	now := time.Now().UTC()
+	var completedAt any
+	if rec.Status != ScheduledActionPending {
+		completedAt = formatStorageTime(now)
+	}
+	_, err := db.ExecContext(ctx, `
+UPDATE scheduled_actions
+SET status = ?, attempts = ?, last_error = ?, run_at = ?, updated_at = ?, completed_at = ?
+WHERE id = ?`,
+		rec.Status, rec.Attempts, rec.LastError, formatStorageTime(rec.RunAt.UTC()), formatStorageTime(now), completedAt, rec.ID)
+	return err
+}
+
+// Returns actions left running by an interrupted process to pending.
+func ResetRunningScheduledActions(ctx context.Context) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	res, err := db.ExecContext(ctx, `UPDATE scheduled_actions SET status = ?, updated_at = ? WHERE status = ?`,
+		ScheduledActionPending, formatStorageTime(time.Now().UTC()), ScheduledActionRunning)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// =========================================================================
+//  Alert Outbox
+// =========================================================================
+
+// Delivery states of an outbox entry. Dead entries used up their attempts and
+// stay until they are retried or purged.
+const (
+	AlertOutboxPending    = "pending"
+	AlertOutboxDelivering = "delivering"
+	AlertOutboxDelivered  = "delivered"
+	AlertOutboxDead       = "dead"
+)
+
+// An alert waiting for, or done with, delivery to one channel. Channel and
+// Payload hold the channel settings and the event as JSON.
+type AlertOutboxRecord struct {
+	ID            int64      `json:"id"`
+	ChannelID     string     `json:"channelId"`
+	Channel       string     `json:"-"`
+	EventType     string     `json:"eventType"`
+	Payload       string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	MaxAttempts   int        `json:"maxAttempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+}
+
+const alertOutboxColumns = `id, channel_id, channel, event_type, payload, status, attempts, max_attempts, next_attempt_at, last_error, created_at, updated_at, delivered_at`
+
+func scanAlertOutbox(scanner interface{ Scan(...any) error }) (AlertOutboxRecord, error) {
+	var rec AlertOutboxRecord
+	var lastError, deliveredAt sql.NullString
+	var nextAttemptAt, createdAt, updatedAt string
+	if err := scanner.Scan(&rec.ID, &rec.ChannelID, &rec.Channel, &rec.EventType, &rec.Payload, &rec.Status, &rec.Attempts, &rec.MaxAttempts, &nextAttemptAt, &lastError, &createdAt, &updatedAt, &deliveredAt); err != nil {
+		return rec, err
+	}
+	rec.LastError = stringFromNull(lastError)
+	rec.NextAttemptAt = parseStorageTime(nextAttemptAt)
+	rec.CreatedAt = parseStorageTime(createdAt)
+	rec.UpdatedAt = parseStorageTime(updatedAt)
+	if deliveredAt.Valid && deliveredAt.String != "" {
+		t := parseStorageTime(deliveredAt.String)
+		rec.DeliveredAt = &t
+	}
+	return rec, nil
+}
+
+// Stores an alert for delivery and returns its ID. It is due immediately.
+func EnqueueAlert(ctx context.Context, rec AlertOutboxRecord) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	if rec.MaxAttempts <= 0 {
+		rec.MaxAttempts = 8
+	}
+	now := formatStorageTime(time.Now().UTC())
+	res, err := db.ExecContext(ctx, `
+INSERT INTO alert_outbox (channel_id, channel, event_type, payload, status, attempts, max_attempts, next_attempt_at, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?, ?)`,
+		rec.ChannelID, rec.Channel, rec.EventType, rec.Payload, AlertOutboxPending, rec.MaxAttempts, now, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Lists outbox entries, newest first, optionally only those with the given status.
+func ListAlertOutbox(ctx context.Context, status string, limit int) ([]AlertOutboxRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	query := `SELECT ` + alertOutboxColumns + ` FROM alert_outbox`
+	args := []any{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []AlertOutboxRecord
+	for rows.Next() {
+		rec, err := scanAlertOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Returns the number of outbox entries per status.
+func CountAlertOutbox(ctx context.Context) (map[string]int64, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	rows, err := db.QueryContext(ctx, `SELECT status, COUNT(*) FROM alert_outbox GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string]int64{}
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// Marks up to limit due entries as delivering and returns them, oldest first.
+func ClaimDueAlerts(ctx context.Context, now time.Time, limit int) ([]AlertOutboxRecord, error) {
+	if db == nil {
+		return nil, errors.New("storage not initialised")
+	}
+	rows, err := db.QueryContext(ctx, `
+SELECT `+alertOutboxColumns+`
+FROM alert_outbox
+WHERE status = ? AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+LIMIT ?`, AlertOutboxPending, formatStorageTime(now.UTC()), limit)
+	if err != nil {
+		return nil, err
+	}
+	var due []AlertOutboxRecord
+	for rows.Next() {
+		rec, err := scanAlertOutbox(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, rec)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	claimed := due[:0]
+	updatedAt := formatStorageTime(time.Now().UTC())
+	for _, rec := range due {
+		res, err := db.ExecContext(ctx, `UPDATE alert_outbox SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+			AlertOutboxDelivering, updatedAt, rec.ID, AlertOutboxPending)
+		if err != nil {
+			return claimed, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 1 {
+			rec.Status = AlertOutboxDelivering
+			claimed = append(claimed, rec)
+		}
+	}
+	return claimed, nil
+}
+
+// Stores the outcome of a delivery attempt: status, attempt count, error and next attempt.
+func FinishAlertDelivery(ctx context.Context, rec AlertOutboxRecord) error {
+	if db == nil {
+		return errors.New("storage not initialised")
+	}
+	now := time.Now().UTC()
+	var deliveredAt any
+	if rec.Status == AlertOutboxDelivered {
+		deliveredAt = formatStorageTime(now)
+	}
+	_, err := db.ExecContext(ctx, `
+UPDATE alert_outbox
+SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ?, delivered_at = ?
+WHERE id = ?`,
+		rec.Status, rec.Attempts, rec.LastError, formatStorageTime(rec.NextAttemptAt.UTC()), formatStorageTime(now), deliveredAt, rec.ID)
+	return err
+}
+
+// Queues dead entries for delivery again with fresh attempts; id 0 retries all of them.
+// Returns how many entries were requeued.
+func RetryDeadAlerts(ctx context.Context, id int64) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	now := formatStorageTime(time.Now().UTC())
+	query := `UPDATE alert_outbox SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ? WHERE status = ?`
+	args := []any{AlertOutboxPending, now, now, AlertOutboxDead}
+	if id > 0 {
+		query += ` AND id = ?`
+		args = append(args, id)
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Deletes dead entries; id 0 deletes all of them. Returns how many were deleted.
+func PurgeDeadAlerts(ctx context.Context, id int64) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	query := `DELETE FROM alert_outbox WHERE status = ?`
+	args := []any{AlertOutboxDead}
+	if id > 0 {
+		query += ` AND id = ?`
+		args = append(args, id)
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Deletes delivered entries older than the cutoff.
+func PruneDeliveredAlerts(ctx context.Context, before time.Time) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	res, err := db.ExecContext(ctx, `DELETE FROM alert_outbox WHERE status = ? AND delivered_at < ?`,
+		AlertOutboxDelivered, formatStorageTime(before.UTC()))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Returns entries left delivering by an interrupted process to pending.
+func ResetDeliveringAlerts(ctx context.Context) (int64, error) {
+	if db == nil {
+		return 0, errors.New("storage not initialised")
+	}
+	res, err := db.ExecContext(ctx, `UPDATE alert_outbox SET status = ?, updated_at = ? WHERE status = ?`,
+		AlertOutboxPending, formatStorageTime(time.Now().UTC()), AlertOutboxDelivering)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
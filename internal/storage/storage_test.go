@@ -19,6 +19,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
@@ -333,6 +334,162 @@ func TestCountRecentBanEventsByJail(t *testing.T) {
 	}
 }
 
+func TestImportBanEventsSkipsDuplicates(t *testing.T) {
+	initTestStorage(t)
+
+	ctx := context.Background()
+	bannedAt := time.Date(2026, 3, 2, 8, 15, 0, 0, time.UTC)
+	// Recorded by the callback a moment after fail2ban stored the ban.
+	if _, err := RecordBanEvent(ctx, BanEventRecord{ServerID: "srv-1", ServerName: "server 1", Jail: "sshd", IP: "192.0.2.10", EventType: "ban", OccurredAt: bannedAt.Add(2 * time.Second)}); err != nil {
+		t.Fatalf("RecordBanEvent: %v", err)
+	}
+
+	records := []BanEventRecord{
+		{ServerID: "srv-1", ServerName: "server 1", Jail: "sshd", IP: "192.0.2.10", EventType: "ban", OccurredAt: bannedAt},
+		{ServerID: "srv-1", ServerName: "server 1", Jail: "sshd", IP: "192.0.2.10", EventType: "ban", OccurredAt: bannedAt.Add(-48 * time.Hour)},
+		{ServerID: "srv-1", ServerName: "server 1", Jail: "nginx", IP: "192.0.2.10", EventType: "ban", OccurredAt: bannedAt},
+	}
+	inserted, err := ImportBanEvents(ctx, records, time.Minute)
+	if err != nil {
+		t.Fatalf("ImportBanEvents: %v", err)
+	}
+	if inserted != 2 {
+		t.Fatalf("inserted=%d want 2", inserted)
+	}
+	// Importing the same rows again must not create duplicates.
+	inserted, err = ImportBanEvents(ctx, records, time.Minute)
+	if err != nil {
+		t.Fatalf("ImportBanEvents (repeat): %v", err)
+	}
+	if inserted != 0 {
+		t.Fatalf("repeat inserted=%d want 0", inserted)
+	}
+
+	total, err := CountBanEvents(ctx, time.Time{}, "srv-1")
+	if err != nil {
+		t.Fatalf("CountBanEvents: %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("total=%d want 3", total)
+	}
+}
+
+func TestConfigRevisionsRoundTrip(t *testing.T) {
+	initTestStorage(t)
+
+	ctx := context.Background()
+	base := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	revs := []ConfigRevisionRecord{
+		{ServerID: "srv-1", Kind: "jail", Name: "sshd", Path: "/etc/fail2ban/jail.d/sshd.local", Action: "update", Author: "alice", Content: "[sshd]\nmaxretry = 5\n", ContentHash: "h1", Exists: true, CreatedAt: base},
+		{ServerID: "srv-1", Kind: "jail", Name: "sshd", Path: "/etc/fail2ban/jail.d/sshd.local", Action: "update", Content: "[sshd]\nmaxretry = 3\n", ContentHash: "h2", Exists: true, CreatedAt: base.Add(time.Minute)},
+		{ServerID: "srv-1", Kind: "filter", Name: "custom", Action: "create", Exists: false, CreatedAt: base.Add(2 * time.Minute)},
+		{ServerID: "srv-2", Kind: "jail", Name: "sshd", Action: "update", Exists: true, CreatedAt: base},
+	}
+	var firstID int64
+	for i, rec := range revs {
+		id, err := RecordConfigRevision(ctx, rec)
+		if err != nil {
+			t.Fatalf("RecordConfigRevision: %v", err)
+		}
+		if i == 0 {
+			firstID = id
+		}
+	}
+
+	list, err := ListConfigRevisions(ctx, "srv-1", "", "", 0)
+	if err != nil {
+		t.Fatalf("ListConfigRevisions: %v", err)
+	}
+	if len(list) != 3 || list[0].Kind != "filter" || list[0].Exists {
+		t.Fatalf("unexpected revisions %+v", list)
+	}
+	if list[1].Content != "" {
+		t.Fatalf("list must not include content, got %q", list[1].Content)
+	}
+
+	jailOnly, err := ListConfigRevisions(ctx, "srv-1", "jail", "sshd", 0)
+	if err != nil {
+		t.Fatalf("ListConfigRevisions (filtered): %v", err)
+	}
+	if len(jailOnly) != 2 || jailOnly[0].ContentHash != "h2" {
+		t.Fatalf("unexpected filtered revisions %+v", jailOnly)
+	}
+
+	rev, found, err := GetConfigRevision(ctx, firstID)
+	if err != nil || !found {
+		t.Fatalf("GetConfigRevision: found=%v err=%v", found, err)
+	}
+	if rev.Author != "alice" || rev.Content != "[sshd]\nmaxretry = 5\n" || !rev.CreatedAt.Equal(base) {
+		t.Fatalf("unexpected revision %+v", rev)
+	}
+	if _, found, err := GetConfigRevision(ctx, firstID+100); err != nil || found {
+		t.Fatalf("expected missing revision, found=%v err=%v", found, err)
+	}
+}
+
+func TestConfigRevisionsArePrunedPerFile(t *testing.T) {
+	initTestStorage(t)
+
+	ctx := context.Background()
+	base := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	var lastID int64
+	for i := 0; i < configRevisionsPerFile+5; i++ {
+		id, err := RecordConfigRevision(ctx, ConfigRevisionRecord{ServerID: "srv-1", Kind: "jail", Name: "sshd", Action: "update", Exists: true, CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+		if err != nil {
+			t.Fatalf("RecordConfigRevision: %v", err)
+		}
+		lastID = id
+	}
+	if _, err := RecordConfigRevision(ctx, ConfigRevisionRecord{ServerID: "srv-1", Kind: "filter", Name: "sshd", Action: "update", Exists: true, CreatedAt: base}); err != nil {
+		t.Fatalf("RecordConfigRevision: %v", err)
+	}
+
+	list, err := ListConfigRevisions(ctx, "srv-1", "jail", "sshd", 500)
+	if err != nil {
+		t.Fatalf("ListConfigRevisions: %v", err)
+	}
+	if len(list) != configRevisionsPerFile || list[0].ID != lastID || !list[len(list)-1].CreatedAt.Equal(base.Add(5*time.Minute)) {
+		t.Fatalf("expected the newest %d revisions to be kept, got %d from %s", configRevisionsPerFile, len(list), list[len(list)-1].CreatedAt)
+	}
+	if filters, _ := ListConfigRevisions(ctx, "srv-1", "filter", "sshd", 0); len(filters) != 1 {
+		t.Fatalf("pruning must not touch other files, got %d filter revisions", len(filters))
+	}
+}
+
+func TestConfigTemplatesCRUD(t *testing.T) {
+	initTestStorage(t)
+
+	ctx := context.Background()
+	id, err := SaveConfigTemplate(ctx, ConfigTemplateRecord{Name: "nginx", JailName: "nginx-http-auth", JailContent: "enabled = true\n", ParametersJSON: `{"maxretry":"3"}`})
+	if err != nil {
+		t.Fatalf("SaveConfigTemplate: %v", err)
+	}
+	if _, err := SaveConfigTemplate(ctx, ConfigTemplateRecord{Name: "nginx"}); err == nil {
+		t.Fatalf("expected duplicate template name to be rejected")
+	}
+	if _, err := SaveConfigTemplate(ctx, ConfigTemplateRecord{ID: id, Name: "nginx", JailName: "nginx-http-auth", Description: "updated"}); err != nil {
+		t.Fatalf("SaveConfigTemplate (update): %v", err)
+	}
+	rec, found, err := GetConfigTemplate(ctx, id)
+	if err != nil || !found {
+		t.Fatalf("GetConfigTemplate: found=%v err=%v", found, err)
+	}
+	if rec.Description != "updated" || rec.ParametersJSON != "{}" || rec.JailContent != "" {
+		t.Fatalf("unexpected template after update %+v", rec)
+	}
+	if _, err := SaveConfigTemplate(ctx, ConfigTemplateRecord{ID: id + 10, Name: "other"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows for unknown template, got %v", err)
+	}
+
+	if err := DeleteConfigTemplate(ctx, id); err != nil {
+		t.Fatalf("DeleteConfigTemplate: %v", err)
+	}
+	list, err := ListConfigTemplates(ctx)
+	if err != nil || len(list) != 0 {
+		t.Fatalf("expected no templates, got %+v (%v)", list, err)
+	}
+}
+
 func TestRecordBanEventUsesSortableStorageTime(t *testing.T) {
 	initTestStorage(t)
 
@@ -681,3 +838,134 @@ func TestCountBanEventTotals(t *testing.T) {
 		t.Fatalf("srv-1 totals = %d/%d/%d, want 3/1/2", overall, today, week)
 	}
 }
+
+func TestScheduledActionsLifecycle(t *testing.T) {
+	initTestStorage(t)
+
+	ctx := context.Background()
+	now := time.Now()
+	dueID, err := CreateScheduledAction(ctx, ScheduledActionRecord{Action: "unban", ServerID: "srv-1", Jail: "sshd", IP: "192.0.2.1", RunAt: now.Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("CreateScheduledAction: %v", err)
+	}
+	laterID, err := CreateScheduledAction(ctx, ScheduledActionRecord{Action: "unban", ServerID: "srv-1", Jail: "sshd", IP: "192.0.2.2", RunAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("CreateScheduledAction: %v", err)
+	}
+
+	claimed, err := ClaimDueScheduledActions(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("ClaimDueScheduledActions: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != dueID || claimed[0].Status != ScheduledActionRunning || claimed[0].MaxAttempts != 3 {
+		t.Fatalf("expected only the due action to be claimed, got %+v", claimed)
+	}
+	if again, _ := ClaimDueScheduledActions(ctx, now, 10); len(again) != 0 {
+		t.Fatalf("a running action must not be claimed twice, got %+v", again)
+	}
+	if err := UpdateScheduledAction(ctx, claimed[0]); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected ErrNoRows when editing a running action, got %v", err)
+	}
+
+	if n, err := ResetRunningScheduledActions(ctx); err != nil || n != 1 {
+		t.Fatalf("ResetRunningScheduledActions: n=%d err=%v", n, err)
+	}
+	claimed, _ = ClaimDueScheduledActions(ctx, now, 10)
+	if len(claimed) != 1 {
+		t.Fatalf("expected requeued action to be claimed again, got %+v", claimed)
+	}
+	rec := claimed[0]
+	rec.Status, rec.Attempts, rec.LastError = ScheduledActionDone, 1, ""
+	if err := FinishScheduledAction(ctx, rec); err != nil {
+		t.Fatalf("FinishScheduledAction: %v", err)
+	}
+	done, found, err := GetScheduledAction(ctx, dueID)
+	if err != nil || !found || done.Status != ScheduledActionDone || done.Attempts != 1 || done.CompletedAt == nil {
+		t.Fatalf("unexpected finished action %+v (found=%v err=%v)", done, found, err)
+	}
+
+	if n, err := CancelScheduledActions(ctx, "unban", "srv-1", "sshd", "192.0.2.2"); err != nil || n != 1 {
+		t.Fatalf("CancelScheduledActions: n=%d err=%v", n, err)
+	}
+	cancelled, _, _ := GetScheduledAction(ctx, laterID)
+	if cancelled.Status != ScheduledActionCancelled {
+		t.Fatalf("expected cancelled action, got %+v", cancelled)
+	}
+	pending, err := ListScheduledActions(ctx, ScheduledActionPending, 0)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected no pending actions, got %+v (%v)", pending, err)
+	}
+
+	if err := DeleteScheduledAction(ctx, laterID); err != nil {
+		t.Fatalf("DeleteScheduledAction: %v", err)
+	}
+	if all, _ := ListScheduledActions(ctx, "", 0); len(all) != 1 {
+		t.Fatalf("expected one action left, got %+v", all)
+	}
+}
+
+func TestAlertOutboxLifecycle(t *testing.T) {
+	initTestStorage(t)
+
+	ctx := context.Background()
+	id, err := EnqueueAlert(ctx, AlertOutboxRecord{ChannelID: "ops", Channel: `{"id":"ops"}`, EventType: "ban", Payload: `{"type":"ban"}`, MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("EnqueueAlert: %v", err)
+	}
+	now := time.Now()
+	claimed, err := ClaimDueAlerts(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("ClaimDueAlerts: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != id || claimed[0].Status != AlertOutboxDelivering || claimed[0].Payload != `{"type":"ban"}` {
+		t.Fatalf("expected the queued alert to be claimed, got %+v", claimed)
+	}
+	if again, _ := ClaimDueAlerts(ctx, now, 10); len(again) != 0 {
+		t.Fatalf("an alert being delivered must not be claimed twice, got %+v", again)
+	}
+	if n, err := ResetDeliveringAlerts(ctx); err != nil || n != 1 {
+		t.Fatalf("ResetDeliveringAlerts: n=%d err=%v", n, err)
+	}
+
+	claimed, _ = ClaimDueAlerts(ctx, now, 10)
+	rec := claimed[0]
+	rec.Status, rec.Attempts, rec.LastError, rec.NextAttemptAt = AlertOutboxPending, 1, "timeout", now.Add(time.Hour)
+	if err := FinishAlertDelivery(ctx, rec); err != nil {
+		t.Fatalf("FinishAlertDelivery: %v", err)
+	}
+	if due, _ := ClaimDueAlerts(ctx, now, 10); len(due) != 0 {
+		t.Fatalf("a retry scheduled for later must not be due yet, got %+v", due)
+	}
+
+	rec.Status, rec.Attempts = AlertOutboxDead, 2
+	if err := FinishAlertDelivery(ctx, rec); err != nil {
+		t.Fatalf("FinishAlertDelivery: %v", err)
+	}
+	dead, err := ListAlertOutbox(ctx, AlertOutboxDead, 0)
+	if err != nil || len(dead) != 1 || dead[0].LastError != "timeout" || dead[0].Attempts != 2 {
+		t.Fatalf("expected one dead alert, got %+v err=%v", dead, err)
+	}
+	counts, err := CountAlertOutbox(ctx)
+	if err != nil || counts[AlertOutboxDead] != 1 {
+		t.Fatalf("expected one dead alert in counts, got %v err=%v", counts, err)
+	}
+
+	if n, err := RetryDeadAlerts(ctx, 0); err != nil || n != 1 {
+		t.Fatalf("RetryDeadAlerts: n=%d err=%v", n, err)
+	}
+	claimed, _ = ClaimDueAlerts(ctx, time.Now().Add(time.Second), 10)
+	if len(claimed) != 1 || claimed[0].Attempts != 0 {
+		t.Fatalf("expected the retried alert to be due with fresh attempts, got %+v", claimed)
+	}
+	rec = claimed[0]
+	rec.Status, rec.Attempts, rec.LastError = AlertOutboxDelivered, 1, ""
+	if err := FinishAlertDelivery(ctx, rec); err != nil {
+		t.Fatalf("FinishAlertDelivery: %v", err)
+	}
+	if n, _ := PurgeDeadAlerts(ctx, 0); n != 0 {
+		t.Fatalf("purging dead alerts must not touch delivered ones, deleted %d", n)
+	}
+	if n, err := PruneDeliveredAlerts(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("PruneDeliveredAlerts: n=%d err=%v", n, err)
+	}
+}
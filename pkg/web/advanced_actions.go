@@ -135,6 +135,9 @@ func runAdvancedIntegrationAction(ctx context.Context, action, ip string, settin
 			log.Printf("WARNING: Failed to record permanent block entry: %v", err2)
 		}
 	}
+	if action == "block" && err == nil && !skipLoggingIfAlreadyBlocked {
+		alertPermanentBlockAsync(ip, server, cfg.Integration, message, settings)
+	}
 
 	return err
 }
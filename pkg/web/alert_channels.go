@@ -0,0 +1,375 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/integrations"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+// Event types an alert channel can subscribe to.
+var alertEventTypes = []string{"ban", "unban", "permanent_block", "server_down", "server_recovered", "callback_silence"}
+
+// Severities in ascending order; a channel forwards events at or above its minimum.
+var alertSeverities = []string{"info", "warning", "critical"}
+
+var alertEventSeverity = map[string]string{
+	"unban":            "info",
+	"server_recovered": "info",
+	"ban":              "warning",
+	"callback_silence": "warning",
+	"permanent_block":  "critical",
+	"server_down":      "critical",
+}
+
+// Everything a channel needs to filter and deliver one alert.
+type alertEvent struct {
+	Type        string       `json:"type"`
+	IP          string       `json:"ip,omitempty"`
+	Jail        string       `json:"jail,omitempty"`
+	Hostname    string       `json:"hostname,omitempty"`
+	Failures    string       `json:"failures,omitempty"`
+	Whois       string       `json:"whois,omitempty"`
+	Logs        string       `json:"logs,omitempty"`
+	Country     string       `json:"country,omitempty"`
+	ServerID    string       `json:"serverId,omitempty"`
+	ServerName  string       `json:"serverName,omitempty"`
+	Integration string       `json:"integration,omitempty"`
+	Detail      string       `json:"detail,omitempty"`
+	Since       time.Time    `json:"since,omitzero"`
+	Digest      *alertDigest `json:"digest,omitempty"`
+	OccurredAt  time.Time    `json:"occurredAt"`
+	// Ban event the alert belongs to, and whether its whois and country are
+	// still to be looked up; queued alerts are enriched by the outbox worker.
+	EventID       int64 `json:"eventId,omitempty"`
+	EnrichPending bool  `json:"enrichPending,omitempty"`
+	// Alert outbox entry ID, sent with webhooks so receivers can drop retried duplicates.
+	DeliveryID string `json:"-"`
+}
+
+// =========================================================================
+//  Channel Selection
+// =========================================================================
+
+// Returns the configured channels, or one channel built from the single-provider
+// settings (alertProvider, alertCountries, alerts for bans/unbans) when none are configured.
+func effectiveAlertChannels(settings config.AppSettings) []config.AlertChannel {
+	if len(settings.AlertChannels) > 0 {
+		return settings.AlertChannels
+	}
+	events := []string{"server_down", "server_recovered", "callback_silence"}
+	if settings.EmailAlertsForBans {
+		events = append(events, "ban")
+	}
+	if settings.EmailAlertsForUnbans {
+		events = append(events, "unban")
+	}
+	provider := settings.AlertProvider
+	if provider == "" {
+		provider = "email"
+	}
+	return []config.AlertChannel{{
+		ID:        "default",
+		Name:      provider,
+		Enabled:   true,
+		Provider:  provider,
+		Events:    events,
+		Countries: settings.AlertCountries,
+	}}
+}
+
+// Reports whether the channel wants the event. The country filter is checked
+// again on delivery for events whose country is not known yet.
+func alertChannelMatches(ch config.AlertChannel, ev alertEvent) bool {
+	if !ch.Enabled {
+		return false
+	}
+	if len(ch.Events) > 0 && !slices.Contains(ch.Events, ev.Type) {
+		return false
+	}
+	if ch.MinSeverity != "" && slices.Index(alertSeverities, alertEventSeverity[ev.Type]) < slices.Index(alertSeverities, ch.MinSeverity) {
+		return false
+	}
+	if ev.IP != "" && !ev.EnrichPending && !shouldAlertForCountry(ev.Country, ch.Countries) {
+		return false
+	}
+	if ev.Jail != "" && len(ch.Jails) > 0 && !slices.Contains(ch.Jails, ev.Jail) {
+		return false
+	}
+	if ev.ServerID != "" && len(ch.Servers) > 0 && !slices.Contains(ch.Servers, ev.ServerID) {
+		return false
+	}
+	return true
+}
+
+// Settings with the channel's own recipient and webhook in place of the global ones.
+func alertChannelSettings(ch config.AlertChannel, settings config.AppSettings) config.AppSettings {
+	if ch.Destination != "" {
+		settings.Destemail = ch.Destination
+	}
+	if ch.Webhook != nil {
+		wh := *ch.Webhook
+		if wh.URL == "" {
+			// Only a body template of its own: post to the global webhook.
+			wh.URL, wh.Method, wh.Headers, wh.SkipTLSVerify = settings.Webhook.URL, settings.Webhook.Method, settings.Webhook.Headers, settings.Webhook.SkipTLSVerify
+			wh.Secret = settings.Webhook.Secret
+		}
+		settings.Webhook = wh
+	}
+	return settings
+}
+
+// =========================================================================
+//  Dispatch
+// =========================================================================
+
+// Queues the event for every channel that matches it. Direct delivery is only
+// used as a fallback; its failures are returned together.
+func dispatchAlertEvent(ev alertEvent, settings config.AppSettings) error {
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+	var errs []error
+	sent := 0
+	for _, ch := range effectiveAlertChannels(settings) {
+		if !alertChannelMatches(ch, ev) {
+			continue
+		}
+		sent++
+		if !throttleAlert(ch, ev, ev.OccurredAt) {
+			config.DebugLog("Alert channel %s holds back the %s alert for %s", alertChannelLabel(ch), ev.Type, ev.IP)
+			continue
+		}
+		// Queued alerts are delivered and retried by the outbox worker; send
+		// directly only when the outbox is unavailable.
+		err := enqueueAlert(context.Background(), ch, ev)
+		if err == nil {
+			continue
+		}
+		log.Printf("warning: failed to queue %s alert for %s, sending directly: %v", ev.Type, alertChannelLabel(ch), err)
+		if err := deliverChannelAlert(context.Background(), ch, ev, settings); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", alertChannelLabel(ch), err))
+		}
+	}
+	if sent == 0 {
+		config.DebugLog("No alert channel matches the %s alert for %s%s", ev.Type, ev.IP, ev.ServerName)
+	}
+	return errors.Join(errs...)
+}
+
+// Completes a pending enrichment, then sends the event to the channel unless
+// the channel's country filter now excludes it.
+func deliverChannelAlert(ctx context.Context, ch config.AlertChannel, ev alertEvent, settings config.AppSettings) error {
+	if ev.EnrichPending {
+		ev = enrichAlertEvent(ctx, ev, settings)
+		if !alertChannelMatches(ch, ev) {
+			config.DebugLog("Alert channel %s skips the %s alert for %s from %s", alertChannelLabel(ch), ev.Type, ev.IP, ev.Country)
+			return nil
+		}
+	}
+	return deliverAlert(ctx, ch.Provider, ev, alertChannelSettings(ch, settings))
+}
+
+// Sends one event through a provider.
+func deliverAlert(ctx context.Context, provider string, ev alertEvent, settings config.AppSettings) error {
+	switch ev.Type {
+	case "ban", "unban":
+		switch provider {
+		case "webhook":
+			return postWebhook(ctx, ev, settings)
+		case "elasticsearch":
+			return sendElasticsearchAlert(ctx, ev, settings)
+		default:
+			if ev.Type == "ban" {
+				return sendBanAlert(ctx, ev.IP, ev.Jail, ev.Hostname, ev.Failures, ev.Whois, ev.Logs, ev.Country, settings)
+			}
+			return sendUnbanAlert(ctx, ev.IP, ev.Jail, ev.Hostname, ev.Whois, ev.Country, settings)
+		}
+	case "permanent_block":
+		return deliverPermanentBlockAlert(ctx, provider, ev, settings)
+	case "digest":
+		return deliverDigestAlert(ctx, provider, ev, settings)
+	default:
+		return deliverServerAlert(ctx, provider, ev, settings)
+	}
+}
+
+func alertChannelLabel(ch config.AlertChannel) string {
+	if ch.Name != "" {
+		return ch.Name
+	}
+	return ch.Provider
+}
+
+// =========================================================================
+//  Permanent Block Alerts
+// =========================================================================
+
+// Alerts that an IP was blocked permanently on the advanced-actions integration.
+func alertPermanentBlockAsync(ip string, server config.Fail2banServer, integration, message string, settings config.AppSettings) {
+	go func() {
+		ev := alertEvent{
+			Type:        "permanent_block",
+			IP:          ip,
+			Country:     resolveCountry(ip, "", settings),
+			ServerID:    server.ID,
+			ServerName:  server.Name,
+			Hostname:    server.Hostname,
+			Integration: integration,
+			Detail:      message,
+		}
+		if err := dispatchAlertEvent(ev, settings); err != nil {
+			log.Printf("ERROR: Failed to send permanent_block alert for IP %s: %v", ip, err)
+			if wsHub != nil {
+				wsHub.BroadcastToast("error", fmt.Sprintf("Failed to send permanent_block alert for %s: %v", ip, err))
+			}
+		}
+	}()
+}
+
+func deliverPermanentBlockAlert(ctx context.Context, provider string, ev alertEvent, settings config.AppSettings) error {
+	switch provider {
+	case "webhook":
+		return postWebhook(ctx, ev, settings)
+	case "elasticsearch":
+		return indexElasticsearchDocument(ctx, ev.OccurredAt, map[string]interface{}{
+			"event.kind":                  "alert",
+			"event.type":                  ev.Type,
+			"source.ip":                   ev.IP,
+			"source.geo.country_iso_code": ev.Country,
+			"observer.name":               ev.ServerName,
+			"observer.product":            ev.Integration,
+			"message":                     ev.Detail,
+		}, settings)
+	default:
+		lang := settings.Language
+		if lang == "" {
+			lang = "en"
+		}
+		title := getEmailTranslation(lang, "email.permanent_block.title")
+		subject := fmt.Sprintf("[Fail2Ban-UI] %s: %s", title, ev.IP)
+		details := []emailDetail{
+			{Label: getEmailTranslation(lang, "email.ban.details.banned_ip"), Value: ev.IP},
+			{Label: getEmailTranslation(lang, "email.ban.details.country"), Value: ev.Country},
+			{Label: getEmailTranslation(lang, "email.permanent_block.details.integration"), Value: ev.Integration},
+			{Label: getEmailTranslation(lang, "email.server.details.server"), Value: ev.ServerName},
+			{Label: getEmailTranslation(lang, "email.ban.details.timestamp"), Value: ev.OccurredAt.UTC().Format(time.RFC3339)},
+		}
+		intro := getEmailTranslation(lang, "email.permanent_block.intro")
+		footerText := getEmailTranslation(lang, "email.footer.text")
+		var body string
+		if getEmailStyle() == "modern" {
+			body = buildModernEmailBody(title, intro, details, "", "", "", "", footerText)
+		} else {
+			body = buildClassicEmailBody(title, intro, details, "", "", "", "", footerText, "support@swissmakers.ch")
+		}
+		return sendEmail(ctx, settings.Destemail, subject, body, settings)
+	}
+}
+
+// =========================================================================
+//  Validation
+// =========================================================================
+
+// Normalizes the channel list and rejects incomplete channels.
+func normalizeAlertChannels(channels []config.AlertChannel) ([]config.AlertChannel, error) {
+	seen := make(map[string]bool, len(channels))
+	out := make([]config.AlertChannel, 0, len(channels))
+	for i, ch := range channels {
+		ch.ID = strings.TrimSpace(ch.ID)
+		if ch.ID == "" {
+			ch.ID = fmt.Sprintf("channel-%d", i+1)
+		}
+		if seen[ch.ID] {
+			return nil, fmt.Errorf("alert channel id %q is used twice", ch.ID)
+		}
+		seen[ch.ID] = true
+		ch.Name = strings.TrimSpace(ch.Name)
+		label := ch.Name
+		if label == "" {
+			label = ch.ID
+		}
+
+		ch.Provider = strings.ToLower(strings.TrimSpace(ch.Provider))
+		switch ch.Provider {
+		case "email", "webhook", "elasticsearch":
+		default:
+			return nil, fmt.Errorf("alert channel %s: provider must be email, webhook or elasticsearch", label)
+		}
+		ch.Events = trimmedUnique(ch.Events)
+		for _, ev := range ch.Events {
+			if !slices.Contains(alertEventTypes, ev) {
+				return nil, fmt.Errorf("alert channel %s: unknown event type %q", label, ev)
+			}
+		}
+		ch.MinSeverity = strings.ToLower(strings.TrimSpace(ch.MinSeverity))
+		if ch.MinSeverity != "" && !slices.Contains(alertSeverities, ch.MinSeverity) {
+			return nil, fmt.Errorf("alert channel %s: minimum severity must be info, warning or critical", label)
+		}
+		ch.Countries = trimmedUnique(ch.Countries)
+		for j, c := range ch.Countries {
+			ch.Countries[j] = strings.ToUpper(c)
+		}
+		ch.Jails = trimmedUnique(ch.Jails)
+		ch.Servers = trimmedUnique(ch.Servers)
+
+		if err := normalizeAlertThrottle(&ch.Throttle); err != nil {
+			return nil, fmt.Errorf("alert channel %s: %w", label, err)
+		}
+
+		ch.Destination = strings.TrimSpace(ch.Destination)
+		if ch.Provider != "email" {
+			ch.Destination = ""
+		}
+		if ch.Provider != "webhook" {
+			ch.Webhook = nil
+		}
+		if ch.Webhook != nil {
+			ch.Webhook.URL = strings.TrimSpace(ch.Webhook.URL)
+			if ch.Webhook.URL == "" && strings.TrimSpace(ch.Webhook.Template) == "" {
+				ch.Webhook = nil
+			} else {
+				if ch.Webhook.URL != "" {
+					if err := integrations.ValidateOutboundURL(ch.Webhook.URL, "webhook URL"); err != nil {
+						return nil, fmt.Errorf("alert channel %s: %w", label, err)
+					}
+				}
+				ch.Webhook.Method = strings.ToUpper(strings.TrimSpace(ch.Webhook.Method))
+				if ch.Webhook.Method == "" {
+					ch.Webhook.Method = "POST"
+				}
+				if err := normalizeWebhookTemplate(ch.Webhook); err != nil {
+					return nil, fmt.Errorf("alert channel %s: %w", label, err)
+				}
+			}
+		}
+		out = append(out, ch)
+	}
+	return out, nil
+}
@@ -0,0 +1,103 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+)
+
+func TestAlertChannelMatches(t *testing.T) {
+	siem := config.AlertChannel{ID: "siem", Enabled: true, Provider: "elasticsearch"}
+	soc := config.AlertChannel{ID: "soc", Enabled: true, Provider: "email", Events: []string{"ban"}, Countries: []string{"DE", "CH"}, Jails: []string{"sshd"}}
+	blocks := config.AlertChannel{ID: "blocks", Enabled: true, Provider: "webhook", MinSeverity: "critical", Servers: []string{"web-01"}}
+
+	tests := []struct {
+		name    string
+		channel config.AlertChannel
+		event   alertEvent
+		want    bool
+	}{
+		{"catch-all gets bans", siem, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "nginx", Country: "US"}, true},
+		{"catch-all gets server alerts", siem, alertEvent{Type: "server_down", ServerID: "web-02"}, true},
+		{"sshd ban from CH", soc, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "sshd", Country: "ch"}, true},
+		{"sshd ban from US", soc, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "sshd", Country: "US"}, false},
+		{"other jail", soc, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "nginx", Country: "DE"}, false},
+		{"unsubscribed event", soc, alertEvent{Type: "unban", IP: "192.0.2.1", Jail: "sshd", Country: "DE"}, false},
+		{"critical on the server", blocks, alertEvent{Type: "permanent_block", IP: "192.0.2.1", ServerID: "web-01"}, true},
+		{"below minimum severity", blocks, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "sshd", ServerID: "web-01"}, false},
+		{"other server", blocks, alertEvent{Type: "server_down", ServerID: "web-02"}, false},
+		{"disabled channel", config.AlertChannel{Provider: "email"}, alertEvent{Type: "ban"}, false},
+	}
+	for _, tt := range tests {
+		if got := alertChannelMatches(tt.channel, tt.event); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEffectiveAlertChannelsFallsBackToProvider(t *testing.T) {
+	settings := config.AppSettings{AlertProvider: "webhook", EmailAlertsForBans: true, AlertCountries: []string{"DE"}}
+	channels := effectiveAlertChannels(settings)
+	if len(channels) != 1 || channels[0].Provider != "webhook" {
+		t.Fatalf("expected one channel for the configured provider, got %+v", channels)
+	}
+	if !alertChannelMatches(channels[0], alertEvent{Type: "ban", IP: "192.0.2.1", Country: "DE"}) {
+		t.Errorf("ban from an alert country must be sent")
+	}
+	if alertChannelMatches(channels[0], alertEvent{Type: "unban", IP: "192.0.2.1", Country: "DE"}) {
+		t.Errorf("unbans are disabled and must not be sent")
+	}
+	if !alertChannelMatches(channels[0], alertEvent{Type: "server_down", ServerID: "a"}) {
+		t.Errorf("server alerts are not filtered by country")
+	}
+
+	settings.AlertChannels = []config.AlertChannel{{ID: "x", Enabled: true, Provider: "email"}}
+	if channels := effectiveAlertChannels(settings); len(channels) != 1 || channels[0].ID != "x" {
+		t.Fatalf("configured channels must replace the provider setting, got %+v", channels)
+	}
+}
+
+func TestNormalizeAlertChannels(t *testing.T) {
+	channels, err := normalizeAlertChannels([]config.AlertChannel{{
+		Provider:    " Email ",
+		Events:      []string{"ban", " ban"},
+		Countries:   []string{"de", " ch "},
+		MinSeverity: "Warning",
+		Webhook:     &config.WebhookSettings{URL: "https://hooks.example.com"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ch := channels[0]
+	if ch.ID != "channel-1" || ch.Provider != "email" || len(ch.Events) != 1 || ch.Countries[1] != "CH" || ch.MinSeverity != "warning" || ch.Webhook != nil {
+		t.Fatalf("channel not normalized: %+v", ch)
+	}
+
+	invalid := [][]config.AlertChannel{
+		{{Provider: "sms"}},
+		{{Provider: "email", Events: []string{"reboot"}}},
+		{{Provider: "email", MinSeverity: "urgent"}},
+		{{ID: "a", Provider: "email"}, {ID: "a", Provider: "webhook"}},
+	}
+	for i, list := range invalid {
+		if _, err := normalizeAlertChannels(list); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	alertOutboxPollInterval = 10 * time.Second
+	alertOutboxBatchSize    = 50
+	alertDeliveryTimeout    = time.Minute
+	// Failed deliveries are retried after 30s, 1m, 2m, ... up to an hour apart;
+	// eight attempts ride out an outage of about an hour.
+	alertDeliveryRetryBase   = 30 * time.Second
+	alertDeliveryRetryMax    = time.Hour
+	alertDeliveryMaxAttempts = 8
+	// Delivered entries are kept this long for inspection.
+	alertOutboxRetention = 7 * 24 * time.Hour
+)
+
+// Wakes the outbox worker when an alert is queued, so delivery does not wait for the next poll.
+var alertOutboxWake = make(chan struct{}, 1)
+
+// =========================================================================
+//  Queueing
+// =========================================================================
+
+// Stores an alert for one channel in the outbox.
+func enqueueAlert(ctx context.Context, ch config.AlertChannel, ev alertEvent) error {
+	channel, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if _, err := storage.EnqueueAlert(ctx, storage.AlertOutboxRecord{
+		ChannelID:   ch.ID,
+		Channel:     string(channel),
+		EventType:   ev.Type,
+		Payload:     string(payload),
+		MaxAttempts: alertDeliveryMaxAttempts,
+	}); err != nil {
+		return err
+	}
+	select {
+	case alertOutboxWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// =========================================================================
+//  Delivery Worker
+// =========================================================================
+
+// Delivers queued alerts until ctx is cancelled.
+func RunAlertOutboxLoop(ctx context.Context) {
+	if n, err := storage.ResetDeliveringAlerts(ctx); err != nil {
+		log.Printf("warning: failed to requeue interrupted alert deliveries: %v", err)
+	} else if n > 0 {
+		log.Printf("Requeued %d alert deliveries interrupted by a restart", n)
+	}
+	ticker := time.NewTicker(alertOutboxPollInterval)
+	defer ticker.Stop()
+	var lastPrune time.Time
+	for {
+		queueAlertDigests(ctx, time.Now())
+		deliverDueAlerts(ctx)
+		if time.Since(lastPrune) > time.Hour {
+			lastPrune = time.Now()
+			if n, err := storage.PruneDeliveredAlerts(ctx, lastPrune.Add(-alertOutboxRetention)); err != nil {
+				log.Printf("warning: failed to prune delivered alerts: %v", err)
+			} else if n > 0 {
+				config.DebugLog("Pruned %d delivered alerts from the outbox", n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-alertOutboxWake:
+		}
+	}
+}
+
+func deliverDueAlerts(ctx context.Context) {
+	for {
+		due, err := storage.ClaimDueAlerts(ctx, time.Now(), alertOutboxBatchSize)
+		if err != nil {
+			log.Printf("warning: failed to load queued alerts: %v", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		settings := config.GetSettings()
+		for _, rec := range due {
+			deliverCtx, cancel := context.WithTimeout(ctx, alertDeliveryTimeout)
+			deliveryErr := deliverOutboxAlert(deliverCtx, rec, settings)
+			cancel()
+			rec = alertDeliveryOutcome(rec, deliveryErr, time.Now())
+			if err := storage.FinishAlertDelivery(context.WithoutCancel(ctx), rec); err != nil {
+				log.Printf("warning: failed to record delivery of alert %d: %v", rec.ID, err)
+			}
+			switch rec.Status {
+			case storage.AlertOutboxPending:
+				log.Printf("warning: failed to send %s alert %d to channel %s, retrying at %s: %v", rec.EventType, rec.ID, rec.ChannelID, rec.NextAttemptAt.Format(time.RFC3339), deliveryErr)
+			case storage.AlertOutboxDead:
+				log.Printf("ERROR: Giving up on %s alert %d to channel %s after %d attempts: %v", rec.EventType, rec.ID, rec.ChannelID, rec.Attempts, deliveryErr)
+				if wsHub != nil {
+					wsHub.BroadcastToast("error", fmt.Sprintf("Failed to send %s alert to %s after %d attempts: %v", rec.EventType, rec.ChannelID, rec.Attempts, deliveryErr))
+				}
+			}
+		}
+		if ctx.Err() != nil || len(due) < alertOutboxBatchSize {
+			return
+		}
+	}
+}
+
+// Sends one outbox entry. The channel's current settings are used when it still
+// exists, so a corrected webhook URL also applies to queued retries.
+func deliverOutboxAlert(ctx context.Context, rec storage.AlertOutboxRecord, settings config.AppSettings) error {
+	var ch config.AlertChannel
+	if err := json.Unmarshal([]byte(rec.Channel), &ch); err != nil {
+		return fmt.Errorf("invalid channel in outbox entry: %w", err)
+	}
+	for _, current := range effectiveAlertChannels(settings) {
+		if current.ID == ch.ID && current.Provider == ch.Provider {
+			ch = current
+			break
+		}
+	}
+	var ev alertEvent
+	if err := json.Unmarshal([]byte(rec.Payload), &ev); err != nil {
+		return fmt.Errorf("invalid event in outbox entry: %w", err)
+	}
+	ev.DeliveryID = strconv.FormatInt(rec.ID, 10)
+	return deliverChannelAlert(ctx, ch, ev, settings)
+}
+
+// Applies the result of a delivery attempt: delivered on success, otherwise
+// retried with backoff until the attempts are used up, then dead.
+func alertDeliveryOutcome(rec storage.AlertOutboxRecord, deliveryErr error, now time.Time) storage.AlertOutboxRecord {
+	rec.Attempts++
+	if deliveryErr == nil {
+		rec.Status = storage.AlertOutboxDelivered
+		rec.LastError = ""
+		return rec
+	}
+	rec.LastError = deliveryErr.Error()
+	if rec.Attempts >= rec.MaxAttempts {
+		rec.Status = storage.AlertOutboxDead
+		return rec
+	}
+	delay := alertDeliveryRetryBase << (rec.Attempts - 1)
+	if delay <= 0 || delay > alertDeliveryRetryMax {
+		delay = alertDeliveryRetryMax
+	}
+	rec.Status = storage.AlertOutboxPending
+	rec.NextAttemptAt = now.Add(delay)
+	return rec
+}
+
+// =========================================================================
+//  Handlers
+// =========================================================================
+
+// Lists outbox entries (optionally ?status=dead) with the number of entries per status.
+func ListAlertOutboxHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListAlertOutboxHandler called (alert_outbox.go)")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	entries, err := storage.ListAlertOutbox(c.Request.Context(), strings.TrimSpace(c.Query("status")), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	counts, err := storage.CountAlertOutbox(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	type outboxEntry struct {
+		storage.AlertOutboxRecord
+		ChannelName string     `json:"channelName,omitempty"`
+		Event       alertEvent `json:"event"`
+	}
+	out := make([]outboxEntry, 0, len(entries))
+	for _, rec := range entries {
+		entry := outboxEntry{AlertOutboxRecord: rec}
+		var ch config.AlertChannel
+		if json.Unmarshal([]byte(rec.Channel), &ch) == nil {
+			entry.ChannelName = alertChannelLabel(ch)
+		}
+		_ = json.Unmarshal([]byte(rec.Payload), &entry.Event)
+		// Whois and logs can be large and are not needed to judge a failed delivery.
+		entry.Event.Whois = ""
+		entry.Event.Logs = ""
+		out = append(out, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": out, "counts": counts})
+}
+
+// Queues dead entries again: one with ?id=, otherwise all of them.
+func RetryAlertOutboxHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("RetryAlertOutboxHandler called (alert_outbox.go)")
+	id, ok := alertOutboxIDParam(c)
+	if !ok {
+		return
+	}
+	n, err := storage.RetryDeadAlerts(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if n > 0 {
+		select {
+		case alertOutboxWake <- struct{}{}:
+		default:
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"requeued": n})
+}
+
+// Deletes dead entries: one with ?id=, otherwise all of them.
+func PurgeAlertOutboxHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("PurgeAlertOutboxHandler called (alert_outbox.go)")
+	id, ok := alertOutboxIDParam(c)
+	if !ok {
+		return
+	}
+	n, err := storage.PurgeDeadAlerts(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+func alertOutboxIDParam(c *gin.Context) (int64, bool) {
+	raw := strings.TrimSpace(c.Query("id"))
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid outbox entry id"})
+		return 0, false
+	}
+	return id, true
+}
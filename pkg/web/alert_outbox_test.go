@@ -0,0 +1,170 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+func TestAlertDeliveryOutcome(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rec := storage.AlertOutboxRecord{ID: 1, MaxAttempts: 3, Status: storage.AlertOutboxDelivering}
+
+	rec = alertDeliveryOutcome(rec, errors.New("503 Service Unavailable"), now)
+	if rec.Status != storage.AlertOutboxPending || rec.Attempts != 1 || !rec.NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("first failure should retry after 30s, got %+v", rec)
+	}
+	rec = alertDeliveryOutcome(rec, errors.New("503 Service Unavailable"), now)
+	if rec.Status != storage.AlertOutboxPending || !rec.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("second failure should back off to a minute, got %+v", rec)
+	}
+	rec = alertDeliveryOutcome(rec, errors.New("503 Service Unavailable"), now)
+	if rec.Status != storage.AlertOutboxDead || rec.Attempts != 3 || rec.LastError != "503 Service Unavailable" {
+		t.Fatalf("expected a dead letter after the last attempt, got %+v", rec)
+	}
+
+	capped := alertDeliveryOutcome(storage.AlertOutboxRecord{MaxAttempts: 20, Attempts: 12}, errors.New("boom"), now)
+	if !capped.NextAttemptAt.Equal(now.Add(alertDeliveryRetryMax)) {
+		t.Fatalf("backoff must be capped, got next attempt at %v", capped.NextAttemptAt)
+	}
+
+	delivered := alertDeliveryOutcome(storage.AlertOutboxRecord{MaxAttempts: 3, Attempts: 1, LastError: "old"}, nil, now)
+	if delivered.Status != storage.AlertOutboxDelivered || delivered.LastError != "" || delivered.Attempts != 2 {
+		t.Fatalf("expected success to mark the alert delivered, got %+v", delivered)
+	}
+}
+
+func TestDeliverOutboxAlertRejectsInvalidEntry(t *testing.T) {
+	rec := storage.AlertOutboxRecord{ID: 1, Channel: `{"id":"ops","provider":"webhook"}`, Payload: `not json`}
+	if err := deliverOutboxAlert(t.Context(), rec, config.AppSettings{}); err == nil {
+		t.Fatal("expected an error for an unreadable event")
+	}
+}
+
+func TestDeliverOutboxAlertStopsWhenContextEnds(t *testing.T) {
+	aborted := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	settings := config.AppSettings{Webhook: config.WebhookSettings{URL: srv.URL}}
+	rec := storage.AlertOutboxRecord{ID: 7, Channel: `{"id":"ops","provider":"webhook"}`, Payload: `{"type":"ban","ip":"203.0.113.7"}`}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if err := deliverOutboxAlert(ctx, rec, settings); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the delivery to end with the context, got %v", err)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook request kept running after the context ended")
+	}
+}
+
+func TestDeliverOutboxAlertIndexesAtOccurrence(t *testing.T) {
+	var path string
+	var doc map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&doc)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	settings := config.AppSettings{Elasticsearch: config.ElasticsearchSettings{URL: srv.URL, Index: "f2b"}}
+	rec := storage.AlertOutboxRecord{ID: 9, Channel: `{"id":"es","provider":"elasticsearch"}`,
+		Payload: `{"type":"server_down","serverId":"web1","serverName":"web1","occurredAt":"2026-03-01T23:59:30Z"}`}
+	if err := deliverOutboxAlert(t.Context(), rec, settings); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if path != "/f2b-2026.03.01/_doc" || doc["@timestamp"] != "2026-03-01T23:59:30Z" {
+		t.Fatalf("a retried alert must be indexed at its occurrence, got %s %v", path, doc["@timestamp"])
+	}
+}
+
+// Alerts are queued before the country is known; the worker applies the filter.
+func TestDeliverOutboxAlertEnrichesPendingEvent(t *testing.T) {
+	var delivered []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		delivered = append(delivered, payload)
+	}))
+	defer srv.Close()
+
+	settings := config.AppSettings{
+		Webhook:           config.WebhookSettings{URL: srv.URL},
+		GeoIPProvider:     "maxmind",
+		GeoIPDatabasePath: filepath.Join(t.TempDir(), "missing.mmdb"),
+	}
+	payload := `{"type":"ban","ip":"203.0.113.7","jail":"sshd","whois":"country: DE\n","enrichPending":true}`
+	for _, countries := range []string{`["CH"]`, `["DE"]`} {
+		rec := storage.AlertOutboxRecord{ID: 3, Channel: `{"id":"ops","enabled":true,"provider":"webhook","countries":` + countries + `}`, Payload: payload}
+		if err := deliverOutboxAlert(t.Context(), rec, settings); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+	}
+	if len(delivered) != 1 || delivered[0]["country"] != "DE" {
+		t.Fatalf("expected only the DE channel to get the alert with its country, got %v", delivered)
+	}
+}
+
+// Server alerts keep the outbox entry ID as their delivery ID, so retries are signed with it.
+func TestDeliverOutboxAlertSignsServerAlertWithEntryID(t *testing.T) {
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	settings := config.AppSettings{Webhook: config.WebhookSettings{URL: srv.URL, Secret: "s3cret"}}
+	rec := storage.AlertOutboxRecord{ID: 4711, Channel: `{"id":"ops","provider":"webhook"}`,
+		Payload: `{"type":"server_down","serverId":"web1","serverName":"web1","detail":"ssh: timeout","occurredAt":"2026-03-01T12:00:00Z"}`}
+	if err := deliverOutboxAlert(t.Context(), rec, settings); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if header.Get(webhookDeliveryHeader) != "4711" {
+		t.Fatalf("expected the outbox entry ID as delivery ID, got %q", header.Get(webhookDeliveryHeader))
+	}
+	timestamp := header.Get(webhookTimestampHeader)
+	if header.Get(webhookSignatureHeader) != webhookSignature("s3cret", timestamp, "4711", body) {
+		t.Fatalf("signature does not cover the delivery ID 4711")
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil || payload["timestamp"] != "2026-03-01T12:00:00Z" {
+		t.Fatalf("expected the queued event's time in the payload, got %s", body)
+	}
+}
@@ -0,0 +1,340 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	// Upper bound for every throttle setting (one week).
+	alertThrottleMaxMinutes = 7 * 24 * 60
+	// Window used when a cap is set without one.
+	alertThrottleDefaultWindow = 60
+	// Entries listed per table in a digest.
+	alertDigestTopN = 10
+	// IPs read per server and jail when building a digest.
+	alertDigestIPLimit = 10000
+)
+
+// What a channel has sent and held back recently.
+type alertThrottleState struct {
+	sent        []time.Time          // alerts sent within the rate window
+	seen        map[string]time.Time // last alert per event type, IP and jail
+	overflow    int                  // alerts over the cap since periodStart
+	duplicates  int                  // alerts skipped as duplicates since periodStart
+	periodStart time.Time            // first alert over the cap; zero when no digest is pending
+}
+
+var alertThrottles = struct {
+	sync.Mutex
+	channels map[string]*alertThrottleState
+}{channels: make(map[string]*alertThrottleState)}
+
+// Summary of the bans in a period, sent in place of the alerts a channel held back.
+type alertDigest struct {
+	Since        time.Time          `json:"since"`
+	Until        time.Time          `json:"until"`
+	Bans         int64              `json:"bans"`
+	Suppressed   int                `json:"suppressed"`
+	Duplicates   int                `json:"duplicates"`
+	TopIPs       []alertDigestEntry `json:"topIps"`
+	TopJails     []alertDigestEntry `json:"topJails"`
+	TopCountries []alertDigestEntry `json:"topCountries"`
+	TopServers   []alertDigestEntry `json:"topServers"`
+}
+
+type alertDigestEntry struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// =========================================================================
+//  Throttling
+// =========================================================================
+
+// Reports whether the channel may send the event now. Events without an IP
+// (server health alerts) are never throttled.
+func throttleAlert(ch config.AlertChannel, ev alertEvent, now time.Time) bool {
+	t := ch.Throttle
+	if ev.IP == "" || (t.DedupeMinutes <= 0 && t.MaxAlerts <= 0) {
+		return true
+	}
+	alertThrottles.Lock()
+	defer alertThrottles.Unlock()
+	st := alertThrottles.channels[ch.ID]
+	if st == nil {
+		st = &alertThrottleState{seen: make(map[string]time.Time)}
+		alertThrottles.channels[ch.ID] = st
+	}
+	return st.allow(t, ev, now)
+}
+
+// Records the event and reports whether it is within the channel's limits.
+func (st *alertThrottleState) allow(t config.AlertThrottle, ev alertEvent, now time.Time) bool {
+	key := ev.Type + "|" + ev.IP + "|" + ev.Jail
+	if t.DedupeMinutes > 0 {
+		window := time.Duration(t.DedupeMinutes) * time.Minute
+		if last, ok := st.seen[key]; ok && now.Sub(last) < window {
+			st.duplicates++
+			return false
+		}
+		if len(st.seen) > 1000 {
+			maps.DeleteFunc(st.seen, func(_ string, at time.Time) bool { return now.Sub(at) >= window })
+		}
+	}
+	if t.MaxAlerts > 0 {
+		cutoff := now.Add(-time.Duration(t.WindowMinutes) * time.Minute)
+		st.sent = slices.DeleteFunc(st.sent, func(at time.Time) bool { return !at.After(cutoff) })
+		if len(st.sent) >= t.MaxAlerts {
+			st.overflow++
+			if st.periodStart.IsZero() {
+				st.periodStart = now
+			}
+			return false
+		}
+		st.sent = append(st.sent, now)
+	}
+	if t.DedupeMinutes > 0 {
+		st.seen[key] = now
+	}
+	return true
+}
+
+// Checks the throttle settings and fills in the default window.
+func normalizeAlertThrottle(t *config.AlertThrottle) error {
+	for _, v := range []int{t.DedupeMinutes, t.MaxAlerts, t.WindowMinutes, t.DigestMinutes} {
+		if v < 0 {
+			return errors.New("throttle values must not be negative")
+		}
+	}
+	if t.DedupeMinutes > alertThrottleMaxMinutes || t.WindowMinutes > alertThrottleMaxMinutes || t.DigestMinutes > alertThrottleMaxMinutes {
+		return fmt.Errorf("throttle periods must not exceed %d minutes", alertThrottleMaxMinutes)
+	}
+	if t.MaxAlerts == 0 {
+		t.WindowMinutes = 0
+		t.DigestMinutes = 0
+	} else if t.WindowMinutes == 0 {
+		t.WindowMinutes = alertThrottleDefaultWindow
+	}
+	return nil
+}
+
+// =========================================================================
+//  Digests
+// =========================================================================
+
+type pendingAlertDigest struct {
+	channel    config.AlertChannel
+	since      time.Time
+	overflow   int
+	duplicates int
+}
+
+// Takes the digests that are due and resets their counters. State of channels
+// that were removed or no longer throttle is dropped.
+func takeDueAlertDigests(channels []config.AlertChannel, now time.Time) []pendingAlertDigest {
+	alertThrottles.Lock()
+	defer alertThrottles.Unlock()
+	byID := make(map[string]config.AlertChannel, len(channels))
+	for _, ch := range channels {
+		byID[ch.ID] = ch
+	}
+	var due []pendingAlertDigest
+	for id, st := range alertThrottles.channels {
+		ch, ok := byID[id]
+		if !ok || (ch.Throttle.DedupeMinutes <= 0 && ch.Throttle.MaxAlerts <= 0) {
+			delete(alertThrottles.channels, id)
+			continue
+		}
+		// Without a digest the overflow is only logged, once per rate window.
+		period := ch.Throttle.DigestMinutes
+		if period <= 0 {
+			period = ch.Throttle.WindowMinutes
+		}
+		if st.periodStart.IsZero() || now.Sub(st.periodStart) < time.Duration(period)*time.Minute {
+			continue
+		}
+		if ch.Throttle.DigestMinutes > 0 {
+			due = append(due, pendingAlertDigest{channel: ch, since: st.periodStart, overflow: st.overflow, duplicates: st.duplicates})
+		} else {
+			log.Printf("Alert channel %s held back %d alerts over its limit since %s", alertChannelLabel(ch), st.overflow, st.periodStart.Format(time.RFC3339))
+		}
+		st.overflow, st.duplicates, st.periodStart = 0, 0, time.Time{}
+	}
+	return due
+}
+
+// Builds and queues the digests that are due.
+func queueAlertDigests(ctx context.Context, now time.Time) {
+	settings := config.GetSettings()
+	for _, d := range takeDueAlertDigests(effectiveAlertChannels(settings), now) {
+		digest, err := buildAlertDigest(ctx, d.channel, d.since, now)
+		if err != nil {
+			log.Printf("warning: failed to build alert digest for %s: %v", alertChannelLabel(d.channel), err)
+			continue
+		}
+		digest.Suppressed, digest.Duplicates = d.overflow, d.duplicates
+		ev := alertEvent{Type: "digest", Digest: &digest, OccurredAt: now.UTC()}
+		if err := enqueueAlert(ctx, d.channel, ev); err != nil {
+			log.Printf("warning: failed to queue alert digest for %s: %v", alertChannelLabel(d.channel), err)
+		}
+	}
+}
+
+// Summarises the bans since the given time that match the channel's server,
+// jail and country filters.
+func buildAlertDigest(ctx context.Context, ch config.AlertChannel, since, until time.Time) (alertDigest, error) {
+	digest := alertDigest{Since: since.UTC(), Until: until.UTC()}
+	servers, err := storage.CountBanEventsByServer(ctx, since)
+	if err != nil {
+		return digest, err
+	}
+	ips := make(map[string]int64)
+	jails := make(map[string]int64)
+	countries := make(map[string]int64)
+	serverTotals := make(map[string]int64)
+	for _, serverID := range slices.Sorted(maps.Keys(servers)) {
+		if len(ch.Servers) > 0 && !slices.Contains(ch.Servers, serverID) {
+			continue
+		}
+		serverName := serverID
+		if srv, ok := config.GetServerByID(serverID); ok && srv.Name != "" {
+			serverName = srv.Name
+		}
+		byJail, err := storage.CountRecentBanEventsByJail(ctx, serverID, since)
+		if err != nil {
+			return digest, err
+		}
+		for jail := range byJail {
+			if len(ch.Jails) > 0 && !slices.Contains(ch.Jails, jail) {
+				continue
+			}
+			stats, _, err := storage.ListBanEventIPs(ctx, storage.BanEventFilter{ServerID: serverID, Jail: jail, Since: since, Until: until}, alertDigestIPLimit)
+			if err != nil {
+				return digest, err
+			}
+			for _, stat := range stats {
+				if !shouldAlertForCountry(stat.Country, ch.Countries) {
+					continue
+				}
+				country := strings.ToUpper(stat.Country)
+				if country == "" {
+					country = "unknown"
+				}
+				ips[stat.IP] += stat.Count
+				jails[jail] += stat.Count
+				countries[country] += stat.Count
+				serverTotals[serverName] += stat.Count
+				digest.Bans += stat.Count
+			}
+		}
+	}
+	digest.TopIPs = topAlertDigestEntries(ips)
+	digest.TopJails = topAlertDigestEntries(jails)
+	digest.TopCountries = topAlertDigestEntries(countries)
+	digest.TopServers = topAlertDigestEntries(serverTotals)
+	return digest, nil
+}
+
+// Returns the largest counts, highest first.
+func topAlertDigestEntries(counts map[string]int64) []alertDigestEntry {
+	entries := make([]alertDigestEntry, 0, len(counts))
+	for name, count := range counts {
+		entries = append(entries, alertDigestEntry{Name: name, Count: count})
+	}
+	slices.SortFunc(entries, func(a, b alertDigestEntry) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Name, b.Name))
+	})
+	if len(entries) > alertDigestTopN {
+		entries = entries[:alertDigestTopN]
+	}
+	return entries
+}
+
+func formatAlertDigestEntries(entries []alertDigestEntry) string {
+	parts := make([]string, 0, len(entries))
+	for _, e := range entries {
+		parts = append(parts, fmt.Sprintf("%s (%d)", e.Name, e.Count))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func deliverDigestAlert(ctx context.Context, provider string, ev alertEvent, settings config.AppSettings) error {
+	d := ev.Digest
+	if d == nil {
+		return errors.New("digest alert without summary")
+	}
+	switch provider {
+	case "webhook":
+		return postWebhook(ctx, ev, settings)
+	case "elasticsearch":
+		return indexElasticsearchDocument(ctx, ev.OccurredAt, map[string]interface{}{
+			"event.kind":      "metric",
+			"event.type":      ev.Type,
+			"event.start":     d.Since.Format(time.RFC3339),
+			"event.end":       d.Until.Format(time.RFC3339),
+			"fail2ban.digest": d,
+			"message":         fmt.Sprintf("%d bans, %d alerts suppressed", d.Bans, d.Suppressed),
+		}, settings)
+	default:
+		lang := settings.Language
+		if lang == "" {
+			lang = "en"
+		}
+		title := getEmailTranslation(lang, "email.digest.title")
+		subject := fmt.Sprintf("[Fail2Ban-UI] %s: %d", title, d.Bans)
+		details := []emailDetail{
+			{Label: getEmailTranslation(lang, "email.digest.details.period"), Value: d.Since.Format(time.RFC3339) + " - " + d.Until.Format(time.RFC3339)},
+			{Label: getEmailTranslation(lang, "email.digest.details.bans"), Value: fmt.Sprint(d.Bans)},
+			{Label: getEmailTranslation(lang, "email.digest.details.suppressed"), Value: fmt.Sprint(d.Suppressed)},
+			{Label: getEmailTranslation(lang, "email.digest.details.duplicates"), Value: fmt.Sprint(d.Duplicates)},
+			{Label: getEmailTranslation(lang, "email.digest.details.top_ips"), Value: formatAlertDigestEntries(d.TopIPs)},
+			{Label: getEmailTranslation(lang, "email.digest.details.top_jails"), Value: formatAlertDigestEntries(d.TopJails)},
+			{Label: getEmailTranslation(lang, "email.digest.details.top_countries"), Value: formatAlertDigestEntries(d.TopCountries)},
+			{Label: getEmailTranslation(lang, "email.digest.details.top_servers"), Value: formatAlertDigestEntries(d.TopServers)},
+		}
+		intro := getEmailTranslation(lang, "email.digest.intro")
+		footerText := getEmailTranslation(lang, "email.footer.text")
+		var body string
+		if getEmailStyle() == "modern" {
+			body = buildModernEmailBody(title, intro, details, "", "", "", "", footerText)
+		} else {
+			body = buildClassicEmailBody(title, intro, details, "", "", "", "", footerText, "support@swissmakers.ch")
+		}
+		return sendEmail(ctx, settings.Destemail, subject, body, settings)
+	}
+}
@@ -0,0 +1,113 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+)
+
+func TestAlertThrottleStateAllow(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	limits := config.AlertThrottle{DedupeMinutes: 10, MaxAlerts: 2, WindowMinutes: 60}
+	st := &alertThrottleState{seen: make(map[string]time.Time)}
+	ban := func(ip string) alertEvent { return alertEvent{Type: "ban", IP: ip, Jail: "sshd"} }
+
+	if !st.allow(limits, ban("192.0.2.1"), now) {
+		t.Fatal("first alert must pass")
+	}
+	if st.allow(limits, ban("192.0.2.1"), now.Add(5*time.Minute)) {
+		t.Fatal("same IP and jail within the dedupe window must be skipped")
+	}
+	if !st.allow(limits, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "nginx"}, now.Add(5*time.Minute)) {
+		t.Fatal("another jail is not a duplicate")
+	}
+	if st.allow(limits, ban("192.0.2.2"), now.Add(6*time.Minute)) {
+		t.Fatal("third alert in the window must exceed the cap")
+	}
+	if st.overflow != 1 || st.duplicates != 1 || !st.periodStart.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("unexpected counters: overflow=%d duplicates=%d periodStart=%v", st.overflow, st.duplicates, st.periodStart)
+	}
+	if !st.allow(limits, ban("192.0.2.1"), now.Add(61*time.Minute)) {
+		t.Fatal("alerts must pass again once the window has moved on")
+	}
+}
+
+func TestTakeDueAlertDigests(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ch := config.AlertChannel{ID: "digest-test", Enabled: true, Provider: "email", Throttle: config.AlertThrottle{MaxAlerts: 1, WindowMinutes: 60, DigestMinutes: 30}}
+	t.Cleanup(func() {
+		alertThrottles.Lock()
+		delete(alertThrottles.channels, ch.ID)
+		alertThrottles.Unlock()
+	})
+
+	for i := range 3 {
+		throttleAlert(ch, alertEvent{Type: "ban", IP: "192.0.2.1", Jail: "sshd"}, now.Add(time.Duration(i)*time.Minute))
+	}
+	if due := takeDueAlertDigests([]config.AlertChannel{ch}, now.Add(20*time.Minute)); len(due) != 0 {
+		t.Fatalf("digest must wait for its interval, got %+v", due)
+	}
+	due := takeDueAlertDigests([]config.AlertChannel{ch}, now.Add(31*time.Minute))
+	if len(due) != 1 || due[0].overflow != 2 || !due[0].since.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected one digest covering two held-back alerts, got %+v", due)
+	}
+	if again := takeDueAlertDigests([]config.AlertChannel{ch}, now.Add(62*time.Minute)); len(again) != 0 {
+		t.Fatalf("counters must be reset after a digest, got %+v", again)
+	}
+
+	takeDueAlertDigests(nil, now)
+	alertThrottles.Lock()
+	_, kept := alertThrottles.channels[ch.ID]
+	alertThrottles.Unlock()
+	if kept {
+		t.Fatal("state of removed channels must be dropped")
+	}
+}
+
+func TestNormalizeAlertThrottle(t *testing.T) {
+	th := config.AlertThrottle{MaxAlerts: 5}
+	if err := normalizeAlertThrottle(&th); err != nil || th.WindowMinutes != alertThrottleDefaultWindow {
+		t.Fatalf("expected the default window, got %+v err=%v", th, err)
+	}
+	th = config.AlertThrottle{DedupeMinutes: 5, DigestMinutes: 30}
+	if err := normalizeAlertThrottle(&th); err != nil || th.DigestMinutes != 0 {
+		t.Fatalf("a digest without a cap has nothing to summarise, got %+v err=%v", th, err)
+	}
+	if err := normalizeAlertThrottle(&config.AlertThrottle{MaxAlerts: -1}); err == nil {
+		t.Fatal("expected negative values to be rejected")
+	}
+	if err := normalizeAlertThrottle(&config.AlertThrottle{DedupeMinutes: alertThrottleMaxMinutes + 1}); err == nil {
+		t.Fatal("expected overly long periods to be rejected")
+	}
+}
+
+func TestTopAlertDigestEntries(t *testing.T) {
+	counts := map[string]int64{"b": 3, "a": 3, "c": 5}
+	for i := range alertDigestTopN {
+		counts[string(rune('d'+i))] = 1
+	}
+	top := topAlertDigestEntries(counts)
+	if len(top) != alertDigestTopN || top[0].Name != "c" || top[1].Name != "a" || top[2].Name != "b" {
+		t.Fatalf("unexpected order: %+v", top)
+	}
+	if got := formatAlertDigestEntries(top[:2]); got != "c (5), a (3)" {
+		t.Fatalf("unexpected formatting: %q", got)
+	}
+}
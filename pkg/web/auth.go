@@ -22,6 +22,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/swissmakers/fail2ban-ui/internal/auth"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -54,6 +55,13 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("roles", session.Roles)
 		c.Set("accessLevel", session.AccessLevel)
 
+		// Config revisions written during this request are attributed to the user.
+		author := session.Username
+		if author == "" {
+			author = session.Email
+		}
+		c.Request = c.Request.WithContext(fail2ban.WithRevisionAuthor(c.Request.Context(), author))
+
 		c.Next()
 	}
 }
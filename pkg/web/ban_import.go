@@ -0,0 +1,179 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Ban History Import
+// =========================================================================
+
+const (
+	banImportBatchSize = 500
+	banImportTimeout   = 10 * time.Minute
+	// Callback events are stamped on receipt, a few seconds after fail2ban stored the ban.
+	banImportDedupeWindow = time.Minute
+)
+
+// Progress of a running import, pushed to WebSocket clients as "ban_import_progress".
+type BanImportProgress struct {
+	ServerID   string `json:"serverId"`
+	ServerName string `json:"serverName"`
+	Status     string `json:"status"` // reading, importing, done, error
+	Total      int    `json:"total"`
+	Processed  int    `json:"processed"`
+	Imported   int    `json:"imported"`
+	Error      string `json:"error,omitempty"`
+}
+
+var banImportRunning sync.Map
+
+// Starts importing the server's fail2ban ban history in the background.
+// Returns false if an import for this server is already running.
+func startBanHistoryImport(server config.Fail2banServer) bool {
+	if _, running := banImportRunning.LoadOrStore(server.ID, struct{}{}); running {
+		return false
+	}
+	go func() {
+		defer banImportRunning.Delete(server.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), banImportTimeout)
+		defer cancel()
+		importBanHistory(ctx, server)
+	}()
+	return true
+}
+
+func importBanHistory(ctx context.Context, server config.Fail2banServer) {
+	progress := BanImportProgress{ServerID: server.ID, ServerName: server.Name, Status: "reading"}
+	report := func() {
+		if wsHub != nil {
+			wsHub.BroadcastBanImportProgress(progress)
+		}
+	}
+	fail := func(err error) {
+		log.Printf("warning: ban history import for server %s failed: %v", server.Name, err)
+		progress.Status = "error"
+		progress.Error = err.Error()
+		report()
+	}
+	report()
+
+	conn, err := fail2ban.GetManager().Connector(server.ID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	bans, err := fail2ban.ReadBanHistory(ctx, conn)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Rows the retention job would delete right away are not imported.
+	settings := config.GetSettings()
+	var cutoff time.Time
+	if settings.EventRetentionDays > 0 {
+		cutoff = time.Now().UTC().AddDate(0, 0, -settings.EventRetentionDays)
+	}
+	hostname := server.Hostname
+	if hostname == "" {
+		hostname = server.Host
+	}
+	if hostname == "" {
+		hostname = server.Name
+	}
+
+	countries := make(map[string]string)
+	records := make([]storage.BanEventRecord, 0, len(bans))
+	for _, ban := range bans {
+		if ban.TimeOfBan.Before(cutoff) {
+			continue
+		}
+		country, ok := countries[ban.IP]
+		if !ok {
+			country = resolveCountry(ban.IP, "", settings)
+			countries[ban.IP] = country
+		}
+		failures := ""
+		if ban.Failures > 0 {
+			failures = strconv.Itoa(ban.Failures)
+		}
+		records = append(records, storage.BanEventRecord{
+			ServerID:   server.ID,
+			ServerName: server.Name,
+			Jail:       ban.Jail,
+			IP:         ban.IP,
+			Country:    country,
+			Hostname:   hostname,
+			Failures:   failures,
+			Logs:       filterRelevantLogs(strings.Join(ban.Matches, "\n"), ban.IP, settings.MaxLogLines),
+			EventType:  "ban",
+			OccurredAt: ban.TimeOfBan,
+		})
+	}
+
+	progress.Status = "importing"
+	progress.Total = len(records)
+	report()
+	for start := 0; start < len(records); start += banImportBatchSize {
+		batch := records[start:min(start+banImportBatchSize, len(records))]
+		inserted, err := storage.ImportBanEvents(ctx, batch, banImportDedupeWindow)
+		if err != nil {
+			fail(err)
+			return
+		}
+		progress.Processed += len(batch)
+		progress.Imported += inserted
+		report()
+	}
+
+	progress.Status = "done"
+	report()
+	log.Printf("Imported %d of %d historical bans from server %s", progress.Imported, progress.Total, server.Name)
+}
+
+// Starts a ban history import for the given server.
+func ImportBanHistoryHandler(c *gin.Context) {
+	server, ok := config.GetServerByID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		return
+	}
+	if !server.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "server is disabled"})
+		return
+	}
+	if !startBanHistoryImport(server) {
+		c.JSON(http.StatusConflict, gin.H{"error": "an import for this server is already running"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "ban history import started"})
+}
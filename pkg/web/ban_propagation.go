@@ -0,0 +1,184 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+)
+
+// =========================================================================
+//  Fleet-wide Ban Propagation
+// =========================================================================
+
+const (
+	// An IP is propagated at most once per window, so several hosts banning the
+	// same attacker at once cause a single fan-out.
+	banPropagationCooldown = 10 * time.Minute
+	banPropagationTimeout  = 2 * time.Minute
+)
+
+var (
+	banPropagationMu   sync.Mutex
+	recentPropagations = make(map[string]time.Time)
+)
+
+// Bans ip on the other servers in the background when the jail opted in to propagation.
+func propagateBanAsync(origin config.Fail2banServer, ip, jail string, cfg config.BanPropagationSettings) {
+	if !banPropagationApplies(cfg, jail, ip) || !claimBanPropagation(ip, cfg.TargetJail, time.Now()) {
+		return
+	}
+	go func() {
+		manager := fail2ban.GetManager()
+		candidates := manager.Connectors()
+		if len(cfg.TargetTags) > 0 {
+			candidates = manager.SelectConnectors(nil, cfg.TargetTags)
+		}
+		var targets []fail2ban.Connector
+		for _, conn := range candidates {
+			if conn.Server().ID != origin.ID {
+				targets = append(targets, conn)
+			}
+		}
+		if len(targets) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), banPropagationTimeout)
+		defer cancel()
+		results := executeManualBan(ctx, targets, manualBan{
+			IP:         ip,
+			Jails:      []string{cfg.TargetJail},
+			Reason:     fmt.Sprintf("Propagated from %s (%s)", origin.Name, jail),
+			Propagated: true,
+		})
+		for _, res := range results {
+			if !res.Success {
+				log.Printf("warning: failed to propagate ban of %s from %s to %s: %s", ip, origin.Name, res.ServerName, res.Error)
+			}
+		}
+		config.DebugLog("Propagated ban of %s from %s/%s to %d servers", ip, origin.Name, jail, len(targets))
+	}()
+}
+
+// Reports whether a ban in jail is propagated: the feature is on, the jail opted in
+// and the IP is not allowlisted.
+func banPropagationApplies(cfg config.BanPropagationSettings, jail, ip string) bool {
+	if !cfg.Enabled || cfg.TargetJail == "" {
+		return false
+	}
+	optedIn := false
+	for _, j := range cfg.Jails {
+		if j == jail {
+			optedIn = true
+			break
+		}
+	}
+	return optedIn && !ipAllowlisted(ip, cfg.Allowlist)
+}
+
+// Reports whether ip (an address or range) lies within one of the allowlist entries.
+func ipAllowlisted(ip string, allowlist []string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		if _, network, err := net.ParseCIDR(ip); err == nil {
+			addr = network.IP
+		}
+	}
+	if addr == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if other := net.ParseIP(entry); other != nil && other.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Marks ip as propagated to targetJail; false if that already happened within the cooldown.
+func claimBanPropagation(ip, targetJail string, now time.Time) bool {
+	key := targetJail + "|" + ip
+	banPropagationMu.Lock()
+	defer banPropagationMu.Unlock()
+	for k, at := range recentPropagations {
+		if now.Sub(at) >= banPropagationCooldown {
+			delete(recentPropagations, k)
+		}
+	}
+	if _, recent := recentPropagations[key]; recent {
+		return false
+	}
+	recentPropagations[key] = now
+	return true
+}
+
+// Validates the propagation settings of a settings update.
+func normalizeBanPropagationSettings(cfg *config.BanPropagationSettings) error {
+	cfg.TargetJail = strings.TrimSpace(cfg.TargetJail)
+	cfg.Jails = trimmedUnique(cfg.Jails)
+	cfg.TargetTags = trimmedUnique(cfg.TargetTags)
+	cfg.Allowlist = trimmedUnique(cfg.Allowlist)
+	for _, entry := range cfg.Allowlist {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("ban propagation allowlist: %q is not an IP address or CIDR range", entry)
+			}
+		}
+	}
+	for _, jail := range cfg.Jails {
+		if err := fail2ban.ValidateJailName(jail); err != nil {
+			return fmt.Errorf("ban propagation: %w", err)
+		}
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TargetJail == "" {
+		return fmt.Errorf("ban propagation needs a target jail")
+	}
+	if err := fail2ban.ValidateJailName(cfg.TargetJail); err != nil {
+		return fmt.Errorf("ban propagation target jail: %w", err)
+	}
+	if len(cfg.Jails) == 0 {
+		return fmt.Errorf("ban propagation needs at least one source jail")
+	}
+	return nil
+}
+
+func trimmedUnique(values []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+)
+
+func TestBanPropagationApplies(t *testing.T) {
+	cfg := config.BanPropagationSettings{
+		Enabled:    true,
+		Jails:      []string{"sshd"},
+		TargetJail: "recidive",
+		Allowlist:  []string{"10.0.0.0/8", "192.0.2.10"},
+	}
+	tests := []struct {
+		name string
+		jail string
+		ip   string
+		want bool
+	}{
+		{"opted-in jail", "sshd", "198.51.100.1", true},
+		{"other jail", "nginx-http-auth", "198.51.100.1", false},
+		{"allowlisted range", "sshd", "10.1.2.3", false},
+		{"allowlisted address", "sshd", "192.0.2.10", false},
+		{"banned range inside allowlist", "sshd", "10.20.0.0/16", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := banPropagationApplies(cfg, tt.jail, tt.ip); got != tt.want {
+				t.Fatalf("banPropagationApplies(%q, %q) = %v, want %v", tt.jail, tt.ip, got, tt.want)
+			}
+		})
+	}
+	cfg.Enabled = false
+	if banPropagationApplies(cfg, "sshd", "198.51.100.1") {
+		t.Fatalf("disabled propagation must not apply")
+	}
+}
+
+func TestClaimBanPropagationCooldown(t *testing.T) {
+	now := time.Now()
+	if !claimBanPropagation("198.51.100.7", "recidive", now) {
+		t.Fatalf("first propagation must be claimed")
+	}
+	if claimBanPropagation("198.51.100.7", "recidive", now.Add(time.Minute)) {
+		t.Fatalf("repeat within the cooldown must be refused")
+	}
+	if !claimBanPropagation("198.51.100.7", "sshd", now.Add(time.Minute)) {
+		t.Fatalf("another target jail is claimed separately")
+	}
+	if !claimBanPropagation("198.51.100.7", "recidive", now.Add(banPropagationCooldown)) {
+		t.Fatalf("propagation must be possible again after the cooldown")
+	}
+}
+
+func TestNormalizeBanPropagationSettings(t *testing.T) {
+	cfg := config.BanPropagationSettings{
+		Enabled:    true,
+		Jails:      []string{" sshd ", "sshd", ""},
+		TargetJail: " recidive ",
+		TargetTags: []string{"prod", " prod"},
+		Allowlist:  []string{"10.0.0.0/8"},
+	}
+	if err := normalizeBanPropagationSettings(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Jails) != 1 || cfg.TargetJail != "recidive" || len(cfg.TargetTags) != 1 {
+		t.Fatalf("settings not normalized: %+v", cfg)
+	}
+
+	invalid := []config.BanPropagationSettings{
+		{Enabled: true, Jails: []string{"sshd"}},
+		{Enabled: true, TargetJail: "recidive"},
+		{Allowlist: []string{"not-an-ip"}},
+	}
+	for _, c := range invalid {
+		if err := normalizeBanPropagationSettings(&c); err == nil {
+			t.Errorf("expected %+v to be rejected", c)
+		}
+	}
+}
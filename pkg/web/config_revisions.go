@@ -0,0 +1,164 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Config Revisions
+// =========================================================================
+
+// Lists recorded revisions of the selected server's jail, filter and action files, newest first.
+// Optional query parameters: kind (jail, filter or action), name and limit.
+func ListConfigRevisionsHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListConfigRevisionsHandler called (config_revisions.go)")
+	kind := c.Query("kind")
+	if kind != "" && kind != fail2ban.ConfigKindJail && kind != fail2ban.ConfigKindFilter && kind != fail2ban.ConfigKindAction {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be jail, filter or action"})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	revisions, err := storage.ListConfigRevisions(c.Request.Context(), conn.Server().ID, kind, c.Query("name"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if revisions == nil {
+		revisions = []storage.ConfigRevisionRecord{}
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// Returns a unified diff from a revision to another revision ("to" query parameter)
+// or, by default, to the file as it currently is on the server.
+func DiffConfigRevisionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("DiffConfigRevisionHandler called (config_revisions.go)")
+	ctx := c.Request.Context()
+	from, status, err := loadConfigRevision(c, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var toContent, toName string
+	to := c.DefaultQuery("to", "current")
+	if to == "current" {
+		conn, err := fail2ban.GetManager().Connector(from.ServerID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		content, path, exists := fail2ban.CurrentConfigContent(ctx, conn, from.Kind, from.Name)
+		if !exists {
+			path = from.Path
+		}
+		toContent, toName = content, path+" (current)"
+	} else {
+		rev, status, err := loadConfigRevision(c, to)
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if rev.ServerID != from.ServerID || rev.Kind != from.Kind || rev.Name != from.Name {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("revisions %d and %d belong to different files", from.ID, rev.ID)})
+			return
+		}
+		toContent, toName = rev.Content, fmt.Sprintf("%s (revision %d)", rev.Path, rev.ID)
+	}
+	fromName := fmt.Sprintf("%s (revision %d)", from.Path, from.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.ID,
+		"to":   to,
+		"diff": fail2ban.UnifiedDiff(fromName, toName, from.Content, toContent),
+	})
+}
+
+// Writes a revision back to its server. With "reload" fail2ban is reloaded afterwards.
+func RollbackConfigRevisionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("RollbackConfigRevisionHandler called (config_revisions.go)")
+	var req struct {
+		Reload bool `json:"reload"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+			return
+		}
+	}
+	rev, status, err := loadConfigRevision(c, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := fail2ban.GetManager().Connector(rev.ServerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+	if err := fail2ban.RestoreConfigRevision(ctx, conn, fail2ban.ConfigRevision{
+		ServerID: rev.ServerID,
+		Kind:     rev.Kind,
+		Name:     rev.Name,
+		Path:     rev.Path,
+		Content:  rev.Content,
+		Exists:   rev.Exists,
+	}); err != nil {
+		respondConfigWriteError(c, "failed to roll back: ", err)
+		return
+	}
+	if req.Reload {
+		if err := conn.Reload(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "rolled back, but fail2ban reload failed: " + err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s %s rolled back to revision %d", rev.Kind, rev.Name, rev.ID), "reloaded": req.Reload})
+}
+
+func loadConfigRevision(c *gin.Context, rawID string) (storage.ConfigRevisionRecord, int, error) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id <= 0 {
+		return storage.ConfigRevisionRecord{}, http.StatusBadRequest, fmt.Errorf("invalid revision id %q", rawID)
+	}
+	rev, found, err := storage.GetConfigRevision(c.Request.Context(), id)
+	if err != nil {
+		return storage.ConfigRevisionRecord{}, http.StatusInternalServerError, err
+	}
+	if !found {
+		return storage.ConfigRevisionRecord{}, http.StatusNotFound, fmt.Errorf("revision %d not found", id)
+	}
+	return rev, http.StatusOK, nil
+}
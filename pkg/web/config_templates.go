@@ -0,0 +1,247 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Fleet Templates
+// =========================================================================
+
+// Template as exchanged with the frontend.
+type ConfigTemplatePayload struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	fail2ban.ConfigTemplate
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Servers a template is applied to or checked against: explicit IDs plus every server carrying one of the tags.
+type templateSelection struct {
+	ServerIDs []string `json:"serverIds"`
+	Tags      []string `json:"tags"`
+}
+
+func templateFromRecord(rec storage.ConfigTemplateRecord) ConfigTemplatePayload {
+	tpl := ConfigTemplatePayload{
+		ID:          rec.ID,
+		Name:        rec.Name,
+		Description: rec.Description,
+		ConfigTemplate: fail2ban.ConfigTemplate{
+			JailName:      rec.JailName,
+			JailContent:   rec.JailContent,
+			FilterName:    rec.FilterName,
+			FilterContent: rec.FilterContent,
+			Parameters:    map[string]string{},
+		},
+		CreatedAt: rec.CreatedAt,
+		UpdatedAt: rec.UpdatedAt,
+	}
+	if rec.ParametersJSON != "" {
+		_ = json.Unmarshal([]byte(rec.ParametersJSON), &tpl.Parameters)
+	}
+	return tpl
+}
+
+func loadConfigTemplate(c *gin.Context) (ConfigTemplatePayload, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return ConfigTemplatePayload{}, false
+	}
+	rec, found, err := storage.GetConfigTemplate(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return ConfigTemplatePayload{}, false
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return ConfigTemplatePayload{}, false
+	}
+	return templateFromRecord(rec), true
+}
+
+// Lists all templates.
+func ListConfigTemplatesHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListConfigTemplatesHandler called (config_templates.go)")
+	records, err := storage.ListConfigTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	templates := make([]ConfigTemplatePayload, 0, len(records))
+	for _, rec := range records {
+		templates = append(templates, templateFromRecord(rec))
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// Creates a template, or updates it when the payload carries an ID.
+func UpsertConfigTemplateHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("UpsertConfigTemplateHandler called (config_templates.go)")
+	var req ConfigTemplatePayload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.JailName = strings.TrimSpace(req.JailName)
+	req.FilterName = strings.TrimSpace(req.FilterName)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "template name is required"})
+		return
+	}
+	if err := req.ConfigTemplate.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Parameters == nil {
+		req.Parameters = map[string]string{}
+	}
+	params, _ := json.Marshal(req.Parameters)
+
+	id, err := storage.SaveConfigTemplate(c.Request.Context(), storage.ConfigTemplateRecord{
+		ID:             req.ID,
+		Name:           req.Name,
+		Description:    strings.TrimSpace(req.Description),
+		JailName:       req.JailName,
+		JailContent:    req.JailContent,
+		FilterName:     req.FilterName,
+		FilterContent:  req.FilterContent,
+		ParametersJSON: string(params),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		case strings.Contains(err.Error(), "UNIQUE"):
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("a template named %q already exists", req.Name)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	rec, _, err := storage.GetConfigTemplate(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"template": templateFromRecord(rec)})
+}
+
+// Deletes a template. Servers it was applied to keep their files.
+func DeleteConfigTemplateHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("DeleteConfigTemplateHandler called (config_templates.go)")
+	tpl, ok := loadConfigTemplate(c)
+	if !ok {
+		return
+	}
+	if err := storage.DeleteConfigTemplate(c.Request.Context(), tpl.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "template deleted"})
+}
+
+// Writes a template to the selected servers in parallel and reports the result per server.
+func ApplyConfigTemplateHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ApplyConfigTemplateHandler called (config_templates.go)")
+	tpl, ok := loadConfigTemplate(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		templateSelection
+		Reload bool `json:"reload"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	if len(req.ServerIDs) == 0 && len(req.Tags) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "select at least one server or tag"})
+		return
+	}
+	conns := fail2ban.GetManager().SelectConnectors(req.ServerIDs, req.Tags)
+	if len(conns) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no enabled server matches the selection"})
+		return
+	}
+	// Writes outlive a closed browser tab; the revision author is kept.
+	ctx := context.WithoutCancel(c.Request.Context())
+	results := fail2ban.ApplyConfigTemplateToAll(ctx, conns, tpl.ConfigTemplate, req.Reload)
+	failed := 0
+	for _, res := range results {
+		if !res.Success {
+			failed++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results, "applied": len(results) - failed, "failed": failed})
+}
+
+// Compares each selected server (all enabled servers by default) with the template.
+func ConfigTemplateDriftHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ConfigTemplateDriftHandler called (config_templates.go)")
+	tpl, ok := loadConfigTemplate(c)
+	if !ok {
+		return
+	}
+	sel := templateSelection{
+		ServerIDs: splitQueryList(c.Query("serverIds")),
+		Tags:      splitQueryList(c.Query("tags")),
+	}
+	manager := fail2ban.GetManager()
+	conns := manager.Connectors()
+	if len(sel.ServerIDs) > 0 || len(sel.Tags) > 0 {
+		conns = manager.SelectConnectors(sel.ServerIDs, sel.Tags)
+	}
+	results := fail2ban.CheckConfigTemplateDriftAll(c.Request.Context(), conns, tpl.ConfigTemplate)
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func splitQueryList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Pull Mode Event Collection
+// =========================================================================
+
+// How often servers in pull mode are polled, and how long one poll may take.
+const (
+	eventPullInterval = 30 * time.Second
+	eventPullTimeout  = 25 * time.Second
+)
+
+var eventPullInFlight sync.Map
+
+// Polls every enabled server in pull mode until ctx is cancelled.
+func RunEventPullLoop(ctx context.Context) {
+	ticker := time.NewTicker(eventPullInterval)
+	defer ticker.Stop()
+	for {
+		pullAllServerEvents(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func pullAllServerEvents(ctx context.Context) {
+	manager := fail2ban.GetManager()
+	for _, server := range config.ListServers() {
+		if !server.Enabled || server.EventMode != "pull" {
+			continue
+		}
+		conn, err := manager.Connector(server.ID)
+		if err != nil {
+			continue
+		}
+		// A slow host must not stack up polls behind itself.
+		if _, busy := eventPullInFlight.LoadOrStore(server.ID, struct{}{}); busy {
+			continue
+		}
+		go func(server config.Fail2banServer, conn fail2ban.Connector) {
+			defer eventPullInFlight.Delete(server.ID)
+			pullCtx, cancel := context.WithTimeout(ctx, eventPullTimeout)
+			defer cancel()
+			if err := pullServerEvents(pullCtx, server, conn); err != nil {
+				log.Printf("warning: failed to pull events from server %s: %v", server.Name, err)
+			}
+		}(server, conn)
+	}
+}
+
+// Reads the server's fail2ban log past the stored cursor and feeds new events into the notification pipeline.
+func pullServerEvents(ctx context.Context, server config.Fail2banServer, conn fail2ban.Connector) error {
+	stored, _, err := storage.GetEventPullCursor(ctx, server.ID)
+	if err != nil {
+		return err
+	}
+	cursor := fail2ban.EventCursor{Path: stored.Path, Offset: stored.Offset}
+	events, next, err := fail2ban.PullEvents(ctx, conn, cursor)
+	if err != nil {
+		return err
+	}
+
+	hostname := server.Hostname
+	if hostname == "" {
+		hostname = server.Host
+	}
+	if hostname == "" {
+		hostname = server.Name
+	}
+	for _, ev := range events {
+		switch ev.Type {
+		case "ban":
+			failures := ""
+			if ev.Failures > 0 {
+				failures = strconv.Itoa(ev.Failures)
+			}
+			err = handleBanNotificationAt(ctx, server, ev.IP, ev.Jail, hostname, failures, "", strings.Join(ev.Logs, "\n"), ev.OccurredAt)
+		case "unban":
+			err = handleUnbanNotificationAt(ctx, server, ev.IP, ev.Jail, hostname, "", "", ev.OccurredAt)
+		}
+		if err != nil {
+			log.Printf("warning: failed to process pulled %s event for %s on %s: %v", ev.Type, ev.IP, server.Name, err)
+		}
+	}
+	if len(events) > 0 {
+		config.DebugLog("Pulled %d events from server %s (offset %d -> %d)", len(events), server.Name, cursor.Offset, next.Offset)
+	}
+	if next == cursor {
+		return nil
+	}
+	return storage.SaveEventPullCursor(ctx, storage.EventPullCursor{
+		ServerID: server.ID,
+		Path:     next.Path,
+		Offset:   next.Offset,
+	})
+}
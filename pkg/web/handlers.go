@@ -170,6 +170,22 @@ func resolveConnector(c *gin.Context) (fail2ban.Connector, error) {
 	return manager.DefaultConnector()
 }
 
+// Responds to a failed jail or filter write. Writes refused by the fail2ban
+// config test return 422 with the line-level issues under "validation"; writes
+// that could not be tested return 503 with "untested" set.
+func respondConfigWriteError(c *gin.Context, prefix string, err error) {
+	var validationErr *fail2ban.ConfigValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": prefix + err.Error(), "validation": validationErr})
+		return
+	}
+	if errors.Is(err, fail2ban.ErrConfigNotTested) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": prefix + err.Error(), "untested": true})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
+}
+
 // Resolves a server by ID, hostname, or falls back to default.
 func resolveServerForNotification(serverID, hostname string) (config.Fail2banServer, error) {
 	if serverID != "" {
@@ -262,8 +278,29 @@ func SummaryHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// Returns the fail2ban-client status of one jail: failure and ban counters, log files or journal matches and banned IPs.
+func JailStatusHandler(c *gin.Context) {
+	jail := c.Param("jail")
+	if err := fail2ban.ValidateJailName(jail); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status, err := conn.GetJailStatus(c.Request.Context(), jail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
 // Searches all servers and jails for a live ban of the given IP via
 // fail2ban-client, unlike the dashboard which only searches stored ban events.
+// Each match carries the ban window when the server reports it.
 func SearchBannedIPHandler(c *gin.Context) {
 	ip := c.Param("ip")
 	if err := integrations.ValidateIP(ip); err != nil {
@@ -275,6 +312,7 @@ func SearchBannedIPHandler(c *gin.Context) {
 		ServerID   string `json:"serverId"`
 		ServerName string `json:"serverName"`
 		Jail       string `json:"jail"`
+		fail2ban.BannedIP
 	}
 	type serverError struct {
 		ServerID   string `json:"serverId"`
@@ -309,13 +347,22 @@ func SearchBannedIPHandler(c *gin.Context) {
 				if info.TotalBanned == 0 {
 					continue
 				}
+				// The status list is cheap; ban windows are read from the ban
+				// database, so fetch them only for the jails that hold the IP.
+				status, err := conn.GetJailStatus(ctx, info.JailName)
+				if err != nil || !slices.Contains(status.BannedIPs, ip) {
+					continue
+				}
 				banned, err := conn.GetBannedIPs(ctx, info.JailName)
 				if err != nil {
 					continue
 				}
-				if slices.Contains(banned, ip) {
+				for _, entry := range banned {
+					if entry.IP != ip {
+						continue
+					}
 					mu.Lock()
-					matches = append(matches, jailMatch{ServerID: server.ID, ServerName: server.Name, Jail: info.JailName})
+					matches = append(matches, jailMatch{ServerID: server.ID, ServerName: server.Name, Jail: info.JailName, BannedIP: entry})
 					mu.Unlock()
 				}
 			}
@@ -338,7 +385,7 @@ func SearchBannedIPHandler(c *gin.Context) {
 	})
 }
 
-// Returns paginated banned IPs for a specific jail on the selected server.
+// Returns paginated banned IPs for a specific jail on the selected server, with ban start, expiry and ban count where known.
 func ListJailBannedIPsHandler(c *gin.Context) {
 	jail := c.Param("jail")
 	if err := fail2ban.ValidateJailName(jail); err != nil {
@@ -390,9 +437,9 @@ func ListJailBannedIPsHandler(c *gin.Context) {
 	filtered := allIPs
 	if query != "" {
 		lowerQuery := strings.ToLower(query)
-		filtered = make([]string, 0, len(allIPs))
+		filtered = make([]fail2ban.BannedIP, 0, len(allIPs))
 		for _, ip := range allIPs {
-			if strings.Contains(strings.ToLower(ip), lowerQuery) {
+			if strings.Contains(strings.ToLower(ip.IP), lowerQuery) {
 				filtered = append(filtered, ip)
 			}
 		}
@@ -436,20 +483,47 @@ func BanIPHandler(c *gin.Context) {
 		return
 	}
 
+	// Optional body: {"duration": "2h", "reason": "..."}
+	var body struct {
+		Duration string `json:"duration"`
+		Reason   string `json:"reason"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+			return
+		}
+	}
+	ban, err := normalizeManualBan(manualBanRequest{IP: ip, Jails: []string{jail}, Duration: body.Duration, Reason: body.Reason})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ban.Actor = requestActor(c)
+
 	conn, err := resolveConnector(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, buildErrorResponse(err, "dashboard.manual_block.error"))
 		return
 	}
 
-	if err := conn.BanIP(c.Request.Context(), jail, ip); err != nil {
+	server := conn.Server()
+	key := manualBanKey(server.ID, jail, ban.IP)
+	note := registerManualBanNote(key, ban)
+	if err := conn.BanIP(c.Request.Context(), jail, ban.IP); err != nil {
+		dropManualBanNote(key, note)
 		c.JSON(http.StatusInternalServerError, buildErrorResponse(err, "dashboard.manual_block.error"))
 		return
 	}
-	fmt.Println(ip + " in jail " + jail + " banned successfully.")
-	c.JSON(http.StatusOK, gin.H{
+	expiresAt := completeManualBan(context.WithoutCancel(c.Request.Context()), server, jail, ban, note)
+	fmt.Println(ban.IP + " in jail " + jail + " banned successfully.")
+	resp := gin.H{
 		"message": "IP banned successfully",
-	})
+	}
+	if expiresAt != nil {
+		resp["expiresAt"] = expiresAt
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // Unbans a given IP from a specific jail.
@@ -478,6 +552,7 @@ func UnbanIPHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, buildErrorResponse(err, ""))
 		return
 	}
+	cancelManualUnban(c.Request.Context(), conn.Server().ID, jail, ip)
 	fmt.Println(ip + " from jail " + jail + " unbanned successfully.")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "IP unbanned successfully",
@@ -1067,31 +1142,12 @@ func ThreatIntelHandler(c *gin.Context) {
 		})
 		return
 	}
-	requestURL := ""
-	switch provider {
-	case "alienvault":
-		requestURL = "https://otx.alienvault.com/api/v1/indicators/IPv4/" + url.PathEscape(ip) + "/general"
-	case "abuseipdb":
-		requestURL = "https://api.abuseipdb.com/api/v2/check?ipAddress=" + url.QueryEscape(ip) + "&maxAgeInDays=90&verbose=true"
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported threat-intel provider"})
-		return
-	}
-
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, requestURL, nil)
+	req, err := newThreatIntelRequest(c.Request.Context(), provider, ip, settings)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
 		return
 	}
 
-	req.Header.Set("Accept", "application/json")
-	switch provider {
-	case "alienvault":
-		req.Header.Set("X-OTX-API-KEY", strings.TrimSpace(settings.ThreatIntel.AlienVaultAPIKey))
-	case "abuseipdb":
-		req.Header.Set("Key", strings.TrimSpace(settings.ThreatIntel.AbuseIPDBAPIKey))
-	}
-
 	client := newOutboundHTTPClient(12 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
@@ -1166,6 +1222,32 @@ func ThreatIntelHandler(c *gin.Context) {
 	c.Data(resp.StatusCode, "application/json", responseBody)
 }
 
+// Builds the lookup request for an IP at the given threat-intel provider.
+func newThreatIntelRequest(ctx context.Context, provider, ip string, settings config.AppSettings) (*http.Request, error) {
+	requestURL := ""
+	switch provider {
+	case "alienvault":
+		requestURL = "https://otx.alienvault.com/api/v1/indicators/IPv4/" + url.PathEscape(ip) + "/general"
+	case "abuseipdb":
+		requestURL = "https://api.abuseipdb.com/api/v2/check?ipAddress=" + url.QueryEscape(ip) + "&maxAgeInDays=90&verbose=true"
+	default:
+		return nil, fmt.Errorf("unsupported threat-intel provider %q", provider)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	switch provider {
+	case "alienvault":
+		req.Header.Set("X-OTX-API-KEY", strings.TrimSpace(settings.ThreatIntel.AlienVaultAPIKey))
+	case "abuseipdb":
+		req.Header.Set("Key", strings.TrimSpace(settings.ThreatIntel.AbuseIPDBAPIKey))
+	}
+	return req, nil
+}
+
 func parseRetryAfter(value string, fallback time.Duration) time.Duration {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
@@ -1195,10 +1277,64 @@ func parseRetryAfter(value string, fallback time.Duration) time.Duration {
 func ListServersHandler(c *gin.Context) {
 	servers := config.ListServers()
 	masked := maskServerSecrets(servers)
-	if !userHasAdminAccess(c) {
+	admin := userHasAdminAccess(c)
+	if !admin {
 		masked = stripServerConnectionDetails(masked)
 	}
-	c.JSON(http.StatusOK, gin.H{"servers": masked})
+	type serverEntry struct {
+		config.Fail2banServer
+		Capabilities      *fail2ban.ServerCapabilities       `json:"capabilities,omitempty"`
+		EffectiveDefaults *config.EffectiveJailDefaultValues `json:"effectiveDefaults,omitempty"`
+	}
+	manager := fail2ban.GetManager()
+	entries := make([]serverEntry, 0, len(masked))
+	for i, server := range masked {
+		entry := serverEntry{Fail2banServer: server}
+		if admin {
+			defaults := config.EffectiveJailDefaults(servers[i])
+			entry.EffectiveDefaults = &defaults
+		}
+		if caps, ok := fail2ban.CachedServerCapabilities(servers[i]); ok {
+			entry.Capabilities = &caps
+		} else if conn, err := manager.Connector(server.ID); err == nil && admin {
+			// Listing must not wait for every host; the next request picks the result up.
+			// Only admins make the UI connect to the hosts, as for testing a server.
+			fail2ban.RefreshServerCapabilitiesAsync(conn, capabilityDetectTimeout)
+		}
+		entries = append(entries, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"servers": entries})
+}
+
+const capabilityDetectTimeout = 30 * time.Second
+
+// Detects the fail2ban version, python version, backend and actions of a server (?refresh=true bypasses the cache and needs admin permission).
+func ServerCapabilitiesHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ServerCapabilitiesHandler called (handlers.go)")
+	conn, err := fail2ban.GetManager().Connector(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// Detection connects to the host, so it takes the same permission as testing
+	// the server; other users only get what has been detected already.
+	refresh := c.Query("refresh") == "true"
+	if !userHasAdminAccess(c) {
+		if refresh {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		var cached *fail2ban.ServerCapabilities
+		if caps, ok := fail2ban.CachedServerCapabilities(conn.Server()); ok {
+			cached = &caps
+		}
+		c.JSON(http.StatusOK, gin.H{"serverId": conn.Server().ID, "capabilities": cached})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), capabilityDetectTimeout)
+	defer cancel()
+	c.JSON(http.StatusOK, gin.H{"serverId": conn.Server().ID, "capabilities": fail2ban.GetServerCapabilities(ctx, conn, refresh)})
 }
 
 // Creates or updates a Fail2ban server configuration.
@@ -1243,9 +1379,15 @@ func UpsertServerHandler(c *gin.Context) {
 		return
 	}
 
+	if err := normalizeJailDefaultOverrides(&req.JailDefaults); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Check if server exists and was previously disabled
 	oldServer, wasEnabled := config.GetServerByID(req.ID)
 	wasDisabled := !wasEnabled || !oldServer.Enabled
+	jailDefaultsChanged := wasEnabled && !jailDefaultOverridesEqual(oldServer.JailDefaults, req.JailDefaults)
 
 	server, err := config.UpsertServer(req)
 	if err != nil {
@@ -1321,6 +1463,28 @@ func UpsertServerHandler(c *gin.Context) {
 		}
 	}
 
+	// Changed overrides on a running server are written to its jail.local right away.
+	var jailDefaultsWarning string
+	if jailDefaultsChanged && server.Enabled && !justEnabled {
+		if conn, err := fail2ban.GetManager().Connector(server.ID); err == nil {
+			if err := conn.UpdateDefaultSettings(c.Request.Context()); err != nil {
+				jailDefaultsWarning = fmt.Sprintf("failed to update DEFAULT settings on %s: %v", server.Name, err)
+			} else if err := conn.Reload(c.Request.Context()); err != nil {
+				jailDefaultsWarning = fmt.Sprintf("DEFAULT settings updated on %s, but reload failed: %v", server.Name, err)
+			}
+			if jailDefaultsWarning != "" {
+				log.Printf("WARNING: %s", jailDefaultsWarning)
+			}
+		}
+	}
+
+	// New servers start with the ban history fail2ban already keeps in its own database.
+	if !wasEnabled && server.Enabled {
+		if startBanHistoryImport(server) {
+			config.DebugLog("Started ban history import for new server %s", server.Name)
+		}
+	}
+
 	resp := gin.H{"server": maskServer(server)}
 	if jailLocalWarning {
 		resp["jailLocalWarning"] = true
@@ -1331,6 +1495,9 @@ func UpsertServerHandler(c *gin.Context) {
 	if restartWarning != "" {
 		resp["restartWarning"] = restartWarning
 	}
+	if jailDefaultsWarning != "" {
+		resp["jailDefaultsWarning"] = jailDefaultsWarning
+	}
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -1345,6 +1512,9 @@ func DeleteServerHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := storage.DeleteEventPullCursor(c.Request.Context(), id); err != nil {
+		log.Printf("warning: failed to delete event pull cursor for server %s: %v", id, err)
+	}
 	if err := config.ReloadFail2banManager(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1461,7 +1631,10 @@ func TestServerHandler(c *gin.Context) {
 
 	// Checks the jail.local integrity: if it exists but is not managed by Fail2ban-UI, we warn the user.
 	// If the file was removed (e.g. after finished migration or just deleted), we initialize a fresh managed file.
-	resp := gin.H{"messageKey": "servers.actions.test_success"}
+	resp := gin.H{
+		"messageKey":   "servers.actions.test_success",
+		"capabilities": fail2ban.GetServerCapabilities(ctx, conn, true),
+	}
 	if exists, hasUI, err := conn.CheckJailLocalIntegrity(ctx); err == nil {
 		if exists && !hasUI {
 			resp["jailLocalWarning"] = true
@@ -1517,6 +1690,11 @@ func resolveCountry(ip, providedCountry string, settings config.AppSettings) str
 }
 
 func HandleBanNotification(ctx context.Context, server config.Fail2banServer, ip, jail, hostname, failures, whois, logs string) error {
+	return handleBanNotificationAt(ctx, server, ip, jail, hostname, failures, whois, logs, time.Now().UTC())
+}
+
+// Same as HandleBanNotification, for events whose time is known (pulled from the fail2ban log).
+func handleBanNotificationAt(ctx context.Context, server config.Fail2banServer, ip, jail, hostname, failures, whois, logs string, occurredAt time.Time) error {
 	settings := config.GetSettings()
 	country := resolveCountry(ip, "", settings)
 	filteredLogs := filterRelevantLogs(logs, ip, settings.MaxLogLines)
@@ -1531,27 +1709,32 @@ func HandleBanNotification(ctx context.Context, server config.Fail2banServer, ip
 		Whois:      whois,
 		Logs:       filteredLogs,
 		EventType:  "ban",
-		OccurredAt: time.Now().UTC(),
-	}
-	eventID, err := storage.RecordBanEvent(ctx, event)
-	if err != nil {
-		log.Printf("WARNING: Failed to record ban event: %v", err)
+		OccurredAt: occurredAt,
 	}
-	event.ID = eventID
-
-	// Broadcasts the ban event to WebSocket clients
-	if wsHub != nil {
-		wsHub.BroadcastBanEvent(event)
+	// Bans issued from the UI carry the acting user and reason; the event may already be recorded.
+	note := takeManualBanNote(manualBanKey(server.ID, jail, ip))
+	if note != nil {
+		event.Reason = note.reason
+		event.Actor = note.actor
 	}
+	eventID := recordBanEventOnce(ctx, note, event)
 
 	evaluateAdvancedActions(ctx, settings, server, ip)
+	// Bans copied from another server are not propagated again.
+	if note == nil || !note.propagated {
+		propagateBanAsync(server, ip, jail, settings.BanPropagation)
+	}
 
-	enrichAndAlertAsync(eventID, "ban", ip, jail, hostname, failures, filteredLogs, whois, country, settings)
+	enrichAndAlertAsync(eventID, server, "ban", ip, jail, hostname, failures, filteredLogs, whois, country, settings)
 	return nil
 }
 
 // Records an unban event, broadcasts it via WebSocket, and sends an email alert if enabled.
 func HandleUnbanNotification(ctx context.Context, server config.Fail2banServer, ip, jail, hostname, whois, country string) error {
+	return handleUnbanNotificationAt(ctx, server, ip, jail, hostname, whois, country, time.Now().UTC())
+}
+
+func handleUnbanNotificationAt(ctx context.Context, server config.Fail2banServer, ip, jail, hostname, whois, country string, occurredAt time.Time) error {
 	settings := config.GetSettings()
 	country = resolveCountry(ip, country, settings)
 	event := storage.BanEventRecord{
@@ -1565,7 +1748,7 @@ func HandleUnbanNotification(ctx context.Context, server config.Fail2banServer,
 		Whois:      whois,
 		Logs:       "",
 		EventType:  "unban",
-		OccurredAt: time.Now().UTC(),
+		OccurredAt: occurredAt,
 	}
 	eventID, err := storage.RecordBanEvent(ctx, event)
 	if err != nil {
@@ -1578,7 +1761,7 @@ func HandleUnbanNotification(ctx context.Context, server config.Fail2banServer,
 		wsHub.BroadcastUnbanEvent(event)
 	}
 
-	enrichAndAlertAsync(eventID, "unban", ip, jail, hostname, "", "", whois, country, settings)
+	enrichAndAlertAsync(eventID, server, "unban", ip, jail, hostname, "", "", whois, country, settings)
 	return nil
 }
 
@@ -1586,116 +1769,167 @@ func HandleUnbanNotification(ctx context.Context, server config.Fail2banServer,
 //  Alert Dispatch
 // =========================================================================
 
-// Completes whois enrichment and dispatches alerts in the background
-// Whois lookups can take up to 10 seconds and should never block the fail2ban callback response
-func enrichAndAlertAsync(eventID int64, alertType, ip, jail, hostname, failures, logs, providedWhois, country string, settings config.AppSettings) {
+// Queues the alerts right away and completes the whois and country enrichment
+// of the stored event in the background. Whois lookups can take up to 10
+// seconds and should never block the fail2ban callback response, and queued
+// alerts must not wait for them: they are enriched by the outbox worker.
+func enrichAndAlertAsync(eventID int64, server config.Fail2banServer, alertType, ip, jail, hostname, failures, logs, providedWhois, country string, settings config.AppSettings) {
+	ev := alertEvent{
+		Type:          alertType,
+		IP:            ip,
+		Jail:          jail,
+		Hostname:      hostname,
+		Failures:      failures,
+		Whois:         providedWhois,
+		Logs:          logs,
+		Country:       country,
+		ServerID:      server.ID,
+		ServerName:    server.Name,
+		EventID:       eventID,
+		EnrichPending: providedWhois == "" || country == "",
+	}
 	go func() {
-		whoisData := providedWhois
-		if whoisData == "" {
-			log.Printf("Performing whois lookup for IP %s", ip)
-			data, err := lookupWhois(ip)
-			if err != nil {
-				log.Printf("WARNING: Whois lookup failed for IP %s: %v", ip, err)
-			} else {
-				whoisData = data
+		if err := dispatchAlertEvent(ev, settings); err != nil {
+			log.Printf("ERROR: Failed to send %s alert for IP %s: %v", alertType, ip, err)
+			if wsHub != nil {
+				wsHub.BroadcastToast("error", fmt.Sprintf("Failed to send %s alert for %s: %v", alertType, ip, err))
 			}
 		}
-		if country == "" {
-			if resolved, err := lookupCountry(ip, settings.GeoIPProvider, settings.GeoIPDatabasePath); err == nil {
-				country = resolved
-			} else {
-				log.Printf("WARNING: GeoIP lookup failed for IP %s: %v", ip, err)
-			}
+		if !ev.EnrichPending || eventID <= 0 {
+			return
 		}
-		if country == "" && whoisData != "" {
-			if extracted := extractCountryFromWhois(whoisData); extracted != "" {
-				country = extracted
-				log.Printf("Extracted country %s from whois data for IP %s", country, ip)
-			}
+		updateCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		enriched := lookupAlertEnrichment(ev, settings)
+		if enriched.Whois == "" && enriched.Country == "" {
+			return
 		}
-		if eventID > 0 && (whoisData != "" || country != "") {
-			updateCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			if err := storage.UpdateBanEventEnrichment(updateCtx, eventID, whoisData, country); err != nil {
-				log.Printf("WARNING: Failed to store whois enrichment for event %d: %v", eventID, err)
-			} else if wsHub != nil {
-				if enriched, found, err := storage.GetBanEventByID(updateCtx, eventID); err == nil && found {
-					enriched.Whois = ""
-					enriched.Logs = ""
-					wsHub.BroadcastBanEventUpdate(enriched)
-				}
+		if err := storage.UpdateBanEventEnrichment(updateCtx, eventID, enriched.Whois, enriched.Country); err != nil {
+			log.Printf("WARNING: Failed to store whois enrichment for event %d: %v", eventID, err)
+		} else if wsHub != nil {
+			if stored, found, err := storage.GetBanEventByID(updateCtx, eventID); err == nil && found {
+				stored.Whois = ""
+				stored.Logs = ""
+				wsHub.BroadcastBanEventUpdate(stored)
 			}
-			cancel()
 		}
+	}()
+}
 
-		displayCountry := country
-		if displayCountry == "" {
-			displayCountry = "UNKNOWN"
-		}
-		if alertType == "unban" {
-			if !settings.EmailAlertsForUnbans {
-				log.Printf("Alerts for unbans are disabled. No alert sent for IP %s", ip)
-				return
-			}
-			if !shouldAlertForCountry(country, settings.AlertCountries) {
-				log.Printf("IP %s belongs to %s, which is NOT in alert countries (%v). No alert sent.", ip, displayCountry, settings.AlertCountries)
-				return
-			}
-		} else {
-			if !shouldAlertForCountry(country, settings.AlertCountries) {
-				log.Printf("ERROR: IP %s belongs to %s, which is NOT in alert countries (%v). No alert sent.", ip, displayCountry, settings.AlertCountries)
-				return
+// Fills in the whois and country of a queued event, from its stored ban event
+// when that has been enriched already, otherwise by looking them up.
+func enrichAlertEvent(ctx context.Context, ev alertEvent, settings config.AppSettings) alertEvent {
+	if ev.EventID > 0 {
+		if stored, found, err := storage.GetBanEventByID(ctx, ev.EventID); err == nil && found {
+			if ev.Whois == "" {
+				ev.Whois = stored.Whois
 			}
-			if !settings.EmailAlertsForBans {
-				log.Printf("ERROR: Alerts for bans are disabled. No alert sent for IP %s", ip)
-				return
+			if ev.Country == "" {
+				ev.Country = stored.Country
 			}
 		}
+	}
+	if ev.Whois == "" || ev.Country == "" {
+		ev = lookupAlertEnrichment(ev, settings)
+	}
+	ev.EnrichPending = false
+	return ev
+}
 
-		if err := dispatchAlert(alertType, ip, jail, hostname, failures, whoisData, logs, country, settings); err != nil {
-			log.Printf("ERROR: Failed to send %s alert for IP %s: %v", alertType, ip, err)
-			if wsHub != nil {
-				wsHub.BroadcastToast("error", fmt.Sprintf("Failed to send %s alert for %s: %v", alertType, ip, err))
-			}
+// Looks up the missing whois data and country of an event.
+func lookupAlertEnrichment(ev alertEvent, settings config.AppSettings) alertEvent {
+	if ev.Whois == "" {
+		log.Printf("Performing whois lookup for IP %s", ev.IP)
+		if data, err := lookupWhois(ev.IP); err != nil {
+			log.Printf("WARNING: Whois lookup failed for IP %s: %v", ev.IP, err)
+		} else {
+			ev.Whois = data
 		}
-	}()
+	}
+	if ev.Country == "" {
+		if resolved, err := lookupCountry(ev.IP, settings.GeoIPProvider, settings.GeoIPDatabasePath); err == nil {
+			ev.Country = resolved
+		} else {
+			log.Printf("WARNING: GeoIP lookup failed for IP %s: %v", ev.IP, err)
+		}
+	}
+	if ev.Country == "" && ev.Whois != "" {
+		if extracted := extractCountryFromWhois(ev.Whois); extracted != "" {
+			ev.Country = extracted
+			log.Printf("Extracted country %s from whois data for IP %s", ev.Country, ev.IP)
+		}
+	}
+	return ev
 }
 
-// Routes an alert to the configured provider (email, webhook, or elasticsearch).
-func dispatchAlert(alertType, ip, jail, hostname, failures, whois, logs, country string, settings config.AppSettings) error {
-	switch settings.AlertProvider {
-	case "webhook":
-		return sendWebhookAlert(alertType, ip, jail, hostname, failures, whois, logs, country, settings)
-	case "elasticsearch":
-		return sendElasticsearchAlert(alertType, ip, jail, hostname, failures, whois, logs, country, settings)
-	default:
-		if alertType == "ban" {
-			return sendBanAlert(ip, jail, hostname, failures, whois, logs, country, settings)
-		}
-		return sendUnbanAlert(ip, jail, hostname, whois, country, settings)
+// Builds the default JSON payload of a webhook alert.
+func webhookPayload(ev alertEvent) map[string]interface{} {
+	timestamp := ev.OccurredAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
 	}
+	payload := map[string]interface{}{
+		"event":     ev.Type,
+		"timestamp": timestamp.UTC().Format(time.RFC3339),
+	}
+	switch ev.Type {
+	case "permanent_block":
+		payload["ip"] = ev.IP
+		payload["country"] = ev.Country
+		payload["integration"] = ev.Integration
+		payload["serverId"] = ev.ServerID
+		payload["server"] = ev.ServerName
+		payload["detail"] = ev.Detail
+	case "server_down", "server_recovered", "callback_silence":
+		payload["serverId"] = ev.ServerID
+		payload["server"] = ev.ServerName
+		payload["hostname"] = ev.Hostname
+		payload["detail"] = ev.Detail
+		payload["since"] = ev.Since.Format(time.RFC3339)
+	case "digest":
+		if d := ev.Digest; d != nil {
+			payload["since"] = d.Since.Format(time.RFC3339)
+			payload["until"] = d.Until.Format(time.RFC3339)
+			payload["bans"] = d.Bans
+			payload["suppressed"] = d.Suppressed
+			payload["duplicates"] = d.Duplicates
+			payload["topIps"] = d.TopIPs
+			payload["topJails"] = d.TopJails
+			payload["topCountries"] = d.TopCountries
+			payload["topServers"] = d.TopServers
+		}
+	default:
+		payload["ip"] = ev.IP
+		payload["jail"] = ev.Jail
+		payload["hostname"] = ev.Hostname
+		payload["country"] = ev.Country
+		payload["failures"] = ev.Failures
+		payload["whois"] = ev.Whois
+		payload["logs"] = ev.Logs
+	}
+	return payload
 }
 
-// Sends a JSON payload to the configured webhook URL.
-func sendWebhookAlert(alertType, ip, jail, hostname, failures, whois, logs, country string, settings config.AppSettings) error {
+// Sends the event to the configured webhook URL: the default JSON payload, or
+// the webhook's body template rendered with the event when one is set.
+func postWebhook(ctx context.Context, ev alertEvent, settings config.AppSettings) error {
 	cfg := settings.Webhook
 	if err := integrations.ValidateOutboundURL(cfg.URL, "webhook URL"); err != nil {
 		return err
 	}
-
-	payload := map[string]interface{}{
-		"event":     alertType,
-		"ip":        ip,
-		"jail":      jail,
-		"hostname":  hostname,
-		"country":   country,
-		"failures":  failures,
-		"whois":     whois,
-		"logs":      logs,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	}
-
-	data, err := json.Marshal(payload)
-	if err != nil {
+	payload := webhookPayload(ev)
+	contentType := "application/json"
+	var data []byte
+	var err error
+	if cfg.Template != "" {
+		data, err = renderWebhookTemplate(cfg.Template, buildWebhookTemplateData(ev, payload, cfg.Template, settings))
+		if err != nil {
+			return err
+		}
+		if cfg.ContentType != "" {
+			contentType = cfg.ContentType
+		}
+	} else if data, err = json.Marshal(payload); err != nil {
 		return fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
@@ -1704,14 +1938,20 @@ func sendWebhookAlert(alertType, ip, jail, hostname, failures, whois, logs, coun
 		method = "POST"
 	}
 
-	req, err := http.NewRequest(method, cfg.URL, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, method, cfg.URL, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to create webhook request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	for k, v := range cfg.Headers {
 		req.Header.Set(k, v)
 	}
+	// Signed last so that custom headers cannot replace the signature.
+	deliveryID := ev.DeliveryID
+	if deliveryID == "" {
+		deliveryID = newWebhookDeliveryID()
+	}
+	signWebhookRequest(req, data, cfg.Secret, deliveryID, time.Now())
 
 	client := newOutboundHTTPClient(15 * time.Second)
 	if cfg.SkipTLSVerify {
@@ -1735,46 +1975,53 @@ func sendWebhookAlert(alertType, ip, jail, hostname, failures, whois, logs, coun
 	return nil
 }
 
-// Sends a document to the configured Elasticsearch index.
-func sendElasticsearchAlert(alertType, ip, jail, hostname, failures, whois, logs, country string, settings config.AppSettings) error {
-	cfg := settings.Elasticsearch
-	if err := integrations.ValidateOutboundURL(cfg.URL, "elasticsearch URL"); err != nil {
-		return err
-	}
-
-	index := cfg.Index
-	if index == "" {
-		index = "fail2ban-events"
-	}
-	dateSuffix := time.Now().UTC().Format("2006.01.02")
-	indexName := index + "-" + dateSuffix
-
+// Sends a ban or unban alert document to the configured Elasticsearch index.
+func sendElasticsearchAlert(ctx context.Context, ev alertEvent, settings config.AppSettings) error {
 	doc := map[string]interface{}{
-		"@timestamp":                  time.Now().UTC().Format(time.RFC3339),
 		"event.kind":                  "alert",
-		"event.type":                  alertType,
-		"source.ip":                   ip,
-		"source.geo.country_iso_code": country,
-		"observer.hostname":           hostname,
-		"fail2ban.jail":               jail,
-		"fail2ban.failures":           failures,
-		"fail2ban.whois":              whois,
-		"fail2ban.logs":               logs,
+		"event.type":                  ev.Type,
+		"source.ip":                   ev.IP,
+		"source.geo.country_iso_code": ev.Country,
+		"observer.hostname":           ev.Hostname,
+		"fail2ban.jail":               ev.Jail,
+		"fail2ban.failures":           ev.Failures,
+		"fail2ban.whois":              ev.Whois,
+		"fail2ban.logs":               ev.Logs,
 	}
 
 	// Parses log lines into structured ECS fields
-	if logFields := enrichment.ParseLogLines(logs, jail); logFields != nil {
+	if logFields := enrichment.ParseLogLines(ev.Logs, ev.Jail); logFields != nil {
 		for k, v := range logFields {
 			doc[k] = v
 		}
 	}
 
 	// Parses whois text into structured fields
-	if whoisFields := enrichment.ParseWhois(whois); whoisFields != nil {
+	if whoisFields := enrichment.ParseWhois(ev.Whois); whoisFields != nil {
 		for k, v := range whoisFields {
 			doc[k] = v
 		}
 	}
+	return indexElasticsearchDocument(ctx, ev.OccurredAt, doc, settings)
+}
+
+// Indexes a document, stamped with the time the event occurred, into the daily
+// index of that time, so retried deliveries land where the event belongs.
+func indexElasticsearchDocument(ctx context.Context, occurredAt time.Time, doc map[string]interface{}, settings config.AppSettings) error {
+	cfg := settings.Elasticsearch
+	if err := integrations.ValidateOutboundURL(cfg.URL, "elasticsearch URL"); err != nil {
+		return err
+	}
+	index := cfg.Index
+	if index == "" {
+		index = "fail2ban-events"
+	}
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	occurredAt = occurredAt.UTC()
+	doc["@timestamp"] = occurredAt.Format(time.RFC3339)
+	indexName := index + "-" + occurredAt.Format("2006.01.02")
 
 	data, err := json.Marshal(doc)
 	if err != nil {
@@ -1784,7 +2031,7 @@ func sendElasticsearchAlert(alertType, ip, jail, hostname, failures, whois, logs
 	esURL := strings.TrimSuffix(cfg.URL, "/")
 	reqURL := fmt.Sprintf("%s/%s/_doc", esURL, indexName)
 
-	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to create elasticsearch request: %w", err)
 	}
@@ -1826,7 +2073,16 @@ func TestWebhookHandler(c *gin.Context) {
 		return
 	}
 
-	err := sendWebhookAlert("test", "203.0.113.1", "test-jail", "fail2ban-ui", "0", "", "This is a test webhook from Fail2ban-UI.", "XX", settings)
+	err := postWebhook(c.Request.Context(), alertEvent{
+		Type:       "test",
+		IP:         "203.0.113.1",
+		Jail:       "test-jail",
+		Hostname:   "fail2ban-ui",
+		Failures:   "0",
+		Logs:       "This is a test webhook from Fail2ban-UI.",
+		Country:    "XX",
+		OccurredAt: time.Now().UTC(),
+	}, settings)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1842,7 +2098,16 @@ func TestElasticsearchHandler(c *gin.Context) {
 		return
 	}
 
-	err := sendElasticsearchAlert("test", "203.0.113.1", "test-jail", "fail2ban-ui", "0", "", "This is a test document from Fail2ban-UI.", "XX", settings)
+	err := sendElasticsearchAlert(c.Request.Context(), alertEvent{
+		Type:       "test",
+		IP:         "203.0.113.1",
+		Jail:       "test-jail",
+		Hostname:   "fail2ban-ui",
+		Failures:   "0",
+		Logs:       "This is a test document from Fail2ban-UI.",
+		Country:    "XX",
+		OccurredAt: time.Now().UTC(),
+	}, settings)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2368,7 +2633,7 @@ func SetJailFilterConfigHandler(c *gin.Context) {
 		config.DebugLog("Saving filter config for filter: %s", originalFilterName)
 		if err := conn.SetFilterConfig(c.Request.Context(), originalFilterName, req.Filter); err != nil {
 			config.DebugLog("Failed to save filter config: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save filter config: " + err.Error()})
+			respondConfigWriteError(c, "Failed to save filter config: ", err)
 			return
 		}
 		config.DebugLog("Filter config saved successfully to filter: %s", originalFilterName)
@@ -2376,11 +2641,13 @@ func SetJailFilterConfigHandler(c *gin.Context) {
 		config.DebugLog("No filter config provided, skipping")
 	}
 
+	var skippedKeys []string
 	if req.Jail != "" {
 		config.DebugLog("Saving jail config for jail: %s", jail)
+		req.Jail, skippedKeys = fail2ban.FilterUnsupportedJailKeys(req.Jail, fail2ban.GetServerCapabilities(c.Request.Context(), conn, false))
 		if err := conn.SetJailConfig(c.Request.Context(), jail, req.Jail); err != nil {
 			config.DebugLog("Failed to save jail config: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save jail config: " + err.Error()})
+			respondConfigWriteError(c, "Failed to save jail config: ", err)
 			return
 		}
 		config.DebugLog("Jail config saved successfully")
@@ -2409,11 +2676,12 @@ func SetJailFilterConfigHandler(c *gin.Context) {
 			"warning":          err.Error(),
 			"jailAutoDisabled": true,
 			"jailName":         jail,
+			"skippedKeys":      skippedKeys,
 		})
 		return
 	}
 	config.DebugLog("Fail2ban reloaded successfully")
-	c.JSON(http.StatusOK, gin.H{"message": "Filter and jail config updated and fail2ban reloaded"})
+	c.JSON(http.StatusOK, gin.H{"message": "Filter and jail config updated and fail2ban reloaded", "skippedKeys": skippedKeys})
 }
 
 func equalStringSlices(a, b []string) bool {
@@ -2525,6 +2793,125 @@ func TestLogpathHandler(c *gin.Context) {
 	})
 }
 
+// =========================================================================
+//  Runtime Jail Parameters
+// =========================================================================
+
+// Returns the live bantime, findtime, maxretry and ignoreip of a jail.
+func GetJailRuntimeHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("GetJailRuntimeHandler called (handlers.go)")
+	jail := c.Param("jail")
+	if err := fail2ban.ValidateJailName(jail); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cfg, err := conn.GetJailRuntimeConfig(c.Request.Context(), jail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// Changes jail parameters on the running daemon without a reload; with "persist"
+// the values are also written to jail.d/<jail>.local.
+func SetJailRuntimeHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("SetJailRuntimeHandler called (handlers.go)")
+	jail := c.Param("jail")
+	if err := fail2ban.ValidateJailName(jail); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req struct {
+		fail2ban.JailRuntimeUpdate
+		Persist bool `json:"persist"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	if err := req.JailRuntimeUpdate.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+	if err := conn.SetJailRuntimeConfig(ctx, jail, req.JailRuntimeUpdate); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Persist {
+		if err := fail2ban.PersistJailRuntimeUpdate(ctx, conn, jail, req.JailRuntimeUpdate); err != nil {
+			respondConfigWriteError(c, "applied at runtime, but failed to persist: ", err)
+			return
+		}
+	}
+	cfg, err := conn.GetJailRuntimeConfig(ctx, jail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "jail parameters updated", "persisted": req.Persist, "runtime": cfg})
+}
+
+// Replays log lines through the jail's filter with the given maxretry/findtime/bantime/ignoreip
+// and returns the bans it would have produced per IP. Without logLines or logContent the
+// tail of the jail's logpath is read from the host.
+func SimulateJailHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("SimulateJailHandler called (handlers.go)")
+	jail := c.Param("jail")
+	if err := fail2ban.ValidateJailName(jail); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req struct {
+		fail2ban.JailSimulationParams
+		LogLines   []string `json:"logLines"`
+		LogContent string   `json:"logContent"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+	lines := req.LogLines
+	if req.LogContent != "" {
+		lines = append(lines, strings.Split(req.LogContent, "\n")...)
+	}
+	var files []string
+	if len(lines) == 0 {
+		lines, files, err = fail2ban.ReadJailLogLines(ctx, conn, jail)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read jail logs: " + err.Error()})
+			return
+		}
+	}
+	sim, err := fail2ban.SimulateJail(ctx, conn, jail, lines, req.JailSimulationParams)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Simulation failed: " + err.Error()})
+		return
+	}
+	sim.LogFiles = files
+	c.JSON(http.StatusOK, sim)
+}
+
 // =========================================================================
 //  Jail Management
 // =========================================================================
@@ -3089,22 +3476,25 @@ func CreateJailHandler(c *gin.Context) {
 	if req.Content == "" {
 		req.Content = fmt.Sprintf("[%s]\nenabled = false\n", req.JailName)
 	}
+	var skippedKeys []string
+	req.Content, skippedKeys = fail2ban.FilterUnsupportedJailKeys(req.Content, fail2ban.GetServerCapabilities(c.Request.Context(), conn, false))
 
 	if err := conn.CreateJail(c.Request.Context(), req.JailName, req.Content); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create jail: " + err.Error()})
+		respondConfigWriteError(c, "Failed to create jail: ", err)
 		return
 	}
 
 	// The new jail file is on disk but inactive until fail2ban re-reads its config.
 	if err := conn.Reload(c.Request.Context()); err != nil {
 		c.JSON(http.StatusOK, gin.H{
-			"message": fmt.Sprintf("Jail '%s' created, but fail2ban reload reported a problem", req.JailName),
-			"warning": err.Error(),
+			"message":     fmt.Sprintf("Jail '%s' created, but fail2ban reload reported a problem", req.JailName),
+			"warning":     err.Error(),
+			"skippedKeys": skippedKeys,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Jail '%s' created and applied successfully", req.JailName)})
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Jail '%s' created and applied successfully", req.JailName), "skippedKeys": skippedKeys})
 }
 
 // Removes a jail and its config file.
@@ -3208,6 +3598,69 @@ func validateFail2banDurationField(name, value string) error {
 	return nil
 }
 
+var (
+	fail2banActionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+	fail2banChainPattern      = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+	ignoreIPHostnamePattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.\-]*[A-Za-z0-9])?$`)
+)
+
+// Trims and validates a server's [DEFAULT] overrides; empty fields fall back to the global value.
+func normalizeJailDefaultOverrides(o *config.JailDefaultOverrides) error {
+	ips := make([]string, 0, len(o.IgnoreIPs))
+	for _, ip := range o.IgnoreIPs {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			continue
+		}
+		if fail2ban.ValidateIP(ip) != nil && !ignoreIPHostnamePattern.MatchString(ip) {
+			return fmt.Errorf("ignoreip entry %q is not an IP, CIDR or hostname", ip)
+		}
+		ips = append(ips, ip)
+	}
+	o.IgnoreIPs = ips
+	if len(ips) == 0 {
+		o.IgnoreIPs = nil
+	}
+
+	o.Bantime = strings.ToLower(strings.TrimSpace(o.Bantime))
+	o.Findtime = strings.ToLower(strings.TrimSpace(o.Findtime))
+	bantimeMagnitude := strings.TrimPrefix(o.Bantime, "-")
+	if o.Bantime != "" && bantimeMagnitude == "" {
+		return fmt.Errorf("bantime has an invalid time format: %q (examples: 3600, 48h, -1)", o.Bantime)
+	}
+	if err := validateFail2banDurationField("bantime", bantimeMagnitude); err != nil {
+		return err
+	}
+	if err := validateFail2banDurationField("findtime", o.Findtime); err != nil {
+		return err
+	}
+	if o.Maxretry < 0 {
+		return fmt.Errorf("maxretry must not be negative, got %d", o.Maxretry)
+	}
+
+	o.Banaction = strings.TrimSpace(o.Banaction)
+	o.BanactionAllports = strings.TrimSpace(o.BanactionAllports)
+	o.Chain = strings.TrimSpace(o.Chain)
+	for name, value := range map[string]string{
+		"banaction":          o.Banaction,
+		"banaction_allports": o.BanactionAllports,
+	} {
+		if value != "" && !fail2banActionNamePattern.MatchString(value) {
+			return fmt.Errorf("%s must be an action name, got %q", name, value)
+		}
+	}
+	if o.Chain != "" && !fail2banChainPattern.MatchString(o.Chain) {
+		return fmt.Errorf("chain must be a firewall chain name, got %q", o.Chain)
+	}
+	return nil
+}
+
+func jailDefaultOverridesEqual(a, b config.JailDefaultOverrides) bool {
+	return slices.Equal(a.IgnoreIPs, b.IgnoreIPs) &&
+		a.Bantime == b.Bantime && a.Findtime == b.Findtime && a.Maxretry == b.Maxretry &&
+		a.Banaction == b.Banaction && a.BanactionAllports == b.BanactionAllports && a.Chain == b.Chain
+}
+
 func normalizeAndValidateSettingsRequest(req *config.AppSettings) error {
 	req.Bantime = strings.ToLower(strings.TrimSpace(req.Bantime))
 	req.Findtime = strings.ToLower(strings.TrimSpace(req.Findtime))
@@ -3260,6 +3713,10 @@ func normalizeAndValidateSettingsRequest(req *config.AppSettings) error {
 		return errors.New("threat intelligence provider must be none, alienvault or abuseipdb")
 	}
 
+	if err := normalizeBanPropagationSettings(&req.BanPropagation); err != nil {
+		return err
+	}
+
 	req.Webhook.URL = strings.TrimSpace(req.Webhook.URL)
 	req.Elasticsearch.URL = strings.TrimSpace(req.Elasticsearch.URL)
 	req.Elasticsearch.APIKey = strings.TrimSpace(req.Elasticsearch.APIKey)
@@ -3281,6 +3738,9 @@ func normalizeAndValidateSettingsRequest(req *config.AppSettings) error {
 			return err
 		}
 	}
+	if err := normalizeWebhookTemplate(&req.Webhook); err != nil {
+		return err
+	}
 	if req.AlertProvider == "elasticsearch" {
 		if req.Elasticsearch.URL == "" {
 			return errors.New("elasticsearch URL is required when alert provider is elasticsearch")
@@ -3295,6 +3755,20 @@ func normalizeAndValidateSettingsRequest(req *config.AppSettings) error {
 		}
 	}
 
+	channels, err := normalizeAlertChannels(req.AlertChannels)
+	if err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		if ch.Provider == "webhook" && (ch.Webhook == nil || ch.Webhook.URL == "") && req.Webhook.URL == "" {
+			return fmt.Errorf("alert channel %s needs a webhook URL of its own or in the webhook settings", alertChannelLabel(ch))
+		}
+		if ch.Provider == "elasticsearch" && req.Elasticsearch.URL == "" {
+			return fmt.Errorf("alert channel %s needs the elasticsearch settings", alertChannelLabel(ch))
+		}
+	}
+	req.AlertChannels = channels
+
 	return nil
 }
 
@@ -3490,7 +3964,7 @@ func GetFilterContentHandler(c *gin.Context) {
 	})
 }
 
-// Runs fail2ban-regex against provided log lines and filter content.
+// Runs fail2ban-regex against provided log lines and filter content and returns the raw and parsed output.
 func TestFilterHandler(c *gin.Context) {
 	config.DebugLog("----------------------------")
 	config.DebugLog("TestFilterHandler called (handlers.go)")
@@ -3521,9 +3995,32 @@ func TestFilterHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"output":     output,
 		"filterPath": filterPath,
+		"result":     fail2ban.ParseFilterTestOutput(output),
 	})
 }
 
+// Proposes failregex candidates for sample log lines and test-runs them on the selected server.
+func SuggestFilterRegexHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("SuggestFilterRegexHandler called (handlers.go)")
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req fail2ban.RegexSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+	res, err := fail2ban.SuggestFailregex(c.Request.Context(), conn, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
 // Creates a new filter definition file.
 func CreateFilterHandler(c *gin.Context) {
 	config.DebugLog("----------------------------")
@@ -3555,7 +4052,7 @@ func CreateFilterHandler(c *gin.Context) {
 	}
 
 	if err := conn.CreateFilter(c.Request.Context(), req.FilterName, req.Content); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create filter: " + err.Error()})
+		respondConfigWriteError(c, "Failed to create filter: ", err)
 		return
 	}
 
@@ -3610,6 +4107,152 @@ func DeleteFilterHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Filter '%s' deleted and applied successfully", filterName)})
 }
 
+// =========================================================================
+//  Actions
+// =========================================================================
+
+// Returns all action names in action.d for the selected server.
+func ListActionsHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListActionsHandler called (handlers.go)")
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actions, err := conn.GetActions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list actions: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"actions": actions})
+}
+
+// Returns the content of an action file (.local, falling back to .conf).
+func GetActionContentHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("GetActionContentHandler called (handlers.go)")
+	actionName := c.Param("action")
+	if err := fail2ban.ValidateActionName(actionName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	content, filePath, err := conn.GetActionConfig(c.Request.Context(), actionName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get action content: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"content":    content,
+		"actionPath": filePath,
+	})
+}
+
+// Creates a new action file and reloads Fail2ban.
+func CreateActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("CreateActionHandler called (handlers.go)")
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req struct {
+		ActionName string `json:"actionName" binding:"required"`
+		Content    string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	if err := fail2ban.ValidateActionName(req.ActionName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Content == "" {
+		req.Content = fmt.Sprintf("# Action: %s\n[Definition]\nactionban =\nactionunban =\n", req.ActionName)
+	}
+	if err := conn.CreateAction(c.Request.Context(), req.ActionName, req.Content); err != nil {
+		respondConfigWriteError(c, "Failed to create action: ", err)
+		return
+	}
+	respondActionReload(c, conn, fmt.Sprintf("Action '%s' created", req.ActionName))
+}
+
+// Replaces the content of an action's .local file and reloads Fail2ban.
+func UpdateActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("UpdateActionHandler called (handlers.go)")
+	actionName := c.Param("action")
+	if err := fail2ban.ValidateActionName(actionName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action content is required"})
+		return
+	}
+	if err := conn.SetActionConfig(c.Request.Context(), actionName, req.Content); err != nil {
+		respondConfigWriteError(c, "Failed to save action: ", err)
+		return
+	}
+	respondActionReload(c, conn, fmt.Sprintf("Action '%s' saved", actionName))
+}
+
+// Removes an action's .local override and reloads Fail2ban.
+func DeleteActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("DeleteActionHandler called (handlers.go)")
+	actionName := c.Param("action")
+	if err := fail2ban.ValidateActionName(actionName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := conn.DeleteAction(c.Request.Context(), actionName); err != nil {
+		if errors.Is(err, fail2ban.ErrActionNotOverridden) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to delete action: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete action: " + err.Error()})
+		return
+	}
+	respondActionReload(c, conn, fmt.Sprintf("Action '%s' deleted", actionName))
+}
+
+// Reloads so jails using the action pick up the change; a failed reload is reported as a warning.
+func respondActionReload(c *gin.Context, conn fail2ban.Connector, done string) {
+	if err := conn.Reload(c.Request.Context()); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": done + ", but fail2ban reload reported a problem",
+			"warning": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": done + " and applied successfully"})
+}
+
 // =========================================================================
 //  Restart
 // =========================================================================
@@ -3728,7 +4371,7 @@ func sanitizeEmailHeader(value string) string {
 }
 
 // Connects to the SMTP server and delivers a single HTML message.
-func sendEmail(to, subject, body string, settings config.AppSettings) error {
+func sendEmail(ctx context.Context, to, subject, body string, settings config.AppSettings) error {
 	recipients := shared.SplitCommaList(to)
 	if len(recipients) == 0 {
 		log.Printf("WARNING: sendEmail skipped: no recipients provided.")
@@ -3801,23 +4444,28 @@ func sendEmail(to, subject, body string, settings config.AppSettings) error {
 
 	var client *smtp.Client
 
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
 	if useImplicitTLS {
-		conn, err := tls.Dial("tcp", smtpAddr, tlsConfig)
+		conn, err := (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", smtpAddr)
 		if err != nil {
 			return fmt.Errorf("failed to connect via TLS: %w", err)
 		}
 		defer conn.Close()
+		stop := bindConnToContext(ctx, conn)
+		defer stop()
 
 		client, err = smtp.NewClient(conn, smtpHost)
 		if err != nil {
 			return fmt.Errorf("failed to create SMTP client: %w", err)
 		}
 	} else {
-		conn, err := net.DialTimeout("tcp", smtpAddr, 30*time.Second)
+		conn, err := dialer.DialContext(ctx, "tcp", smtpAddr)
 		if err != nil {
 			return fmt.Errorf("failed to connect to SMTP server: %w", err)
 		}
 		defer conn.Close()
+		stop := bindConnToContext(ctx, conn)
+		defer stop()
 
 		client, err = smtp.NewClient(conn, smtpHost)
 		if err != nil {
@@ -3854,6 +4502,15 @@ func sendEmail(to, subject, body string, settings config.AppSettings) error {
 	return nil
 }
 
+// Applies the context deadline to the SMTP connection and unblocks any pending
+// read or write when the context is cancelled. The returned func stops the watch.
+func bindConnToContext(ctx context.Context, conn net.Conn) func() bool {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+}
+
 // Sends the actual message
 // Performs the MAIL/RCPT/DATA sequence on an open SMTP connection.
 func sendSMTPMessage(client *smtp.Client, from string, recipients []string, msg []byte) error {
@@ -4265,7 +4922,7 @@ func extractStatusCodeFromLine(line string) int {
 }
 
 // Composes and sends the ban notification email.
-func sendBanAlert(ip, jail, hostname, failures, whois, logs, country string, settings config.AppSettings) error {
+func sendBanAlert(ctx context.Context, ip, jail, hostname, failures, whois, logs, country string, settings config.AppSettings) error {
 	lang := settings.Language
 	if lang == "" {
 		lang = "en"
@@ -4348,11 +5005,11 @@ func sendBanAlert(ip, jail, hostname, failures, whois, logs, country string, set
 		body = buildClassicEmailBody(title, intro, details, whoisHTML, logsHTML, whoisTitle, logsTitle, footerText, supportEmail)
 	}
 
-	return sendEmail(settings.Destemail, subject, body, settings)
+	return sendEmail(ctx, settings.Destemail, subject, body, settings)
 }
 
 // Composes and sends the unban notification email.
-func sendUnbanAlert(ip, jail, hostname, whois, country string, settings config.AppSettings) error {
+func sendUnbanAlert(ctx context.Context, ip, jail, hostname, whois, country string, settings config.AppSettings) error {
 	lang := settings.Language
 	if lang == "" {
 		lang = "en"
@@ -4417,7 +5074,7 @@ func sendUnbanAlert(ip, jail, hostname, whois, country string, settings config.A
 	} else {
 		body = buildClassicEmailBody(title, intro, details, whoisHTML, "", whoisTitle, "", footerText, supportEmail)
 	}
-	return sendEmail(settings.Destemail, subject, body, settings)
+	return sendEmail(ctx, settings.Destemail, subject, body, settings)
 }
 
 // Sends a test email to verify the SMTP configuration.
@@ -4462,6 +5119,7 @@ func TestEmailHandler(c *gin.Context) {
 	subject := getEmailTranslation(lang, "email.test.subject")
 
 	err := sendEmail(
+		c.Request.Context(),
 		settings.Destemail,
 		subject,
 		testBody,
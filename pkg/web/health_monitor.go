@@ -0,0 +1,392 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Server Health Monitor
+// =========================================================================
+
+const healthProbeTimeout = 20 * time.Second
+
+// Current health of a server, pushed to WebSocket clients as "server_health" on every status change.
+type ServerHealth struct {
+	ServerID   string                 `json:"serverId"`
+	ServerName string                 `json:"serverName"`
+	Status     string                 `json:"status"`
+	Previous   string                 `json:"previousStatus,omitempty"`
+	Since      time.Time              `json:"since"`
+	CheckedAt  time.Time              `json:"checkedAt"`
+	LatencyMs  int64                  `json:"latencyMs"`
+	Checks     []fail2ban.HealthCheck `json:"checks,omitempty"`
+	Detail     string                 `json:"detail,omitempty"`
+	// When the last ban or unban event of the server was received.
+	LastEventAt *time.Time `json:"lastEventAt,omitempty"`
+}
+
+type serverHealthState struct {
+	ServerHealth
+	downAlerted    bool
+	silenceAlerted bool
+}
+
+// An alert about a server rather than an IP.
+type serverAlert struct {
+	Type   string // server_down, server_recovered, callback_silence
+	Server config.Fail2banServer
+	Detail string
+	Since  time.Time
+}
+
+var healthMonitor = struct {
+	mu       sync.Mutex
+	states   map[string]*serverHealthState
+	inFlight sync.Map
+	started  time.Time
+}{states: make(map[string]*serverHealthState)}
+
+// Probes all enabled servers at the configured interval until ctx is cancelled.
+func RunHealthMonitorLoop(ctx context.Context) {
+	healthMonitor.mu.Lock()
+	healthMonitor.started = time.Now().UTC()
+	healthMonitor.mu.Unlock()
+	seedServerHealth(ctx)
+
+	for {
+		settings := config.GetSettings()
+		if settings.HealthMonitor.Enabled {
+			probeAllServers(ctx, settings)
+		}
+		interval := time.Duration(settings.HealthMonitor.IntervalSeconds) * time.Second
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Restores the last recorded status, so a restart does not record every server as a new transition.
+func seedServerHealth(ctx context.Context) {
+	latest, err := storage.LatestServerHealthEvents(ctx)
+	if err != nil {
+		log.Printf("warning: failed to load server health history: %v", err)
+		return
+	}
+	healthMonitor.mu.Lock()
+	defer healthMonitor.mu.Unlock()
+	for id, rec := range latest {
+		healthMonitor.states[id] = restoredServerHealth(id, rec)
+	}
+}
+
+// Builds the state of a server from its last recorded transition. The outage
+// keeps its start, but counts as not alerted yet: the alert may not have been
+// sent before the restart, or the alert period may have passed while
+// Fail2ban-UI was stopped, so the first probe reports a server still down.
+func restoredServerHealth(id string, rec storage.ServerHealthEventRecord) *serverHealthState {
+	return &serverHealthState{ServerHealth: ServerHealth{
+		ServerID:   id,
+		ServerName: rec.ServerName,
+		Status:     rec.Status,
+		Previous:   rec.PreviousStatus,
+		Since:      rec.OccurredAt,
+		Detail:     rec.Detail,
+	}}
+}
+
+func probeAllServers(ctx context.Context, settings config.AppSettings) {
+	manager := fail2ban.GetManager()
+	for _, server := range config.ListServers() {
+		if !server.Enabled {
+			continue
+		}
+		conn, err := manager.Connector(server.ID)
+		if err != nil {
+			continue
+		}
+		if _, busy := healthMonitor.inFlight.LoadOrStore(server.ID, struct{}{}); busy {
+			continue
+		}
+		go func(server config.Fail2banServer, conn fail2ban.Connector) {
+			defer healthMonitor.inFlight.Delete(server.ID)
+			probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+			defer cancel()
+			probe := fail2ban.ProbeServer(probeCtx, conn)
+			var lastEvent *time.Time
+			if at, ok, err := storage.LastBanEventReceivedAt(probeCtx, server.ID); err == nil && ok {
+				lastEvent = &at
+			}
+			for _, alert := range updateServerHealth(ctx, server, probe, lastEvent, settings.HealthMonitor, time.Now().UTC()) {
+				host := alert.Server.Hostname
+				if host == "" {
+					host = alert.Server.Host
+				}
+				ev := alertEvent{
+					Type:       alert.Type,
+					Hostname:   host,
+					ServerID:   alert.Server.ID,
+					ServerName: alert.Server.Name,
+					Detail:     alert.Detail,
+					Since:      alert.Since,
+				}
+				if err := dispatchAlertEvent(ev, config.GetSettings()); err != nil {
+					log.Printf("ERROR: Failed to send %s alert for server %s: %v", alert.Type, server.Name, err)
+					if wsHub != nil {
+						wsHub.BroadcastToast("error", fmt.Sprintf("Failed to send %s alert for %s: %v", alert.Type, server.Name, err))
+					}
+				}
+			}
+		}(server, conn)
+	}
+}
+
+// Applies a probe result: records and broadcasts status changes and returns the alerts that are due.
+func updateServerHealth(ctx context.Context, server config.Fail2banServer, probe fail2ban.ServerProbe, lastEvent *time.Time, cfg config.HealthMonitorSettings, now time.Time) []serverAlert {
+	healthMonitor.mu.Lock()
+	st, ok := healthMonitor.states[server.ID]
+	if !ok {
+		st = &serverHealthState{}
+		healthMonitor.states[server.ID] = st
+	}
+	changed := st.Status != probe.Status
+	if changed {
+		st.Previous = st.Status
+		st.Status = probe.Status
+		st.Since = now
+	}
+	st.ServerID = server.ID
+	st.ServerName = server.Name
+	st.CheckedAt = now
+	st.LatencyMs = probe.Latency.Milliseconds()
+	st.Checks = probe.Checks
+	st.LastEventAt = lastEvent
+	detail := probeDetail(probe)
+	st.Detail = detail
+
+	var alerts []serverAlert
+	downAfter := time.Duration(cfg.DownAlertMinutes) * time.Minute
+	switch {
+	case st.Status == fail2ban.ServerStatusDown && downAfter > 0 && !st.downAlerted && now.Sub(st.Since) >= downAfter:
+		st.downAlerted = true
+		alerts = append(alerts, serverAlert{Type: "server_down", Server: server, Detail: detail, Since: st.Since})
+	case st.Status != fail2ban.ServerStatusDown && st.downAlerted:
+		st.downAlerted = false
+		alerts = append(alerts, serverAlert{Type: "server_recovered", Server: server, Detail: detail, Since: st.Since})
+	}
+
+	silence := time.Duration(cfg.CallbackSilenceMinutes) * time.Minute
+	if silence > 0 && server.EventMode != "pull" && st.Status != fail2ban.ServerStatusDown {
+		// Without any event yet, the silence counts from the monitor start.
+		ref := healthMonitor.started
+		if lastEvent != nil && lastEvent.After(ref) {
+			ref = *lastEvent
+		}
+		if now.Sub(ref) >= silence {
+			if !st.silenceAlerted {
+				st.silenceAlerted = true
+				alerts = append(alerts, serverAlert{
+					Type:   "callback_silence",
+					Server: server,
+					Detail: fmt.Sprintf("no ban or unban event received for %s", now.Sub(ref).Round(time.Minute)),
+					Since:  ref,
+				})
+			}
+		} else {
+			st.silenceAlerted = false
+		}
+	}
+	snapshot := st.ServerHealth
+	healthMonitor.mu.Unlock()
+
+	if changed {
+		recordServerHealthChange(ctx, snapshot, probe, detail)
+	}
+	return alerts
+}
+
+func recordServerHealthChange(ctx context.Context, health ServerHealth, probe fail2ban.ServerProbe, detail string) {
+	checks, _ := json.Marshal(probe.Checks)
+	if _, err := storage.RecordServerHealthEvent(ctx, storage.ServerHealthEventRecord{
+		ServerID:       health.ServerID,
+		ServerName:     health.ServerName,
+		Status:         health.Status,
+		PreviousStatus: health.Previous,
+		Detail:         detail,
+		ChecksJSON:     string(checks),
+		OccurredAt:     health.Since,
+	}); err != nil {
+		log.Printf("warning: failed to record health of server %s: %v", health.ServerName, err)
+	}
+	if health.Previous != "" {
+		log.Printf("Server %s is %s (was %s)", health.ServerName, health.Status, health.Previous)
+	}
+	if wsHub != nil {
+		wsHub.BroadcastServerHealth(health)
+	}
+}
+
+// Joins the details of the failed checks.
+func probeDetail(probe fail2ban.ServerProbe) string {
+	detail := ""
+	for _, check := range probe.Checks {
+		if check.OK {
+			continue
+		}
+		if detail != "" {
+			detail += "; "
+		}
+		detail += check.Name + ": " + check.Detail
+	}
+	return detail
+}
+
+// Returns the last known health of all enabled servers.
+func serverHealthSnapshot() []ServerHealth {
+	enabled := make(map[string]bool)
+	for _, server := range config.ListServers() {
+		enabled[server.ID] = server.Enabled
+	}
+	healthMonitor.mu.Lock()
+	defer healthMonitor.mu.Unlock()
+	out := make([]ServerHealth, 0, len(healthMonitor.states))
+	for id, st := range healthMonitor.states {
+		if enabled[id] {
+			out = append(out, st.ServerHealth)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ServerName < out[j].ServerName })
+	return out
+}
+
+// =========================================================================
+//  Server Alerts
+// =========================================================================
+
+// Sends a server alert event through a provider (email, webhook, or elasticsearch).
+func deliverServerAlert(ctx context.Context, provider string, ev alertEvent, settings config.AppSettings) error {
+	switch provider {
+	case "webhook":
+		return postWebhook(ctx, ev, settings)
+	case "elasticsearch":
+		return indexElasticsearchDocument(ctx, ev.OccurredAt, map[string]interface{}{
+			"event.kind":        "alert",
+			"event.type":        ev.Type,
+			"event.start":       ev.Since.Format(time.RFC3339),
+			"observer.hostname": ev.Hostname,
+			"observer.name":     ev.ServerName,
+			"message":           ev.Detail,
+		}, settings)
+	default:
+		return sendServerAlertEmail(ctx, ev, settings)
+	}
+}
+
+// Composes and sends a server alert email.
+func sendServerAlertEmail(ctx context.Context, ev alertEvent, settings config.AppSettings) error {
+	lang := settings.Language
+	if lang == "" {
+		lang = "en"
+	}
+	prefix := "email." + ev.Type
+	title := getEmailTranslation(lang, prefix+".title")
+	subject := fmt.Sprintf("[Fail2Ban-UI] %s: %s", title, ev.ServerName)
+	details := []emailDetail{
+		{Label: getEmailTranslation(lang, "email.server.details.server"), Value: ev.ServerName},
+		{Label: getEmailTranslation(lang, "email.server.details.host"), Value: ev.Hostname},
+		{Label: getEmailTranslation(lang, "email.server.details.since"), Value: ev.Since.Format(time.RFC3339)},
+	}
+	if ev.Detail != "" {
+		details = append(details, emailDetail{Label: getEmailTranslation(lang, "email.server.details.detail"), Value: ev.Detail})
+	}
+	intro := getEmailTranslation(lang, prefix+".intro")
+	footerText := getEmailTranslation(lang, "email.footer.text")
+
+	var body string
+	if getEmailStyle() == "modern" {
+		body = buildModernEmailBody(title, intro, details, "", "", "", "", footerText)
+	} else {
+		body = buildClassicEmailBody(title, intro, details, "", "", "", "", footerText, "support@swissmakers.ch")
+	}
+	return sendEmail(ctx, settings.Destemail, subject, body, settings)
+}
+
+// =========================================================================
+//  Handlers
+// =========================================================================
+
+// Returns the last probe result of every monitored server.
+func ListServerHealthHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListServerHealthHandler called (health_monitor.go)")
+	settings := config.GetSettings()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":         settings.HealthMonitor.Enabled,
+		"intervalSeconds": settings.HealthMonitor.IntervalSeconds,
+		"servers":         serverHealthSnapshot(),
+	})
+}
+
+// Returns the recorded status transitions of a server, newest first.
+func ServerHealthHistoryHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ServerHealthHistoryHandler called (health_monitor.go)")
+	server, ok := config.GetServerByID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	events, err := storage.ListServerHealthEvents(c.Request.Context(), server.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load health history: " + err.Error()})
+		return
+	}
+	type historyEntry struct {
+		storage.ServerHealthEventRecord
+		Checks json.RawMessage `json:"checks,omitempty"`
+	}
+	history := make([]historyEntry, 0, len(events))
+	for _, ev := range events {
+		entry := historyEntry{ServerHealthEventRecord: ev}
+		if json.Valid([]byte(ev.ChecksJSON)) {
+			entry.Checks = json.RawMessage(ev.ChecksJSON)
+		}
+		history = append(history, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"serverId": server.ID, "events": history})
+}
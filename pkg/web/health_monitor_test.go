@@ -0,0 +1,150 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+func resetHealthMonitor(started time.Time) {
+	healthMonitor.mu.Lock()
+	defer healthMonitor.mu.Unlock()
+	healthMonitor.states = make(map[string]*serverHealthState)
+	healthMonitor.started = started
+}
+
+func alertTypes(alerts []serverAlert) []string {
+	var out []string
+	for _, a := range alerts {
+		out = append(out, a.Type)
+	}
+	return out
+}
+
+func TestUpdateServerHealthDownAlertAfterPeriod(t *testing.T) {
+	start := time.Date(2026, 6, 19, 12, 0, 0, 0, time.UTC)
+	resetHealthMonitor(start)
+	server := config.Fail2banServer{ID: "s1", Name: "web01", EventMode: "pull"}
+	cfg := config.HealthMonitorSettings{Enabled: true, IntervalSeconds: 60, DownAlertMinutes: 5}
+	up := fail2ban.ServerProbe{Status: fail2ban.ServerStatusUp}
+	down := fail2ban.ServerProbe{Status: fail2ban.ServerStatusDown, Checks: []fail2ban.HealthCheck{{Name: "fail2ban", Detail: "connection refused"}}}
+	ctx := context.Background()
+
+	steps := []struct {
+		probe fail2ban.ServerProbe
+		after time.Duration
+		want  []string
+	}{
+		{up, 0, nil},
+		{down, time.Minute, nil},
+		{down, 4 * time.Minute, nil},
+		{down, 6 * time.Minute, []string{"server_down"}},
+		{down, 7 * time.Minute, nil},
+		{up, 8 * time.Minute, []string{"server_recovered"}},
+		{up, 9 * time.Minute, nil},
+	}
+	for i, step := range steps {
+		got := alertTypes(updateServerHealth(ctx, server, step.probe, nil, cfg, start.Add(step.after)))
+		if len(got) != len(step.want) || (len(got) > 0 && got[0] != step.want[0]) {
+			t.Fatalf("step %d: expected alerts %v, got %v", i, step.want, got)
+		}
+	}
+
+	snapshot := healthMonitor.states["s1"].ServerHealth
+	if snapshot.Status != fail2ban.ServerStatusUp || snapshot.Previous != fail2ban.ServerStatusDown {
+		t.Fatalf("unexpected state %+v", snapshot)
+	}
+	if !snapshot.Since.Equal(start.Add(8 * time.Minute)) {
+		t.Fatalf("since must be the time of the last transition, got %s", snapshot.Since)
+	}
+}
+
+// An outage recorded before a restart is reported by the first probe that still finds the server down.
+func TestRestoredServerHealthAlertsOnFirstProbe(t *testing.T) {
+	start := time.Date(2026, 6, 19, 12, 0, 0, 0, time.UTC)
+	resetHealthMonitor(start)
+	healthMonitor.states["s1"] = restoredServerHealth("s1", storage.ServerHealthEventRecord{
+		ServerName: "web01", Status: fail2ban.ServerStatusDown, PreviousStatus: fail2ban.ServerStatusUp, OccurredAt: start.Add(-time.Hour),
+	})
+	server := config.Fail2banServer{ID: "s1", Name: "web01", EventMode: "pull"}
+	cfg := config.HealthMonitorSettings{Enabled: true, IntervalSeconds: 60, DownAlertMinutes: 5}
+	down := fail2ban.ServerProbe{Status: fail2ban.ServerStatusDown}
+
+	got := alertTypes(updateServerHealth(context.Background(), server, down, nil, cfg, start))
+	if len(got) != 1 || got[0] != "server_down" {
+		t.Fatalf("expected the first probe to report the outage, got %v", got)
+	}
+	if !healthMonitor.states["s1"].Since.Equal(start.Add(-time.Hour)) {
+		t.Fatalf("the outage must keep its recorded start, got %s", healthMonitor.states["s1"].Since)
+	}
+}
+
+func TestUpdateServerHealthShortOutageDoesNotAlert(t *testing.T) {
+	start := time.Date(2026, 6, 19, 12, 0, 0, 0, time.UTC)
+	resetHealthMonitor(start)
+	server := config.Fail2banServer{ID: "s1", Name: "web01", EventMode: "pull"}
+	cfg := config.HealthMonitorSettings{Enabled: true, IntervalSeconds: 60, DownAlertMinutes: 5}
+	ctx := context.Background()
+
+	updateServerHealth(ctx, server, fail2ban.ServerProbe{Status: fail2ban.ServerStatusDown}, nil, cfg, start)
+	if alerts := updateServerHealth(ctx, server, fail2ban.ServerProbe{Status: fail2ban.ServerStatusUp}, nil, cfg, start.Add(2*time.Minute)); len(alerts) != 0 {
+		t.Fatalf("a recovery without a down alert must not alert, got %v", alertTypes(alerts))
+	}
+}
+
+func TestUpdateServerHealthCallbackSilence(t *testing.T) {
+	start := time.Date(2026, 6, 19, 12, 0, 0, 0, time.UTC)
+	resetHealthMonitor(start)
+	server := config.Fail2banServer{ID: "s1", Name: "web01"}
+	cfg := config.HealthMonitorSettings{Enabled: true, IntervalSeconds: 60, CallbackSilenceMinutes: 60}
+	up := fail2ban.ServerProbe{Status: fail2ban.ServerStatusUp}
+	ctx := context.Background()
+
+	if alerts := updateServerHealth(ctx, server, up, nil, cfg, start.Add(30*time.Minute)); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %v", alertTypes(alerts))
+	}
+	alerts := updateServerHealth(ctx, server, up, nil, cfg, start.Add(61*time.Minute))
+	if got := alertTypes(alerts); len(got) != 1 || got[0] != "callback_silence" {
+		t.Fatalf("expected callback_silence, got %v", got)
+	}
+	if alerts := updateServerHealth(ctx, server, up, nil, cfg, start.Add(90*time.Minute)); len(alerts) != 0 {
+		t.Fatalf("silence must only be reported once, got %v", alertTypes(alerts))
+	}
+
+	// A new event resets the silence, a later gap is reported again.
+	event := start.Add(100 * time.Minute)
+	if alerts := updateServerHealth(ctx, server, up, &event, cfg, start.Add(101*time.Minute)); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %v", alertTypes(alerts))
+	}
+	alerts = updateServerHealth(ctx, server, up, &event, cfg, start.Add(161*time.Minute))
+	if got := alertTypes(alerts); len(got) != 1 || got[0] != "callback_silence" {
+		t.Fatalf("expected a second callback_silence, got %v", got)
+	}
+
+	// Pull mode servers do not use callbacks.
+	resetHealthMonitor(start)
+	server.EventMode = "pull"
+	if alerts := updateServerHealth(ctx, server, up, nil, cfg, start.Add(120*time.Minute)); len(alerts) != 0 {
+		t.Fatalf("pull mode must not report callback silence, got %v", alertTypes(alerts))
+	}
+}
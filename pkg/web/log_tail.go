@@ -0,0 +1,302 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+)
+
+// =========================================================================
+//  Live Log Tail
+// =========================================================================
+
+const (
+	// Lines are collected and pushed to the client in batches.
+	logTailFlushInterval = 250 * time.Millisecond
+	// Lines per second forwarded per subscription; the rest is counted as dropped.
+	logTailRateLimit = 50
+	// Lines forwarded per subscription before it is stopped.
+	logTailLineCap      = 5000
+	logTailMaxFiles     = 16
+	logTailMaxPerClient = 3
+	// Each SSH tail holds a session beside the pool's command slots.
+	logTailMaxPerServer = 2
+	logTailMaxTotal     = 20
+)
+
+var (
+	errLogTailLimit = errors.New("too many log tails are running")
+	logTails        = &logTailManager{subs: make(map[string]*logTailSubscription)}
+)
+
+// A followed line as sent in "log_tail" messages. Match is the 1-based index of
+// the failregex that matched the line, 0 when none did.
+type LogTailMessageLine struct {
+	File  string `json:"file"`
+	Line  string `json:"line"`
+	Match int    `json:"match,omitempty"`
+}
+
+type logTailSubscription struct {
+	id       string
+	clientID string
+	serverID string
+	jail     string
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	pending []LogTailMessageLine
+	dropped int
+	sent    int
+	window  time.Time
+	inWin   int
+	capped  bool
+}
+
+type logTailManager struct {
+	mu   sync.Mutex
+	subs map[string]*logTailSubscription
+}
+
+// Registers a subscription if the per-client, per-server and global limits allow it.
+func (m *logTailManager) add(sub *logTailSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.subs) >= logTailMaxTotal {
+		return errLogTailLimit
+	}
+	perClient, perServer := 0, 0
+	for _, s := range m.subs {
+		if s.clientID == sub.clientID {
+			perClient++
+		}
+		if s.serverID == sub.serverID {
+			perServer++
+		}
+	}
+	if perClient >= logTailMaxPerClient || perServer >= logTailMaxPerServer {
+		return errLogTailLimit
+	}
+	m.subs[sub.id] = sub
+	return nil
+}
+
+func (m *logTailManager) remove(id string) {
+	m.mu.Lock()
+	delete(m.subs, id)
+	m.mu.Unlock()
+}
+
+// Stops a subscription; only the client that started it may stop it.
+func (m *logTailManager) stop(id, clientID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.subs[id]
+	if !ok || sub.clientID != clientID {
+		return false
+	}
+	sub.cancel()
+	return true
+}
+
+// Stops all subscriptions of a disconnected client.
+func (m *logTailManager) stopClient(clientID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sub := range m.subs {
+		if sub.clientID == clientID {
+			sub.cancel()
+		}
+	}
+}
+
+// Queues a line, applying the rate limit and the line cap.
+func (s *logTailSubscription) push(line LogTailMessageLine, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.capped {
+		return
+	}
+	if now.Sub(s.window) >= time.Second {
+		s.window, s.inWin = now, 0
+	}
+	if s.inWin >= logTailRateLimit {
+		s.dropped++
+		return
+	}
+	s.inWin++
+	s.sent++
+	s.pending = append(s.pending, line)
+	if s.sent >= logTailLineCap {
+		s.capped = true
+		s.cancel()
+	}
+}
+
+// Takes the queued lines and the number of lines dropped since the last call.
+func (s *logTailSubscription) take() ([]LogTailMessageLine, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines, dropped := s.pending, s.dropped
+	s.pending, s.dropped = nil, 0
+	return lines, dropped
+}
+
+// Follows the files until the subscription is cancelled, the cap is reached or the stream fails.
+func runLogTail(ctx context.Context, hub *Hub, sub *logTailSubscription, follower fail2ban.LogFollower, files []string, matcher *fail2ban.FailregexMatcher) {
+	defer logTails.remove(sub.id)
+	defer sub.cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- follower.FollowLogFiles(ctx, files, func(line fail2ban.LogTailLine) {
+			sub.push(LogTailMessageLine{File: line.File, Line: line.Line, Match: matcher.Match(line.Line)}, time.Now())
+		})
+	}()
+
+	flush := func() {
+		lines, dropped := sub.take()
+		if len(lines) == 0 && dropped == 0 {
+			return
+		}
+		hub.sendToClient(sub.clientID, map[string]interface{}{
+			"type":           "log_tail",
+			"subscriptionId": sub.id,
+			"lines":          lines,
+			"dropped":        dropped,
+		})
+	}
+	ticker := time.NewTicker(logTailFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			flush()
+		case err := <-done:
+			flush()
+			status := map[string]interface{}{
+				"type":           "log_tail_status",
+				"subscriptionId": sub.id,
+				"status":         "stopped",
+			}
+			sub.mu.Lock()
+			capped := sub.capped
+			sub.mu.Unlock()
+			switch {
+			case capped:
+				status["status"] = "capped"
+			case err != nil && ctx.Err() == nil:
+				status["status"] = "error"
+				status["error"] = err.Error()
+				log.Printf("warning: log tail of jail %s on server %s failed: %v", sub.jail, sub.serverID, err)
+			}
+			hub.sendToClient(sub.clientID, status)
+			return
+		}
+	}
+}
+
+// Starts streaming new lines of the jail's log files to the requesting WebSocket client.
+func StartLogTailHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("StartLogTailHandler called (log_tail.go)")
+	jail := c.Param("jail")
+	if err := fail2ban.ValidateJailName(jail); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req struct {
+		ClientID string `json:"clientId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ClientID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "clientId of the WebSocket connection is required"})
+		return
+	}
+	if wsHub == nil || !wsHub.hasClient(req.ClientID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown WebSocket client"})
+		return
+	}
+	conn, err := resolveConnector(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	follower, ok := conn.(fail2ban.LogFollower)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "connector does not support following log files"})
+		return
+	}
+	ctx := c.Request.Context()
+	files, err := fail2ban.ResolveJailLogFiles(ctx, conn, jail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to resolve jail logpath: " + err.Error()})
+		return
+	}
+	if len(files) > logTailMaxFiles {
+		files = files[:logTailMaxFiles]
+	}
+	// Highlighting is best effort; the tail also works without a readable filter.
+	matcher, err := fail2ban.LoadFailregexMatcher(ctx, conn, jail)
+	if err != nil {
+		config.DebugLog("Log tail of jail %s runs without highlighting: %v", jail, err)
+		matcher = &fail2ban.FailregexMatcher{}
+	}
+
+	tailCtx, cancel := context.WithCancel(context.Background())
+	sub := &logTailSubscription{
+		id:       newClientID(),
+		clientID: req.ClientID,
+		serverID: conn.Server().ID,
+		jail:     jail,
+		cancel:   cancel,
+	}
+	if err := logTails.add(sub); err != nil {
+		cancel()
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	go runLogTail(tailCtx, wsHub, sub, follower, files, matcher)
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptionId": sub.id,
+		"files":          files,
+		"failregex":      matcher.Count(),
+		"skipped":        matcher.Skipped,
+		"rateLimit":      logTailRateLimit,
+		"lineCap":        logTailLineCap,
+	})
+}
+
+// Stops a log tail started by the requesting WebSocket client.
+func StopLogTailHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("StopLogTailHandler called (log_tail.go)")
+	if !logTails.stop(c.Param("id"), c.Query("clientId")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "log tail not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "log tail stopped"})
+}
@@ -0,0 +1,79 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogTailSubscriptionRateLimitAndCap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &logTailSubscription{cancel: cancel}
+	now := time.Now()
+	for i := 0; i < logTailRateLimit+10; i++ {
+		sub.push(LogTailMessageLine{Line: "x"}, now)
+	}
+	lines, dropped := sub.take()
+	if len(lines) != logTailRateLimit || dropped != 10 {
+		t.Fatalf("expected %d lines and 10 dropped, got %d / %d", logTailRateLimit, len(lines), dropped)
+	}
+	if lines, dropped = sub.take(); len(lines) != 0 || dropped != 0 {
+		t.Fatalf("take must reset the queue")
+	}
+
+	for i := 1; ctx.Err() == nil; i++ {
+		sub.push(LogTailMessageLine{Line: "x"}, now.Add(time.Duration(i)*time.Second))
+	}
+	if sub.sent != logTailLineCap || !sub.capped {
+		t.Fatalf("expected subscription to stop at the line cap, sent %d", sub.sent)
+	}
+}
+
+func TestLogTailManagerLimits(t *testing.T) {
+	m := &logTailManager{subs: make(map[string]*logTailSubscription)}
+	add := func(id, client, server string) error {
+		_, cancel := context.WithCancel(context.Background())
+		return m.add(&logTailSubscription{id: id, clientID: client, serverID: server, cancel: cancel})
+	}
+	if err := add("a", "c1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := add("b", "c2", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := add("c", "c3", "s1"); !errors.Is(err, errLogTailLimit) {
+		t.Fatalf("expected per-server limit, got %v", err)
+	}
+	for i, server := range []string{"s2", "s3"} {
+		if err := add(string(rune('d'+i)), "c1", server); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := add("z", "c1", "s4"); !errors.Is(err, errLogTailLimit) {
+		t.Fatalf("expected per-client limit, got %v", err)
+	}
+
+	if m.stop("a", "c2") {
+		t.Fatalf("a client must not stop another client's tail")
+	}
+	if !m.stop("a", "c1") {
+		t.Fatalf("expected owner to stop its tail")
+	}
+}
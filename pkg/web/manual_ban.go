@@ -0,0 +1,369 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	manualBanReasonMaxLen = 500
+	// How long a manual ban waits for the matching fail2ban callback before it is
+	// treated as a fresh ban again.
+	manualBanNoteTTL = 2 * time.Minute
+)
+
+// Body of POST /api/bans. Without allServers, serverIds or tags the ban targets
+// the server selected in the UI.
+type manualBanRequest struct {
+	IP         string   `json:"ip"`
+	Jails      []string `json:"jails"`
+	Duration   string   `json:"duration"`
+	Reason     string   `json:"reason"`
+	AllServers bool     `json:"allServers"`
+	templateSelection
+}
+
+// Validated manual ban; Duration is in seconds, 0 keeps the jail's bantime.
+// Propagated marks bans copied from another server, which must not propagate again.
+type manualBan struct {
+	IP         string
+	Jails      []string
+	Duration   int64
+	Reason     string
+	Actor      string
+	Propagated bool
+}
+
+// Actor and reason of a manual ban, kept until the fail2ban callback for it arrives.
+// The ban event is recorded once, by whichever of the two gets there first.
+type manualBanNote struct {
+	actor      string
+	reason     string
+	propagated bool
+	expires    time.Time
+	once       sync.Once
+	eventID    int64
+}
+
+var (
+	manualBanNotesMu sync.Mutex
+	manualBanNotes   = make(map[string]*manualBanNote)
+)
+
+// =========================================================================
+//  Handlers
+// =========================================================================
+
+// Bans an IP or range in one or more jails across the selected servers.
+func ManualBanHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ManualBanHandler called (manual_ban.go)")
+	var req manualBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	ban, err := normalizeManualBan(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ban.Actor = requestActor(c)
+
+	manager := fail2ban.GetManager()
+	var conns []fail2ban.Connector
+	switch {
+	case req.AllServers:
+		conns = manager.Connectors()
+	case len(req.ServerIDs) > 0 || len(req.Tags) > 0:
+		conns = manager.SelectConnectors(req.ServerIDs, req.Tags)
+	default:
+		conn, err := resolveConnector(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, buildErrorResponse(err, "dashboard.manual_block.error"))
+			return
+		}
+		conns = []fail2ban.Connector{conn}
+	}
+	if len(conns) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no enabled server matches the selection"})
+		return
+	}
+
+	// Bans outlive a closed browser tab.
+	ctx := context.WithoutCancel(c.Request.Context())
+	results := executeManualBan(ctx, conns, ban)
+	failed := 0
+	for _, res := range results {
+		if !res.Success {
+			failed++
+		}
+	}
+	log.Printf("Manual ban of %s in %s: %d of %d targets succeeded", ban.IP, strings.Join(ban.Jails, ", "), len(results)-failed, len(results))
+	c.JSON(http.StatusOK, gin.H{"ip": ban.IP, "results": results, "banned": len(results) - failed, "failed": failed})
+}
+
+// =========================================================================
+//  Validation
+// =========================================================================
+
+// Validates a manual ban request. Ranges are reduced to their network address;
+// anything broader than a /8 (IPv4) or /32 (IPv6) is refused as a likely lockout.
+func normalizeManualBan(req manualBanRequest) (manualBan, error) {
+	var ban manualBan
+	target := strings.TrimSpace(req.IP)
+	if strings.Contains(target, "/") {
+		_, network, err := net.ParseCIDR(target)
+		if err != nil {
+			return ban, fmt.Errorf("invalid IP address or CIDR range %q", target)
+		}
+		ones, bits := network.Mask.Size()
+		if (bits == 32 && ones < 8) || (bits == 128 && ones < 32) {
+			return ban, fmt.Errorf("range %s is too broad; use at least /8 for IPv4 and /32 for IPv6", network)
+		}
+		if ones == bits {
+			ban.IP = network.IP.String()
+		} else {
+			ban.IP = network.String()
+		}
+	} else {
+		parsed := net.ParseIP(target)
+		if parsed == nil {
+			return ban, fmt.Errorf("invalid IP address or CIDR range %q", target)
+		}
+		ban.IP = parsed.String()
+	}
+
+	seen := make(map[string]bool, len(req.Jails))
+	for _, jail := range req.Jails {
+		jail = strings.TrimSpace(jail)
+		if jail == "" || seen[jail] {
+			continue
+		}
+		if err := fail2ban.ValidateJailName(jail); err != nil {
+			return ban, err
+		}
+		seen[jail] = true
+		ban.Jails = append(ban.Jails, jail)
+	}
+	if len(ban.Jails) == 0 {
+		return ban, fmt.Errorf("select at least one jail")
+	}
+
+	if d := strings.TrimSpace(req.Duration); d != "" {
+		seconds, err := fail2ban.ParseFail2banDuration(d)
+		if err != nil {
+			return ban, fmt.Errorf("invalid duration: %w", err)
+		}
+		if seconds <= 0 {
+			return ban, fmt.Errorf("duration must be positive; leave it empty to use the jail's bantime")
+		}
+		ban.Duration = seconds
+	}
+
+	ban.Reason = strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(ban.Reason) > manualBanReasonMaxLen {
+		return ban, fmt.Errorf("reason must not exceed %d characters", manualBanReasonMaxLen)
+	}
+	return ban, nil
+}
+
+// Returns the signed-in user for audit records, empty when authentication is off.
+func requestActor(c *gin.Context) string {
+	if name := c.GetString("username"); name != "" {
+		return name
+	}
+	return c.GetString("userEmail")
+}
+
+// =========================================================================
+//  Execution
+// =========================================================================
+
+// Bans on all connectors in parallel and records a ban event per successful target.
+func executeManualBan(ctx context.Context, conns []fail2ban.Connector, ban manualBan) []fail2ban.ManualBanResult {
+	servers := make(map[string]config.Fail2banServer, len(conns))
+	notes := make(map[string]*manualBanNote)
+	for _, conn := range conns {
+		server := conn.Server()
+		servers[server.ID] = server
+		for _, jail := range ban.Jails {
+			key := manualBanKey(server.ID, jail, ban.IP)
+			notes[key] = registerManualBanNote(key, ban)
+		}
+	}
+
+	results := fail2ban.BanIPOnAll(ctx, conns, ban.Jails, ban.IP, ban.Duration)
+	for i := range results {
+		res := &results[i]
+		key := manualBanKey(res.ServerID, res.Jail, ban.IP)
+		if !res.Success {
+			dropManualBanNote(key, notes[key])
+			continue
+		}
+		res.ExpiresAt = completeManualBan(ctx, servers[res.ServerID], res.Jail, ban, notes[key])
+	}
+	return results
+}
+
+// Records the ban event of a successful manual ban and schedules its expiry.
+func completeManualBan(ctx context.Context, server config.Fail2banServer, jail string, ban manualBan, note *manualBanNote) *time.Time {
+	settings := config.GetSettings()
+	now := time.Now().UTC()
+	hostname := server.Hostname
+	if hostname == "" {
+		hostname = server.Host
+	}
+	if hostname == "" {
+		hostname = server.Name
+	}
+	recordBanEventOnce(ctx, note, storage.BanEventRecord{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		Jail:       jail,
+		IP:         ban.IP,
+		Country:    resolveCountry(ban.IP, "", settings),
+		Hostname:   hostname,
+		EventType:  "ban",
+		Reason:     ban.Reason,
+		Actor:      ban.Actor,
+		OccurredAt: now,
+	})
+	if ban.Duration <= 0 {
+		return nil
+	}
+	expires := now.Add(time.Duration(ban.Duration) * time.Second)
+	scheduleManualUnban(ctx, server.ID, jail, ban.IP, ban.Actor, expires)
+	return &expires
+}
+
+// Records and broadcasts a ban event. For manual bans this happens only once,
+// whether the UI request or the fail2ban callback completes first.
+func recordBanEventOnce(ctx context.Context, note *manualBanNote, event storage.BanEventRecord) int64 {
+	record := func() int64 {
+		eventID, err := storage.RecordBanEvent(ctx, event)
+		if err != nil {
+			log.Printf("WARNING: Failed to record ban event: %v", err)
+		}
+		event.ID = eventID
+		if wsHub != nil {
+			wsHub.BroadcastBanEvent(event)
+		}
+		return eventID
+	}
+	if note == nil {
+		return record()
+	}
+	note.once.Do(func() { note.eventID = record() })
+	return note.eventID
+}
+
+// =========================================================================
+//  Manual Ban Notes
+// =========================================================================
+
+func manualBanKey(serverID, jail, ip string) string {
+	return serverID + "|" + jail + "|" + ip
+}
+
+// Stores a fresh note for key, replacing any earlier one, and drops expired notes.
+func registerManualBanNote(key string, ban manualBan) *manualBanNote {
+	note := &manualBanNote{actor: ban.Actor, reason: ban.Reason, propagated: ban.Propagated, expires: time.Now().Add(manualBanNoteTTL)}
+	manualBanNotesMu.Lock()
+	defer manualBanNotesMu.Unlock()
+	now := time.Now()
+	for k, n := range manualBanNotes {
+		if now.After(n.expires) {
+			delete(manualBanNotes, k)
+		}
+	}
+	manualBanNotes[key] = note
+	return note
+}
+
+// Returns the pending note for key and removes it; the callback is its last user.
+func takeManualBanNote(key string) *manualBanNote {
+	manualBanNotesMu.Lock()
+	defer manualBanNotesMu.Unlock()
+	note, ok := manualBanNotes[key]
+	if !ok {
+		return nil
+	}
+	delete(manualBanNotes, key)
+	if time.Now().After(note.expires) {
+		return nil
+	}
+	return note
+}
+
+// Removes note unless a newer manual ban has replaced it.
+func dropManualBanNote(key string, note *manualBanNote) {
+	manualBanNotesMu.Lock()
+	defer manualBanNotesMu.Unlock()
+	if manualBanNotes[key] == note {
+		delete(manualBanNotes, key)
+	}
+}
+
+// =========================================================================
+//  Expiring Manual Bans
+// =========================================================================
+
+// Queues the unban of an expiring manual ban as a scheduled action, replacing any
+// pending expiry of the same ban. The scheduler lifts it, also after a restart.
+func scheduleManualUnban(ctx context.Context, serverID, jail, ip, actor string, at time.Time) {
+	if _, err := storage.CancelScheduledActions(ctx, ScheduledUnban, serverID, jail, ip); err != nil {
+		log.Printf("warning: failed to replace pending expiry of %s in %s: %v", ip, jail, err)
+	}
+	_, err := storage.CreateScheduledAction(ctx, storage.ScheduledActionRecord{
+		Action:    ScheduledUnban,
+		ServerID:  serverID,
+		Jail:      jail,
+		IP:        ip,
+		RunAt:     at,
+		Note:      "Expiry of manual ban",
+		CreatedBy: actor,
+	})
+	if err != nil {
+		log.Printf("warning: failed to schedule expiry of manual ban of %s in %s: %v", ip, jail, err)
+	}
+}
+
+// Cancels the pending expiry of a manual ban, e.g. after it was unbanned by hand.
+func cancelManualUnban(ctx context.Context, serverID, jail, ip string) {
+	if _, err := storage.CancelScheduledActions(ctx, ScheduledUnban, serverID, jail, ip); err != nil {
+		log.Printf("warning: failed to cancel pending expiry of %s in %s: %v", ip, jail, err)
+	}
+}
@@ -0,0 +1,90 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeManualBan(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      manualBanRequest
+		wantIP   string
+		duration int64
+		wantErr  string
+	}{
+		{"single ip", manualBanRequest{IP: " 192.0.2.7 ", Jails: []string{"sshd"}}, "192.0.2.7", 0, ""},
+		{"range reduced to network", manualBanRequest{IP: "192.0.2.77/24", Jails: []string{"sshd"}}, "192.0.2.0/24", 0, ""},
+		{"host prefix", manualBanRequest{IP: "2001:db8::1/128", Jails: []string{"sshd"}}, "2001:db8::1", 0, ""},
+		{"duration", manualBanRequest{IP: "192.0.2.7", Jails: []string{"sshd"}, Duration: "1h30m"}, "192.0.2.7", 5400, ""},
+		{"too broad v4", manualBanRequest{IP: "10.0.0.0/7", Jails: []string{"sshd"}}, "", 0, "too broad"},
+		{"too broad v6", manualBanRequest{IP: "2001::/16", Jails: []string{"sshd"}}, "", 0, "too broad"},
+		{"invalid ip", manualBanRequest{IP: "example.com", Jails: []string{"sshd"}}, "", 0, "invalid IP"},
+		{"no jail", manualBanRequest{IP: "192.0.2.7", Jails: []string{" "}}, "", 0, "at least one jail"},
+		{"bad jail", manualBanRequest{IP: "192.0.2.7", Jails: []string{"ssh d;"}}, "", 0, "jail"},
+		{"permanent duration", manualBanRequest{IP: "192.0.2.7", Jails: []string{"sshd"}, Duration: "-1"}, "", 0, "positive"},
+		{"long reason", manualBanRequest{IP: "192.0.2.7", Jails: []string{"sshd"}, Reason: strings.Repeat("x", manualBanReasonMaxLen+1)}, "", 0, "reason"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ban, err := normalizeManualBan(tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ban.IP != tt.wantIP || ban.Duration != tt.duration {
+				t.Fatalf("got ip %q duration %d, want %q %d", ban.IP, ban.Duration, tt.wantIP, tt.duration)
+			}
+		})
+	}
+}
+
+func TestManualBanNoteRecordsOnce(t *testing.T) {
+	key := manualBanKey("srv-1", "sshd", "192.0.2.7")
+	note := registerManualBanNote(key, manualBan{Actor: "alice", Reason: "scanner"})
+
+	calls := 0
+	record := func() { note.once.Do(func() { calls++; note.eventID = 42 }) }
+	record()
+
+	taken := takeManualBanNote(key)
+	if taken != note || taken.actor != "alice" || taken.reason != "scanner" {
+		t.Fatalf("expected the registered note, got %+v", taken)
+	}
+	record()
+	if calls != 1 || taken.eventID != 42 {
+		t.Fatalf("expected a single recording with event 42, got %d calls, event %d", calls, taken.eventID)
+	}
+	if takeManualBanNote(key) != nil {
+		t.Fatalf("note must only be handed out once")
+	}
+
+	// A newer ban for the same key must survive the cleanup of an older one.
+	older := registerManualBanNote(key, manualBan{Actor: "alice"})
+	newer := registerManualBanNote(key, manualBan{Actor: "bob"})
+	dropManualBanNote(key, older)
+	if got := takeManualBanNote(key); got != newer {
+		t.Fatalf("expected the newer note to be kept, got %+v", got)
+	}
+}
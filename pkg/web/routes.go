@@ -55,8 +55,17 @@ func RegisterRoutes(r *gin.Engine, hub *Hub) {
 
 		// Internal API calls from frontend (e.g. manual actions) to backend to execute Ban / Unban
 		api.GET("/jails/:jail/banned", RequirePermission(PermissionRead), ListJailBannedIPsHandler)
+		api.GET("/jails/:jail/status", RequirePermission(PermissionRead), JailStatusHandler)
 		api.POST("/jails/:jail/unban/:ip", RequirePermission(PermissionBan), UnbanIPHandler)
 		api.POST("/jails/:jail/ban/:ip", RequirePermission(PermissionBan), BanIPHandler)
+		api.POST("/bans", RequirePermission(PermissionBan), ManualBanHandler)
+
+		// Actions that run at a later time (timed unbans, unblocks and jail toggles)
+		api.GET("/scheduled-actions", RequirePermission(PermissionRead), ListScheduledActionsHandler)
+		api.GET("/scheduled-actions/:id", RequirePermission(PermissionRead), GetScheduledActionHandler)
+		api.POST("/scheduled-actions", RequirePermission(PermissionBan), CreateScheduledActionHandler)
+		api.PUT("/scheduled-actions/:id", RequirePermission(PermissionBan), UpdateScheduledActionHandler)
+		api.DELETE("/scheduled-actions/:id", RequirePermission(PermissionBan), DeleteScheduledActionHandler)
 
 		// Search which jails currently ban this IP -> searches on all servers
 		api.GET("/ips/:ip/search", RequirePermission(PermissionRead), SearchBannedIPHandler)
@@ -65,6 +74,11 @@ func RegisterRoutes(r *gin.Engine, hub *Hub) {
 		api.GET("/jails/:jail/config", RequirePermission(PermissionAdmin), GetJailFilterConfigHandler)
 		api.POST("/jails/:jail/config", RequirePermission(PermissionAdmin), SetJailFilterConfigHandler)
 		api.POST("/jails/:jail/logpath/test", RequirePermission(PermissionAdmin), TestLogpathHandler)
+		api.GET("/jails/:jail/runtime", RequirePermission(PermissionAdmin), GetJailRuntimeHandler)
+		api.POST("/jails/:jail/runtime", RequirePermission(PermissionAdmin), SetJailRuntimeHandler)
+		api.POST("/jails/:jail/simulate", RequirePermission(PermissionAdmin), SimulateJailHandler)
+		api.POST("/jails/:jail/tail", RequirePermission(PermissionAdmin), StartLogTailHandler)
+		api.DELETE("/tail/:id", RequirePermission(PermissionAdmin), StopLogTailHandler)
 		api.GET("/jails/manage", RequirePermission(PermissionAdmin), ManageJailsHandler)
 		api.POST("/jails/manage", RequirePermission(PermissionAdmin), UpdateJailManagementHandler)
 		api.POST("/jails", RequirePermission(PermissionAdmin), CreateJailHandler)
@@ -74,15 +88,42 @@ func RegisterRoutes(r *gin.Engine, hub *Hub) {
 		api.GET("/filters", RequirePermission(PermissionAdmin), ListFiltersHandler)
 		api.GET("/filters/:filter/content", RequirePermission(PermissionAdmin), GetFilterContentHandler)
 		api.POST("/filters/test", RequirePermission(PermissionAdmin), TestFilterHandler)
+		api.POST("/filters/suggest", RequirePermission(PermissionAdmin), SuggestFilterRegexHandler)
 		api.POST("/filters", RequirePermission(PermissionAdmin), CreateFilterHandler)
 		api.DELETE("/filters/:filter", RequirePermission(PermissionAdmin), DeleteFilterHandler)
 
+		// Internal API calls for action management (action.d)
+		api.GET("/actions", RequirePermission(PermissionAdmin), ListActionsHandler)
+		api.GET("/actions/:action/content", RequirePermission(PermissionAdmin), GetActionContentHandler)
+		api.POST("/actions", RequirePermission(PermissionAdmin), CreateActionHandler)
+		api.POST("/actions/:action", RequirePermission(PermissionAdmin), UpdateActionHandler)
+		api.DELETE("/actions/:action", RequirePermission(PermissionAdmin), DeleteActionHandler)
+
+		// Internal API calls for jail and filter config revisions
+		api.GET("/config/revisions", RequirePermission(PermissionAdmin), ListConfigRevisionsHandler)
+		api.GET("/config/revisions/:id/diff", RequirePermission(PermissionAdmin), DiffConfigRevisionHandler)
+		api.POST("/config/revisions/:id/rollback", RequirePermission(PermissionAdmin), RollbackConfigRevisionHandler)
+
+		// Internal API calls for fleet-wide jail and filter templates
+		api.GET("/templates", RequirePermission(PermissionAdmin), ListConfigTemplatesHandler)
+		api.POST("/templates", RequirePermission(PermissionAdmin), UpsertConfigTemplateHandler)
+		api.DELETE("/templates/:id", RequirePermission(PermissionAdmin), DeleteConfigTemplateHandler)
+		api.POST("/templates/:id/apply", RequirePermission(PermissionAdmin), ApplyConfigTemplateHandler)
+		api.GET("/templates/:id/drift", RequirePermission(PermissionAdmin), ConfigTemplateDriftHandler)
+
 		// Internal API calls for Fail2ban-UI settings
 		api.GET("/settings", RequirePermission(PermissionRead), GetSettingsHandler)
 		api.POST("/settings", RequirePermission(PermissionAdmin), UpdateSettingsHandler)
 		api.POST("/settings/test-email", RequirePermission(PermissionAdmin), TestEmailHandler)
 		api.POST("/settings/test-webhook", RequirePermission(PermissionAdmin), TestWebhookHandler)
 		api.POST("/settings/test-elasticsearch", RequirePermission(PermissionAdmin), TestElasticsearchHandler)
+		api.GET("/settings/webhook-presets", RequirePermission(PermissionRead), ListWebhookPresetsHandler)
+		api.POST("/settings/webhook-preview", RequirePermission(PermissionAdmin), PreviewWebhookTemplateHandler)
+
+		// Alert outbox: queued, failed and dead-lettered alert deliveries
+		api.GET("/alerts/outbox", RequirePermission(PermissionAdmin), ListAlertOutboxHandler)
+		api.POST("/alerts/outbox/retry", RequirePermission(PermissionAdmin), RetryAlertOutboxHandler)
+		api.DELETE("/alerts/outbox", RequirePermission(PermissionAdmin), PurgeAlertOutboxHandler)
 
 		// Internal API calls for advanced actions
 		api.GET("/advanced-actions/blocks", RequirePermission(PermissionAdmin), ListPermanentBlocksHandler)
@@ -97,6 +138,10 @@ func RegisterRoutes(r *gin.Engine, hub *Hub) {
 		api.POST("/servers/:id/default", RequirePermission(PermissionAdmin), SetDefaultServerHandler)
 		api.GET("/ssh/keys", RequirePermission(PermissionAdmin), ListSSHKeysHandler)
 		api.POST("/servers/:id/test", RequirePermission(PermissionAdmin), TestServerHandler)
+		api.POST("/servers/:id/import-history", RequirePermission(PermissionAdmin), ImportBanHistoryHandler)
+		api.GET("/servers/:id/capabilities", RequirePermission(PermissionRead), ServerCapabilitiesHandler)
+		api.GET("/servers/health", RequirePermission(PermissionRead), ListServerHealthHandler)
+		api.GET("/servers/:id/health", RequirePermission(PermissionRead), ServerHealthHistoryHandler)
 
 		// Internal API to restart Fail2ban
 		api.POST("/fail2ban/restart", RequirePermission(PermissionAdmin), RestartFail2banHandler)
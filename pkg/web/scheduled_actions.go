@@ -0,0 +1,418 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/fail2ban"
+	"github.com/swissmakers/fail2ban-ui/internal/integrations"
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	scheduledActionPollInterval = 15 * time.Second
+	scheduledActionTimeout      = 2 * time.Minute
+	scheduledActionBatchSize    = 20
+	// Failed runs are retried after 1m, 2m, 4m, ... up to an hour apart.
+	scheduledActionRetryBase = time.Minute
+	scheduledActionRetryMax  = time.Hour
+	scheduledActionMaxTries  = 10
+)
+
+// Actions the scheduler can run. Jail and integration actions need admin rights.
+const (
+	ScheduledUnban       = "unban"
+	ScheduledUnblock     = "unblock"
+	ScheduledEnableJail  = "enable_jail"
+	ScheduledDisableJail = "disable_jail"
+)
+
+// Body of POST and PUT /api/scheduled-actions. The run time is either an absolute
+// runAt or a delay relative to now ("30m", "7d").
+type scheduledActionRequest struct {
+	Action      string `json:"action"`
+	ServerID    string `json:"serverId"`
+	Jail        string `json:"jail"`
+	IP          string `json:"ip"`
+	RunAt       string `json:"runAt"`
+	In          string `json:"in"`
+	MaxAttempts int    `json:"maxAttempts"`
+	Note        string `json:"note"`
+}
+
+// =========================================================================
+//  Scheduler
+// =========================================================================
+
+// Runs due scheduled actions until ctx is cancelled.
+func RunScheduledActionLoop(ctx context.Context) {
+	if n, err := storage.ResetRunningScheduledActions(ctx); err != nil {
+		log.Printf("warning: failed to reset interrupted scheduled actions: %v", err)
+	} else if n > 0 {
+		log.Printf("Requeued %d scheduled actions interrupted by a restart", n)
+	}
+	ticker := time.NewTicker(scheduledActionPollInterval)
+	defer ticker.Stop()
+	for {
+		runDueScheduledActions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runDueScheduledActions(ctx context.Context) {
+	due, err := storage.ClaimDueScheduledActions(ctx, time.Now(), scheduledActionBatchSize)
+	if err != nil {
+		log.Printf("warning: failed to load due scheduled actions: %v", err)
+	}
+	for _, rec := range due {
+		runCtx, cancel := context.WithTimeout(ctx, scheduledActionTimeout)
+		runErr := executeScheduledAction(runCtx, rec)
+		cancel()
+		rec = scheduledActionOutcome(rec, runErr, time.Now())
+		if err := storage.FinishScheduledAction(context.WithoutCancel(ctx), rec); err != nil {
+			log.Printf("warning: failed to record outcome of scheduled action %d: %v", rec.ID, err)
+		}
+		switch rec.Status {
+		case storage.ScheduledActionDone:
+			log.Printf("Scheduled action %d (%s) completed", rec.ID, describeScheduledAction(rec))
+		case storage.ScheduledActionPending:
+			log.Printf("warning: scheduled action %d (%s) failed, retrying at %s: %v", rec.ID, describeScheduledAction(rec), rec.RunAt.Format(time.RFC3339), runErr)
+		default:
+			log.Printf("warning: scheduled action %d (%s) failed after %d attempts: %v", rec.ID, describeScheduledAction(rec), rec.Attempts, runErr)
+		}
+	}
+}
+
+// Applies the result of a run: done on success, otherwise retried with backoff
+// until the attempts are used up.
+func scheduledActionOutcome(rec storage.ScheduledActionRecord, runErr error, now time.Time) storage.ScheduledActionRecord {
+	rec.Attempts++
+	if runErr == nil {
+		rec.Status = storage.ScheduledActionDone
+		rec.LastError = ""
+		return rec
+	}
+	rec.LastError = runErr.Error()
+	if rec.Attempts >= rec.MaxAttempts {
+		rec.Status = storage.ScheduledActionFailed
+		return rec
+	}
+	delay := scheduledActionRetryBase << (rec.Attempts - 1)
+	if delay <= 0 || delay > scheduledActionRetryMax {
+		delay = scheduledActionRetryMax
+	}
+	rec.Status = storage.ScheduledActionPending
+	rec.RunAt = now.Add(delay)
+	return rec
+}
+
+// Runs one action through the connector or the advanced-actions integration.
+func executeScheduledAction(ctx context.Context, rec storage.ScheduledActionRecord) error {
+	if rec.Action == ScheduledUnblock {
+		settings := config.GetSettings()
+		server := config.Fail2banServer{}
+		if rec.ServerID != "" {
+			server, _ = config.GetServerByID(rec.ServerID)
+		}
+		return runAdvancedIntegrationAction(ctx, "unblock", rec.IP, settings, server, map[string]any{"scheduledAction": rec.ID}, false)
+	}
+
+	conn, err := fail2ban.GetManager().Connector(rec.ServerID)
+	if err != nil {
+		return err
+	}
+	switch rec.Action {
+	case ScheduledUnban:
+		if err := conn.UnbanIP(ctx, rec.Jail, rec.IP); err != nil {
+			return err
+		}
+		return nil
+	case ScheduledEnableJail, ScheduledDisableJail:
+		enabled := rec.Action == ScheduledEnableJail
+		if err := conn.UpdateJailEnabledStates(ctx, map[string]bool{rec.Jail: enabled}); err != nil {
+			return err
+		}
+		reloadErr := conn.Reload(ctx)
+		if reloadErr == nil || !enabled {
+			return reloadErr
+		}
+		// A jail that breaks the reload is switched off again, as in the jail manager.
+		if err := conn.UpdateJailEnabledStates(ctx, map[string]bool{rec.Jail: false}); err == nil {
+			_ = conn.Reload(ctx)
+		}
+		return fmt.Errorf("jail %s was disabled again because fail2ban failed to reload: %w", rec.Jail, reloadErr)
+	}
+	return fmt.Errorf("unsupported scheduled action %q", rec.Action)
+}
+
+func describeScheduledAction(rec storage.ScheduledActionRecord) string {
+	switch rec.Action {
+	case ScheduledUnban:
+		return fmt.Sprintf("unban %s in %s on %s", rec.IP, rec.Jail, rec.ServerID)
+	case ScheduledUnblock:
+		return fmt.Sprintf("lift permanent block of %s", rec.IP)
+	case ScheduledEnableJail:
+		return fmt.Sprintf("enable jail %s on %s", rec.Jail, rec.ServerID)
+	case ScheduledDisableJail:
+		return fmt.Sprintf("disable jail %s on %s", rec.Jail, rec.ServerID)
+	}
+	return rec.Action
+}
+
+// =========================================================================
+//  Handlers
+// =========================================================================
+
+// Lists scheduled actions, optionally filtered by ?status=.
+func ListScheduledActionsHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListScheduledActionsHandler called (scheduled_actions.go)")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	actions, err := storage.ListScheduledActions(c.Request.Context(), strings.TrimSpace(c.Query("status")), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if actions == nil {
+		actions = []storage.ScheduledActionRecord{}
+	}
+	c.JSON(http.StatusOK, gin.H{"actions": actions})
+}
+
+// Returns a single scheduled action.
+func GetScheduledActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("GetScheduledActionHandler called (scheduled_actions.go)")
+	rec, ok := loadScheduledAction(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, rec)
+}
+
+// Schedules a new action.
+func CreateScheduledActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("CreateScheduledActionHandler called (scheduled_actions.go)")
+	rec, ok := bindScheduledAction(c)
+	if !ok {
+		return
+	}
+	rec.CreatedBy = requestActor(c)
+	id, err := storage.CreateScheduledAction(c.Request.Context(), rec)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	created, _, err := storage.GetScheduledAction(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// Changes a pending action; actions that already ran cannot be edited.
+func UpdateScheduledActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("UpdateScheduledActionHandler called (scheduled_actions.go)")
+	existing, ok := loadScheduledAction(c)
+	if !ok {
+		return
+	}
+	if !scheduledActionAllowed(c, existing.Action) {
+		return
+	}
+	rec, ok := bindScheduledAction(c)
+	if !ok {
+		return
+	}
+	rec.ID = existing.ID
+	if err := storage.UpdateScheduledAction(c.Request.Context(), rec); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusConflict, gin.H{"error": "only pending actions can be changed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	updated, _, err := storage.GetScheduledAction(c.Request.Context(), rec.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// Removes a scheduled action; a pending one is thereby cancelled.
+func DeleteScheduledActionHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("DeleteScheduledActionHandler called (scheduled_actions.go)")
+	rec, ok := loadScheduledAction(c)
+	if !ok {
+		return
+	}
+	if !scheduledActionAllowed(c, rec.Action) {
+		return
+	}
+	if rec.Status == storage.ScheduledActionRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "the action is running right now"})
+		return
+	}
+	if err := storage.DeleteScheduledAction(c.Request.Context(), rec.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduled action deleted"})
+}
+
+func loadScheduledAction(c *gin.Context) (storage.ScheduledActionRecord, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled action id"})
+		return storage.ScheduledActionRecord{}, false
+	}
+	rec, found, err := storage.GetScheduledAction(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return rec, false
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scheduled action not found"})
+		return rec, false
+	}
+	return rec, true
+}
+
+// Decodes and validates the request body into a record.
+func bindScheduledAction(c *gin.Context) (storage.ScheduledActionRecord, bool) {
+	var req scheduledActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON: " + err.Error()})
+		return storage.ScheduledActionRecord{}, false
+	}
+	rec, err := normalizeScheduledAction(req, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return rec, false
+	}
+	if !scheduledActionAllowed(c, rec.Action) {
+		return rec, false
+	}
+	return rec, true
+}
+
+// Jail and integration actions change configuration and are limited to admins.
+func scheduledActionAllowed(c *gin.Context, action string) bool {
+	if action == ScheduledUnban || userHasAdminAccess(c) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	return false
+}
+
+// Validates a request against the action's required fields and resolves its run time.
+func normalizeScheduledAction(req scheduledActionRequest, now time.Time) (storage.ScheduledActionRecord, error) {
+	rec := storage.ScheduledActionRecord{
+		Action:      strings.ToLower(strings.TrimSpace(req.Action)),
+		ServerID:    strings.TrimSpace(req.ServerID),
+		Jail:        strings.TrimSpace(req.Jail),
+		IP:          strings.TrimSpace(req.IP),
+		MaxAttempts: req.MaxAttempts,
+		Note:        strings.TrimSpace(req.Note),
+	}
+	if rec.MaxAttempts <= 0 {
+		rec.MaxAttempts = 3
+	}
+	if rec.MaxAttempts > scheduledActionMaxTries {
+		return rec, fmt.Errorf("maxAttempts must not exceed %d", scheduledActionMaxTries)
+	}
+	if len(rec.Note) > 500 {
+		return rec, errors.New("note must not exceed 500 characters")
+	}
+
+	needServer, needJail, needIP := false, false, false
+	switch rec.Action {
+	case ScheduledUnban:
+		needServer, needJail, needIP = true, true, true
+	case ScheduledUnblock:
+		needIP = true
+	case ScheduledEnableJail, ScheduledDisableJail:
+		needServer, needJail = true, true
+	default:
+		return rec, fmt.Errorf("action must be one of %s, %s, %s or %s", ScheduledUnban, ScheduledUnblock, ScheduledEnableJail, ScheduledDisableJail)
+	}
+	if needServer {
+		if rec.ServerID == "" {
+			return rec, errors.New("serverId is required")
+		}
+		if _, ok := config.GetServerByID(rec.ServerID); !ok {
+			return rec, fmt.Errorf("server %s not found", rec.ServerID)
+		}
+	}
+	if needJail {
+		if err := fail2ban.ValidateJailName(rec.Jail); err != nil {
+			return rec, err
+		}
+	} else {
+		rec.Jail = ""
+	}
+	if needIP {
+		if err := integrations.ValidateIP(rec.IP); err != nil {
+			return rec, err
+		}
+	} else {
+		rec.IP = ""
+	}
+
+	switch {
+	case strings.TrimSpace(req.RunAt) != "":
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(req.RunAt))
+		if err != nil {
+			return rec, fmt.Errorf("runAt must be an RFC 3339 time: %w", err)
+		}
+		rec.RunAt = at
+	case strings.TrimSpace(req.In) != "":
+		seconds, err := fail2ban.ParseFail2banDuration(strings.TrimSpace(req.In))
+		if err != nil || seconds <= 0 {
+			return rec, fmt.Errorf("in must be a positive duration such as 30m or 7d")
+		}
+		rec.RunAt = now.Add(time.Duration(seconds) * time.Second)
+	default:
+		return rec, errors.New("runAt or in is required")
+	}
+	return rec, nil
+}
@@ -0,0 +1,84 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/storage"
+)
+
+func TestScheduledActionOutcome(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rec := storage.ScheduledActionRecord{ID: 1, Action: ScheduledUnban, MaxAttempts: 3, Status: storage.ScheduledActionRunning}
+
+	rec = scheduledActionOutcome(rec, errors.New("connection refused"), now)
+	if rec.Status != storage.ScheduledActionPending || rec.Attempts != 1 || !rec.RunAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("first failure should retry after a minute, got %+v", rec)
+	}
+	rec = scheduledActionOutcome(rec, errors.New("connection refused"), now)
+	if rec.Status != storage.ScheduledActionPending || !rec.RunAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("second failure should back off to two minutes, got %+v", rec)
+	}
+	rec = scheduledActionOutcome(rec, errors.New("connection refused"), now)
+	if rec.Status != storage.ScheduledActionFailed || rec.Attempts != 3 || rec.LastError != "connection refused" {
+		t.Fatalf("expected failure after the last attempt, got %+v", rec)
+	}
+
+	retried := scheduledActionOutcome(storage.ScheduledActionRecord{MaxAttempts: 10, Attempts: 8}, errors.New("boom"), now)
+	if !retried.RunAt.Equal(now.Add(scheduledActionRetryMax)) {
+		t.Fatalf("backoff must be capped, got run at %v", retried.RunAt)
+	}
+
+	done := scheduledActionOutcome(storage.ScheduledActionRecord{MaxAttempts: 3, Attempts: 1, LastError: "old"}, nil, now)
+	if done.Status != storage.ScheduledActionDone || done.LastError != "" || done.Attempts != 2 {
+		t.Fatalf("expected success to finish the action, got %+v", done)
+	}
+}
+
+func TestNormalizeScheduledAction(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	rec, err := normalizeScheduledAction(scheduledActionRequest{Action: " Unblock ", IP: "192.0.2.5", Jail: "sshd", In: "7d", Note: " expires "}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Action != ScheduledUnblock || rec.Jail != "" || rec.MaxAttempts != 3 || rec.Note != "expires" || !rec.RunAt.Equal(now.Add(7*24*time.Hour)) {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+
+	rec, err = normalizeScheduledAction(scheduledActionRequest{Action: ScheduledUnblock, IP: "192.0.2.5", RunAt: "2026-03-02T08:00:00Z"}, now)
+	if err != nil || !rec.RunAt.Equal(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected absolute run time, got %+v (%v)", rec, err)
+	}
+
+	invalid := map[string]scheduledActionRequest{
+		"unknown action":   {Action: "reboot", In: "1h"},
+		"missing run time": {Action: ScheduledUnblock, IP: "192.0.2.5"},
+		"bad duration":     {Action: ScheduledUnblock, IP: "192.0.2.5", In: "soon"},
+		"bad ip":           {Action: ScheduledUnblock, IP: "not-an-ip", In: "1h"},
+		"missing server":   {Action: ScheduledUnban, Jail: "sshd", IP: "192.0.2.5", In: "1h"},
+		"too many tries":   {Action: ScheduledUnblock, IP: "192.0.2.5", In: "1h", MaxAttempts: 50},
+	}
+	for name, req := range invalid {
+		if _, err := normalizeScheduledAction(req, now); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
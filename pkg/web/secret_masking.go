@@ -52,6 +52,7 @@ func maskAppSettingsSecrets(s config.AppSettings) config.AppSettings {
 	s.AdvancedActions.OPNsense.APIKey = maskSecret(s.AdvancedActions.OPNsense.APIKey)
 	s.AdvancedActions.OPNsense.APISecret = maskSecret(s.AdvancedActions.OPNsense.APISecret)
 
+	s.Webhook.Secret = maskSecret(s.Webhook.Secret)
 	if len(s.Webhook.Headers) > 0 {
 		masked := make(map[string]string, len(s.Webhook.Headers))
 		for k, v := range s.Webhook.Headers {
@@ -59,6 +60,22 @@ func maskAppSettingsSecrets(s config.AppSettings) config.AppSettings {
 		}
 		s.Webhook.Headers = masked
 	}
+	if len(s.AlertChannels) > 0 {
+		channels := make([]config.AlertChannel, len(s.AlertChannels))
+		for i, ch := range s.AlertChannels {
+			if ch.Webhook != nil && (len(ch.Webhook.Headers) > 0 || ch.Webhook.Secret != "") {
+				webhook := *ch.Webhook
+				webhook.Secret = maskSecret(webhook.Secret)
+				webhook.Headers = make(map[string]string, len(ch.Webhook.Headers))
+				for k, v := range ch.Webhook.Headers {
+					webhook.Headers[k] = maskSecret(v)
+				}
+				ch.Webhook = &webhook
+			}
+			channels[i] = ch
+		}
+		s.AlertChannels = channels
+	}
 
 	if len(s.Servers) > 0 {
 		s.Servers = maskServerSecrets(s.Servers)
@@ -79,11 +96,38 @@ func restoreMaskedSecrets(req *config.AppSettings, stored config.AppSettings) {
 	req.AdvancedActions.OPNsense.APIKey = restoreSecret(req.AdvancedActions.OPNsense.APIKey, stored.AdvancedActions.OPNsense.APIKey)
 	req.AdvancedActions.OPNsense.APISecret = restoreSecret(req.AdvancedActions.OPNsense.APISecret, stored.AdvancedActions.OPNsense.APISecret)
 
+	req.Webhook.Secret = restoreSecret(req.Webhook.Secret, stored.Webhook.Secret)
 	for k, v := range req.Webhook.Headers {
 		if v == secretMaskSentinel {
 			req.Webhook.Headers[k] = stored.Webhook.Headers[k]
 		}
 	}
+	storedChannels := make(map[string]config.AlertChannel, len(stored.AlertChannels))
+	for _, ch := range stored.AlertChannels {
+		storedChannels[ch.ID] = ch
+	}
+	for _, ch := range req.AlertChannels {
+		if ch.Webhook == nil {
+			continue
+		}
+		prev, ok := storedChannels[ch.ID]
+		if ch.Webhook.Secret == secretMaskSentinel {
+			ch.Webhook.Secret = ""
+			if ok && prev.Webhook != nil {
+				ch.Webhook.Secret = prev.Webhook.Secret
+			}
+		}
+		for k, v := range ch.Webhook.Headers {
+			if v != secretMaskSentinel {
+				continue
+			}
+			if ok && prev.Webhook != nil {
+				ch.Webhook.Headers[k] = prev.Webhook.Headers[k]
+			} else {
+				delete(ch.Webhook.Headers, k)
+			}
+		}
+	}
 
 	if len(req.Servers) > 0 {
 		storedByID := make(map[string]string, len(stored.Servers))
@@ -110,8 +154,9 @@ func maskServerSecrets(servers []shared.Fail2banServer) []shared.Fail2banServer
 	return out
 }
 
-// stripServerConnectionDetails removes connection information non-admin users
-// have no need to see (support users only ban/unban through the UI).
+// stripServerConnectionDetails removes connection information and the [DEFAULT]
+// overrides (ignoreip lists, ban actions) non-admin users have no need to see
+// (support users only ban/unban through the UI).
 func stripServerConnectionDetails(servers []shared.Fail2banServer) []shared.Fail2banServer {
 	for i := range servers {
 		servers[i].Host = ""
@@ -120,8 +165,10 @@ func stripServerConnectionDetails(servers []shared.Fail2banServer) []shared.Fail
 		servers[i].ConfigPath = ""
 		servers[i].SSHUser = ""
 		servers[i].SSHKeyPath = ""
+		servers[i].SSHHostKey = ""
 		servers[i].AgentURL = ""
 		servers[i].AgentSecret = ""
+		servers[i].JailDefaults = shared.JailDefaultOverrides{}
 	}
 	return servers
 }
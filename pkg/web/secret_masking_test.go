@@ -108,6 +108,7 @@ func settingsWithAllSecrets() (config.AppSettings, map[string]func(*config.AppSe
 		"PfSense.APISecret":      func(s *config.AppSettings) *string { return &s.AdvancedActions.PfSense.APISecret },
 		"OPNsense.APIKey":        func(s *config.AppSettings) *string { return &s.AdvancedActions.OPNsense.APIKey },
 		"OPNsense.APISecret":     func(s *config.AppSettings) *string { return &s.AdvancedActions.OPNsense.APISecret },
+		"Webhook.Secret":         func(s *config.AppSettings) *string { return &s.Webhook.Secret },
 	}
 	for name, get := range fields {
 		*get(&s) = "secret-" + name
@@ -148,3 +149,45 @@ func TestMaskServer(t *testing.T) {
 		t.Errorf("empty secret should stay empty, got %q", empty.AgentSecret)
 	}
 }
+
+func TestStripServerConnectionDetails(t *testing.T) {
+	servers := stripServerConnectionDetails([]config.Fail2banServer{{
+		ID: "edge", Name: "edge", Type: "ssh", Host: "10.0.0.5", SSHUser: "f2b",
+		JailDefaults: config.JailDefaultOverrides{IgnoreIPs: []string{"10.0.0.0/8"}, Banaction: "nftables"},
+	}})
+	if servers[0].Host != "" || servers[0].SSHUser != "" {
+		t.Errorf("connection details not stripped: %+v", servers[0])
+	}
+	if len(servers[0].JailDefaults.IgnoreIPs) != 0 || servers[0].JailDefaults.Banaction != "" {
+		t.Errorf("jail default overrides not stripped: %+v", servers[0].JailDefaults)
+	}
+	if servers[0].Name != "edge" {
+		t.Errorf("server name must be kept, got %q", servers[0].Name)
+	}
+}
+
+func TestAlertChannelWebhookHeadersMasked(t *testing.T) {
+	stored := config.AppSettings{AlertChannels: []config.AlertChannel{{
+		ID:      "ops",
+		Webhook: &config.WebhookSettings{URL: "https://hooks.example.com", Headers: map[string]string{"Authorization": "Bearer ops"}, Secret: "ops-signing"},
+	}}}
+
+	masked := maskAppSettingsSecrets(stored)
+	if masked.AlertChannels[0].Webhook.Headers["Authorization"] != secretMaskSentinel {
+		t.Fatalf("channel webhook header not masked: %q", masked.AlertChannels[0].Webhook.Headers["Authorization"])
+	}
+	if masked.AlertChannels[0].Webhook.Secret != secretMaskSentinel {
+		t.Fatalf("channel webhook secret not masked: %q", masked.AlertChannels[0].Webhook.Secret)
+	}
+	if stored.AlertChannels[0].Webhook.Headers["Authorization"] != "Bearer ops" || stored.AlertChannels[0].Webhook.Secret != "ops-signing" {
+		t.Fatalf("masking mutated the stored channel")
+	}
+
+	restoreMaskedSecrets(&masked, stored)
+	if masked.AlertChannels[0].Webhook.Headers["Authorization"] != "Bearer ops" {
+		t.Fatalf("unchanged channel header should be restored, got %q", masked.AlertChannels[0].Webhook.Headers["Authorization"])
+	}
+	if masked.AlertChannels[0].Webhook.Secret != "ops-signing" {
+		t.Fatalf("unchanged channel webhook secret should be restored, got %q", masked.AlertChannels[0].Webhook.Secret)
+	}
+}
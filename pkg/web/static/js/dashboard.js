@@ -515,6 +515,7 @@ function renderDashboard() {
         + '        <th class="px-2 py-1 sm:px-6 sm:py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider" data-i18n="dashboard.table.jail">Jail</th>'
         + '        <th class="hidden sm:table-cell px-2 py-1 sm:px-6 sm:py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider" data-i18n="dashboard.table.total_banned">Total Banned</th>'
         + '        <th class="hidden sm:table-cell px-2 py-1 sm:px-6 sm:py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider" data-i18n="dashboard.table.new_last_hour">New Last Hour</th>'
+        + '        <th class="hidden md:table-cell px-2 py-1 sm:px-6 sm:py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider" data-i18n="dashboard.table.currently_failed" title="' + escapeHtml(t('dashboard.table.currently_failed_help', 'Failures counted by the filter that have not led to a ban yet (total since jail start in brackets)')) + '">Currently Failed</th>'
         + '        <th class="px-2 py-1 sm:px-6 sm:py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider" data-i18n="dashboard.table.banned_ips">Banned IPs</th>'
         + '      </tr>'
         + '    </thead>'
@@ -535,6 +536,7 @@ function renderDashboard() {
           + '  </td>'
           + '  <td id="' + totalId + '" class="hidden sm:table-cell px-2 py-1 sm:px-6 sm:py-4 whitespace-normal break-words">' + (jail.totalBanned || 0) + '</td>'
           + '  <td id="' + newLastHourId + '" class="hidden sm:table-cell px-2 py-1 sm:px-6 sm:py-4 whitespace-normal break-words">' + (jail.newInLastHour || 0) + '</td>'
+          + '  <td class="hidden md:table-cell px-2 py-1 sm:px-6 sm:py-4 whitespace-normal break-words">' + renderJailFailures(jail.status) + '</td>'
           + '  <td class="px-2 py-1 sm:px-6 sm:py-4 whitespace-normal break-words" id="' + cellId + '">' + bannedHTML + '</td>'
           + '</tr>';
       });
@@ -577,7 +579,37 @@ function renderDashboard() {
         + '        </div>'
         + '        <div>'
         + '          <label for="blockIPInput" class="block text-sm font-medium text-gray-700 mb-2" data-i18n="dashboard.manual_block.ip_label">IP Address</label>'
-        + '          <input type="text" id="blockIPInput" class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500" data-i18n-placeholder="dashboard.manual_block.ip_placeholder" placeholder="e.g., 88.76.21.123" pattern="^([0-9]{1,3}\\.){3}[0-9]{1,3}$|^([0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}$" required>'
+        + '          <input type="text" id="blockIPInput" class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500" data-i18n-placeholder="dashboard.manual_block.ip_placeholder" placeholder="e.g., 88.76.21.123" required>'
+        + '        </div>'
+        + '        <div>'
+        + '          <label for="blockDurationInput" class="block text-sm font-medium text-gray-700 mb-2" data-i18n="dashboard.manual_block.duration_label">Duration</label>'
+        + '          <input type="text" id="blockDurationInput" class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500" data-i18n-placeholder="dashboard.manual_block.duration_placeholder" placeholder="Jail bantime (e.g. 2h, 7d)">'
+        + '        </div>'
+        + '        <div class="md:col-span-2">'
+        + '          <label for="blockReasonInput" class="block text-sm font-medium text-gray-700 mb-2" data-i18n="dashboard.manual_block.reason_label">Reason</label>'
+        + '          <input type="text" id="blockReasonInput" maxlength="500" class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500" data-i18n-placeholder="dashboard.manual_block.reason_placeholder" placeholder="Optional note stored with the ban">'
+        + '        </div>'
+        + '        <div>'
+        + '          <label for="blockScopeSelect" class="block text-sm font-medium text-gray-700 mb-2" data-i18n="dashboard.manual_block.scope_label">Servers</label>'
+        + '          <select id="blockScopeSelect" onchange="updateManualBlockScope()" class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500">'
+        + '            <option value="current" data-i18n="dashboard.manual_block.scope_current">Selected server</option>'
+        + '            <option value="all" data-i18n="dashboard.manual_block.scope_all">All servers</option>'
+        + '            <option value="tags" data-i18n="dashboard.manual_block.scope_tags">Servers with tags</option>'
+        + '            <option value="servers" data-i18n="dashboard.manual_block.scope_servers">Chosen servers</option>'
+        + '          </select>'
+        + '        </div>'
+        + '        <div id="blockScopeTagsField" class="hidden md:col-span-2">'
+        + '          <label for="blockScopeTagsInput" class="block text-sm font-medium text-gray-700 mb-2" data-i18n="dashboard.manual_block.tags_label">Tags</label>'
+        + '          <input type="text" id="blockScopeTagsInput" class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500" data-i18n-placeholder="dashboard.manual_block.tags_placeholder" placeholder="Comma-separated, e.g. production, dmz">'
+        + '        </div>'
+        + '        <div id="blockScopeServersField" class="hidden md:col-span-2">'
+        + '          <label for="blockScopeServersSelect" class="block text-sm font-medium text-gray-700 mb-2" data-i18n="dashboard.manual_block.servers_label">Servers</label>'
+        + '          <select id="blockScopeServersSelect" multiple class="w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500">';
+      serversCache.filter(function(s) { return s.enabled; }).forEach(function(server) {
+        html += '            <option value="' + escapeHtml(server.id) + '">' + escapeHtml(server.name || server.id) + '</option>';
+      });
+      html += ''
+        + '          </select>'
         + '        </div>'
         + '        <div class="flex items-end">'
         + '          <button type="button" onclick="handleManualBlock()" class="w-full bg-red-600 text-white px-4 py-2 rounded hover:bg-red-700 transition-colors flex items-center justify-center gap-2">'
@@ -626,6 +658,42 @@ function renderDashboard() {
 //  Rendering the colapsable "Banned IPs per jail" section
 // =========================================================================
 
+// Short "1d 4h" / "35m" form of a remaining ban time in seconds.
+function formatBanRemaining(seconds) {
+  var units = [[86400, 'd'], [3600, 'h'], [60, 'm']];
+  var parts = [];
+  var rest = Math.max(0, Math.floor(seconds || 0));
+  units.forEach(function(unit) {
+    if (parts.length < 2 && rest >= unit[0]) {
+      parts.push(Math.floor(rest / unit[0]) + unit[1]);
+      rest = rest % unit[0];
+    }
+  });
+  return parts.length ? parts.join(' ') : '<1m';
+}
+
+// Remaining time, permanence and ban count of a banned IP entry; empty when the server reported no window.
+function bannedIpMeta(entry) {
+  var labels = [];
+  if (entry.permanent) {
+    labels.push(t('dashboard.banned.permanent', 'permanent'));
+  } else if (entry.expiresAt) {
+    labels.push(t('dashboard.banned.expires_in', 'expires in {time}').replace('{time}', formatBanRemaining(entry.remainingSeconds)));
+  }
+  if (entry.banCount > 1) {
+    labels.push(t('dashboard.banned.ban_count', 'ban #{count}').replace('{count}', entry.banCount));
+  }
+  if (!labels.length) return '';
+  var title = [];
+  if (entry.bannedAt) {
+    title.push(t('dashboard.banned.banned_at', 'Banned at {time}').replace('{time}', formatDateTime(entry.bannedAt)));
+  }
+  if (entry.expiresAt) {
+    title.push(t('dashboard.banned.expires_at', 'Expires at {time}').replace('{time}', formatDateTime(entry.expiresAt)));
+  }
+  return '<span class="ml-2 text-xs text-gray-500" title="' + escapeHtml(title.join('\n')) + '">' + escapeHtml(labels.join(' - ')) + '</span>';
+}
+
 function renderBannedIPs(jailName) {
   var state = getJailBannedState(jailName);
   var query = (bannedIPsFilterText || '').trim();
@@ -642,7 +710,8 @@ function renderBannedIPs(jailName) {
     }
     return content + '<em class="text-gray-500" data-i18n="dashboard.no_banned_ips">No banned IPs</em></div>';
   }
-  function bannedIpRow(ip) {
+  function bannedIpRow(entry) {
+    var ip = typeof entry === 'string' ? entry : entry.ip;
     var safeIp = escapeHtml(ip);
     var encodedIp = encodeURIComponent(ip);
     var ipLabel = '';
@@ -657,17 +726,18 @@ function renderBannedIPs(jailName) {
     } else {
       ipLabel = '<span class="text-sm" data-ip-value="' + encodedIp + '">' + ipText + '</span>';
     }
+    var meta = typeof entry === 'string' ? '' : bannedIpMeta(entry);
     return ''
       + '<div class="flex items-center justify-between banned-ip-item" data-ip="' + safeIp + '">'
-      + ipLabel
+      + '<span>' + ipLabel + meta + '</span>'
       + '  <button class="bg-yellow-500 text-white px-3 py-1 rounded text-sm hover:bg-yellow-600 transition-colors"'
       + '    onclick="unbanIP(\'' + escapeHtml(jailName) + '\', \'' + escapeHtml(ip) + '\')">'
       + '    <span data-i18n="dashboard.unban">Unban</span>'
       + '  </button>'
       + '</div>';
   }
-  state.ips.forEach(function(ip) {
-    content += bannedIpRow(ip);
+  state.ips.forEach(function(entry) {
+    content += bannedIpRow(entry);
   });
   if (state.hasMore) {
     var loadMoreLabel = t('dashboard.banned.show_more', 'Show more');
@@ -836,6 +906,12 @@ function renderLogOverviewContent() {
       } else {
         eventTypeBadge = ' <span class="ml-2 inline-flex items-center px-2 py-0.5 rounded text-xs font-medium bg-red-100 text-red-800">' + t('logs.badge.banned', 'Banned') + '</span>';
       }
+      if (event.actor || event.reason) {
+        var manualLabel = event.actor
+          ? t('logs.badge.manual_by', 'Manual: {actor}').replace('{actor}', event.actor)
+          : t('logs.badge.manual', 'Manual');
+        eventTypeBadge += ' <span class="ml-2 inline-flex items-center px-2 py-0.5 rounded text-xs font-medium bg-gray-100 text-gray-800" title="' + escapeHtml(event.reason || '') + '">' + escapeHtml(manualLabel) + '</span>';
+      }
       html += ''
         + '      <tr class="hover:bg-gray-50">'
         + '        <td class="px-2 py-2 whitespace-nowrap">' + escapeHtml(formatDateTime(event.occurredAt || event.createdAt)) + '</td>'
@@ -1052,6 +1128,18 @@ function toggleManualBlockSection() {
   }
 }
 
+// Shows the tag or server picker matching the selected ban scope.
+function updateManualBlockScope() {
+  var scope = document.getElementById('blockScopeSelect');
+  var tagsField = document.getElementById('blockScopeTagsField');
+  var serversField = document.getElementById('blockScopeServersField');
+  if (!scope || !tagsField || !serversField) {
+    return;
+  }
+  tagsField.classList.toggle('hidden', scope.value !== 'tags');
+  serversField.classList.toggle('hidden', scope.value !== 'servers');
+}
+
 function handleManualBlock() {
   var jailSelect = document.getElementById('blockJailSelect');
   var ipInput = document.getElementById('blockIPInput');
@@ -1070,17 +1158,86 @@ function handleManualBlock() {
     ipInput.focus();
     return;
   }
-  // IPv4 / IPv6 validation
-  var ipv4Pattern = /^([0-9]{1,3}\.){3}[0-9]{1,3}$/;
-  var ipv6Pattern = /^([0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}$/;
+  // IPv4 / IPv6 address, optionally with a CIDR prefix
+  var ipv4Pattern = /^([0-9]{1,3}\.){3}[0-9]{1,3}(\/[0-9]{1,2})?$/;
+  var ipv6Pattern = /^([0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}(\/[0-9]{1,3})?$/;
   if (!ipv4Pattern.test(ip) && !ipv6Pattern.test(ip)) {
     showToast(t('dashboard.manual_block.invalid_ip', 'Please enter a valid IP address'), 'error');
     ipInput.focus();
     return;
   }
-  banIP(jail, ip);
-  ipInput.value = '';
-  jailSelect.value = '';
+
+  var durationInput = document.getElementById('blockDurationInput');
+  var reasonInput = document.getElementById('blockReasonInput');
+  var scopeSelect = document.getElementById('blockScopeSelect');
+  var payload = {
+    ip: ip,
+    jails: [jail],
+    duration: durationInput ? durationInput.value.trim() : '',
+    reason: reasonInput ? reasonInput.value.trim() : ''
+  };
+  var scope = scopeSelect ? scopeSelect.value : 'current';
+  var target = currentServer ? (currentServer.name || currentServer.id) : '';
+  if (scope === 'all') {
+    payload.allServers = true;
+    target = t('dashboard.manual_block.scope_all', 'All servers');
+  } else if (scope === 'tags') {
+    var tagsInput = document.getElementById('blockScopeTagsInput');
+    payload.tags = (tagsInput ? tagsInput.value : '').split(',').map(function(v) { return v.trim(); }).filter(Boolean);
+    if (!payload.tags.length) {
+      showToast(t('dashboard.manual_block.scope_required', 'Please select at least one server or tag'), 'error');
+      return;
+    }
+    target = payload.tags.join(', ');
+  } else if (scope === 'servers') {
+    var serversSelect = document.getElementById('blockScopeServersSelect');
+    payload.serverIds = serversSelect ? Array.prototype.filter.call(serversSelect.options, function(o) { return o.selected; }).map(function(o) { return o.value; }) : [];
+    if (!payload.serverIds.length) {
+      showToast(t('dashboard.manual_block.scope_required', 'Please select at least one server or tag'), 'error');
+      return;
+    }
+    target = payload.serverIds.length + ' ' + t('dashboard.manual_block.servers_label', 'Servers');
+  }
+
+  var confirmMsg = isLOTRModeActive
+    ? 'Banish ' + ip + ' from the realm in ' + jail + '?'
+    : t('dashboard.manual_block.confirm_scope', 'Block {ip} in jail {jail} on {target}?').replace('{ip}', ip).replace('{jail}', jail).replace('{target}', target);
+  if (!confirm(confirmMsg)) {
+    return;
+  }
+  showLoading(true);
+  fetch(withServerParam('/api/bans'), {
+    method: 'POST',
+    headers: serverHeaders({ 'Content-Type': 'application/json' }),
+    body: JSON.stringify(payload)
+  })
+    .then(function(res) { return res.json(); })
+    .then(function(data) {
+      showLoading(false);
+      if (data.error) {
+        showToast(formatApiError(data, 'dashboard.toast.block_error', 'Error blocking IP'), 'error');
+        return;
+      }
+      var results = data.results || [];
+      if (data.failed > 0) {
+        var failures = results.filter(function(r) { return !r.success; }).map(function(r) { return r.serverName + ': ' + r.error; });
+        showToast(t('dashboard.manual_block.partial', 'Blocked on {ok} of {total} targets').replace('{ok}', data.banned).replace('{total}', results.length) + ': ' + failures.join('; '), 'warning', 10000);
+      } else {
+        showToast(t('dashboard.manual_block.success', 'IP blocked successfully'), 'success');
+      }
+      results.filter(function(r) { return r.success && r.warning; }).forEach(function(r) {
+        showToast(r.serverName + ': ' + r.warning, 'warning', 10000);
+      });
+      ipInput.value = '';
+      jailSelect.value = '';
+      if (durationInput) durationInput.value = '';
+      if (reasonInput) reasonInput.value = '';
+      refreshAfterManualAction(jail);
+    })
+    .catch(function(err) {
+      showLoading(false);
+      showToast(t('common.error', 'Error') + ': ' + err, 'error');
+    });
 }
 
 function renderLogOverviewSection() {
@@ -1134,6 +1291,17 @@ function applyAllJailRowVisibility() {
   });
 }
 
+// Failure counters from fail2ban-client status; agents without status support show a dash.
+function renderJailFailures(status) {
+  if (!status) {
+    return '<span class="text-gray-400">-</span>';
+  }
+  var current = status.currentlyFailed || 0;
+  var cls = current > 0 ? 'text-yellow-700 font-semibold' : '';
+  return '<span class="' + cls + '">' + current + '</span>'
+    + ' <span class="text-xs text-gray-400">(' + (status.totalFailed || 0) + ')</span>';
+}
+
 function updateSummaryCountersFromLatestSummary() {
   if (!latestSummary || !Array.isArray(latestSummary.jails)) {
     return;
@@ -304,7 +304,7 @@ function testSelectedFilter() {
         showToast(t('filters.toast.test_error', 'Error testing filter') + ': ' + data.error, 'error');
         return;
       }
-      renderTestResults(data.output || '', data.filterPath || '');
+      renderTestResults(data.output || '', data.filterPath || '', data.result || null);
     })
     .catch(err => {
       showToast(t('filters.toast.test_error', 'Error testing filter') + ': ' + err, 'error');
@@ -312,7 +312,7 @@ function testSelectedFilter() {
     .finally(() => showLoading(false));
 }
 
-function renderTestResults(output, filterPath) {
+function renderTestResults(output, filterPath, result) {
   const testResultsEl = document.getElementById('testResults');
   let html = '<h5 class="text-lg font-medium text-white mb-4" data-i18n="filter_debug.test_results_title">Test Results</h5>';
 
@@ -324,6 +324,10 @@ function renderTestResults(output, filterPath) {
   }
   if (!output || output.trim() === '') {
     html += '<p class="text-gray-400" data-i18n="filter_debug.no_matches">No output received.</p>';
+  } else if (result && result.totals && result.totals.lines > 0) {
+    html += renderStructuredTestResult(result);
+    html += '<details class="mt-4"><summary class="cursor-pointer text-gray-400">' + t('filter_debug.raw_output', 'Raw fail2ban-regex output') + '</summary>';
+    html += '<pre class="mt-2 text-white whitespace-pre-wrap overflow-x-auto">' + escapeHtml(output) + '</pre></details>';
   } else {
     html += '<pre class="text-white whitespace-pre-wrap overflow-x-auto">' + escapeHtml(output) + '</pre>';
   }
@@ -334,6 +338,60 @@ function renderTestResults(output, filterPath) {
   }
 }
 
+// Renders totals, per-line results (matched lines with the failregex that hit them) and date templates.
+function renderStructuredTestResult(result) {
+  const totals = result.totals;
+  let html = '<div class="mb-3 flex flex-wrap gap-4 text-sm">';
+  html += '<span>' + t('filter_debug.total_lines', 'Lines') + ': ' + totals.lines + '</span>';
+  html += '<span class="text-green-400">' + t('filter_debug.matched', 'Matched') + ': ' + totals.matched + '</span>';
+  html += '<span class="text-yellow-400">' + t('filter_debug.ignored', 'Ignored') + ': ' + totals.ignored + '</span>';
+  html += '<span class="text-red-400">' + t('filter_debug.missed', 'Missed') + ': ' + totals.missed + '</span>';
+  html += '</div>';
+
+  if (result.failregex && result.failregex.length) {
+    html += '<div class="mb-3 text-sm"><div class="text-gray-400 mb-1">' + t('filter_debug.failregex_hits', 'Failregex hits') + '</div>';
+    result.failregex.forEach(function(stat) {
+      const color = stat.hits > 0 ? 'text-green-300' : 'text-gray-500';
+      html += '<div class="' + color + ' break-all">#' + stat.index + ' [' + stat.hits + '] ' + escapeHtml(stat.regex) + '</div>';
+    });
+    html += '</div>';
+  }
+
+  const rows = [];
+  (result.matched || []).forEach(function(m) {
+    let info = m.regexes && m.regexes.length ? '#' + m.regexes.join(', #') : '';
+    if (m.ip) info += (info ? ' ' : '') + escapeHtml(m.ip);
+    rows.push({ cls: 'text-green-300', label: t('filter_debug.matched', 'Matched'), info: info, line: m.line });
+  });
+  (result.ignored || []).forEach(function(line) {
+    rows.push({ cls: 'text-yellow-300', label: t('filter_debug.ignored', 'Ignored'), info: '', line: line });
+  });
+  (result.missed || []).forEach(function(line) {
+    rows.push({ cls: 'text-red-300', label: t('filter_debug.missed', 'Missed'), info: '', line: line });
+  });
+  if (rows.length) {
+    html += '<table class="w-full text-xs mb-3"><tbody>';
+    rows.forEach(function(row) {
+      html += '<tr class="border-t border-gray-700 align-top">';
+      html += '<td class="pr-3 py-1 whitespace-nowrap ' + row.cls + '">' + row.label + '</td>';
+      html += '<td class="pr-3 py-1 whitespace-nowrap text-gray-300">' + row.info + '</td>';
+      html += '<td class="py-1 break-all">' + escapeHtml(row.line) + '</td>';
+      html += '</tr>';
+    });
+    html += '</tbody></table>';
+  }
+
+  const templates = (result.dateTemplates || []).filter(function(tpl) { return tpl.hits > 0; });
+  html += '<div class="text-sm"><span class="text-gray-400">' + t('filter_debug.date_template', 'Date template') + ':</span> ';
+  if (templates.length) {
+    html += templates.map(function(tpl) { return '<span class="font-mono">' + escapeHtml(tpl.format) + '</span> [' + tpl.hits + ']'; }).join(', ');
+  } else {
+    html += '<span class="text-red-300">' + t('filter_debug.no_date_template', 'No date template matched') + '</span>';
+  }
+  html += '</div>';
+  return html;
+}
+
 // =========================================================================
 //  Filter Section Init
 // =========================================================================
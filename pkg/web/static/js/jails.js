@@ -37,6 +37,7 @@ function createJail() {
       }
       closeModal('createJailModal');
       showToast(data.message || t('jails.toast.create_success', 'Jail created successfully'), 'success');
+      showSkippedJailKeys(data.skippedKeys);
       openManageJailsModal();
     })
     .catch(function(err) {
@@ -90,6 +91,7 @@ function saveJailConfig() {
       } else {
         showToast(t('filter_debug.save_success', 'Filter and jail config saved and reloaded'), 'success');
       }
+      showSkippedJailKeys(data.skippedKeys);
       return refreshData({ silent: true });
     })
     .catch(function(err) {
@@ -101,6 +103,12 @@ function saveJailConfig() {
     });
 }
 
+// Warns about jail options that were commented out because the server's fail2ban version lacks them.
+function showSkippedJailKeys(keys) {
+  if (!keys || !keys.length) return;
+  showToast(t('jails.toast.keys_unsupported', 'Not supported by the fail2ban version of this server, commented out: {keys}').replace('{keys}', keys.join(', ')), 'warning', 12000);
+}
+
 function updateJailConfigFromFilter() {
   const filterSelect = document.getElementById('newJailFilter');
   const jailNameInput = document.getElementById('newJailName');
@@ -192,6 +192,17 @@ function renderServerManagerList() {
       descriptor.push(server.hostname);
     }
     var meta = descriptor.join(' - ');
+    var capabilities = '';
+    var caps = server.capabilities;
+    if (caps && caps.fail2banVersion) {
+      var capParts = ['fail2ban ' + caps.fail2banVersion];
+      if (caps.pythonVersion) capParts.push('Python ' + caps.pythonVersion);
+      if (caps.backend) capParts.push(t('servers.card.backend', 'Backend') + ': ' + caps.backend);
+      capabilities = '<div class="mt-1 text-xs text-gray-500">' + escapeHtml(capParts.join(' - ')) + '</div>';
+    } else if (caps && caps.error) {
+      capabilities = '<div class="mt-1 text-xs text-yellow-700">' + escapeHtml(t('servers.card.version_unknown', 'Version unknown') + ': ' + caps.error) + '</div>';
+    }
+    var jailDefaults = renderServerJailDefaults(server.effectiveDefaults);
     var tags = (server.tags || []).length
       ? '<div class="mt-2 text-xs text-gray-500">' + escapeHtml(server.tags.join(', ')) + '</div>'
       : '';
@@ -220,6 +231,8 @@ function renderServerManagerList() {
       + '<code class="px-1 py-0.5 bg-gray-100 rounded select-all">' + escapeHtml(server.id || '') + '</code>'
       + '</p>'
       +        localDetails
+      +        capabilities
+      +        jailDefaults
       +        tags
       + '    </div>'
       + '    <div class="flex flex-col gap-2">'
@@ -230,6 +243,7 @@ function renderServerManagerList() {
         ? '<button class="text-sm text-blue-600 hover:text-blue-800 relative group" onclick="restartFail2banServer(\'' + escapeHtml(server.id) + '\')" data-i18n="servers.actions.reload" title="">Reload Fail2ban</button>'
         : '<button class="text-sm text-blue-600 hover:text-blue-800" onclick="restartFail2banServer(\'' + escapeHtml(server.id) + '\')" data-i18n="servers.actions.restart">Restart Fail2ban</button>') : '')
       + '      <button class="text-sm text-blue-600 hover:text-blue-800" onclick="testServerConnection(\'' + escapeHtml(server.id) + '\')" data-i18n="servers.actions.test">Test connection</button>'
+      + (server.enabled ? '<button class="text-sm text-blue-600 hover:text-blue-800" onclick="importServerBanHistory(\'' + escapeHtml(server.id) + '\')" data-i18n="servers.actions.import_history">Import ban history</button>' : '')
       + '      <button class="text-sm text-red-600 hover:text-red-800" onclick="deleteServer(\'' + escapeHtml(server.id) + '\')" data-i18n="servers.actions.delete">Delete</button>'
       + '    </div>'
       + '  </div>'
@@ -292,10 +306,69 @@ function setCurrentServer(serverId) {
   refreshData();
 }
 
+// Compact line with the [DEFAULT] values written to the server's jail.local; overridden ones are highlighted.
+function renderServerJailDefaults(eff) {
+  if (!eff) return '';
+  var overridden = eff.overridden || [];
+  var overriddenTitle = escapeHtml(t('servers.card.jail_defaults_overridden', 'Overridden for this server'));
+  var entries = serverJailDefaultFields.map(function(field) {
+    return { key: field.key, text: field.label + ' ' + (eff[field.key] || ''), empty: !eff[field.key] };
+  });
+  entries.push({ key: 'ignoreips', text: 'ignoreip ' + (eff.ignoreips || []).join(' '), empty: !(eff.ignoreips || []).length });
+  var parts = entries.filter(function(entry) { return !entry.empty; }).map(function(entry) {
+    if (overridden.indexOf(entry.key) !== -1) {
+      return '<span class="font-semibold text-blue-700" title="' + overriddenTitle + '">' + escapeHtml(entry.text) + '</span>';
+    }
+    return escapeHtml(entry.text);
+  });
+  if (!parts.length) return '';
+  return '<div class="mt-1 text-xs text-gray-500">' + escapeHtml(t('servers.card.jail_defaults', 'Jail defaults')) + ': ' + parts.join(' - ') + '</div>';
+}
+
 // =========================================================================
 //  Server manager form actions
 // =========================================================================
 
+// Form inputs for the per-server [DEFAULT] overrides (ignoreips is handled separately).
+var serverJailDefaultFields = [
+  { id: 'serverJailBantime', key: 'bantime', label: 'bantime' },
+  { id: 'serverJailFindtime', key: 'findtime', label: 'findtime' },
+  { id: 'serverJailMaxretry', key: 'maxretry', label: 'maxretry' },
+  { id: 'serverJailBanaction', key: 'banaction', label: 'banaction' },
+  { id: 'serverJailBanactionAllports', key: 'banactionAllports', label: 'banaction_allports' },
+  { id: 'serverJailChain', key: 'chain', label: 'chain' }
+];
+
+// Fills the override inputs; empty inputs show the global value the server falls back to.
+function fillServerJailDefaults(server) {
+  var overrides = (server && server.jailDefaults) || {};
+  var eff = (server && server.effectiveDefaults) || {};
+  var overridden = eff.overridden || [];
+  var globalPlaceholder = t('servers.form.jail_defaults.global_placeholder', 'Global default');
+  serverJailDefaultFields.forEach(function(field) {
+    var input = document.getElementById(field.id);
+    input.value = overrides[field.key] || '';
+    var globalValue = overridden.indexOf(field.key) === -1 ? eff[field.key] : '';
+    input.placeholder = globalValue ? globalPlaceholder + ': ' + globalValue : globalPlaceholder;
+  });
+  var ipsInput = document.getElementById('serverJailIgnoreIPs');
+  ipsInput.value = (overrides.ignoreips || []).join(' ');
+  var globalIPs = overridden.indexOf('ignoreips') === -1 ? (eff.ignoreips || []).join(' ') : '';
+  ipsInput.placeholder = globalIPs ? globalPlaceholder + ': ' + globalIPs : globalPlaceholder;
+}
+
+function collectServerJailDefaults() {
+  var overrides = {};
+  serverJailDefaultFields.forEach(function(field) {
+    var value = document.getElementById(field.id).value.trim();
+    if (!value) return;
+    overrides[field.key] = field.key === 'maxretry' ? parseInt(value, 10) : value;
+  });
+  var ips = document.getElementById('serverJailIgnoreIPs').value.split(/[\s,]+/).filter(Boolean);
+  if (ips.length) overrides.ignoreips = ips;
+  return overrides;
+}
+
 function resetServerForm() {
   showServerFormView();
   document.getElementById('serverId').value = '';
@@ -308,9 +381,13 @@ function resetServerForm() {
   document.getElementById('serverHostname').value = '';
   document.getElementById('serverSSHUser').value = '';
   document.getElementById('serverSSHKey').value = '';
+  document.getElementById('serverSSHHostKey').value = '';
+  document.getElementById('serverSSHHostKey').dataset.pinned = '';
   document.getElementById('serverAgentUrl').value = '';
   document.getElementById('serverAgentSecret').value = '';
   document.getElementById('serverTags').value = '';
+  fillServerJailDefaults(null);
+  document.getElementById('serverEventMode').value = 'callback';
   document.getElementById('serverDefault').checked = false;
   document.getElementById('serverEnabled').checked = false;
   document.getElementById('serverReverseTunnel').checked = false;
@@ -332,9 +409,13 @@ function editServer(serverId) {
   document.getElementById('serverHostname').value = server.hostname || '';
   document.getElementById('serverSSHUser').value = server.sshUser || '';
   document.getElementById('serverSSHKey').value = server.sshKeyPath || '';
+  document.getElementById('serverSSHHostKey').value = server.sshHostKey || '';
+  document.getElementById('serverSSHHostKey').dataset.pinned = server.sshHostKey || '';
   document.getElementById('serverAgentUrl').value = server.agentUrl || '';
   document.getElementById('serverAgentSecret').value = server.agentSecret || '';
   document.getElementById('serverTags').value = (server.tags || []).join(',');
+  fillServerJailDefaults(server);
+  document.getElementById('serverEventMode').value = server.eventMode || 'callback';
   document.getElementById('serverDefault').checked = !!server.isDefault;
   document.getElementById('serverEnabled').checked = !!server.enabled;
   document.getElementById('serverReverseTunnel').checked = !!server.reverseTunnelEnabled;
@@ -449,11 +530,16 @@ function submitServerForm(event) {
     hostname: document.getElementById('serverHostname').value.trim(),
     sshUser: document.getElementById('serverSSHUser').value.trim(),
     sshKeyPath: document.getElementById('serverSSHKey').value.trim(),
+    sshHostKey: document.getElementById('serverSSHHostKey').value.trim(),
+    // An empty key keeps the pinned one unless the user cleared it here.
+    sshHostKeyReset: !!document.getElementById('serverSSHHostKey').dataset.pinned && !document.getElementById('serverSSHHostKey').value.trim(),
     agentUrl: document.getElementById('serverAgentUrl').value.trim(),
     agentSecret: document.getElementById('serverAgentSecret').value.trim(),
     tags: document.getElementById('serverTags').value
       ? document.getElementById('serverTags').value.split(',').map(function(tag) { return tag.trim(); }).filter(Boolean)
       : [],
+    jailDefaults: collectServerJailDefaults(),
+    eventMode: document.getElementById('serverEventMode').value,
     enabled: document.getElementById('serverEnabled').checked,
     reverseTunnelEnabled: document.getElementById('serverReverseTunnel').checked
   };
@@ -511,6 +597,8 @@ function submitServerForm(event) {
   if (payload.type !== 'ssh') {
     delete payload.sshUser;
     delete payload.sshKeyPath;
+    delete payload.sshHostKey;
+    delete payload.sshHostKeyReset;
     delete payload.reverseTunnelEnabled;
   }
   if (payload.type !== 'agent') {
@@ -548,6 +636,9 @@ function submitServerForm(event) {
       if (data.actionFileWarning) {
         showToast(data.actionFileWarning, 'warning', 12000);
       }
+      if (data.jailDefaultsWarning) {
+        showToast(data.jailDefaultsWarning, 'warning', 12000);
+      }
       var saved = data.server || {};
       currentServerId = saved.id || currentServerId;
       return loadServers().then(function() {
@@ -733,6 +824,40 @@ function testServerConnection(serverId) {
     });
 }
 
+function importServerBanHistory(serverId) {
+  if (!serverId) return;
+  fetch(appPath('/api/servers/' + encodeURIComponent(serverId) + '/import-history'), {
+    method: 'POST'
+  })
+    .then(function(res) { return res.json(); })
+    .then(function(data) {
+      if (data.error) {
+        showToast(formatApiError(data, 'servers.actions.import_history_failure', 'Ban history import failed'), 'error');
+        return;
+      }
+      showToast(t('servers.actions.import_history_started', 'Ban history import started'), 'info');
+    })
+    .catch(function(err) {
+      showToast(t('servers.actions.import_history_failure', 'Ban history import failed') + ': ' + err, 'error');
+    });
+}
+
+// Shows the final state of a ban history import reported over the WebSocket.
+function handleBanImportProgress(progress) {
+  if (!progress) return;
+  var name = progress.serverName || progress.serverId;
+  if (progress.status === 'done') {
+    showToast(t('servers.actions.import_history_done', 'Ban history imported')
+      + ' (' + name + '): ' + progress.imported + ' / ' + progress.total, 'success');
+    if (typeof refreshData === 'function') {
+      refreshData({ silent: true });
+    }
+  } else if (progress.status === 'error') {
+    showToast(t('servers.actions.import_history_failure', 'Ban history import failed')
+      + ' (' + name + '): ' + (progress.error || ''), 'error', 10000);
+  }
+}
+
 function deleteServer(serverId) {
   if (!confirm(t('servers.actions.delete_confirm', 'Delete this server entry?'))) return;
   showLoading(true);
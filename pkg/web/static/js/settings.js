@@ -99,6 +99,9 @@ function loadSettings() {
       applyWebhookSettings(data.webhook || {});
       applyElasticsearchSettings(data.elasticsearch || {});
       applyThreatIntelSettings(data.threatIntel || {});
+      applyHealthMonitorSettings(data.healthMonitor || {});
+      applyBanPropagationSettings(data.banPropagation || {});
+      applyAlertChannels(data.alertChannels || []);
       updateAlertProviderFields();
       updateThreatIntelProviderFields();
       updateAlertFieldsState();
@@ -155,6 +158,8 @@ function loadSettings() {
 
       applyAdvancedActionsSettings(data.advancedActions || {});
       loadPermanentBlockLog();
+      loadAlertOutbox();
+      loadWebhookPresets();
     })
     .catch(err => {
       showToast(t('settings.toast.load_error', 'Error loading settings') + ': ' + err, 'error');
@@ -240,6 +245,9 @@ function saveSettings(event) {
     webhook: collectWebhookSettings(),
     elasticsearch: collectElasticsearchSettings(),
     threatIntel: collectThreatIntelSettings(),
+    healthMonitor: collectHealthMonitorSettings(),
+    banPropagation: collectBanPropagationSettings(),
+    alertChannels: collectAlertChannels(),
     advancedActions: collectAdvancedActionsSettings()
   };
 
@@ -284,12 +292,13 @@ function saveSettings(event) {
 
 function updateAlertProviderFields() {
   const selected = document.getElementById('alertProvider').value;
+  const inUse = alertChannelProviders();
   const emailDiv = document.getElementById('alertEmailFields');
   const webhookDiv = document.getElementById('alertWebhookFields');
   const esDiv = document.getElementById('alertElasticsearchFields');
-  if (emailDiv) emailDiv.classList.toggle('hidden', selected !== 'email');
-  if (webhookDiv) webhookDiv.classList.toggle('hidden', selected !== 'webhook');
-  if (esDiv) esDiv.classList.toggle('hidden', selected !== 'elasticsearch');
+  if (emailDiv) emailDiv.classList.toggle('hidden', selected !== 'email' && !inUse.email);
+  if (webhookDiv) webhookDiv.classList.toggle('hidden', selected !== 'webhook' && !inUse.webhook);
+  if (esDiv) esDiv.classList.toggle('hidden', selected !== 'elasticsearch' && !inUse.elasticsearch);
 }
 
 function updateSmtpAuthOnChange() {
@@ -315,7 +324,7 @@ function updateSmtpAuthFields() {
 function updateAlertFieldsState() {
   const alertsForBans = document.getElementById('emailAlertsForBans').checked;
   const alertsForUnbans = document.getElementById('emailAlertsForUnbans').checked;
-  const alertsEnabled = alertsForBans || alertsForUnbans;
+  const alertsEnabled = alertsForBans || alertsForUnbans || Object.keys(alertChannelProviders()).length > 0;
 
   const emailFields = [
     document.getElementById('destEmail'),
@@ -377,6 +386,10 @@ function applyWebhookSettings(cfg) {
   document.getElementById('webhookUrl').value = cfg.url || '';
   document.getElementById('webhookMethod').value = cfg.method || 'POST';
   document.getElementById('webhookSkipTLS').checked = cfg.skipTLSVerify || false;
+  document.getElementById('webhookTemplate').value = cfg.template || '';
+  document.getElementById('webhookContentType').value = cfg.contentType || '';
+  document.getElementById('webhookSecret').value = cfg.secret || '';
+  document.getElementById('webhookPreview').classList.add('hidden');
   const headersEl = document.getElementById('webhookHeaders');
   if (headersEl && cfg.headers && typeof cfg.headers === 'object') {
     headersEl.value = Object.entries(cfg.headers).map(([k, v]) => k + ': ' + v).join('\n');
@@ -400,10 +413,64 @@ function collectWebhookSettings() {
     url: document.getElementById('webhookUrl').value.trim(),
     method: document.getElementById('webhookMethod').value || 'POST',
     headers: headers,
-    skipTLSVerify: document.getElementById('webhookSkipTLS').checked
+    skipTLSVerify: document.getElementById('webhookSkipTLS').checked,
+    template: document.getElementById('webhookTemplate').value,
+    contentType: document.getElementById('webhookContentType').value.trim(),
+    secret: document.getElementById('webhookSecret').value.trim()
   };
 }
 
+// Built-in body templates, loaded once from the server.
+var webhookPresets = [];
+
+function loadWebhookPresets() {
+  fetch(appPath('/api/settings/webhook-presets'))
+    .then(res => res.json())
+    .then(data => {
+      webhookPresets = data.presets || [];
+      document.querySelectorAll('.webhook-preset-select').forEach(fillWebhookPresetSelect);
+    })
+    .catch(err => console.error('Error loading webhook presets:', err));
+}
+
+function fillWebhookPresetSelect(select) {
+  while (select.options.length > 1) select.remove(1);
+  webhookPresets.forEach(function(preset) {
+    const opt = document.createElement('option');
+    opt.value = preset.id;
+    opt.textContent = preset.name;
+    select.appendChild(opt);
+  });
+}
+
+function applyWebhookPreset(select, templateEl, contentTypeEl) {
+  const preset = webhookPresets.find(function(p) { return p.id === select.value; });
+  select.value = '';
+  if (!preset) return;
+  if (templateEl.value.trim() && !confirm(t('settings.webhook.preset_replace', 'Replace the current template?'))) return;
+  templateEl.value = preset.template;
+  contentTypeEl.value = preset.contentType;
+}
+
+function previewWebhookTemplate(templateEl, contentTypeEl, eventType, output) {
+  fetch(appPath('/api/settings/webhook-preview'), {
+    method: 'POST',
+    headers: serverHeaders({ 'Content-Type': 'application/json' }),
+    body: JSON.stringify({ template: templateEl.value, contentType: contentTypeEl.value.trim(), event: eventType })
+  })
+    .then(res => res.json())
+    .then(data => {
+      output.classList.remove('hidden', 'text-red-600');
+      if (data.error) {
+        output.classList.add('text-red-600');
+        output.textContent = data.error;
+        return;
+      }
+      output.textContent = 'Content-Type: ' + data.contentType + '\n\n' + data.body + (data.warning ? '\n\n' + t('settings.webhook.preview_warning', 'Warning') + ': ' + data.warning : '');
+    })
+    .catch(err => showToast(String(err), 'error'));
+}
+
 function sendTestWebhook() {
   showLoading(true);
   fetch(appPath('/api/settings/test-webhook'), {
@@ -465,6 +532,252 @@ function sendTestElasticsearch() {
     .finally(() => showLoading(false));
 }
 
+// =========================================================================
+//  Health Monitor Settings
+// =========================================================================
+
+function applyHealthMonitorSettings(cfg) {
+  cfg = cfg || {};
+  document.getElementById('healthMonitorEnabled').checked = cfg.enabled !== undefined ? cfg.enabled : true;
+  document.getElementById('healthMonitorInterval').value = cfg.intervalSeconds || 60;
+  document.getElementById('healthMonitorDownAlert').value = (typeof cfg.downAlertMinutes === 'number') ? cfg.downAlertMinutes : 5;
+  document.getElementById('healthMonitorCallbackSilence').value = (typeof cfg.callbackSilenceMinutes === 'number') ? cfg.callbackSilenceMinutes : 0;
+}
+
+function collectHealthMonitorSettings() {
+  var interval = parseInt(document.getElementById('healthMonitorInterval').value, 10);
+  var downAlert = parseInt(document.getElementById('healthMonitorDownAlert').value, 10);
+  var silence = parseInt(document.getElementById('healthMonitorCallbackSilence').value, 10);
+  return {
+    enabled: document.getElementById('healthMonitorEnabled').checked,
+    intervalSeconds: isNaN(interval) ? 60 : interval,
+    downAlertMinutes: isNaN(downAlert) ? 5 : Math.max(downAlert, 0),
+    callbackSilenceMinutes: isNaN(silence) ? 0 : Math.max(silence, 0)
+  };
+}
+
+// =========================================================================
+//  Ban Propagation Settings
+// =========================================================================
+
+function applyBanPropagationSettings(cfg) {
+  cfg = cfg || {};
+  document.getElementById('banPropagationEnabled').checked = !!cfg.enabled;
+  document.getElementById('banPropagationJails').value = (cfg.jails || []).join(', ');
+  document.getElementById('banPropagationTargetJail').value = cfg.targetJail || '';
+  document.getElementById('banPropagationTargetTags').value = (cfg.targetTags || []).join(', ');
+  document.getElementById('banPropagationAllowlist').value = (cfg.allowlist || []).join(' ');
+}
+
+function collectBanPropagationSettings() {
+  function list(id) {
+    return document.getElementById(id).value.split(/[\s,]+/).map(function(v) { return v.trim(); }).filter(Boolean);
+  }
+  return {
+    enabled: document.getElementById('banPropagationEnabled').checked,
+    jails: list('banPropagationJails'),
+    targetJail: document.getElementById('banPropagationTargetJail').value.trim(),
+    targetTags: document.getElementById('banPropagationTargetTags').value.split(',').map(function(v) { return v.trim(); }).filter(Boolean),
+    allowlist: list('banPropagationAllowlist')
+  };
+}
+
+// =========================================================================
+//  Alert Channels
+// =========================================================================
+
+const alertChannelEventTypes = ['ban', 'unban', 'permanent_block', 'server_down', 'server_recovered', 'callback_silence'];
+// Webhook settings as loaded, so headers edited through the API survive a save.
+var alertChannelWebhooks = {};
+var alertChannelSeq = 0;
+
+function applyAlertChannels(channels) {
+  const list = document.getElementById('alertChannelsList');
+  if (!list) return;
+  list.innerHTML = '';
+  alertChannelWebhooks = {};
+  (channels || []).forEach(function(ch) { addAlertChannel(ch); });
+  updateAlertProviderFields();
+}
+
+function addAlertChannel(ch) {
+  const list = document.getElementById('alertChannelsList');
+  if (!list) return;
+  const isNew = !ch;
+  ch = ch || { enabled: true, provider: 'email', events: ['ban'] };
+  alertChannelSeq++;
+  const id = ch.id || ('channel-' + Date.now().toString(36) + alertChannelSeq);
+  if (ch.webhook) alertChannelWebhooks[id] = ch.webhook;
+  const events = ch.events || [];
+  const serverIds = ch.servers || [];
+  const throttle = ch.throttle || {};
+
+  const card = document.createElement('div');
+  card.className = 'border border-gray-200 rounded-md p-4';
+  card.dataset.channelId = id;
+  const inputClass = 'w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500';
+  const serverOptions = (serversCache || []).map(function(server) {
+    const selected = serverIds.indexOf(server.id) !== -1 ? ' selected' : '';
+    return '<option value="' + escapeHtml(server.id) + '"' + selected + '>' + escapeHtml(server.name || server.id) + '</option>';
+  }).join('') + serverIds.filter(function(sid) {
+    return !(serversCache || []).some(function(server) { return server.id === sid; });
+  }).map(function(sid) {
+    return '<option value="' + escapeHtml(sid) + '" selected>' + escapeHtml(sid) + '</option>';
+  }).join('');
+  const eventBoxes = alertChannelEventTypes.map(function(type) {
+    const checked = events.indexOf(type) !== -1 ? ' checked' : '';
+    return '<label class="flex items-center text-sm text-gray-700"><input type="checkbox" class="alert-channel-event rounded border-gray-300 text-blue-600 focus:ring-blue-500" value="' + type + '"' + checked + '>'
+      + '<span class="ml-2">' + escapeHtml(t('settings.alert_channels.event.' + type, type)) + '</span></label>';
+  }).join('');
+
+  card.innerHTML = ''
+    + '<div class="flex items-center justify-between mb-3">'
+    + '  <label class="flex items-center"><input type="checkbox" class="alert-channel-enabled rounded border-gray-300 text-blue-600 focus:ring-blue-500"' + (ch.enabled ? ' checked' : '') + '>'
+    + '  <span class="ml-2 text-sm font-medium text-gray-700">' + escapeHtml(t('settings.alert_channels.enabled', 'Enabled')) + '</span></label>'
+    + '  <button type="button" class="alert-channel-remove px-3 py-1.5 text-xs rounded border border-red-300 text-red-600 hover:bg-red-50">' + escapeHtml(t('settings.alert_channels.remove', 'Remove')) + '</button>'
+    + '</div>'
+    + '<div class="grid grid-cols-1 md:grid-cols-3 gap-4 mb-3">'
+    + '  <div><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.name', 'Name')) + '</label>'
+    + '  <input type="text" class="alert-channel-name ' + inputClass + '" value="' + escapeHtml(ch.name || '') + '" placeholder="' + escapeHtml(t('settings.alert_channels.name_placeholder', 'e.g. SOC email')) + '"></div>'
+    + '  <div><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_provider', 'Alert Provider')) + '</label>'
+    + '  <select class="alert-channel-provider ' + inputClass + '">'
+    + '    <option value="email">' + escapeHtml(t('settings.alert_provider_email', 'Email (SMTP)')) + '</option>'
+    + '    <option value="webhook">' + escapeHtml(t('settings.alert_provider_webhook', 'Webhook')) + '</option>'
+    + '    <option value="elasticsearch">' + escapeHtml(t('settings.alert_provider_elasticsearch', 'Elasticsearch')) + '</option>'
+    + '  </select></div>'
+    + '  <div><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.min_severity', 'Minimum Severity')) + '</label>'
+    + '  <select class="alert-channel-severity ' + inputClass + '">'
+    + '    <option value="">' + escapeHtml(t('settings.alert_channels.severity_any', 'Any')) + '</option>'
+    + '    <option value="info">' + escapeHtml(t('settings.alert_channels.severity_info', 'Info')) + '</option>'
+    + '    <option value="warning">' + escapeHtml(t('settings.alert_channels.severity_warning', 'Warning')) + '</option>'
+    + '    <option value="critical">' + escapeHtml(t('settings.alert_channels.severity_critical', 'Critical')) + '</option>'
+    + '  </select></div>'
+    + '</div>'
+    + '<label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.events', 'Events')) + '</label>'
+    + '<div class="grid grid-cols-2 md:grid-cols-3 gap-2 mb-3">' + eventBoxes + '</div>'
+    + '<div class="grid grid-cols-1 md:grid-cols-3 gap-4">'
+    + '  <div><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.countries', 'Countries')) + '</label>'
+    + '  <input type="text" class="alert-channel-countries ' + inputClass + '" value="' + escapeHtml((ch.countries || []).join(', ')) + '" placeholder="DE, CH"></div>'
+    + '  <div><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.jails', 'Jails')) + '</label>'
+    + '  <input type="text" class="alert-channel-jails ' + inputClass + '" value="' + escapeHtml((ch.jails || []).join(', ')) + '" placeholder="sshd"></div>'
+    + '  <div><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.servers', 'Servers')) + '</label>'
+    + '  <select class="alert-channel-servers ' + inputClass + '" multiple size="3">' + serverOptions + '</select></div>'
+    + '</div>'
+    + '<label class="block text-sm font-medium text-gray-700 mt-3 mb-2">' + escapeHtml(t('settings.alert_channels.throttle', 'Throttling')) + '</label>'
+    + '<div class="grid grid-cols-2 md:grid-cols-4 gap-4">'
+    + alertChannelThrottleInput('dedupe', 'settings.alert_channels.throttle_dedupe', 'Skip repeats for the same IP/jail (min)', throttle.dedupeMinutes)
+    + alertChannelThrottleInput('max', 'settings.alert_channels.throttle_max', 'Max alerts', throttle.maxAlerts)
+    + alertChannelThrottleInput('window', 'settings.alert_channels.throttle_window', 'Per window (min)', throttle.windowMinutes)
+    + alertChannelThrottleInput('digest', 'settings.alert_channels.throttle_digest', 'Digest after (min)', throttle.digestMinutes)
+    + '</div>'
+    + '<p class="text-xs text-gray-500 mt-1">' + escapeHtml(t('settings.alert_channels.throttle_hint', 'Applies to ban, unban and permanent block alerts. Alerts over the limit are summarised in a digest of the top IPs, jails, countries and servers. Leave empty to disable.')) + '</p>'
+    + '<div class="alert-channel-email mt-3"><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.destination', 'Recipient')) + '</label>'
+    + '  <input type="text" class="alert-channel-destination ' + inputClass + '" value="' + escapeHtml(ch.destination || '') + '" placeholder="' + escapeHtml(t('settings.alert_channels.destination_placeholder', 'Destination email from the email settings')) + '"></div>'
+    + '<div class="alert-channel-webhook mt-3"><label class="block text-sm font-medium text-gray-700 mb-2">' + escapeHtml(t('settings.alert_channels.webhook_url', 'Webhook URL')) + '</label>'
+    + '  <input type="text" class="alert-channel-webhook-url ' + inputClass + '" value="' + escapeHtml((ch.webhook && ch.webhook.url) || '') + '" placeholder="' + escapeHtml(t('settings.alert_channels.webhook_url_placeholder', 'Webhook URL from the webhook settings')) + '">'
+    + '  <input type="password" autocomplete="new-password" class="alert-channel-webhook-secret mt-2 ' + inputClass + '" value="' + escapeHtml((ch.webhook && ch.webhook.secret) || '') + '" placeholder="' + escapeHtml(t('settings.alert_channels.webhook_secret_placeholder', 'Signing secret (optional)')) + '">'
+    + '  <div class="flex items-center justify-between mt-3 mb-2"><label class="block text-sm font-medium text-gray-700">' + escapeHtml(t('settings.webhook.template', 'Body Template')) + '</label>'
+    + '  <select class="alert-channel-webhook-preset webhook-preset-select border border-gray-300 rounded-md px-2 py-1 text-sm"><option value="">' + escapeHtml(t('settings.webhook.preset_select', 'Load a preset...')) + '</option></select></div>'
+    + '  <textarea rows="4" class="alert-channel-webhook-template font-mono text-sm ' + inputClass + '" placeholder="' + escapeHtml(t('settings.alert_channels.webhook_template_placeholder', 'Template from the webhook settings')) + '">' + escapeHtml((ch.webhook && ch.webhook.template) || '') + '</textarea>'
+    + '  <div class="flex items-center gap-2 mt-2">'
+    + '    <input type="text" class="alert-channel-webhook-content-type ' + inputClass + '" value="' + escapeHtml((ch.webhook && ch.webhook.contentType) || '') + '" placeholder="application/json">'
+    + '    <button type="button" class="alert-channel-webhook-preview-btn px-3 py-1.5 text-xs rounded border border-blue-300 text-blue-600 hover:bg-blue-50">' + escapeHtml(t('settings.webhook.preview', 'Preview')) + '</button>'
+    + '  </div>'
+    + '  <pre class="alert-channel-webhook-preview hidden mt-2 p-3 bg-gray-50 border border-gray-200 rounded-md text-xs font-mono whitespace-pre-wrap break-all"></pre></div>';
+
+  card.querySelector('.alert-channel-provider').value = ch.provider || 'email';
+  card.querySelector('.alert-channel-severity').value = ch.minSeverity || '';
+  card.querySelector('.alert-channel-remove').addEventListener('click', function() {
+    card.remove();
+    updateAlertProviderFields();
+    updateAlertFieldsState();
+  });
+  const presetSelect = card.querySelector('.alert-channel-webhook-preset');
+  const templateInput = card.querySelector('.alert-channel-webhook-template');
+  const contentTypeInput = card.querySelector('.alert-channel-webhook-content-type');
+  fillWebhookPresetSelect(presetSelect);
+  presetSelect.addEventListener('change', function() {
+    applyWebhookPreset(presetSelect, templateInput, contentTypeInput);
+  });
+  card.querySelector('.alert-channel-webhook-preview-btn').addEventListener('click', function() {
+    const firstEvent = card.querySelector('.alert-channel-event:checked');
+    previewWebhookTemplate(templateInput, contentTypeInput, firstEvent ? firstEvent.value : 'ban', card.querySelector('.alert-channel-webhook-preview'));
+  });
+  card.querySelector('.alert-channel-provider').addEventListener('change', function() {
+    updateAlertChannelFields(card);
+    updateAlertProviderFields();
+  });
+  list.appendChild(card);
+  updateAlertChannelFields(card);
+  if (isNew) {
+    updateAlertProviderFields();
+    updateAlertFieldsState();
+  }
+}
+
+function alertChannelThrottleInput(name, key, fallback, value) {
+  return '<div><label class="block text-xs text-gray-600 mb-1">' + escapeHtml(t(key, fallback)) + '</label>'
+    + '<input type="number" min="0" class="alert-channel-throttle-' + name + ' w-full border border-gray-300 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500" value="' + (value ? Number(value) : '') + '"></div>';
+}
+
+function updateAlertChannelFields(card) {
+  const provider = card.querySelector('.alert-channel-provider').value;
+  card.querySelector('.alert-channel-email').classList.toggle('hidden', provider !== 'email');
+  card.querySelector('.alert-channel-webhook').classList.toggle('hidden', provider !== 'webhook');
+}
+
+// Providers used by the channels in the editor, as a set.
+function alertChannelProviders() {
+  const used = {};
+  document.querySelectorAll('#alertChannelsList [data-channel-id]').forEach(function(card) {
+    used[card.querySelector('.alert-channel-provider').value] = true;
+  });
+  return used;
+}
+
+function collectAlertChannels() {
+  function list(value) {
+    return value.split(/[\s,]+/).map(function(v) { return v.trim(); }).filter(Boolean);
+  }
+  const channels = [];
+  document.querySelectorAll('#alertChannelsList [data-channel-id]').forEach(function(card) {
+    const id = card.dataset.channelId;
+    const provider = card.querySelector('.alert-channel-provider').value;
+    const channel = {
+      id: id,
+      name: card.querySelector('.alert-channel-name').value.trim(),
+      enabled: card.querySelector('.alert-channel-enabled').checked,
+      provider: provider,
+      events: Array.from(card.querySelectorAll('.alert-channel-event:checked')).map(function(el) { return el.value; }),
+      countries: list(card.querySelector('.alert-channel-countries').value).map(function(c) { return c.toUpperCase(); }),
+      jails: list(card.querySelector('.alert-channel-jails').value),
+      servers: Array.from(card.querySelector('.alert-channel-servers').selectedOptions).map(function(opt) { return opt.value; }),
+      minSeverity: card.querySelector('.alert-channel-severity').value,
+      throttle: {
+        dedupeMinutes: parseInt(card.querySelector('.alert-channel-throttle-dedupe').value, 10) || 0,
+        maxAlerts: parseInt(card.querySelector('.alert-channel-throttle-max').value, 10) || 0,
+        windowMinutes: parseInt(card.querySelector('.alert-channel-throttle-window').value, 10) || 0,
+        digestMinutes: parseInt(card.querySelector('.alert-channel-throttle-digest').value, 10) || 0
+      }
+    };
+    if (provider === 'email') {
+      channel.destination = card.querySelector('.alert-channel-destination').value.trim();
+    }
+    const webhookUrl = card.querySelector('.alert-channel-webhook-url').value.trim();
+    const webhookTemplate = card.querySelector('.alert-channel-webhook-template').value;
+    if (provider === 'webhook' && (webhookUrl || webhookTemplate.trim())) {
+      channel.webhook = Object.assign({ method: 'POST', headers: {} }, alertChannelWebhooks[id] || {}, {
+        url: webhookUrl,
+        template: webhookTemplate,
+        contentType: card.querySelector('.alert-channel-webhook-content-type').value.trim(),
+        secret: card.querySelector('.alert-channel-webhook-secret').value.trim()
+      });
+    }
+    channels.push(channel);
+  });
+  return channels;
+}
+
 // =========================================================================
 //  Threat Intelligence Settings
 // =========================================================================
@@ -724,6 +1037,106 @@ function clearPermanentBlockLog() {
     .catch(function(err) { showToast(String(err), 'error'); });
 }
 
+// =========================================================================
+//  Alert Outbox
+// =========================================================================
+
+function loadAlertOutbox() {
+  fetch(appPath('/api/alerts/outbox?status=dead'))
+    .then(res => res.json())
+    .then(data => {
+      if (data.error) {
+        showToast(t('settings.alert_outbox.load_error', 'Error loading failed alert deliveries') + ': ' + data.error, 'error');
+        return;
+      }
+      renderAlertOutbox(data.entries || [], data.counts || {});
+    })
+    .catch(err => {
+      showToast(t('settings.alert_outbox.load_error', 'Error loading failed alert deliveries') + ': ' + err, 'error');
+    });
+}
+
+function renderAlertOutboxRow(entry) {
+  const ev = entry.event || {};
+  const subject = [ev.ip, ev.jail, ev.serverName].filter(Boolean).join(' / ');
+  return ''
+    + '<tr class="border-t">'
+    + '  <td class="px-3 py-2 text-sm">' + escapeHtml(entry.channelName || entry.channelId) + '</td>'
+    + '  <td class="px-3 py-2 text-sm">' + escapeHtml(entry.eventType) + '</td>'
+    + '  <td class="px-3 py-2 font-mono text-xs">' + (escapeHtml(subject) || '&nbsp;') + '</td>'
+    + '  <td class="px-3 py-2 text-sm text-red-600">' + escapeHtml(entry.lastError || '') + '</td>'
+    + '  <td class="px-3 py-2 text-xs text-gray-500">' + escapeHtml(String(entry.attempts)) + '</td>'
+    + '  <td class="px-3 py-2 text-xs text-gray-500">' + (entry.updatedAt ? new Date(entry.updatedAt).toLocaleString() : '') + '</td>'
+    + '  <td class="px-3 py-2 text-right whitespace-nowrap">'
+    + '    <button type="button" class="text-sm text-blue-600 hover:text-blue-800" onclick="retryAlertOutbox(' + Number(entry.id) + ')" data-i18n="settings.alert_outbox.retry">Retry</button>'
+    + '    <button type="button" class="ml-2 text-sm text-red-600 hover:text-red-800" onclick="purgeAlertOutbox(' + Number(entry.id) + ')" data-i18n="settings.alert_outbox.delete">Delete</button>'
+    + '  </td>'
+    + '</tr>';
+}
+
+function renderAlertOutbox(entries, counts) {
+  const summary = document.getElementById('alertOutboxSummary');
+  if (summary) {
+    const queued = (counts.pending || 0) + (counts.delivering || 0);
+    summary.textContent = queued > 0
+      ? t('settings.alert_outbox.queued', 'Alerts waiting for delivery') + ': ' + queued
+      : '';
+  }
+  const container = document.getElementById('alertOutboxLog');
+  if (!container) return;
+  if (!entries.length) {
+    container.innerHTML = '<p class="text-sm text-gray-500 p-4" data-i18n="settings.alert_outbox.empty">No failed alert deliveries.</p>';
+    if (typeof updateTranslations === 'function') updateTranslations();
+    return;
+  }
+  container.innerHTML = ''
+    + '<table class="min-w-full text-sm">'
+    + '  <thead class="bg-gray-50 text-left">'
+    + '    <tr>'
+    + '      <th class="px-3 py-2" data-i18n="settings.alert_outbox.channel">Channel</th>'
+    + '      <th class="px-3 py-2" data-i18n="settings.alert_outbox.event">Event</th>'
+    + '      <th class="px-3 py-2" data-i18n="settings.alert_outbox.subject">Subject</th>'
+    + '      <th class="px-3 py-2" data-i18n="settings.alert_outbox.error">Last Error</th>'
+    + '      <th class="px-3 py-2" data-i18n="settings.alert_outbox.attempts">Attempts</th>'
+    + '      <th class="px-3 py-2" data-i18n="settings.advanced.log_updated">Updated</th>'
+    + '      <th class="px-3 py-2 text-right" data-i18n="settings.advanced.log_actions">Actions</th>'
+    + '    </tr>'
+    + '  </thead>'
+    + '  <tbody>' + entries.map(renderAlertOutboxRow).join('') + '</tbody>'
+    + '</table>';
+  if (typeof updateTranslations === 'function') updateTranslations();
+}
+
+function retryAlertOutbox(id) {
+  const query = id ? '?id=' + encodeURIComponent(id) : '';
+  fetch(appPath('/api/alerts/outbox/retry' + query), { method: 'POST', headers: serverHeaders() })
+    .then(function(res) { return res.json(); })
+    .then(function(data) {
+      if (data.error) {
+        showToast(data.error, 'error');
+        return;
+      }
+      showToast(t('settings.alert_outbox.retry_success', 'Alerts queued for delivery') + ': ' + (data.requeued || 0), 'success');
+      loadAlertOutbox();
+    })
+    .catch(function(err) { showToast(String(err), 'error'); });
+}
+
+function purgeAlertOutbox(id) {
+  if (!id && !confirm(t('settings.alert_outbox.purge_confirm', 'Delete all failed alert deliveries? These alerts will not be sent.'))) return;
+  const query = id ? '?id=' + encodeURIComponent(id) : '';
+  fetch(appPath('/api/alerts/outbox' + query), { method: 'DELETE', headers: serverHeaders() })
+    .then(function(res) { return res.json(); })
+    .then(function(data) {
+      if (data.error) {
+        showToast(data.error, 'error');
+        return;
+      }
+      loadAlertOutbox();
+    })
+    .catch(function(err) { showToast(String(err), 'error'); });
+}
+
 // =========================================================================
 //  Advanced Test
 // =========================================================================
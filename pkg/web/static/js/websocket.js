@@ -18,6 +18,8 @@ class WebSocketManager {
     this.statusCallbacks = [];
     this.banEventCallbacks = [];
     this.consoleLogCallbacks = [];
+    this.logTailCallbacks = [];
+    this.clientId = null;
     this.connectedAt = null;
     this.lastHeartbeatAt = null;
     this.messageCount = 0;
@@ -128,11 +130,47 @@ class WebSocketManager {
       case 'toast':
         this.handleToast(message);
         break;
+      case 'ws_client':
+        this.clientId = message.clientId;
+        break;
+      case 'log_tail':
+      case 'log_tail_status':
+        this.handleLogTail(message);
+        break;
+      case 'server_health':
+        this.handleServerHealth(message.data);
+        break;
+      case 'ban_import_progress':
+        if (typeof handleBanImportProgress === 'function') {
+          handleBanImportProgress(message.data);
+        }
+        break;
       default:
         console.log('Unknown message type:', message.type);
     }
   }
 
+  handleServerHealth(health) {
+    if (!health) {
+      return;
+    }
+    if (typeof handleServerHealth === 'function') {
+      handleServerHealth(health);
+    }
+    if (typeof showToast !== 'function' || !health.previousStatus) {
+      return;
+    }
+    const name = health.serverName || health.serverId;
+    const detail = health.detail || '';
+    if (health.status === 'down') {
+      showToast(t('servers.health.down', 'Server {server} is down: {detail}').replace('{server}', name).replace('{detail}', detail), 'error');
+    } else if (health.status === 'degraded') {
+      showToast(t('servers.health.degraded', 'Server {server} is degraded: {detail}').replace('{server}', name).replace('{detail}', detail), 'warning');
+    } else if (health.status === 'up') {
+      showToast(t('servers.health.recovered', 'Server {server} is healthy again').replace('{server}', name), 'success');
+    }
+  }
+
   handleToast(message) {
     if (typeof showToast === 'function' && message && message.message) {
       showToast(message.message, message.level || 'info');
@@ -151,6 +189,16 @@ class WebSocketManager {
     }
   }
 
+  handleLogTail(message) {
+    this.logTailCallbacks.forEach(callback => {
+      try {
+        callback(message);
+      } catch (err) {
+        console.error('Error in log tail callback:', err);
+      }
+    });
+  }
+
   handleBanEvent(eventData) {
     // Check if we've already processed this event (prevent duplicates)
     if (eventData.id && this.lastBanEventId !== null && eventData.id <= this.lastBanEventId) {
@@ -224,6 +272,33 @@ class WebSocketManager {
     this.consoleLogCallbacks.push(callback);
   }
 
+  // Receives "log_tail" batches and "log_tail_status" updates of this connection's tails.
+  onLogTail(callback) {
+    this.logTailCallbacks.push(callback);
+  }
+
+  // Starts following the jail's log files; lines arrive through onLogTail.
+  startLogTail(jail) {
+    if (!this.clientId) {
+      return Promise.reject(new Error('WebSocket not connected'));
+    }
+    return fetch(withServerParam('/api/jails/' + encodeURIComponent(jail) + '/tail'), {
+      method: 'POST',
+      headers: serverHeaders({ 'Content-Type': 'application/json' }),
+      body: JSON.stringify({ clientId: this.clientId })
+    }).then(res => res.json().then(data => {
+      if (!res.ok) {
+        throw new Error(data.error || res.statusText);
+      }
+      return data;
+    }));
+  }
+
+  stopLogTail(subscriptionId) {
+    const url = appPath('/api/tail/' + encodeURIComponent(subscriptionId)) + '?clientId=' + encodeURIComponent(this.clientId || '');
+    return fetch(url, { method: 'DELETE' });
+  }
+
   disconnect() {
     if (this.ws) {
       this.ws.close();
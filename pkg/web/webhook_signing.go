@@ -0,0 +1,85 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Every outgoing webhook carries a delivery ID that stays the same across
+// retries: the alert outbox entry ID, or a random ID for direct sends such as
+// test webhooks. Webhooks with a secret carry two more headers:
+//
+//	X-Fail2ban-UI-Delivery: 4711
+//	X-Fail2ban-UI-Timestamp: 1760612345
+//	X-Fail2ban-UI-Signature: sha256=<hex>
+//
+// The signature is the hex-encoded HMAC-SHA256, keyed with the webhook secret,
+// of the timestamp, a ".", the delivery ID, a "." and the raw request body,
+// exactly as sent. Receivers verify a call by:
+//
+//  1. rejecting it when the timestamp is more than a few minutes away from
+//     their own clock (five minutes is a sensible window), which stops replays
+//     of captured requests;
+//  2. computing HMAC-SHA256(secret, timestamp + "." + delivery + "." + body)
+//     over the body bytes before any JSON parsing;
+//  3. comparing the result with the signature header in constant time
+//     (hmac.Equal, hmac.compare_digest, crypto.timingSafeEqual, ...).
+//
+// Retries are signed again with a fresh timestamp, so their signatures differ.
+// Receivers drop duplicates by remembering the delivery IDs they accepted;
+// since the ID is signed, it cannot be changed to slip a replay past them.
+// That is why the signed message is not the plain timestamp + "." + body.
+const (
+	webhookDeliveryHeader  = "X-Fail2ban-UI-Delivery"
+	webhookTimestampHeader = "X-Fail2ban-UI-Timestamp"
+	webhookSignatureHeader = "X-Fail2ban-UI-Signature"
+)
+
+// Returns the signature header value for a delivery sent at the given timestamp.
+func webhookSignature(secret, timestamp, deliveryID string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + deliveryID + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns a delivery ID for webhooks sent outside the alert outbox.
+func newWebhookDeliveryID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "direct-" + hex.EncodeToString(b)
+}
+
+// Adds the delivery ID and, for a webhook with a secret, the timestamp and signature headers.
+func signWebhookRequest(req *http.Request, body []byte, secret, deliveryID string, now time.Time) {
+	req.Header.Set(webhookDeliveryHeader, deliveryID)
+	if secret == "" {
+		return
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, webhookSignature(secret, timestamp, deliveryID, body))
+}
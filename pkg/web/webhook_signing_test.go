@@ -0,0 +1,96 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+)
+
+func TestSignWebhookRequest(t *testing.T) {
+	body := []byte(`{"event":"ban","ip":"203.0.113.7"}`)
+	now := time.Unix(1760612345, 0)
+	req, _ := http.NewRequest(http.MethodPost, "https://hooks.example.com/f2b", nil)
+	signWebhookRequest(req, body, "s3cret", "4711", now)
+
+	timestamp := req.Header.Get(webhookTimestampHeader)
+	if timestamp != "1760612345" || req.Header.Get(webhookDeliveryHeader) != "4711" {
+		t.Fatalf("unexpected headers %v", req.Header)
+	}
+	// Verify the way a receiver would, from the documented scheme.
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + ".4711." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	got := req.Header.Get(webhookSignatureHeader)
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+
+	if webhookSignature("s3cret", timestamp, "4711", append(body, ' ')) == got {
+		t.Errorf("a changed body must change the signature")
+	}
+	if webhookSignature("s3cret", "1760612346", "4711", body) == got {
+		t.Errorf("a changed timestamp must change the signature")
+	}
+	if webhookSignature("s3cret", timestamp, "4712", body) == got {
+		t.Errorf("a changed delivery ID must change the signature")
+	}
+	if webhookSignature("other", timestamp, "4711", body) == got {
+		t.Errorf("a different secret must change the signature")
+	}
+
+	unsigned, _ := http.NewRequest(http.MethodPost, "https://hooks.example.com/f2b", nil)
+	signWebhookRequest(unsigned, body, "", "4711", now)
+	if unsigned.Header.Get(webhookTimestampHeader) != "" || unsigned.Header.Get(webhookSignatureHeader) != "" {
+		t.Errorf("a webhook without secret must be sent unsigned")
+	}
+	if unsigned.Header.Get(webhookDeliveryHeader) != "4711" {
+		t.Errorf("the delivery ID is sent without a secret as well")
+	}
+}
+
+func TestNewWebhookDeliveryID(t *testing.T) {
+	a, b := newWebhookDeliveryID(), newWebhookDeliveryID()
+	if a == b || !strings.HasPrefix(a, "direct-") {
+		t.Fatalf("unexpected delivery IDs %q %q", a, b)
+	}
+}
+
+func TestAlertChannelWebhookSecret(t *testing.T) {
+	settings := config.AppSettings{Webhook: config.WebhookSettings{URL: "https://hooks.example.com/global", Secret: "global"}}
+
+	own := alertChannelSettings(config.AlertChannel{
+		Webhook: &config.WebhookSettings{URL: "https://chat.example.com/hook", Secret: "channel"},
+	}, settings)
+	if own.Webhook.Secret != "channel" {
+		t.Errorf("a channel with its own URL should sign with its own secret, got %q", own.Webhook.Secret)
+	}
+
+	templateOnly := alertChannelSettings(config.AlertChannel{
+		Webhook: &config.WebhookSettings{Template: `{"text":{{json .Summary}}}`},
+	}, settings)
+	if templateOnly.Webhook.Secret != "global" || !strings.HasSuffix(templateOnly.Webhook.URL, "/global") {
+		t.Errorf("a template-only channel should post signed to the global webhook, got %q %q", templateOnly.Webhook.URL, templateOnly.Webhook.Secret)
+	}
+}
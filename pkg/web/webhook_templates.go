@@ -0,0 +1,515 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+	"github.com/swissmakers/fail2ban-ui/internal/enrichment"
+)
+
+// =========================================================================
+//  Types and Constants
+// =========================================================================
+
+const (
+	webhookTemplateMaxSize = 64 << 10
+	webhookBodyMaxSize     = 1 << 20
+	webhookThreatIntelWait = 10 * time.Second
+)
+
+// What a webhook body template can use. Fields that do not apply to the event are empty.
+type webhookTemplateData struct {
+	Event       string // ban, unban, permanent_block, server_down, server_recovered, callback_silence, digest or test
+	Title       string // localized title, as in the alert emails
+	Summary     string // one-line description of the event
+	IP          string
+	Jail        string
+	Hostname    string
+	Country     string
+	Failures    string
+	Whois       string
+	Logs        string
+	ServerID    string
+	ServerName  string
+	Integration string
+	Detail      string
+	Since       time.Time
+	OccurredAt  time.Time
+	Timestamp   string                 // OccurredAt as RFC 3339
+	LogFields   map[string]interface{} // fields parsed from Logs (ECS names, e.g. "source.ip")
+	WhoisFields map[string]interface{} // fields parsed from Whois
+	ThreatIntel map[string]interface{} // provider, ip, fetchedAt and data; only looked up when the template uses it
+	Digest      *alertDigest
+	Payload     map[string]interface{} // the default JSON payload
+}
+
+// A built-in body template for a common receiver.
+type webhookPreset struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Template    string `json:"template"`
+}
+
+var webhookPresets = []webhookPreset{
+	{
+		ID:          "slack",
+		Name:        "Slack",
+		ContentType: "application/json",
+		Template: `{
+  "text": {{json .Summary}},
+  "blocks": [
+    {"type": "section", "text": {"type": "mrkdwn", "text": {{json (printf "*%s*\n%s" .Title .Summary)}}}}
+  ]
+}`,
+	},
+	{
+		ID:          "teams",
+		Name:        "Microsoft Teams",
+		ContentType: "application/json",
+		Template: `{
+  "type": "message",
+  "attachments": [{
+    "contentType": "application/vnd.microsoft.card.adaptive",
+    "content": {
+      "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+      "type": "AdaptiveCard",
+      "version": "1.4",
+      "body": [
+        {"type": "TextBlock", "size": "Medium", "weight": "Bolder", "text": {{json .Title}}},
+        {"type": "TextBlock", "wrap": true, "text": {{json .Summary}}}
+      ]
+    }
+  }]
+}`,
+	},
+	{
+		ID:          "mattermost",
+		Name:        "Mattermost",
+		ContentType: "application/json",
+		Template:    `{"username": "Fail2ban-UI", "text": {{json (printf "#### %s\n%s" .Title .Summary)}}}`,
+	},
+	{
+		ID:          "discord",
+		Name:        "Discord",
+		ContentType: "application/json",
+		Template:    `{"username": "Fail2ban-UI", "content": {{json (truncate 2000 (printf "**%s**\n%s" .Title .Summary))}}}`,
+	},
+	{
+		ID:          "ntfy",
+		Name:        "ntfy",
+		ContentType: "text/plain; charset=utf-8",
+		Template:    `{{.Summary}}`,
+	},
+	{
+		ID:          "json",
+		Name:        "Default JSON",
+		ContentType: "application/json",
+		Template:    `{{json .Payload}}`,
+	},
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	// Shortens s to n characters.
+	"truncate": func(n int, s string) string {
+		if n < 0 || utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n])
+	},
+	// Returns v, or def when v is empty.
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// =========================================================================
+//  Rendering
+// =========================================================================
+
+func parseWebhookTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Funcs(webhookTemplateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func renderWebhookTemplate(text string, data webhookTemplateData) ([]byte, error) {
+	tmpl, err := parseWebhookTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	buf := &limitedBuffer{limit: webhookBodyMaxSize}
+	if err := tmpl.Execute(buf, data); err != nil {
+		if errors.Is(err, errWebhookBodyTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	return buf.buf.Bytes(), nil
+}
+
+var errWebhookBodyTooLarge = fmt.Errorf("rendered webhook body exceeds %d bytes", webhookBodyMaxSize)
+
+// Refuses writes past the limit, so a template that loops over large values
+// stops rendering there instead of building the whole body in memory first.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.limit {
+		return 0, errWebhookBodyTooLarge
+	}
+	return b.buf.Write(p)
+}
+
+// Collects the template data for an event. Threat intel is only looked up when
+// the template refers to it, so other templates cost no provider requests.
+func buildWebhookTemplateData(ev alertEvent, payload map[string]interface{}, text string, settings config.AppSettings) webhookTemplateData {
+	timestamp, _ := payload["timestamp"].(string)
+	data := webhookTemplateData{
+		Event:       ev.Type,
+		Title:       alertTitle(ev, settings),
+		Summary:     alertSummary(ev),
+		IP:          ev.IP,
+		Jail:        ev.Jail,
+		Hostname:    ev.Hostname,
+		Country:     ev.Country,
+		Failures:    ev.Failures,
+		Whois:       ev.Whois,
+		Logs:        ev.Logs,
+		ServerID:    ev.ServerID,
+		ServerName:  ev.ServerName,
+		Integration: ev.Integration,
+		Detail:      ev.Detail,
+		Since:       ev.Since,
+		OccurredAt:  ev.OccurredAt,
+		Timestamp:   timestamp,
+		LogFields:   enrichment.ParseLogLines(ev.Logs, ev.Jail),
+		WhoisFields: enrichment.ParseWhois(ev.Whois),
+		Digest:      ev.Digest,
+		Payload:     payload,
+	}
+	if ev.IP != "" && strings.Contains(text, ".ThreatIntel") {
+		data.ThreatIntel = lookupAlertThreatIntel(ev.IP, settings)
+	}
+	return data
+}
+
+// Returns the localized title the alert emails use for the event type.
+func alertTitle(ev alertEvent, settings config.AppSettings) string {
+	lang := settings.Language
+	if lang == "" {
+		lang = "en"
+	}
+	key := "email." + ev.Type + ".title"
+	if title := getEmailTranslation(lang, key); title != key {
+		return title
+	}
+	return ev.Type
+}
+
+// Describes the event in one line.
+func alertSummary(ev alertEvent) string {
+	where := ev.ServerName
+	if where == "" {
+		where = ev.Hostname
+	}
+	var b strings.Builder
+	switch ev.Type {
+	case "permanent_block":
+		fmt.Fprintf(&b, "Permanently blocked %s", ev.IP)
+		if ev.Integration != "" {
+			fmt.Fprintf(&b, " on %s", ev.Integration)
+		}
+		if ev.Country != "" {
+			fmt.Fprintf(&b, " (%s)", ev.Country)
+		}
+	case "server_down":
+		fmt.Fprintf(&b, "Server %s is down", where)
+	case "server_recovered":
+		fmt.Fprintf(&b, "Server %s has recovered", where)
+	case "callback_silence":
+		fmt.Fprintf(&b, "Server %s has not reported any events", where)
+	case "digest":
+		if d := ev.Digest; d != nil {
+			fmt.Fprintf(&b, "%d bans from %s to %s, %d alerts held back", d.Bans, d.Since.Format(time.RFC3339), d.Until.Format(time.RFC3339), d.Suppressed)
+		}
+	default:
+		verb := map[string]string{"ban": "Banned", "unban": "Unbanned"}[ev.Type]
+		if verb == "" {
+			verb = "Test alert for"
+		}
+		fmt.Fprintf(&b, "%s %s", verb, ev.IP)
+		if ev.Jail != "" {
+			fmt.Fprintf(&b, " in jail %s", ev.Jail)
+		}
+		if where != "" {
+			fmt.Fprintf(&b, " on %s", where)
+		}
+		if ev.Country != "" {
+			fmt.Fprintf(&b, " (%s)", ev.Country)
+		}
+		if ev.Type == "ban" && ev.Failures != "" {
+			fmt.Fprintf(&b, " after %s failures", ev.Failures)
+		}
+	}
+	if ev.Detail != "" && ev.Type != "digest" {
+		fmt.Fprintf(&b, ": %s", ev.Detail)
+	}
+	return b.String()
+}
+
+// Looks up threat intel for an alert through the same cache as the threat-intel
+// modal. Returns nil when no provider is configured or the lookup fails.
+func lookupAlertThreatIntel(ip string, settings config.AppSettings) map[string]interface{} {
+	provider := strings.ToLower(strings.TrimSpace(settings.ThreatIntel.Provider))
+	switch {
+	case provider == "alienvault" && strings.TrimSpace(settings.ThreatIntel.AlienVaultAPIKey) != "":
+	case provider == "abuseipdb" && strings.TrimSpace(settings.ThreatIntel.AbuseIPDBAPIKey) != "":
+	default:
+		return nil
+	}
+	decode := func(body []byte) map[string]interface{} {
+		var out map[string]interface{}
+		if json.Unmarshal(body, &out) != nil {
+			return nil
+		}
+		return out
+	}
+
+	cacheKey := provider + ":" + ip
+	now := time.Now()
+	threatIntelMu.RLock()
+	cached, hasCached := threatIntelCache[cacheKey]
+	retryUntil, hasRetry := threatIntelRetry[cacheKey]
+	threatIntelMu.RUnlock()
+	if hasCached && now.Before(cached.ExpiresAt) {
+		return decode(cached.Body)
+	}
+	if hasRetry && now.Before(retryUntil) {
+		if hasCached {
+			return decode(cached.Body)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookThreatIntelWait)
+	defer cancel()
+	req, err := newThreatIntelRequest(ctx, provider, ip, settings)
+	if err != nil {
+		return nil
+	}
+	resp, err := newOutboundHTTPClient(webhookThreatIntelWait).Do(req)
+	if err != nil {
+		config.DebugLog("Threat intel lookup for %s failed: %v", ip, err)
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		threatIntelMu.Lock()
+		pruneThreatIntelCachesLocked(now)
+		threatIntelRetry[cacheKey] = now.Add(parseRetryAfter(resp.Header.Get("Retry-After"), 2*time.Minute))
+		threatIntelMu.Unlock()
+		return nil
+	}
+	body, err := readLimitedBody(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) != nil {
+		return nil
+	}
+	result := map[string]interface{}{
+		"provider":  provider,
+		"ip":        ip,
+		"fetchedAt": now.UTC().Format(time.RFC3339),
+		"data":      parsed,
+	}
+	if responseBody, err := json.Marshal(result); err == nil {
+		threatIntelMu.Lock()
+		pruneThreatIntelCachesLocked(now)
+		threatIntelCache[cacheKey] = threatIntelCacheEntry{Body: responseBody, CachedAt: now, ExpiresAt: now.Add(30 * time.Minute)}
+		delete(threatIntelRetry, cacheKey)
+		threatIntelMu.Unlock()
+	}
+	return result
+}
+
+// =========================================================================
+//  Validation
+// =========================================================================
+
+// Checks the body template and content type of a webhook.
+func normalizeWebhookTemplate(wh *config.WebhookSettings) error {
+	if strings.TrimSpace(wh.Template) == "" {
+		wh.Template = ""
+		wh.ContentType = ""
+		return nil
+	}
+	if len(wh.Template) > webhookTemplateMaxSize {
+		return fmt.Errorf("webhook template must not exceed %d bytes", webhookTemplateMaxSize)
+	}
+	if _, err := parseWebhookTemplate(wh.Template); err != nil {
+		return err
+	}
+	wh.ContentType = strings.TrimSpace(wh.ContentType)
+	if wh.ContentType == "" {
+		wh.ContentType = "application/json"
+	}
+	if _, _, err := mime.ParseMediaType(wh.ContentType); err != nil {
+		return fmt.Errorf("invalid webhook content type %q", wh.ContentType)
+	}
+	return nil
+}
+
+// =========================================================================
+//  Handlers
+// =========================================================================
+
+// Lists the built-in webhook body templates.
+func ListWebhookPresetsHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("ListWebhookPresetsHandler called (webhook_templates.go)")
+	c.JSON(http.StatusOK, gin.H{"presets": webhookPresets})
+}
+
+type webhookPreviewRequest struct {
+	Template    string `json:"template"`
+	ContentType string `json:"contentType"`
+	Event       string `json:"event"`
+}
+
+// Renders a webhook body template with a sample event.
+func PreviewWebhookTemplateHandler(c *gin.Context) {
+	config.DebugLog("----------------------------")
+	config.DebugLog("PreviewWebhookTemplateHandler called (webhook_templates.go)")
+	var req webhookPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	wh := config.WebhookSettings{Template: req.Template, ContentType: req.ContentType}
+	if err := normalizeWebhookTemplate(&wh); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if wh.Template == "" {
+		wh.Template, wh.ContentType = `{{json .Payload}}`, "application/json"
+	}
+	ev, err := sampleAlertEvent(req.Event)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	data := buildWebhookTemplateData(ev, webhookPayload(ev), "", config.GetSettings())
+	data.ThreatIntel = map[string]interface{}{
+		"provider":  "abuseipdb",
+		"ip":        ev.IP,
+		"fetchedAt": ev.OccurredAt.Format(time.RFC3339),
+		"data":      map[string]interface{}{"data": map[string]interface{}{"abuseConfidenceScore": 100, "totalReports": 42}},
+	}
+	body, err := renderWebhookTemplate(wh.Template, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp := gin.H{"contentType": wh.ContentType, "body": string(body)}
+	if mediaType, _, _ := mime.ParseMediaType(wh.ContentType); strings.HasSuffix(mediaType, "json") && !json.Valid(body) {
+		resp["warning"] = "the rendered body is not valid JSON"
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Returns an example event of the given type for previews.
+func sampleAlertEvent(eventType string) (alertEvent, error) {
+	if eventType == "" {
+		eventType = "ban"
+	}
+	if eventType != "digest" && !slices.Contains(alertEventTypes, eventType) {
+		return alertEvent{}, errors.New("unknown event type")
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	ev := alertEvent{
+		Type:       eventType,
+		ServerID:   "server-1",
+		ServerName: "web-01",
+		Hostname:   "web-01.example.com",
+		OccurredAt: now,
+	}
+	switch eventType {
+	case "server_down", "server_recovered", "callback_silence":
+		ev.Since = now.Add(-5 * time.Minute)
+		if eventType == "server_down" {
+			ev.Detail = "fail2ban-client ping failed"
+		}
+	case "digest":
+		ev.ServerID, ev.ServerName, ev.Hostname = "", "", ""
+		ev.Digest = &alertDigest{
+			Since:        now.Add(-time.Hour),
+			Until:        now,
+			Bans:         412,
+			Suppressed:   380,
+			Duplicates:   45,
+			TopIPs:       []alertDigestEntry{{Name: "203.0.113.7", Count: 31}},
+			TopJails:     []alertDigestEntry{{Name: "sshd", Count: 390}},
+			TopCountries: []alertDigestEntry{{Name: "CN", Count: 122}},
+			TopServers:   []alertDigestEntry{{Name: "web-01", Count: 260}},
+		}
+	default:
+		ev.IP = "203.0.113.7"
+		ev.Country = "CN"
+		if eventType == "permanent_block" {
+			ev.Integration = "opnsense"
+			ev.Detail = "blocked after 5 bans"
+			break
+		}
+		ev.Jail = "sshd"
+		ev.Failures = "5"
+		ev.Whois = "inetnum: 203.0.113.0 - 203.0.113.255\nnetname: EXAMPLE-NET\ncountry: CN\n"
+		ev.Logs = now.Format("Jan _2 15:04:05") + " web-01 sshd[1234]: Failed password for invalid user admin from 203.0.113.7 port 52814 ssh2"
+	}
+	return ev, nil
+}
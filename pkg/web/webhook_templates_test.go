@@ -0,0 +1,120 @@
+// Fail2ban UI - A Swiss made, management interface for Fail2ban.
+//
+// Copyright (C) 2026 Swissmakers GmbH (https://swissmakers.ch)
+//
+// Licensed under the GNU Affero General Public License, Version 3 (AGPL-3.0)
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/swissmakers/fail2ban-ui/internal/config"
+)
+
+func TestWebhookPresetsRenderEveryEvent(t *testing.T) {
+	for _, preset := range webhookPresets {
+		for _, eventType := range append(slices.Clone(alertEventTypes), "digest") {
+			ev, err := sampleAlertEvent(eventType)
+			if err != nil {
+				t.Fatalf("sampleAlertEvent(%s): %v", eventType, err)
+			}
+			body, err := renderWebhookTemplate(preset.Template, buildWebhookTemplateData(ev, webhookPayload(ev), preset.Template, config.AppSettings{}))
+			if err != nil {
+				t.Fatalf("%s/%s: %v", preset.ID, eventType, err)
+			}
+			if mediaType, _, _ := mime.ParseMediaType(preset.ContentType); mediaType == "application/json" && !json.Valid(body) {
+				t.Fatalf("%s/%s rendered invalid JSON: %s", preset.ID, eventType, body)
+			}
+			if len(strings.TrimSpace(string(body))) == 0 {
+				t.Fatalf("%s/%s rendered an empty body", preset.ID, eventType)
+			}
+		}
+	}
+}
+
+func TestWebhookTemplateData(t *testing.T) {
+	ev, _ := sampleAlertEvent("ban")
+	tmpl := `{{.Event}} {{.IP}} {{.ServerName}} {{index .LogFields "event.action"}} {{json .Payload.jail}} {{default "none" .Integration}} {{truncate 3 .Country}}`
+	body, err := renderWebhookTemplate(tmpl, buildWebhookTemplateData(ev, webhookPayload(ev), tmpl, config.AppSettings{}))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := `ban 203.0.113.7 web-01 failed_password "sshd" none CN`
+	if string(body) != want {
+		t.Fatalf("got %q, want %q", body, want)
+	}
+
+	if _, err := renderWebhookTemplate(`{{.Nope}}`, webhookTemplateData{}); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+}
+
+func TestAlertSummary(t *testing.T) {
+	ev, _ := sampleAlertEvent("ban")
+	if got := alertSummary(ev); got != "Banned 203.0.113.7 in jail sshd on web-01 (CN) after 5 failures" {
+		t.Fatalf("unexpected ban summary: %q", got)
+	}
+	down, _ := sampleAlertEvent("server_down")
+	if got := alertSummary(down); got != "Server web-01 is down: fail2ban-client ping failed" {
+		t.Fatalf("unexpected server summary: %q", got)
+	}
+}
+
+func TestWebhookPayloadKeepsFormat(t *testing.T) {
+	ban, _ := sampleAlertEvent("ban")
+	payload := webhookPayload(ban)
+	for _, key := range []string{"event", "ip", "jail", "hostname", "country", "failures", "whois", "logs", "timestamp"} {
+		if _, ok := payload[key]; !ok {
+			t.Fatalf("ban payload lacks %q: %v", key, payload)
+		}
+	}
+	down, _ := sampleAlertEvent("server_down")
+	if p := webhookPayload(down); p["server"] != "web-01" || p["since"] == "" || p["ip"] != nil {
+		t.Fatalf("unexpected server_down payload: %v", p)
+	}
+}
+
+func TestNormalizeWebhookTemplate(t *testing.T) {
+	wh := config.WebhookSettings{Template: `{"text": {{json .Summary}}}`}
+	if err := normalizeWebhookTemplate(&wh); err != nil || wh.ContentType != "application/json" {
+		t.Fatalf("expected the default content type, got %+v err=%v", wh, err)
+	}
+	wh = config.WebhookSettings{Template: "   ", ContentType: "text/plain"}
+	if err := normalizeWebhookTemplate(&wh); err != nil || wh.Template != "" || wh.ContentType != "" {
+		t.Fatalf("a blank template must be cleared, got %+v err=%v", wh, err)
+	}
+	if err := normalizeWebhookTemplate(&config.WebhookSettings{Template: "{{.IP"}); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	if err := normalizeWebhookTemplate(&config.WebhookSettings{Template: "{{.IP}}", ContentType: "not a type"}); err == nil {
+		t.Fatal("expected an invalid content type to be rejected")
+	}
+}
+
+func TestRenderWebhookTemplateStopsAtSizeLimit(t *testing.T) {
+	// Rendering the whole body first would take gigabytes; the limit ends it early.
+	_, err := renderWebhookTemplate(`{{range 1000000000}}{{$.Summary}}{{end}}`, webhookTemplateData{Summary: "ban 192.0.2.1"})
+	if !errors.Is(err, errWebhookBodyTooLarge) {
+		t.Fatalf("expected errWebhookBodyTooLarge, got %v", err)
+	}
+	body, err := renderWebhookTemplate(`{{range 3}}{{$.IP}} {{end}}`, webhookTemplateData{IP: "192.0.2.1"})
+	if err != nil || string(body) != "192.0.2.1 192.0.2.1 192.0.2.1 " {
+		t.Fatalf("unexpected body %q (%v)", body, err)
+	}
+}
@@ -17,6 +17,8 @@
 package web
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -40,16 +42,25 @@ type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 	send chan []byte
+	// Random ID the client uses to start per-connection streams such as log tails.
+	id string
 }
 
 type Hub struct {
 	clients    map[*Client]bool
 	broadcast  chan []byte
+	direct     chan directMessage
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
 }
 
+// Message for a single client, delivered by the Hub's run loop.
+type directMessage struct {
+	clientID string
+	data     []byte
+}
+
 const (
 	writeWait  = 10 * time.Second
 	pongWait   = 60 * time.Second
@@ -146,6 +157,7 @@ func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte, 256),
+		direct:     make(chan directMessage, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 	}
@@ -162,6 +174,7 @@ func (h *Hub) Run() {
 			h.mu.Lock()
 			h.clients[client] = true
 			h.mu.Unlock()
+			h.sendClientID(client)
 			log.Printf("WebSocket client connected. Total clients: %d", len(h.clients))
 
 		case client := <-h.unregister:
@@ -171,6 +184,7 @@ func (h *Hub) Run() {
 				close(client.send)
 			}
 			h.mu.Unlock()
+			logTails.stopClient(client.id)
 			log.Printf("WebSocket client disconnected. Total clients: %d", len(h.clients))
 
 		case message := <-h.broadcast:
@@ -185,12 +199,54 @@ func (h *Hub) Run() {
 			}
 			h.mu.RUnlock()
 
+		case message := <-h.direct:
+			h.mu.RLock()
+			for client := range h.clients {
+				if client.id != message.clientID {
+					continue
+				}
+				select {
+				case client.send <- message.data:
+				default:
+					// A slow client loses this message but keeps its connection.
+				}
+			}
+			h.mu.RUnlock()
+
 		case <-ticker.C:
 			h.sendHeartbeat()
 		}
 	}
 }
 
+// Tells a newly connected client its ID.
+func (h *Hub) sendClientID(client *Client) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":     "ws_client",
+		"clientId": client.id,
+	})
+	if err != nil {
+		log.Printf("Error marshaling client ID: %v", err)
+		return
+	}
+	select {
+	case client.send <- data:
+	default:
+	}
+}
+
+// Reports whether a client with this ID is connected.
+func (h *Hub) hasClient(clientID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if client.id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Hub) sendHeartbeat() {
 	message := map[string]interface{}{
 		"type":   "heartbeat",
@@ -245,14 +301,73 @@ func (h *Hub) BroadcastBanEventUpdate(event storage.BanEventRecord) {
 	h.broadcastEvent("ban_event_update", event)
 }
 
+// Encodes and queues a message for a single client.
+func (h *Hub) sendToClient(clientID string, message map[string]interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling %v: %v", message["type"], err)
+		return
+	}
+	select {
+	case h.direct <- directMessage{clientID: clientID, data: data}:
+	default:
+		log.Printf("Direct channel full, dropping %v", message["type"])
+	}
+}
+
 func (h *Hub) BroadcastUnbanEvent(event storage.BanEventRecord) {
 	h.broadcastEvent("unban_event", event)
 }
 
+// Reports a change of a server's health status.
+func (h *Hub) BroadcastServerHealth(health ServerHealth) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type": "server_health",
+		"data": health,
+	})
+	if err != nil {
+		log.Printf("Error marshaling server health: %v", err)
+		return
+	}
+
+	select {
+	case h.broadcast <- data:
+	default:
+		log.Printf("Broadcast channel full, dropping server health")
+	}
+}
+
+// Reports the progress of a ban history import.
+func (h *Hub) BroadcastBanImportProgress(progress BanImportProgress) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type": "ban_import_progress",
+		"data": progress,
+	})
+	if err != nil {
+		log.Printf("Error marshaling ban import progress: %v", err)
+		return
+	}
+
+	select {
+	case h.broadcast <- data:
+	default:
+		log.Printf("Broadcast channel full, dropping ban import progress")
+	}
+}
+
 // =========================================================================
 //  WebSocket Helper Functions
 // =========================================================================
 
+// Returns a random hex ID for clients and their streams.
+func newClientID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
 func (c *Client) readPump() {
 	defer func() {
 		c.hub.unregister <- c
@@ -329,6 +444,7 @@ func serveWS(hub *Hub, c *gin.Context) {
 		hub:  hub,
 		conn: conn,
 		send: make(chan []byte, 256),
+		id:   newClientID(),
 	}
 
 	client.hub.register <- client